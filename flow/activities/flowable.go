@@ -297,6 +297,8 @@ func (a *FlowableActivity) SyncFlow(
 	var syncingBatchID atomic.Int64
 	var syncState atomic.Pointer[string]
 	syncState.Store(shared.Ptr("setup"))
+	// whether the destination synced the last replay of the durable CDC buffer
+	var cdcBufferDraining atomic.Bool
 	shutdown := heartbeatRoutine(ctx, func() string {
 		// Must load Waiting after BatchID to avoid race saying we're waiting on currently processing batch
 		sBatchID := syncingBatchID.Load()
@@ -318,6 +320,13 @@ func (a *FlowableActivity) SyncFlow(
 	ctx = internal.WithOperationContext(ctx, protos.FlowOperation_FLOW_OPERATION_SYNC)
 	logger := internal.LoggerFromCtx(ctx)
 
+	// destination connectors are created per batch, but the source connection lives as long as this activity.
+	// The version is loaded before connecting, so an update landing while connecting is seen as newer
+	srcConfigVersion, err := connectors.LoadPeerConfigVersion(ctx, a.CatalogPool, config.SourceName)
+	if err != nil {
+		return a.Alerter.LogFlowError(ctx, config.FlowJobName, err)
+	}
+
 	srcConn, err := connectors.GetByNameAs[connectors.CDCPullConnectorCore](ctx, config.Env, a.CatalogPool, config.SourceName)
 	if err != nil {
 		return a.Alerter.LogFlowError(ctx, config.FlowJobName, err)
@@ -369,13 +378,18 @@ func (a *FlowableActivity) SyncFlow(
 		var syncErr error
 		if config.System == protos.TypeSystem_Q {
 			syncResponse, syncErr = a.syncRecords(groupCtx, config, options, srcConn.(connectors.CDCPullConnector),
-				normRequests, &syncingBatchID, &syncState)
+				normRequests, &syncingBatchID, &syncState, &cdcBufferDraining)
 		} else {
 			syncResponse, syncErr = a.syncPg(groupCtx, config, options, srcConn.(connectors.CDCPullPgConnector),
-				normRequests, &syncingBatchID, &syncState)
+				normRequests, &syncingBatchID, &syncState, &cdcBufferDraining)
 		}
 
-		if syncErr != nil {
+		var cdcBufferErr *exceptions.CDCBufferSyncError
+		if syncErr != nil && groupCtx.Err() == nil && errors.As(syncErr, &cdcBufferErr) {
+			// syncCore already logged the error, the source keeps being drained into the buffer while it has room
+			logger.Warn("failed to sync CDC buffer, continuing to buffer changes",
+				slog.Int64("bufferedBytes", cdcBufferErr.BufferedBytes), slog.Any("error", syncErr))
+		} else if syncErr != nil {
 			if groupCtx.Err() != nil {
 				// need to return ctx.Err(), avoid returning syncErr that's wrapped context canceled
 				break
@@ -396,6 +410,12 @@ func (a *FlowableActivity) SyncFlow(
 		if (options.NumberOfSyncs > 0 && syncNum >= options.NumberOfSyncs) || (reconnectAfterBatches > 0 && syncNum >= reconnectAfterBatches) {
 			break
 		}
+		if version, err := connectors.LoadPeerConfigVersion(groupCtx, a.CatalogPool, config.SourceName); err != nil {
+			logger.Warn("failed to check for source peer update", slog.Any("error", err))
+		} else if version != srcConfigVersion {
+			logger.Info("source peer was updated, reconnecting", slog.String("peerName", config.SourceName))
+			break
+		}
 	}
 
 	syncState.Store(shared.Ptr("cleanup"))
@@ -419,6 +439,7 @@ func (a *FlowableActivity) syncRecords(
 	normRequests chan<- NormalizeBatchRequest,
 	syncingBatchID *atomic.Int64,
 	syncWaiting *atomic.Pointer[string],
+	cdcBufferDraining *atomic.Bool,
 ) (*model.SyncResponse, error) {
 	var adaptStream func(stream *model.CDCStream[model.RecordItems]) (*model.CDCStream[model.RecordItems], error)
 	if config.Script != "" {
@@ -449,6 +470,12 @@ func (a *FlowableActivity) syncRecords(
 			return stream, nil
 		}
 	}
+	if config.DurableCdcBuffer {
+		return syncBuffered(ctx, a, config, options, srcConn, normRequests,
+			syncingBatchID, syncWaiting, cdcBufferDraining, adaptStream,
+			connectors.CDCPullConnector.PullRecords,
+			connectors.CDCSyncConnector.SyncRecords)
+	}
 	return syncCore(ctx, a, config, options, srcConn, normRequests,
 		syncingBatchID, syncWaiting, adaptStream,
 		connectors.CDCPullConnector.PullRecords,
@@ -463,7 +490,14 @@ func (a *FlowableActivity) syncPg(
 	normRequests chan<- NormalizeBatchRequest,
 	syncingBatchID *atomic.Int64,
 	syncWaiting *atomic.Pointer[string],
+	cdcBufferDraining *atomic.Bool,
 ) (*model.SyncResponse, error) {
+	if config.DurableCdcBuffer {
+		return syncBuffered(ctx, a, config, options, srcConn, normRequests,
+			syncingBatchID, syncWaiting, cdcBufferDraining, nil,
+			connectors.CDCPullPgConnector.PullPg,
+			connectors.CDCSyncPgConnector.SyncPg)
+	}
 	return syncCore(ctx, a, config, options, srcConn, normRequests,
 		syncingBatchID, syncWaiting, nil,
 		connectors.CDCPullPgConnector.PullPg,
@@ -507,9 +541,29 @@ func (a *FlowableActivity) GetQRepPartitions(ctx context.Context,
 	}
 	defer connectors.CloseConnector(ctx, srcConn)
 
-	partitions, err := srcConn.GetQRepPartitions(ctx, config, last)
-	if err != nil {
-		return nil, a.Alerter.LogFlowError(ctx, config.FlowJobName, fmt.Errorf("failed to get partitions from source: %w", err))
+	var partitions []*protos.QRepPartition
+	var plan *protos.QRepParitionResult
+	if config.Resumable {
+		if plan, err = internal.LoadSnapshotPartitionPlan(ctx, a.CatalogPool, config.FlowJobName); err != nil {
+			return nil, a.Alerter.LogFlowError(ctx, config.FlowJobName, err)
+		}
+	}
+	if plan != nil {
+		logger.Info("resuming with partitions planned by an earlier run", slog.Int("partitions", len(plan.Partitions)))
+		partitions = plan.Partitions
+	} else {
+		partitions, err = srcConn.GetQRepPartitions(ctx, config, last)
+		if err != nil {
+			return nil, a.Alerter.LogFlowError(ctx, config.FlowJobName, fmt.Errorf("failed to get partitions from source: %w", err))
+		}
+		if config.Resumable {
+			plan, err = internal.SaveSnapshotPartitionPlan(ctx, a.CatalogPool, config.FlowJobName, config.ParentMirrorName,
+				&protos.QRepParitionResult{Partitions: partitions})
+			if err != nil {
+				return nil, a.Alerter.LogFlowError(ctx, config.FlowJobName, err)
+			}
+			partitions = plan.Partitions
+		}
 	}
 	if len(partitions) > 0 {
 		if err := monitoring.InitializeQRepRun(
@@ -712,7 +766,7 @@ func (a *FlowableActivity) SendWALHeartbeat(ctx context.Context) error {
 
 		func() {
 			pgConfig := pgPeer.GetPostgresConfig()
-			pgConn, peerErr := connpostgres.NewPostgresConnector(ctx, nil, pgConfig)
+			pgConn, peerErr := connectors.GetAs[*connpostgres.PostgresConnector](ctx, nil, pgPeer)
 			if peerErr != nil {
 				logger.Error("error creating connector for postgres peer",
 					slog.String("peer", pgPeer.Name), slog.String("host", pgConfig.Host), slog.Any("error", err))
@@ -1225,12 +1279,35 @@ func (a *FlowableActivity) RemoveFlowDetailsFromCatalog(
 			logger.Info("flow entries removed from catalog",
 				slog.Int("rowsAffected", int(ct.RowsAffected())))
 		}
+		if err := alerting.DeleteFlowAlerts(ctx, tx, flowName); err != nil {
+			return fmt.Errorf("unable to clear alerts of flow in catalog: %w", err)
+		}
 	}
 
 	if err := connmetadata.SyncFlowCleanupInTx(ctx, tx, flowName); err != nil {
 		return fmt.Errorf("unable to clear metadata for flow cleanup: %w", err)
 	}
 
+	// a resync starts the initial load over
+	if _, err := tx.Exec(ctx, "DELETE FROM snapshot_resume_state WHERE flow_name=$1", flowName); err != nil {
+		return fmt.Errorf("unable to clear snapshot resume state in catalog: %w", err)
+	}
+	if _, err := tx.Exec(ctx, "DELETE FROM snapshot_partition_plans WHERE parent_mirror_name=$1", flowName); err != nil {
+		return fmt.Errorf("unable to clear snapshot partition plans in catalog: %w", err)
+	}
+	if _, err := tx.Exec(ctx, "DELETE FROM snapshot_table_offsets WHERE flow_name=$1", flowName); err != nil {
+		return fmt.Errorf("unable to clear snapshot table offsets in catalog: %w", err)
+	}
+	if _, err := tx.Exec(ctx, "DELETE FROM incremental_snapshots WHERE flow_name=$1", flowName); err != nil {
+		return fmt.Errorf("unable to clear incremental snapshots in catalog: %w", err)
+	}
+	if _, err := tx.Exec(ctx, "DELETE FROM cdc_buffer_segments WHERE flow_name=$1", flowName); err != nil {
+		return fmt.Errorf("unable to clear CDC buffer segments in catalog: %w", err)
+	}
+	if _, err := tx.Exec(ctx, "DELETE FROM cdc_buffer_offsets WHERE flow_name=$1", flowName); err != nil {
+		return fmt.Errorf("unable to clear CDC buffer offset in catalog: %w", err)
+	}
+
 	// only for ClickHouse, should be a no-op for other destination connectors
 	if _, err := tx.Exec(ctx, `DELETE FROM ch_s3_stage WHERE flow_job_name = $1`, flowName); err != nil {
 		return fmt.Errorf("failed to clear avro stage for flow %s: %w", flowName, err)
@@ -1240,6 +1317,17 @@ func (a *FlowableActivity) RemoveFlowDetailsFromCatalog(
 		return fmt.Errorf("failed to commit transaction to remove flow details from catalog: %w", err)
 	}
 
+	// segments are forgotten once the catalog is cleared, leftover files are only wasted space
+	if bufferDir, err := internal.PeerDBCDCBufferDir(ctx, req.Env); err != nil {
+		logger.Warn("failed to get CDC buffer directory", slog.Any("error", err))
+	} else if bufferDir != "" {
+		if store, err := utils.NewCDCBufferStore(ctx, bufferDir, flowName); err != nil {
+			logger.Warn("failed to get CDC buffer store", slog.Any("error", err))
+		} else if err := store.RemoveAll(ctx); err != nil {
+			logger.Warn("failed to remove CDC buffer", slog.Any("error", err))
+		}
+	}
+
 	return nil
 }
 
@@ -0,0 +1,365 @@
+// durable CDC buffer for flowable.go
+package activities
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"sync/atomic"
+
+	"go.opentelemetry.io/otel/attribute"
+	"golang.org/x/sync/errgroup"
+
+	"github.com/PeerDB-io/peerdb/flow/connectors"
+	connmysql "github.com/PeerDB-io/peerdb/flow/connectors/mysql"
+	"github.com/PeerDB-io/peerdb/flow/connectors/utils"
+	"github.com/PeerDB-io/peerdb/flow/generated/protos"
+	"github.com/PeerDB-io/peerdb/flow/internal"
+	"github.com/PeerDB-io/peerdb/flow/model"
+	"github.com/PeerDB-io/peerdb/flow/otel_metrics"
+	"github.com/PeerDB-io/peerdb/flow/shared"
+	"github.com/PeerDB-io/peerdb/flow/shared/exceptions"
+)
+
+// syncBuffered replaces syncCore for mirrors with a durable CDC buffer.
+// Changes are pulled into a segment and acknowledged to the source before being replayed to the destination,
+// so a destination outage only stops pulling once the buffer is full.
+// Once the destination syncs a replay again, the backlog is replayed before pulling more.
+// A failed replay is returned as a CDCBufferSyncError while the buffer has room
+func syncBuffered[TPull connectors.CDCPullConnectorCore, TSync connectors.CDCSyncConnectorCore, Items model.Items](
+	ctx context.Context,
+	a *FlowableActivity,
+	config *protos.FlowConnectionConfigs,
+	options *protos.SyncFlowOptions,
+	srcConn TPull,
+	normRequests chan<- NormalizeBatchRequest,
+	syncingBatchID *atomic.Int64,
+	syncState *atomic.Pointer[string],
+	draining *atomic.Bool,
+	adaptStream func(*model.CDCStream[Items]) (*model.CDCStream[Items], error),
+	pull func(TPull, context.Context, shared.CatalogPool, *otel_metrics.OtelManager, *model.PullRecordsRequest[Items]) error,
+	sync func(TSync, context.Context, *model.SyncRecordsRequest[Items]) (*model.SyncResponse, error),
+) (*model.SyncResponse, error) {
+	flowName := config.FlowJobName
+	ctx = context.WithValue(ctx, shared.FlowNameKey, flowName)
+	logger := internal.LoggerFromCtx(ctx)
+
+	bufferDir, err := internal.PeerDBCDCBufferDir(ctx, config.Env)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get CDC buffer directory: %w", err)
+	}
+	if bufferDir == "" {
+		return nil, a.Alerter.LogFlowError(ctx, flowName, errors.New("durable CDC buffer requires PEERDB_CDC_BUFFER_DIR to be set"))
+	}
+	maxBytes, err := internal.PeerDBCDCBufferMaxBytes(ctx, config.Env)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get CDC buffer max bytes: %w", err)
+	}
+	store, err := utils.NewCDCBufferStore(ctx, bufferDir, flowName)
+	if err != nil {
+		return nil, a.Alerter.LogFlowError(ctx, flowName, err)
+	}
+
+	segments, err := internal.LoadCDCBufferSegments(ctx, a.CatalogPool, flowName)
+	if err != nil {
+		return nil, a.Alerter.LogFlowError(ctx, flowName, err)
+	}
+	var bufferedBytes int64
+	for _, segment := range segments {
+		bufferedBytes += segment.SizeBytes
+	}
+
+	if len(segments) > 0 && draining.Load() {
+		logger.Info("replaying CDC buffer backlog before pulling",
+			slog.Int("segments", len(segments)), slog.Int64("bufferedBytes", bufferedBytes))
+	} else if bufferedBytes < maxBytes {
+		syncState.Store(shared.Ptr("buffering"))
+		segment, err := bufferCore[TPull, TSync](ctx, a, config, options, srcConn, store, pull)
+		if err != nil {
+			return nil, err
+		}
+		if segment != nil {
+			segments = append(segments, *segment)
+			bufferedBytes += segment.SizeBytes
+		}
+	} else {
+		logger.Warn("CDC buffer is full, not pulling until it is synced",
+			slog.Int64("bufferedBytes", bufferedBytes), slog.Int64("maxBytes", maxBytes))
+	}
+	a.OtelManager.Metrics.CDCBufferBytesGauge.Record(ctx, bufferedBytes)
+	a.OtelManager.Metrics.CDCBufferSegmentsGauge.Record(ctx, int64(len(segments)))
+	if len(segments) == 0 {
+		return nil, nil
+	}
+
+	// replay whole segments up to the batch size, but always at least one
+	batchSize := int64(options.BatchSize)
+	if batchSize == 0 {
+		batchSize = 250_000
+	}
+	replaySegments := segments[:1]
+	numRecords := segments[0].NumRecords
+	for _, segment := range segments[1:] {
+		if numRecords+segment.NumRecords > batchSize {
+			break
+		}
+		numRecords += segment.NumRecords
+		replaySegments = segments[:len(replaySegments)+1]
+	}
+
+	res, err := syncCore(ctx, a, config, options, srcConn, normRequests, syncingBatchID, syncState, adaptStream,
+		func(_ TPull, ctx context.Context, _ shared.CatalogPool, _ *otel_metrics.OtelManager, req *model.PullRecordsRequest[Items]) error {
+			defer req.RecordStream.Close()
+			if numRecords > 0 {
+				req.RecordStream.SignalAsNotEmpty()
+			} else {
+				req.RecordStream.SignalAsEmpty()
+			}
+			for _, segment := range replaySegments {
+				if err := utils.ReplayCDCBufferSegment(ctx, store, segment, req.RecordStream); err != nil {
+					return err
+				}
+			}
+			return nil
+		}, sync)
+	if err != nil {
+		draining.Store(false)
+		if ctx.Err() != nil || bufferedBytes >= maxBytes {
+			return nil, err
+		}
+		return nil, exceptions.NewCDCBufferSyncError(err, bufferedBytes)
+	}
+	draining.Store(len(segments) > len(replaySegments))
+
+	syncState.Store(shared.Ptr("cleaning buffer"))
+	if err := finishCDCBufferReplay(ctx, store, segments, replaySegments,
+		srcConn.UpdateReplStateLastOffset,
+		func(segmentID int64) error {
+			return internal.DeleteCDCBufferSegments(ctx, a.CatalogPool, flowName, segmentID)
+		},
+	); err != nil {
+		return nil, a.Alerter.LogFlowError(ctx, flowName, err)
+	}
+	for _, segment := range replaySegments {
+		bufferedBytes -= segment.SizeBytes
+	}
+	a.OtelManager.Metrics.CDCBufferBytesGauge.Record(ctx, bufferedBytes)
+	a.OtelManager.Metrics.CDCBufferSegmentsGauge.Record(ctx, int64(len(segments)-len(replaySegments)))
+
+	return res, nil
+}
+
+// bufferCore pulls a batch from the source into a new segment of the buffer,
+// returning nil when there was nothing to buffer
+func bufferCore[TPull connectors.CDCPullConnectorCore, TSync connectors.CDCSyncConnectorCore, Items model.Items](
+	ctx context.Context,
+	a *FlowableActivity,
+	config *protos.FlowConnectionConfigs,
+	options *protos.SyncFlowOptions,
+	srcConn TPull,
+	store utils.CDCBufferStore,
+	pull func(TPull, context.Context, shared.CatalogPool, *otel_metrics.OtelManager, *model.PullRecordsRequest[Items]) error,
+) (*internal.CDCBufferSegment, error) {
+	flowName := config.FlowJobName
+	logger := internal.LoggerFromCtx(ctx)
+
+	tblNameMapping := make(map[string]model.NameAndExclude, len(options.TableMappings))
+	for _, v := range options.TableMappings {
+		tblNameMapping[v.SourceTableIdentifier] = model.NewNameAndExclude(v.DestinationTableIdentifier, v.Exclude)
+	}
+
+	batchSize := options.BatchSize
+	if batchSize == 0 {
+		batchSize = 250_000
+	}
+
+	// the source is acknowledged up to what was buffered, which is ahead of the destination
+	lastOffset, err := func() (model.CdcCheckpoint, error) {
+		if offsetID, offsetText, ok, err := internal.LoadCDCBufferOffset(ctx, a.CatalogPool, flowName); err != nil {
+			return model.CdcCheckpoint{}, err
+		} else if ok {
+			return model.CdcCheckpoint{ID: offsetID, Text: offsetText}, nil
+		}
+		if myConn, isMy := any(srcConn).(*connmysql.MySqlConnector); isMy {
+			return myConn.GetLastOffset(ctx, flowName)
+		} else {
+			dstConn, err := connectors.GetByNameAs[TSync](ctx, config.Env, a.CatalogPool, config.DestinationName)
+			if err != nil {
+				return model.CdcCheckpoint{}, fmt.Errorf("failed to get destination connector: %w", err)
+			}
+			defer connectors.CloseConnector(ctx, dstConn)
+
+			return dstConn.GetLastOffset(ctx, flowName)
+		}
+	}()
+	if err != nil {
+		return nil, a.Alerter.LogFlowError(ctx, flowName, err)
+	}
+
+	logger.Info("pulling records into CDC buffer...", slog.Any("LastOffset", lastOffset))
+	consumedOffset := atomic.Int64{}
+	consumedOffset.Store(lastOffset.ID)
+
+	channelBufferSize, err := internal.PeerDBCDCChannelBufferSize(ctx, config.Env)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get CDC channel buffer size: %w", err)
+	}
+	recordBatch := model.NewCDCStream[Items](channelBufferSize)
+
+	tableNameSchemaMapping, err := a.getTableNameSchemaMapping(ctx, flowName)
+	if err != nil {
+		return nil, err
+	}
+
+	errGroup, errCtx := errgroup.WithContext(ctx)
+	errGroup.Go(func() (err error) {
+		pullCtx, span := otel_metrics.StartSpan(errCtx, "PullRecords", attribute.String(otel_metrics.FlowNameKey, flowName))
+		defer func() { otel_metrics.EndSpan(span, err) }()
+		return pull(srcConn, pullCtx, a.CatalogPool, a.OtelManager, &model.PullRecordsRequest[Items]{
+			FlowJobName:           flowName,
+			SrcTableIDNameMapping: options.SrcTableIdNameMapping,
+			TableNameMapping:      tblNameMapping,
+			LastOffset:            lastOffset,
+			ConsumedOffset:        &consumedOffset,
+			MaxBatchSize:          batchSize,
+			IdleTimeout: internal.PeerDBCDCIdleTimeoutSeconds(
+				int(options.IdleTimeoutSeconds),
+			),
+			TableNameSchemaMapping:      tableNameSchemaMapping,
+			OverridePublicationName:     config.PublicationName,
+			OverrideReplicationSlotName: config.ReplicationSlotName,
+			RecordStream:                recordBatch,
+			Env:                         config.Env,
+			InternalVersion:             config.Version,
+		})
+	})
+
+	var writer *utils.CDCBufferSegmentWriter[Items]
+	var segmentID int64
+	if !recordBatch.WaitAndCheckEmpty() {
+		if segmentID, err = internal.NextCDCBufferSegmentID(ctx, a.CatalogPool, flowName); err == nil {
+			writer, err = utils.CreateCDCBufferSegment[Items](store, segmentID)
+		}
+		if err != nil {
+			// drain the stream so the pull can finish
+			for range recordBatch.GetRecords() {
+			}
+			_ = errGroup.Wait()
+			return nil, a.Alerter.LogFlowError(ctx, flowName, err)
+		}
+	}
+	var writeErr error
+	for record := range recordBatch.GetRecords() {
+		if writeErr == nil && writer != nil {
+			writeErr = writer.Write(record)
+		}
+	}
+	if err := errGroup.Wait(); err != nil {
+		if writer != nil {
+			writer.Abort()
+		}
+		return nil, fmt.Errorf("[cdc] failed to pull records into CDC buffer: %w", err)
+	}
+	if writeErr != nil {
+		writer.Abort()
+		return nil, a.Alerter.LogFlowError(ctx, flowName, writeErr)
+	}
+
+	if writer == nil {
+		if len(recordBatch.SchemaDeltas) == 0 {
+			logger.Info("no records to buffer")
+			return nil, nil
+		}
+		// schema changes without records still need to reach the destination in order
+		if segmentID, err = internal.NextCDCBufferSegmentID(ctx, a.CatalogPool, flowName); err == nil {
+			writer, err = utils.CreateCDCBufferSegment[Items](store, segmentID)
+		}
+		if err != nil {
+			return nil, a.Alerter.LogFlowError(ctx, flowName, err)
+		}
+	}
+
+	checkpoint := recordBatch.GetLastCheckpoint()
+	checkpoint.ID = max(checkpoint.ID, lastOffset.ID)
+	if checkpoint.Text == "" {
+		checkpoint.Text = lastOffset.Text
+	}
+	segment, err := commitCDCBufferSegment(ctx, store, writer, segmentID, checkpoint, recordBatch.SchemaDeltas,
+		func(segment internal.CDCBufferSegment) error {
+			return internal.CommitCDCBufferSegment(ctx, a.CatalogPool, flowName, segment)
+		},
+		srcConn.UpdateReplStateLastOffset,
+	)
+	if err != nil {
+		return nil, a.Alerter.LogFlowError(ctx, flowName, err)
+	}
+	logger.Info("buffered records", slog.Int64("segmentID", segmentID),
+		slog.Int64("numRecords", segment.NumRecords), slog.Int64("sizeBytes", segment.SizeBytes), slog.Any("checkpoint", checkpoint))
+	return segment, nil
+}
+
+// commitCDCBufferSegment makes a segment durable in the store, then registers it in the catalog,
+// and only then acknowledges its checkpoint to the source, which may then discard the changes
+func commitCDCBufferSegment[Items model.Items](
+	ctx context.Context,
+	store utils.CDCBufferStore,
+	writer *utils.CDCBufferSegmentWriter[Items],
+	segmentID int64,
+	checkpoint model.CdcCheckpoint,
+	schemaDeltas []*protos.TableSchemaDelta,
+	register func(internal.CDCBufferSegment) error,
+	acknowledge func(context.Context, model.CdcCheckpoint) error,
+) (*internal.CDCBufferSegment, error) {
+	size, err := writer.Commit(ctx, checkpoint, schemaDeltas)
+	if err != nil {
+		return nil, err
+	}
+	segment := internal.CDCBufferSegment{
+		FileName:       writer.FileName(),
+		LastOffsetText: checkpoint.Text,
+		SegmentID:      segmentID,
+		LastOffsetID:   checkpoint.ID,
+		NumRecords:     writer.NumRecords(),
+		SizeBytes:      size,
+	}
+	if err := register(segment); err != nil {
+		_ = store.Remove(ctx, segment.FileName)
+		return nil, err
+	}
+	if err := acknowledge(ctx, checkpoint); err != nil {
+		return nil, err
+	}
+	return &segment, nil
+}
+
+// finishCDCBufferReplay runs after syncCore acknowledged the checkpoint of replaySegments.
+// The source is moved back to what has been buffered, then the replayed segments are forgotten by the catalog
+// before their files are removed, as a segment in the catalog without its file fails the mirror
+func finishCDCBufferReplay(
+	ctx context.Context,
+	store utils.CDCBufferStore,
+	segments []internal.CDCBufferSegment,
+	replaySegments []internal.CDCBufferSegment,
+	acknowledge func(context.Context, model.CdcCheckpoint) error,
+	forget func(segmentID int64) error,
+) error {
+	lastSegment := segments[len(segments)-1]
+	if err := acknowledge(ctx, model.CdcCheckpoint{
+		ID:   lastSegment.LastOffsetID,
+		Text: lastSegment.LastOffsetText,
+	}); err != nil {
+		return err
+	}
+	if err := forget(replaySegments[len(replaySegments)-1].SegmentID); err != nil {
+		return err
+	}
+	for _, segment := range replaySegments {
+		if err := store.Remove(ctx, segment.FileName); err != nil {
+			// files of forgotten segments are only wasted space
+			internal.LoggerFromCtx(ctx).Warn("failed to remove CDC buffer segment",
+				slog.String("fileName", segment.FileName), slog.Any("error", err))
+		}
+	}
+	return nil
+}
@@ -0,0 +1,217 @@
+package activities
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/PeerDB-io/peerdb/flow/connectors/utils"
+	"github.com/PeerDB-io/peerdb/flow/internal"
+	"github.com/PeerDB-io/peerdb/flow/model"
+)
+
+var errCDCBufferTest = errors.New("test error")
+
+// cdcBufferTestStore keeps segments in a directory, recording every step and failing the ones asked to
+type cdcBufferTestStore struct {
+	fail   map[string]bool
+	events *[]string
+	dir    string
+}
+
+func (s *cdcBufferTestStore) StagingDir() string {
+	return s.dir
+}
+
+func (s *cdcBufferTestStore) Commit(_ context.Context, stagingPath string, fileName string) error {
+	*s.events = append(*s.events, "store "+fileName)
+	if s.fail["store"] {
+		return errCDCBufferTest
+	}
+	return os.Rename(stagingPath, filepath.Join(s.dir, fileName))
+}
+
+func (s *cdcBufferTestStore) Open(_ context.Context, fileName string) (*os.File, func(), error) {
+	file, err := os.Open(filepath.Join(s.dir, fileName))
+	if err != nil {
+		return nil, nil, err
+	}
+	return file, func() { _ = file.Close() }, nil
+}
+
+func (s *cdcBufferTestStore) Remove(_ context.Context, fileName string) error {
+	*s.events = append(*s.events, "remove "+fileName)
+	if s.fail["remove"] {
+		return errCDCBufferTest
+	}
+	return os.Remove(filepath.Join(s.dir, fileName))
+}
+
+func (s *cdcBufferTestStore) RemoveAll(_ context.Context) error {
+	return os.RemoveAll(s.dir)
+}
+
+func (s *cdcBufferTestStore) Probe(_ context.Context) error {
+	return nil
+}
+
+func TestCommitCDCBufferSegment(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		fail       string
+		name       string
+		wantEvents []string
+		wantFile   bool
+	}{
+		{
+			name:       "success",
+			wantEvents: []string{"store 1.seg", "register 1", "acknowledge 42"},
+			wantFile:   true,
+		},
+		{
+			name:       "store fails",
+			fail:       "store",
+			wantEvents: []string{"store 1.seg"},
+		},
+		{
+			name:       "register fails",
+			fail:       "register",
+			wantEvents: []string{"store 1.seg", "register 1", "remove 1.seg"},
+		},
+		{
+			// the segment stays registered, the next pull starts from its offset
+			name:       "acknowledge fails",
+			fail:       "acknowledge",
+			wantEvents: []string{"store 1.seg", "register 1", "acknowledge 42"},
+			wantFile:   true,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			var events []string
+			store := &cdcBufferTestStore{dir: t.TempDir(), events: &events, fail: map[string]bool{tc.fail: true}}
+			writer, err := utils.CreateCDCBufferSegment[model.RecordItems](store, 1)
+			require.NoError(t, err)
+
+			segment, err := commitCDCBufferSegment(t.Context(), store, writer, 1, model.CdcCheckpoint{ID: 42}, nil,
+				func(segment internal.CDCBufferSegment) error {
+					// the segment is durable before the catalog knows of it
+					_, statErr := os.Stat(filepath.Join(store.dir, segment.FileName))
+					require.NoError(t, statErr)
+					events = append(events, "register 1")
+					if tc.fail == "register" {
+						return errCDCBufferTest
+					}
+					return nil
+				},
+				func(_ context.Context, checkpoint model.CdcCheckpoint) error {
+					require.Equal(t, int64(42), checkpoint.ID)
+					events = append(events, "acknowledge 42")
+					if tc.fail == "acknowledge" {
+						return errCDCBufferTest
+					}
+					return nil
+				},
+			)
+			if tc.fail == "" {
+				require.NoError(t, err)
+				require.Equal(t, "1.seg", segment.FileName)
+				require.Equal(t, int64(42), segment.LastOffsetID)
+			} else {
+				require.ErrorIs(t, err, errCDCBufferTest)
+			}
+			require.Equal(t, tc.wantEvents, events)
+
+			entries, err := os.ReadDir(store.dir)
+			require.NoError(t, err)
+			if tc.wantFile {
+				require.Len(t, entries, 1)
+			} else {
+				require.Empty(t, entries, "staging and segment files should be removed")
+			}
+		})
+	}
+}
+
+func TestFinishCDCBufferReplay(t *testing.T) {
+	t.Parallel()
+
+	segments := []internal.CDCBufferSegment{
+		{SegmentID: 1, FileName: "1.seg", LastOffsetID: 10},
+		{SegmentID: 2, FileName: "2.seg", LastOffsetID: 20},
+		{SegmentID: 3, FileName: "3.seg", LastOffsetID: 30},
+	}
+	testCases := []struct {
+		fail       string
+		name       string
+		wantEvents []string
+	}{
+		{
+			name:       "success",
+			wantEvents: []string{"acknowledge 30", "forget 2", "remove 1.seg", "remove 2.seg"},
+		},
+		{
+			name:       "acknowledge fails",
+			fail:       "acknowledge",
+			wantEvents: []string{"acknowledge 30"},
+		},
+		{
+			// segments are replayed again, files are kept for them
+			name:       "forget fails",
+			fail:       "forget",
+			wantEvents: []string{"acknowledge 30", "forget 2"},
+		},
+		{
+			name:       "remove fails",
+			fail:       "remove",
+			wantEvents: []string{"acknowledge 30", "forget 2", "remove 1.seg", "remove 2.seg"},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			var events []string
+			store := &cdcBufferTestStore{dir: t.TempDir(), events: &events, fail: map[string]bool{tc.fail: true}}
+			for _, segment := range segments {
+				require.NoError(t, os.WriteFile(filepath.Join(store.dir, segment.FileName), nil, 0o600))
+			}
+
+			err := finishCDCBufferReplay(t.Context(), store, segments, segments[:2],
+				func(_ context.Context, checkpoint model.CdcCheckpoint) error {
+					// the source moves to everything buffered, not only what was replayed
+					events = append(events, "acknowledge 30")
+					require.Equal(t, int64(30), checkpoint.ID)
+					if tc.fail == "acknowledge" {
+						return errCDCBufferTest
+					}
+					return nil
+				},
+				func(segmentID int64) error {
+					require.Equal(t, int64(2), segmentID)
+					events = append(events, "forget 2")
+					if tc.fail == "forget" {
+						return errCDCBufferTest
+					}
+					return nil
+				},
+			)
+			if tc.fail == "acknowledge" || tc.fail == "forget" {
+				require.ErrorIs(t, err, errCDCBufferTest)
+			} else {
+				// files of forgotten segments are only wasted space
+				require.NoError(t, err)
+			}
+			require.Equal(t, tc.wantEvents, events)
+		})
+	}
+}
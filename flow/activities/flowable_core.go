@@ -180,8 +180,10 @@ func syncCore[TPull connectors.CDCPullConnectorCore, TSync connectors.CDCSyncCon
 	startTime := time.Now()
 	syncState.Store(shared.Ptr("syncing"))
 	errGroup, errCtx := errgroup.WithContext(ctx)
-	errGroup.Go(func() error {
-		return pull(srcConn, errCtx, a.CatalogPool, a.OtelManager, &model.PullRecordsRequest[Items]{
+	errGroup.Go(func() (err error) {
+		pullCtx, span := otel_metrics.StartSpan(errCtx, "PullRecords", attribute.String(otel_metrics.FlowNameKey, flowName))
+		defer func() { otel_metrics.EndSpan(span, err) }()
+		return pull(srcConn, pullCtx, a.CatalogPool, a.OtelManager, &model.PullRecordsRequest[Items]{
 			FlowJobName:           flowName,
 			SrcTableIDNameMapping: options.SrcTableIdNameMapping,
 			TableNameMapping:      tblNameMapping,
@@ -257,7 +259,9 @@ func syncCore[TPull connectors.CDCPullConnectorCore, TSync connectors.CDCSyncCon
 			return a.Alerter.LogFlowError(ctx, flowName, err)
 		}
 
-		res, err = sync(dstConn, errCtx, &model.SyncRecordsRequest[Items]{
+		syncCtx, span := otel_metrics.StartSpan(errCtx, "SyncRecords",
+			attribute.String(otel_metrics.FlowNameKey, flowName), attribute.Int64(otel_metrics.BatchIdKey, syncBatchID))
+		res, err = sync(dstConn, syncCtx, &model.SyncRecordsRequest[Items]{
 			SyncBatchID:            syncBatchID,
 			Records:                recordBatchSync,
 			ConsumedOffset:         &consumedOffset,
@@ -265,10 +269,13 @@ func syncCore[TPull connectors.CDCPullConnectorCore, TSync connectors.CDCSyncCon
 			TableMappings:          options.TableMappings,
 			StagingPath:            config.CdcStagingPath,
 			Script:                 config.Script,
+			SoftDeleteColName:      config.SoftDeleteColName,
+			SyncedAtColName:        config.SyncedAtColName,
 			TableNameSchemaMapping: tableNameSchemaMapping,
 			Env:                    config.Env,
 			Version:                config.Version,
 		})
+		otel_metrics.EndSpan(span, err)
 		if err != nil {
 			return a.Alerter.LogFlowError(ctx, flowName, fmt.Errorf("failed to push records: %w", err))
 		}
@@ -391,6 +398,14 @@ func (a *FlowableActivity) getPostgresPeerConfigs(ctx context.Context) ([]*proto
 	})
 }
 
+func qrepSpanAttributes(config *protos.QRepConfig, partition *protos.QRepPartition) []attribute.KeyValue {
+	return []attribute.KeyValue{
+		attribute.String(otel_metrics.FlowNameKey, config.FlowJobName),
+		attribute.String(otel_metrics.TableNameKey, config.DestinationTableIdentifier),
+		attribute.String(otel_metrics.PartitionIdKey, partition.PartitionId),
+	}
+}
+
 // replicateQRepPartition replicates a QRepPartition from the source to the destination.
 func replicateQRepPartition[TRead any, TWrite StreamCloser, TSync connectors.QRepSyncConnectorCore, TPull connectors.QRepPullConnectorCore](
 	ctx context.Context,
@@ -446,7 +461,9 @@ func replicateQRepPartition[TRead any, TWrite StreamCloser, TSync connectors.QRe
 		}
 		defer connectors.CloseConnector(ctx, srcConn)
 
-		numRecords, numBytes, err := pullRecords(srcConn, errCtx, config, partition, stream)
+		pullCtx, span := otel_metrics.StartSpan(errCtx, "PullQRepRecords", qrepSpanAttributes(config, partition)...)
+		numRecords, numBytes, err := pullRecords(srcConn, pullCtx, config, partition, stream)
+		otel_metrics.EndSpan(span, err)
 		if err != nil {
 			return a.Alerter.LogFlowError(ctx, config.FlowJobName, fmt.Errorf("[qrep] failed to pull records: %w", err))
 		}
@@ -464,7 +481,9 @@ func replicateQRepPartition[TRead any, TWrite StreamCloser, TSync connectors.QRe
 	errGroup.Go(func() error {
 		var warnings shared.QRepWarnings
 		var err error
-		rowsSynced, warnings, err = syncRecords(dstConn, errCtx, config, partition, outstream)
+		syncCtx, span := otel_metrics.StartSpan(errCtx, "SyncQRepRecords", qrepSpanAttributes(config, partition)...)
+		rowsSynced, warnings, err = syncRecords(dstConn, syncCtx, config, partition, outstream)
+		otel_metrics.EndSpan(span, err)
 		if err != nil {
 			return a.Alerter.LogFlowError(ctx, config.FlowJobName, fmt.Errorf("failed to sync records: %w", err))
 		}
@@ -523,7 +542,9 @@ func replicateXminPartition[TRead any, TWrite any, TSync connectors.QRepSyncConn
 		var pullErr error
 		var numRecords int64
 		var numBytes int64
-		numRecords, numBytes, currentSnapshotXmin, pullErr = pullRecords(srcConn, ctx, config, partition, stream)
+		pullCtx, span := otel_metrics.StartSpan(ctx, "PullQRepRecords", qrepSpanAttributes(config, partition)...)
+		numRecords, numBytes, currentSnapshotXmin, pullErr = pullRecords(srcConn, pullCtx, config, partition, stream)
+		otel_metrics.EndSpan(span, pullErr)
 		if pullErr != nil {
 			logger.Warn(fmt.Sprintf("[xmin] failed to pull records: %v", pullErr))
 			return a.Alerter.LogFlowError(ctx, config.FlowJobName, pullErr)
@@ -573,7 +594,9 @@ func replicateXminPartition[TRead any, TWrite any, TSync connectors.QRepSyncConn
 		defer connectors.CloseConnector(ctx, dstConn)
 
 		var warnings shared.QRepWarnings
-		rowsSynced, warnings, err = syncRecords(dstConn, ctx, config, partition, outstream)
+		syncCtx, span := otel_metrics.StartSpan(ctx, "SyncQRepRecords", qrepSpanAttributes(config, partition)...)
+		rowsSynced, warnings, err = syncRecords(dstConn, syncCtx, config, partition, outstream)
+		otel_metrics.EndSpan(span, err)
 		if err != nil {
 			return a.Alerter.LogFlowError(ctx, config.FlowJobName, fmt.Errorf("failed to sync records: %w", err))
 		}
@@ -649,7 +672,9 @@ func (a *FlowableActivity) startNormalize(
 	}
 
 	logger.Info("normalizing batch", slog.Int64("SyncBatchID", batchID))
-	res, err := dstConn.NormalizeRecords(ctx, &model.NormalizeRecordsRequest{
+	normalizeCtx, span := otel_metrics.StartSpan(ctx, "NormalizeRecords",
+		attribute.String(otel_metrics.FlowNameKey, config.FlowJobName), attribute.Int64(otel_metrics.BatchIdKey, batchID))
+	res, err := dstConn.NormalizeRecords(normalizeCtx, &model.NormalizeRecordsRequest{
 		FlowJobName:            config.FlowJobName,
 		Env:                    config.Env,
 		TableNameSchemaMapping: tableNameSchemaMapping,
@@ -659,6 +684,7 @@ func (a *FlowableActivity) startNormalize(
 		SyncBatchID:            batchID,
 		Version:                config.Version,
 	})
+	otel_metrics.EndSpan(span, err)
 	if err != nil {
 		return a.Alerter.LogFlowError(ctx, config.FlowJobName,
 			exceptions.NewNormalizationError(fmt.Errorf("failed to normalize records: %w", err)))
@@ -11,6 +11,7 @@ import (
 
 	"github.com/PeerDB-io/peerdb/flow/alerting"
 	"github.com/PeerDB-io/peerdb/flow/connectors"
+	connpostgres "github.com/PeerDB-io/peerdb/flow/connectors/postgres"
 	"github.com/PeerDB-io/peerdb/flow/generated/protos"
 	"github.com/PeerDB-io/peerdb/flow/internal"
 	"github.com/PeerDB-io/peerdb/flow/shared"
@@ -27,6 +28,11 @@ type TxSnapshotState struct {
 	SupportsTIDScans bool
 }
 
+type SnapshotReplicaState struct {
+	SupportsSnapshotExport bool
+	SupportsTIDScans       bool
+}
+
 type SnapshotActivity struct {
 	Alerter             *alerting.Alerter
 	CatalogPool         shared.CatalogPool
@@ -96,9 +102,48 @@ func (a *SnapshotActivity) SetupReplication(
 		SlotName:         slotInfo.SlotName,
 		SnapshotName:     slotInfo.SnapshotName,
 		SupportsTidScans: slotInfo.SupportsTIDScans,
+		ConsistentPoint:  slotInfo.ConsistentPoint,
 	}, nil
 }
 
+// ResumeReplication returns the replication setup saved by an earlier attempt of a resumable initial load,
+// or nil when the load has to start over
+func (a *SnapshotActivity) ResumeReplication(
+	ctx context.Context,
+	config *protos.SetupReplicationInput,
+) (*protos.SetupReplicationOutput, error) {
+	ctx = context.WithValue(ctx, shared.FlowNameKey, config.FlowJobName)
+	logger := internal.LoggerFromCtx(ctx)
+
+	output, err := internal.LoadSnapshotResumeState(ctx, a.CatalogPool, config.FlowJobName)
+	if err != nil {
+		return nil, a.Alerter.LogFlowError(ctx, config.FlowJobName, err)
+	} else if output == nil {
+		return nil, nil
+	}
+
+	conn, err := connectors.GetByNameAs[connectors.CDCPullConnectorCore](ctx, nil, a.CatalogPool, config.PeerName)
+	if err != nil {
+		return nil, a.Alerter.LogFlowError(ctx, config.FlowJobName, fmt.Errorf("failed to get connector: %w", err))
+	}
+	defer connectors.CloseConnector(ctx, conn)
+
+	slotInfo, err := conn.GetSlotInfo(ctx, output.SlotName)
+	if err != nil {
+		return nil, a.Alerter.LogFlowError(ctx, config.FlowJobName, fmt.Errorf("failed to get slot info: %w", err))
+	} else if len(slotInfo) == 0 {
+		// changes since the consistent point are gone, partitions already loaded cannot be reconciled
+		return nil, a.Alerter.LogFlowError(ctx, config.FlowJobName,
+			fmt.Errorf("replication slot %s of interrupted initial load no longer exists, resync the mirror", output.SlotName))
+	}
+
+	logger.Info("resuming initial load",
+		slog.String("SlotName", output.SlotName), slog.String("ConsistentPoint", output.ConsistentPoint))
+	a.Alerter.LogFlowInfo(ctx, config.FlowJobName, "Resuming initial load from replication slot "+output.SlotName)
+	a.Alerter.LogFlowEvent(ctx, config.FlowJobName, "Started Snapshot Flow Job")
+	return output, nil
+}
+
 func (a *SnapshotActivity) MaintainTx(ctx context.Context, sessionID string, peer string, env map[string]string) error {
 	shutdown := heartbeatRoutine(ctx, func() string {
 		return "maintaining transaction snapshot"
@@ -162,6 +207,40 @@ func (a *SnapshotActivity) WaitForExportSnapshot(ctx context.Context, sessionID
 	}
 }
 
+// WaitForSnapshotReplica waits for the snapshot peer to replay past the slot's consistent point,
+// returning whether the initial load can use a snapshot exported there
+func (a *SnapshotActivity) WaitForSnapshotReplica(
+	ctx context.Context,
+	flowJobName string,
+	peerName string,
+	consistentPoint string,
+) (*SnapshotReplicaState, error) {
+	ctx = context.WithValue(ctx, shared.FlowNameKey, flowJobName)
+	shutdown := heartbeatRoutine(ctx, func() string {
+		return "waiting for snapshot peer to replay past " + consistentPoint
+	})
+	defer shutdown()
+
+	conn, err := connectors.GetByNameAs[*connpostgres.PostgresConnector](ctx, nil, a.CatalogPool, peerName)
+	if err != nil {
+		return nil, a.Alerter.LogFlowError(ctx, flowJobName, fmt.Errorf("failed to get snapshot peer connector: %w", err))
+	}
+	defer connectors.CloseConnector(ctx, conn)
+
+	if err := conn.WaitForReplayLSN(ctx, consistentPoint); err != nil {
+		return nil, a.Alerter.LogFlowError(ctx, flowJobName, fmt.Errorf("failed waiting for snapshot peer replay: %w", err))
+	}
+
+	pgVersion, err := conn.MajorVersion(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get snapshot peer version: %w", err)
+	}
+	return &SnapshotReplicaState{
+		SupportsSnapshotExport: pgVersion >= shared.POSTGRES_16,
+		SupportsTIDScans:       pgVersion >= shared.POSTGRES_13,
+	}, nil
+}
+
 func (a *SnapshotActivity) LoadTableSchema(
 	ctx context.Context,
 	flowName string,
@@ -0,0 +1,107 @@
+package alerting
+
+import (
+	"context"
+	"errors"
+
+	"github.com/jackc/pgx/v5"
+
+	"github.com/PeerDB-io/peerdb/flow/shared"
+)
+
+type AlertType string
+
+const (
+	AlertTypeSlotLag         AlertType = "slot_lag"
+	AlertTypeBadWALStatus    AlertType = "bad_wal_status"
+	AlertTypeOpenConnections AlertType = "open_connections"
+	AlertTypeNormalizeDelay  AlertType = "normalize_delay"
+	AlertTypeFlowError       AlertType = "flow_error"
+)
+
+// flow errors have no condition to observe clearing, so they don't become active alerts
+func (t AlertType) resolvable() bool {
+	return t != AlertTypeFlowError
+}
+
+// Active alerts move from open to resolved when the alerting condition clears,
+// acknowledging an open alert silences repeat notifications until it resolves
+type AlertStatus string
+
+const (
+	AlertStatusOpen         AlertStatus = "open"
+	AlertStatusAcknowledged AlertStatus = "acknowledged"
+	AlertStatusResolved     AlertStatus = "resolved"
+)
+
+// touchActiveAlert records that the condition behind an active alert was seen again,
+// returns the status of the active alert or empty string if there is none
+func touchActiveAlert(
+	ctx context.Context, catalogPool shared.CatalogPool, alertConfigId int64, alertKey string, alertMessage string,
+) (AlertStatus, error) {
+	var status AlertStatus
+	if err := catalogPool.QueryRow(ctx,
+		`UPDATE peerdb_stats.alert_incidents SET last_seen_at=now(), alert_message=$3
+		WHERE alert_config_id=$1 AND alert_key=$2 AND status<>'resolved' RETURNING status`,
+		alertConfigId, alertKey, alertMessage,
+	).Scan(&status); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return "", nil
+		}
+		return "", err
+	}
+	return status, nil
+}
+
+func openActiveAlert(
+	ctx context.Context,
+	catalogPool shared.CatalogPool,
+	alertConfigId int64,
+	alertKeys *AlertKeys,
+	alertType AlertType,
+	alertKey string,
+	alertMessage string,
+) error {
+	_, err := catalogPool.Exec(ctx,
+		`INSERT INTO peerdb_stats.alert_incidents(alert_config_id,alert_key,alert_type,flow_name,peer_name,alert_message)
+		VALUES($1,$2,$3,$4,$5,$6)
+		ON CONFLICT (alert_config_id, alert_key) WHERE status<>'resolved'
+		DO UPDATE SET last_notified_at=now(), last_seen_at=now(), alert_message=EXCLUDED.alert_message`,
+		alertConfigId, alertKey, alertType, alertKeys.FlowName, alertKeys.PeerName, alertMessage)
+	return err
+}
+
+// resolveActiveAlert returns true if there was an active alert that is now resolved
+func resolveActiveAlert(ctx context.Context, catalogPool shared.CatalogPool, alertConfigId int64, alertKey string) (bool, error) {
+	tag, err := catalogPool.Exec(ctx,
+		`UPDATE peerdb_stats.alert_incidents SET status='resolved', resolved_at=now()
+		WHERE alert_config_id=$1 AND alert_key=$2 AND status<>'resolved'`,
+		alertConfigId, alertKey)
+	if err != nil {
+		return false, err
+	}
+	return tag.RowsAffected() > 0, nil
+}
+
+// AcknowledgeActiveAlert returns false if there is no open alert with the id
+func AcknowledgeActiveAlert(ctx context.Context, catalogPool shared.CatalogPool, id int64, acknowledgedBy string) (bool, error) {
+	tag, err := catalogPool.Exec(ctx,
+		"UPDATE peerdb_stats.alert_incidents SET status=$2, acknowledged_at=now(), acknowledged_by=$3 WHERE id=$1 AND status=$4",
+		id, AlertStatusAcknowledged, acknowledgedBy, AlertStatusOpen)
+	if err != nil {
+		return false, err
+	}
+	return tag.RowsAffected() > 0, nil
+}
+
+// DeleteFlowAlerts drops the alerts of a mirror being dropped, which would otherwise stay active forever
+func DeleteFlowAlerts(ctx context.Context, tx pgx.Tx, flowName string) error {
+	_, err := tx.Exec(ctx, "DELETE FROM peerdb_stats.alert_incidents WHERE flow_name=$1", flowName)
+	return err
+}
+
+// DeleteAlertConfigAlerts drops the alerts raised through an alert config being deleted
+func DeleteAlertConfigAlerts(ctx context.Context, tx pgx.Tx, alertConfigId int64) error {
+	_, err := tx.Exec(ctx, "DELETE FROM peerdb_stats.alert_incidents WHERE alert_config_id=$1", alertConfigId)
+	return err
+}
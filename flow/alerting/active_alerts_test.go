@@ -0,0 +1,184 @@
+package alerting
+
+import (
+	"context"
+	"math/rand/v2"
+	"strconv"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/PeerDB-io/peerdb/flow/generated/protos"
+	"github.com/PeerDB-io/peerdb/flow/internal"
+	"github.com/PeerDB-io/peerdb/flow/shared"
+)
+
+func activeAlertID(t *testing.T, catalogPool shared.CatalogPool, alertConfigId int64, alertKey string) int64 {
+	t.Helper()
+
+	var id int64
+	require.NoError(t, catalogPool.QueryRow(t.Context(),
+		"SELECT id FROM peerdb_stats.alert_incidents WHERE alert_config_id=$1 AND alert_key=$2 AND status<>'resolved'",
+		alertConfigId, alertKey,
+	).Scan(&id))
+	return id
+}
+
+func TestActiveAlertLifecycle(t *testing.T) {
+	t.Parallel()
+
+	ctx := t.Context()
+	catalogPool, err := internal.GetCatalogConnectionPoolFromEnv(ctx)
+	require.NoError(t, err)
+	alertConfigId := rand.Int64N(1 << 40) //nolint:gosec // only avoids collisions with other tests
+	alertKeys := &AlertKeys{FlowName: "test_alert_lifecycle", PeerName: "test_peer"}
+	alertKey := "slot_lag:test_alert_lifecycle"
+
+	status, err := touchActiveAlert(ctx, catalogPool, alertConfigId, alertKey, "lag 1")
+	require.NoError(t, err)
+	require.Empty(t, status, "no alert before it is opened")
+
+	require.NoError(t, openActiveAlert(ctx, catalogPool, alertConfigId, alertKeys, AlertTypeSlotLag, alertKey, "lag 1"))
+	// opening again while active notifies again instead of opening a second alert
+	require.NoError(t, openActiveAlert(ctx, catalogPool, alertConfigId, alertKeys, AlertTypeSlotLag, alertKey, "lag 2"))
+	status, err = touchActiveAlert(ctx, catalogPool, alertConfigId, alertKey, "lag 3")
+	require.NoError(t, err)
+	require.Equal(t, AlertStatusOpen, status)
+
+	id := activeAlertID(t, catalogPool, alertConfigId, alertKey)
+	acknowledged, err := AcknowledgeActiveAlert(ctx, catalogPool, id, "oncall")
+	require.NoError(t, err)
+	require.True(t, acknowledged)
+	acknowledged, err = AcknowledgeActiveAlert(ctx, catalogPool, id, "oncall")
+	require.NoError(t, err)
+	require.False(t, acknowledged, "only open alerts can be acknowledged")
+	status, err = touchActiveAlert(ctx, catalogPool, alertConfigId, alertKey, "lag 4")
+	require.NoError(t, err)
+	require.Equal(t, AlertStatusAcknowledged, status)
+
+	resolved, err := resolveActiveAlert(ctx, catalogPool, alertConfigId, alertKey)
+	require.NoError(t, err)
+	require.True(t, resolved)
+	resolved, err = resolveActiveAlert(ctx, catalogPool, alertConfigId, alertKey)
+	require.NoError(t, err)
+	require.False(t, resolved)
+	status, err = touchActiveAlert(ctx, catalogPool, alertConfigId, alertKey, "lag 5")
+	require.NoError(t, err)
+	require.Empty(t, status)
+
+	// the condition coming back opens a new alert
+	require.NoError(t, openActiveAlert(ctx, catalogPool, alertConfigId, alertKeys, AlertTypeSlotLag, alertKey, "lag 6"))
+	require.NotEqual(t, id, activeAlertID(t, catalogPool, alertConfigId, alertKey))
+
+	tx, err := catalogPool.Begin(ctx)
+	require.NoError(t, err)
+	require.NoError(t, DeleteAlertConfigAlerts(ctx, tx, alertConfigId))
+	require.NoError(t, tx.Commit(ctx))
+	var count int64
+	require.NoError(t, catalogPool.QueryRow(ctx,
+		"SELECT COUNT(*) FROM peerdb_stats.alert_incidents WHERE alert_config_id=$1", alertConfigId,
+	).Scan(&count))
+	require.Zero(t, count)
+}
+
+func TestDeleteFlowAlerts(t *testing.T) {
+	t.Parallel()
+
+	ctx := t.Context()
+	catalogPool, err := internal.GetCatalogConnectionPoolFromEnv(ctx)
+	require.NoError(t, err)
+	alertConfigId := rand.Int64N(1 << 40) //nolint:gosec // only avoids collisions with other tests
+	dropped := &AlertKeys{FlowName: "test_alert_dropped_flow"}
+	kept := &AlertKeys{FlowName: "test_alert_kept_flow"}
+	require.NoError(t, openActiveAlert(ctx, catalogPool, alertConfigId, dropped, AlertTypeNormalizeDelay, "dropped", "delay"))
+	require.NoError(t, openActiveAlert(ctx, catalogPool, alertConfigId, kept, AlertTypeNormalizeDelay, "kept", "delay"))
+
+	tx, err := catalogPool.Begin(ctx)
+	require.NoError(t, err)
+	require.NoError(t, DeleteFlowAlerts(ctx, tx, dropped.FlowName))
+	require.NoError(t, tx.Commit(ctx))
+
+	status, err := touchActiveAlert(ctx, catalogPool, alertConfigId, "dropped", "delay")
+	require.NoError(t, err)
+	require.Empty(t, status)
+	status, err = touchActiveAlert(ctx, catalogPool, alertConfigId, "kept", "delay")
+	require.NoError(t, err)
+	require.Equal(t, AlertStatusOpen, status)
+
+	tx, err = catalogPool.Begin(ctx)
+	require.NoError(t, err)
+	require.NoError(t, DeleteAlertConfigAlerts(ctx, tx, alertConfigId))
+	require.NoError(t, tx.Commit(ctx))
+}
+
+type recordingAlertSender struct {
+	alerts   []string
+	resolved []string
+}
+
+func (s *recordingAlertSender) sendAlert(_ context.Context, _ string, alertMessage string) error {
+	s.alerts = append(s.alerts, alertMessage)
+	return nil
+}
+
+func (s *recordingAlertSender) sendResolved(_ context.Context, _ string, alertMessage string) error {
+	s.resolved = append(s.resolved, alertMessage)
+	return nil
+}
+
+func (s *recordingAlertSender) getSlotLagMBAlertThreshold() uint32 {
+	return 100
+}
+
+func (s *recordingAlertSender) getOpenConnectionsAlertThreshold() uint32 {
+	return 0
+}
+
+func TestSlotLagIncidentsPerSlot(t *testing.T) {
+	t.Parallel()
+
+	ctx := t.Context()
+	catalogPool, err := internal.GetCatalogConnectionPoolFromEnv(ctx)
+	require.NoError(t, err)
+	alerter := &Alerter{CatalogPool: catalogPool}
+	sender := &recordingAlertSender{}
+	alertSenderConfigs := []AlertSenderConfig{{
+		Sender: sender,
+		Id:     rand.Int64N(1 << 40), //nolint:gosec // only avoids collisions with other tests
+	}}
+	suffix := strconv.FormatInt(alertSenderConfigs[0].Id, 10)
+	lagging := &protos.SlotInfo{SlotName: "lagging_" + suffix, LagInMb: 500, WalStatus: "extended"}
+	healthy := &protos.SlotInfo{SlotName: "healthy_" + suffix, LagInMb: 1, WalStatus: "reserved"}
+
+	// both mirrors of the peer are checked on every pass
+	for range 3 {
+		alerter.alertIfSlotLag(ctx, alertSenderConfigs,
+			&AlertKeys{FlowName: "lagging_flow", PeerName: "test_peer", SlotName: lagging.SlotName}, lagging)
+		alerter.alertIfSlotLag(ctx, alertSenderConfigs,
+			&AlertKeys{FlowName: "healthy_flow", PeerName: "test_peer", SlotName: healthy.SlotName}, healthy)
+	}
+	require.Len(t, sender.alerts, 1, "repeated passes are throttled")
+	require.Contains(t, sender.alerts[0], lagging.SlotName)
+	require.Empty(t, sender.resolved, "the healthy slot must not resolve the lagging slot's incident")
+
+	lagging.LagInMb = 2
+	alerter.alertIfSlotLag(ctx, alertSenderConfigs,
+		&AlertKeys{FlowName: "lagging_flow", PeerName: "test_peer", SlotName: lagging.SlotName}, lagging)
+	require.Len(t, sender.resolved, 1)
+	require.Contains(t, sender.resolved[0], lagging.SlotName)
+
+	tx, err := catalogPool.Begin(ctx)
+	require.NoError(t, err)
+	require.NoError(t, DeleteAlertConfigAlerts(ctx, tx, alertSenderConfigs[0].Id))
+	require.NoError(t, tx.Commit(ctx))
+}
+
+func TestSlotAlertKeys(t *testing.T) {
+	t.Parallel()
+
+	laggingLag, laggingWal := slotAlertKeys("", "test_peer", "slot_a")
+	healthyLag, healthyWal := slotAlertKeys("", "test_peer", "slot_b")
+	require.NotEqual(t, laggingLag, healthyLag)
+	require.NotEqual(t, laggingWal, healthyWal)
+	require.NotEqual(t, laggingLag, laggingWal)
+}
@@ -8,8 +8,8 @@ import (
 	"io"
 	"log/slog"
 	"net"
-	"slices"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/ClickHouse/clickhouse-go/v2"
@@ -34,11 +34,18 @@ type Alerter struct {
 	snsTelemetrySender        telemetry.Sender
 	incidentIoTelemetrySender telemetry.Sender
 	otelManager               *otel_metrics.OtelManager
+	// flow errors come in bursts, so senders for them are reused for a while instead of loaded per error
+	flowErrorSendersLoadedAt time.Time
+	flowErrorSenders         []AlertSenderConfig
+	flowErrorSendersLock     sync.Mutex
 }
 
+const flowErrorSendersTTL = time.Minute
+
 type AlertSenderConfig struct {
 	Sender          AlertSender
 	AlertForMirrors []string
+	RoutingRules    []AlertRoutingRule
 	Id              int64
 }
 
@@ -91,7 +98,7 @@ func NewAlerter(ctx context.Context, catalogPool shared.CatalogPool, otelManager
 
 func (a *Alerter) registerSendersFromPool(ctx context.Context) ([]AlertSenderConfig, error) {
 	rows, err := a.CatalogPool.Query(ctx,
-		`SELECT id, service_type, service_config, enc_key_id, alert_for_mirrors
+		`SELECT id, service_type, service_config, enc_key_id, alert_for_mirrors, routing_rules
 		FROM peerdb_stats.alerting_config`)
 	if err != nil {
 		return nil, fmt.Errorf("failed to read alerter config from catalog: %w", err)
@@ -104,7 +111,7 @@ func (a *Alerter) registerSendersFromPool(ctx context.Context) ([]AlertSenderCon
 		var serviceConfigEnc []byte
 		var encKeyId string
 		if err := row.Scan(&alertSenderConfig.Id, &serviceType, &serviceConfigEnc, &encKeyId,
-			&alertSenderConfig.AlertForMirrors); err != nil {
+			&alertSenderConfig.AlertForMirrors, &alertSenderConfig.RoutingRules); err != nil {
 			return alertSenderConfig, err
 		}
 
@@ -154,6 +161,42 @@ func (a *Alerter) registerSendersFromPool(ctx context.Context) ([]AlertSenderCon
 			}
 			alertSenderConfig.Sender = alertSender
 
+			return alertSenderConfig, nil
+		case WEBHOOK:
+			var webhookServiceConfig webhookAlertConfig
+			if err := json.Unmarshal(serviceConfig, &webhookServiceConfig); err != nil {
+				return alertSenderConfig, fmt.Errorf("failed to unmarshal %s service config: %w", serviceType, err)
+			}
+
+			alertSender, err := newWebhookAlertSender(&webhookServiceConfig)
+			if err != nil {
+				return alertSenderConfig, fmt.Errorf("failed to initialize webhook alerter: %w", err)
+			}
+			alertSenderConfig.Sender = alertSender
+			return alertSenderConfig, nil
+		case PAGERDUTY:
+			var pagerDutyServiceConfig pagerDutyAlertConfig
+			if err := json.Unmarshal(serviceConfig, &pagerDutyServiceConfig); err != nil {
+				return alertSenderConfig, fmt.Errorf("failed to unmarshal %s service config: %w", serviceType, err)
+			}
+
+			alertSender, err := newPagerDutyAlertSender(&pagerDutyServiceConfig)
+			if err != nil {
+				return alertSenderConfig, fmt.Errorf("failed to initialize pagerduty alerter: %w", err)
+			}
+			alertSenderConfig.Sender = alertSender
+			return alertSenderConfig, nil
+		case OPSGENIE:
+			var opsgenieServiceConfig opsgenieAlertConfig
+			if err := json.Unmarshal(serviceConfig, &opsgenieServiceConfig); err != nil {
+				return alertSenderConfig, fmt.Errorf("failed to unmarshal %s service config: %w", serviceType, err)
+			}
+
+			alertSender, err := newOpsgenieAlertSender(&opsgenieServiceConfig)
+			if err != nil {
+				return alertSenderConfig, fmt.Errorf("failed to initialize opsgenie alerter: %w", err)
+			}
+			alertSenderConfig.Sender = alertSender
 			return alertSenderConfig, nil
 		default:
 			return alertSenderConfig, fmt.Errorf("unknown service type: %s", serviceType)
@@ -161,13 +204,48 @@ func (a *Alerter) registerSendersFromPool(ctx context.Context) ([]AlertSenderCon
 	})
 }
 
+// flowErrorSenderConfigs is registerSendersFromPool cached for flowErrorSendersTTL
+func (a *Alerter) flowErrorSenderConfigs(ctx context.Context) ([]AlertSenderConfig, error) {
+	a.flowErrorSendersLock.Lock()
+	defer a.flowErrorSendersLock.Unlock()
+	if a.flowErrorSenders != nil && time.Since(a.flowErrorSendersLoadedAt) < flowErrorSendersTTL {
+		return a.flowErrorSenders, nil
+	}
+	alertSenderConfigs, err := a.registerSendersFromPool(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if alertSenderConfigs == nil {
+		alertSenderConfigs = []AlertSenderConfig{}
+	}
+	a.flowErrorSenders = alertSenderConfigs
+	a.flowErrorSendersLoadedAt = time.Now()
+	return alertSenderConfigs, nil
+}
+
 func (a *Alerter) AlertIfSlotLag(ctx context.Context, alertKeys *AlertKeys, slotInfo *protos.SlotInfo) {
 	alertSenderConfigs, err := a.registerSendersFromPool(ctx)
 	if err != nil {
 		internal.LoggerFromCtx(ctx).Warn("failed to set alert senders", slog.Any("error", err))
 		return
 	}
+	a.alertIfSlotLag(ctx, alertSenderConfigs, alertKeys, slotInfo)
+}
 
+// slotAlertKeys are the incident keys of a slot's lag and WAL status alerts.
+// Each mirror's slot is checked on its own, so incidents are per slot rather than per peer,
+// otherwise a healthy slot resolves the incident of a lagging slot on the same peer
+func slotAlertKeys(deploymentUIDPrefix string, peerName string, slotName string) (string, string) {
+	return fmt.Sprintf("%s Slot Lag Threshold Exceeded for Slot %s on Peer %s", deploymentUIDPrefix, slotName, peerName),
+		fmt.Sprintf("%s Bad WAL Status for Slot %s on Peer %s", deploymentUIDPrefix, slotName, peerName)
+}
+
+func (a *Alerter) alertIfSlotLag(
+	ctx context.Context,
+	alertSenderConfigs []AlertSenderConfig,
+	alertKeys *AlertKeys,
+	slotInfo *protos.SlotInfo,
+) {
 	deploymentUIDPrefix := ""
 	if internal.PeerDBDeploymentUID() != "" {
 		deploymentUIDPrefix = fmt.Sprintf("[%s] ", internal.PeerDBDeploymentUID())
@@ -179,46 +257,45 @@ func (a *Alerter) AlertIfSlotLag(ctx context.Context, alertKeys *AlertKeys, slot
 		return
 	}
 
-	// catalog cannot use default threshold to space alerts properly, use the lowest set threshold instead
-	lowestSlotLagMBAlertThreshold := defaultSlotLagMBAlertThreshold
-	var alertSendersForMirrors []AlertSenderConfig
-	for _, alertSenderConfig := range alertSenderConfigs {
-		if len(alertSenderConfig.AlertForMirrors) == 0 || slices.Contains(alertSenderConfig.AlertForMirrors, alertKeys.FlowName) {
-			alertSendersForMirrors = append(alertSendersForMirrors, alertSenderConfig)
-			if alertSenderConfig.Sender.getSlotLagMBAlertThreshold() > 0 {
-				lowestSlotLagMBAlertThreshold = min(lowestSlotLagMBAlertThreshold, alertSenderConfig.Sender.getSlotLagMBAlertThreshold())
-			}
-		}
-	}
+	tags := a.routingTags(ctx, alertSenderConfigs, alertKeys.FlowName)
+	slotLagRoute := newAlertRoute(tags, AlertTypeSlotLag)
+	badWalStatusRoute := newAlertRoute(tags, AlertTypeBadWALStatus)
 
-	thresholdAlertKey := fmt.Sprintf("%s Slot Lag Threshold Exceeded for Peer %s", deploymentUIDPrefix, alertKeys.PeerName)
+	thresholdAlertKey, badWalStatusAlertKey := slotAlertKeys(deploymentUIDPrefix, alertKeys.PeerName, slotInfo.SlotName)
 	thresholdAlertMessageTemplate := fmt.Sprintf("%sSlot `%s` on peer `%s` has exceeded threshold size of %%dMB, "+
 		`currently at %.2fMB!`, deploymentUIDPrefix, slotInfo.SlotName, alertKeys.PeerName, slotInfo.LagInMb)
+	thresholdResolvedMessageTemplate := fmt.Sprintf("%sSlot `%s` on peer `%s` is back under threshold size of %%dMB, "+
+		`currently at %.2fMB.`, deploymentUIDPrefix, slotInfo.SlotName, alertKeys.PeerName, slotInfo.LagInMb)
 
-	badWalStatusAlertKey := fmt.Sprintf("%s Bad WAL Status for Peer %s", deploymentUIDPrefix, alertKeys.PeerName)
 	badWalStatusAlertMessage := fmt.Sprintf("%sSlot `%s` on peer `%s` has bad WAL status: `%s`",
 		deploymentUIDPrefix, slotInfo.SlotName, alertKeys.PeerName, slotInfo.WalStatus)
+	badWalStatusResolvedMessage := fmt.Sprintf("%sSlot `%s` on peer `%s` WAL status is back to `%s`",
+		deploymentUIDPrefix, slotInfo.SlotName, alertKeys.PeerName, slotInfo.WalStatus)
 
-	for _, alertSenderConfig := range alertSendersForMirrors {
-		if a.checkAndAddAlertToCatalog(ctx,
-			alertSenderConfig.Id, thresholdAlertKey,
-			fmt.Sprintf(thresholdAlertMessageTemplate, lowestSlotLagMBAlertThreshold)) {
-			if alertSenderConfig.Sender.getSlotLagMBAlertThreshold() > 0 {
-				if slotInfo.LagInMb > float32(alertSenderConfig.Sender.getSlotLagMBAlertThreshold()) {
-					a.alertToProvider(ctx, alertSenderConfig, thresholdAlertKey,
-						fmt.Sprintf(thresholdAlertMessageTemplate, alertSenderConfig.Sender.getSlotLagMBAlertThreshold()))
-				}
-			} else {
-				if slotInfo.LagInMb > float32(defaultSlotLagMBAlertThreshold) {
-					a.alertToProvider(ctx, alertSenderConfig, thresholdAlertKey,
-						fmt.Sprintf(thresholdAlertMessageTemplate, defaultSlotLagMBAlertThreshold))
-				}
+	for _, alertSenderConfig := range alertSenderConfigs {
+		slotLagMBAlertThreshold := defaultSlotLagMBAlertThreshold
+		if alertSenderConfig.Sender.getSlotLagMBAlertThreshold() > 0 {
+			slotLagMBAlertThreshold = alertSenderConfig.Sender.getSlotLagMBAlertThreshold()
+		}
+		if slotInfo.LagInMb > float32(slotLagMBAlertThreshold) {
+			alertMessage := fmt.Sprintf(thresholdAlertMessageTemplate, slotLagMBAlertThreshold)
+			if alertSenderConfig.shouldAlert(alertKeys.FlowName, slotLagRoute) &&
+				a.checkAndAddAlertToCatalog(ctx, alertSenderConfig.Id, alertKeys, AlertTypeSlotLag, thresholdAlertKey, alertMessage) {
+				a.alertToProvider(ctx, alertSenderConfig, thresholdAlertKey, alertMessage)
 			}
+		} else {
+			a.resolveAlert(ctx, alertSenderConfig, thresholdAlertKey,
+				fmt.Sprintf(thresholdResolvedMessageTemplate, slotLagMBAlertThreshold))
 		}
 
-		if (slotInfo.WalStatus == "lost" || slotInfo.WalStatus == "unreserved") &&
-			a.checkAndAddAlertToCatalog(ctx, alertSenderConfig.Id, badWalStatusAlertKey, badWalStatusAlertMessage) {
-			a.alertToProvider(ctx, alertSenderConfig, badWalStatusAlertKey, badWalStatusAlertMessage)
+		if slotInfo.WalStatus == "lost" || slotInfo.WalStatus == "unreserved" {
+			if alertSenderConfig.shouldAlert(alertKeys.FlowName, badWalStatusRoute) && a.checkAndAddAlertToCatalog(ctx,
+				alertSenderConfig.Id, alertKeys, AlertTypeBadWALStatus, badWalStatusAlertKey, badWalStatusAlertMessage,
+			) {
+				a.alertToProvider(ctx, alertSenderConfig, badWalStatusAlertKey, badWalStatusAlertMessage)
+			}
+		} else {
+			a.resolveAlert(ctx, alertSenderConfig, badWalStatusAlertKey, badWalStatusResolvedMessage)
 		}
 	}
 }
@@ -237,45 +314,34 @@ func (a *Alerter) AlertIfOpenConnections(ctx context.Context, alertKeys *AlertKe
 		deploymentUIDPrefix = fmt.Sprintf("[%s] - ", internal.PeerDBDeploymentUID())
 	}
 
-	// same as with slot lag, use lowest threshold for catalog
 	defaultOpenConnectionsThreshold, err := internal.PeerDBOpenConnectionsAlertThreshold(ctx, nil)
 	if err != nil {
 		internal.LoggerFromCtx(ctx).Warn("failed to get open connections alert threshold from catalog", slog.Any("error", err))
 		return
 	}
-	lowestOpenConnectionsThreshold := defaultOpenConnectionsThreshold
-	for _, alertSender := range alertSenderConfigs {
-		if alertSender.Sender.getOpenConnectionsAlertThreshold() > 0 {
-			lowestOpenConnectionsThreshold = min(lowestOpenConnectionsThreshold,
-				alertSender.Sender.getOpenConnectionsAlertThreshold())
-		}
-	}
 
 	alertKey := fmt.Sprintf("%s Max Open Connections Threshold Exceeded for Peer %s", deploymentUIDPrefix, alertKeys.PeerName)
 	alertMessageTemplate := fmt.Sprintf("%sOpen connections from PeerDB user `%s` on peer `%s`"+
 		` has exceeded threshold size of %%d connections, currently at %d connections!`,
 		deploymentUIDPrefix, openConnections.UserName, alertKeys.PeerName, openConnections.CurrentOpenConnections)
+	resolvedMessageTemplate := fmt.Sprintf("%sOpen connections from PeerDB user `%s` on peer `%s`"+
+		` are back under threshold size of %%d connections, currently at %d connections.`,
+		deploymentUIDPrefix, openConnections.UserName, alertKeys.PeerName, openConnections.CurrentOpenConnections)
 
-	if openConnections.CurrentOpenConnections > int64(lowestOpenConnectionsThreshold) {
-		for _, alertSenderConfig := range alertSenderConfigs {
-			if len(alertSenderConfig.AlertForMirrors) > 0 &&
-				!slices.Contains(alertSenderConfig.AlertForMirrors, alertKeys.FlowName) {
-				continue
-			}
-			if a.checkAndAddAlertToCatalog(ctx,
-				alertSenderConfig.Id, alertKey, fmt.Sprintf(alertMessageTemplate, lowestOpenConnectionsThreshold)) {
-				if alertSenderConfig.Sender.getOpenConnectionsAlertThreshold() > 0 {
-					if openConnections.CurrentOpenConnections > int64(alertSenderConfig.Sender.getOpenConnectionsAlertThreshold()) {
-						a.alertToProvider(ctx, alertSenderConfig, alertKey,
-							fmt.Sprintf(alertMessageTemplate, alertSenderConfig.Sender.getOpenConnectionsAlertThreshold()))
-					}
-				} else {
-					if openConnections.CurrentOpenConnections > int64(defaultOpenConnectionsThreshold) {
-						a.alertToProvider(ctx, alertSenderConfig, alertKey,
-							fmt.Sprintf(alertMessageTemplate, defaultOpenConnectionsThreshold))
-					}
-				}
+	route := newAlertRoute(a.routingTags(ctx, alertSenderConfigs, alertKeys.FlowName), AlertTypeOpenConnections)
+	for _, alertSenderConfig := range alertSenderConfigs {
+		openConnectionsThreshold := defaultOpenConnectionsThreshold
+		if alertSenderConfig.Sender.getOpenConnectionsAlertThreshold() > 0 {
+			openConnectionsThreshold = alertSenderConfig.Sender.getOpenConnectionsAlertThreshold()
+		}
+		if openConnections.CurrentOpenConnections > int64(openConnectionsThreshold) {
+			alertMessage := fmt.Sprintf(alertMessageTemplate, openConnectionsThreshold)
+			if alertSenderConfig.shouldAlert(alertKeys.FlowName, route) &&
+				a.checkAndAddAlertToCatalog(ctx, alertSenderConfig.Id, alertKeys, AlertTypeOpenConnections, alertKey, alertMessage) {
+				a.alertToProvider(ctx, alertSenderConfig, alertKey, alertMessage)
 			}
+		} else {
+			a.resolveAlert(ctx, alertSenderConfig, alertKey, fmt.Sprintf(resolvedMessageTemplate, openConnectionsThreshold))
 		}
 	}
 }
@@ -302,21 +368,71 @@ func (a *Alerter) AlertIfTooLongSinceLastNormalize(ctx context.Context, alertKey
 		deploymentUIDPrefix = fmt.Sprintf("[%s] - ", internal.PeerDBDeploymentUID())
 	}
 
-	if intervalSinceLastNormalize > time.Duration(intervalSinceLastNormalizeThreshold)*time.Minute {
-		alertKey := fmt.Sprintf("%s Too long since last data normalize for PeerDB mirror %s",
-			deploymentUIDPrefix, alertKeys.FlowName)
-		alertMessage := fmt.Sprintf("%sData hasn't been synced to the target for mirror `%s` since the last `%s`."+
+	alertKey := fmt.Sprintf("%s Too long since last data normalize for PeerDB mirror %s",
+		deploymentUIDPrefix, alertKeys.FlowName)
+	exceeded := intervalSinceLastNormalize > time.Duration(intervalSinceLastNormalizeThreshold)*time.Minute
+	var alertMessage string
+	if exceeded {
+		alertMessage = fmt.Sprintf("%sData hasn't been synced to the target for mirror `%s` since the last `%s`."+
 			` This could indicate an issue with the pipeline — please check the UI and logs to confirm.`+
 			` Alternatively, it might be that the source database is idle and not receiving new updates.`, deploymentUIDPrefix,
 			alertKeys.FlowName, intervalSinceLastNormalize)
+	} else {
+		alertMessage = fmt.Sprintf("%sData is being synced to the target for mirror `%s` again, last normalize was `%s` ago.",
+			deploymentUIDPrefix, alertKeys.FlowName, intervalSinceLastNormalize)
+	}
 
-		for _, alertSenderConfig := range alertSenderConfigs {
-			if len(alertSenderConfig.AlertForMirrors) == 0 ||
-				slices.Contains(alertSenderConfig.AlertForMirrors, alertKeys.FlowName) {
-				if a.checkAndAddAlertToCatalog(ctx, alertSenderConfig.Id, alertKey, alertMessage) {
-					a.alertToProvider(ctx, alertSenderConfig, alertKey, alertMessage)
-				}
-			}
+	route := newAlertRoute(a.routingTags(ctx, alertSenderConfigs, alertKeys.FlowName), AlertTypeNormalizeDelay)
+	for _, alertSenderConfig := range alertSenderConfigs {
+		if !exceeded {
+			a.resolveAlert(ctx, alertSenderConfig, alertKey, alertMessage)
+		} else if alertSenderConfig.shouldAlert(alertKeys.FlowName, route) &&
+			a.checkAndAddAlertToCatalog(ctx, alertSenderConfig.Id, alertKeys, AlertTypeNormalizeDelay, alertKey, alertMessage) {
+			a.alertToProvider(ctx, alertSenderConfig, alertKey, alertMessage)
+		}
+	}
+}
+
+// routingTags fetches mirror tags only when some alert config needs them for routing
+func (a *Alerter) routingTags(ctx context.Context, alertSenderConfigs []AlertSenderConfig, flowName string) map[string]string {
+	if !hasRoutingRules(alertSenderConfigs) {
+		return nil
+	}
+	tags, err := GetTags(ctx, a.CatalogPool, flowName)
+	if err != nil {
+		internal.LoggerFromCtx(ctx).Warn("failed to get flow tags for alert routing", slog.Any("error", err))
+	}
+	return tags
+}
+
+// alertIfFlowError sends classified flow errors to alert configs whose routing rules ask for them
+func (a *Alerter) alertIfFlowError(ctx context.Context, flowName string, errorClass ErrorClass, inErr error) {
+	if errorClass.ErrorAction() == Ignore {
+		return
+	}
+	logger := internal.LoggerFromCtx(ctx)
+	alertSenderConfigs, err := a.flowErrorSenderConfigs(ctx)
+	if err != nil {
+		logger.Warn("failed to set alert senders", slog.Any("error", err))
+		return
+	}
+	if !hasRoutingRules(alertSenderConfigs) {
+		return
+	}
+
+	deploymentUIDPrefix := ""
+	if internal.PeerDBDeploymentUID() != "" {
+		deploymentUIDPrefix = fmt.Sprintf("[%s] - ", internal.PeerDBDeploymentUID())
+	}
+	alertKey := fmt.Sprintf("%s %s error for PeerDB mirror %s", deploymentUIDPrefix, errorClass.Class, flowName)
+	alertMessage := fmt.Sprintf("%sMirror `%s` failed with `%s`: %s", deploymentUIDPrefix, flowName, errorClass.Class, inErr.Error())
+	alertKeys := &AlertKeys{FlowName: flowName}
+
+	route := newFlowErrorAlertRoute(a.routingTags(ctx, alertSenderConfigs, flowName), errorClass)
+	for _, alertSenderConfig := range alertSenderConfigs {
+		if alertSenderConfig.shouldAlert(flowName, route) &&
+			a.checkAndAddAlertToCatalog(ctx, alertSenderConfig.Id, alertKeys, AlertTypeFlowError, alertKey, alertMessage) {
+			a.alertToProvider(ctx, alertSenderConfig, alertKey, alertMessage)
 		}
 	}
 }
@@ -327,10 +443,35 @@ func (a *Alerter) alertToProvider(ctx context.Context, alertSenderConfig AlertSe
 	}
 }
 
+// resolveAlert closes the active incident for alertKey, if any,
+// and notifies the provider that the condition has cleared
+func (a *Alerter) resolveAlert(ctx context.Context, alertSenderConfig AlertSenderConfig, alertKey string, resolvedMessage string) {
+	logger := internal.LoggerFromCtx(ctx)
+	resolved, err := resolveActiveAlert(ctx, a.CatalogPool, alertSenderConfig.Id, alertKey)
+	if err != nil {
+		logger.Warn("failed to resolve alert", slog.Any("error", err))
+		return
+	}
+	if !resolved {
+		return
+	}
+	if err := alertSenderConfig.Sender.sendResolved(ctx, alertKey, resolvedMessage); err != nil {
+		logger.Warn("failed to send alert resolution", slog.Any("error", err))
+	}
+}
+
 // Only raises an alert if another alert with the same key hasn't been raised
-// in the past X minutes, where X is configurable and defaults to 15 minutes
+// in the past X minutes, where X is configurable and defaults to 15 minutes,
+// and the active incident for the alert key hasn't been acknowledged
 // returns true if alert added to catalog, so proceed with processing alerts to slack
-func (a *Alerter) checkAndAddAlertToCatalog(ctx context.Context, alertConfigId int64, alertKey string, alertMessage string) bool {
+func (a *Alerter) checkAndAddAlertToCatalog(
+	ctx context.Context,
+	alertConfigId int64,
+	alertKeys *AlertKeys,
+	alertType AlertType,
+	alertKey string,
+	alertMessage string,
+) bool {
 	logger := internal.LoggerFromCtx(ctx)
 	dur, err := internal.PeerDBAlertingGapMinutesAsDuration(ctx, nil)
 	if err != nil {
@@ -342,6 +483,16 @@ func (a *Alerter) checkAndAddAlertToCatalog(ctx context.Context, alertConfigId i
 		return false
 	}
 
+	status, err := touchActiveAlert(ctx, a.CatalogPool, alertConfigId, alertKey, alertMessage)
+	if err != nil {
+		logger.Warn("failed to update active alert", slog.Any("error", err))
+		return false
+	}
+	if status == AlertStatusAcknowledged {
+		logger.Info("Skipped sending alerts: alert has been acknowledged", slog.String("alertKey", alertKey))
+		return false
+	}
+
 	var createdTimestamp time.Time
 	if err := a.CatalogPool.QueryRow(ctx,
 		`SELECT created_timestamp FROM peerdb_stats.alerts_v1 WHERE alert_key=$1 AND alert_config_id=$2
@@ -360,6 +511,11 @@ func (a *Alerter) checkAndAddAlertToCatalog(ctx context.Context, alertConfigId i
 			internal.LoggerFromCtx(ctx).Warn("failed to insert alert", slog.Any("error", err))
 			return false
 		}
+		if alertType.resolvable() {
+			if err := openActiveAlert(ctx, a.CatalogPool, alertConfigId, alertKeys, alertType, alertKey, alertMessage); err != nil {
+				logger.Warn("failed to open active alert", slog.Any("error", err))
+			}
+		}
 		return true
 	}
 
@@ -498,6 +654,8 @@ func (a *Alerter) logFlowErrorInternal(ctx context.Context, flowName, errorType
 	))
 	a.otelManager.Metrics.ErrorsEmittedCounter.Add(ctx, 1, errorAttributeSet)
 	a.otelManager.Metrics.ErrorEmittedGauge.Record(ctx, 1, errorAttributeSet)
+
+	a.alertIfFlowError(ctx, flowName, errorClass, inErr)
 }
 
 func (a *Alerter) LogFlowError(ctx context.Context, flowName string, inErr error) error {
@@ -61,6 +61,14 @@ func (e *EmailAlertSender) getOpenConnectionsAlertThreshold() uint32 {
 }
 
 func (e *EmailAlertSender) sendAlert(ctx context.Context, alertTitle string, alertMessage string) error {
+	return e.sendEmail(ctx, alertTitle, alertMessage)
+}
+
+func (e *EmailAlertSender) sendResolved(ctx context.Context, alertTitle string, alertMessage string) error {
+	return e.sendEmail(ctx, "[Resolved] "+alertTitle, alertMessage)
+}
+
+func (e *EmailAlertSender) sendEmail(ctx context.Context, alertTitle string, alertMessage string) error {
 	_, err := e.client.SendEmail(ctx, &ses.SendEmailInput{
 		Destination: &types.Destination{
 			ToAddresses: e.emailAddresses,
@@ -0,0 +1,95 @@
+package alerting
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/PeerDB-io/peerdb/flow/internal"
+)
+
+const (
+	httpAlertSenderTimeout     = 10 * time.Second
+	httpAlertSenderMaxAttempts = 4
+	httpAlertSenderBaseBackoff = 500 * time.Millisecond
+)
+
+func newAlertHTTPClient() *http.Client {
+	return &http.Client{Timeout: httpAlertSenderTimeout}
+}
+
+// alertDedupKey derives a stable key from the alert key, so that providers with incident
+// deduplication group repeated alerts for the same condition together
+func alertDedupKey(alertKey string) string {
+	h := sha256.New()
+	h.Write([]byte(internal.PeerDBDeploymentUID()))
+	h.Write([]byte{0})
+	h.Write([]byte(alertKey))
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// postWithRetry POSTs body to url, retrying with exponential backoff on network errors,
+// 429 and 5xx responses. Other non-2xx responses are returned as errors immediately.
+func postWithRetry(
+	ctx context.Context,
+	client *http.Client,
+	url string,
+	body []byte,
+	setHeaders func(http.Header),
+) error {
+	backoff := httpAlertSenderBaseBackoff
+	var lastErr error
+	for attempt := 1; attempt <= httpAlertSenderMaxAttempts; attempt++ {
+		retryable, err := postOnce(ctx, client, url, body, setHeaders)
+		if err == nil {
+			return nil
+		}
+		lastErr = err
+		if !retryable || attempt == httpAlertSenderMaxAttempts {
+			break
+		}
+		internal.LoggerFromCtx(ctx).Warn(fmt.Sprintf("alert request attempt #%d failed, retrying in %s: %v", attempt, backoff, err))
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(backoff):
+		}
+		backoff *= 2
+	}
+	return lastErr
+}
+
+func postOnce(
+	ctx context.Context,
+	client *http.Client,
+	url string,
+	body []byte,
+	setHeaders func(http.Header),
+) (bool, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return false, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if setHeaders != nil {
+		setHeaders(req.Header)
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return ctx.Err() == nil, fmt.Errorf("alert request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 200 && resp.StatusCode < 300 {
+		return false, nil
+	}
+	respBody, _ := io.ReadAll(io.LimitReader(resp.Body, 4096))
+	return resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode >= 500,
+		fmt.Errorf("unexpected response from alert endpoint. status: %d. body: %s", resp.StatusCode, respBody)
+}
@@ -0,0 +1,135 @@
+package alerting
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"unicode/utf8"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/PeerDB-io/peerdb/flow/shared"
+)
+
+func TestWebhookAlertSenderSignsPayload(t *testing.T) {
+	var received webhookAlertPayload
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, err := io.ReadAll(r.Body)
+		assert.NoError(t, err)
+		assert.Equal(t, "bar", r.Header.Get("X-Foo"))
+		timestamp := r.Header.Get(shared.WebhookTimestampHeader)
+		assert.NotEmpty(t, timestamp)
+		assert.Equal(t, shared.SignWebhookPayload("secret", timestamp, body), r.Header.Get(shared.WebhookSignatureHeader))
+		assert.NoError(t, json.Unmarshal(body, &received))
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer server.Close()
+
+	sender, err := newWebhookAlertSender(&webhookAlertConfig{
+		URL:           server.URL,
+		SigningSecret: "secret",
+		Headers:       map[string]string{"X-Foo": "bar"},
+	})
+	require.NoError(t, err)
+	require.NoError(t, sender.sendAlert(t.Context(), "title", "message"))
+	require.Equal(t, "title", received.Title)
+	require.Equal(t, "message", received.Message)
+	require.Equal(t, alertDedupKey("title"), received.DedupKey)
+	require.Equal(t, "firing", received.Status)
+
+	require.NoError(t, sender.sendResolved(t.Context(), "title", "resolved"))
+	require.Equal(t, "resolved", received.Status)
+}
+
+func TestWebhookAlertSenderRetries(t *testing.T) {
+	var attempts atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if attempts.Add(1) == 1 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	sender, err := newWebhookAlertSender(&webhookAlertConfig{URL: server.URL})
+	require.NoError(t, err)
+	require.NoError(t, sender.sendAlert(t.Context(), "title", "message"))
+	require.Equal(t, int32(2), attempts.Load())
+}
+
+func TestWebhookAlertSenderDoesNotRetryClientErrors(t *testing.T) {
+	var attempts atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		attempts.Add(1)
+		w.WriteHeader(http.StatusBadRequest)
+	}))
+	defer server.Close()
+
+	sender, err := newWebhookAlertSender(&webhookAlertConfig{URL: server.URL})
+	require.NoError(t, err)
+	require.Error(t, sender.sendAlert(t.Context(), "title", "message"))
+	require.Equal(t, int32(1), attempts.Load())
+}
+
+func TestPagerDutyAlertSender(t *testing.T) {
+	var received pagerDutyEvent
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.NoError(t, json.NewDecoder(r.Body).Decode(&received))
+		w.WriteHeader(http.StatusAccepted)
+	}))
+	defer server.Close()
+
+	sender, err := newPagerDutyAlertSender(&pagerDutyAlertConfig{
+		RoutingKey: "routing",
+		Severity:   "warning",
+		EventsURL:  server.URL,
+	})
+	require.NoError(t, err)
+	require.NoError(t, sender.sendAlert(t.Context(), "title", "message"))
+	require.Equal(t, "routing", received.RoutingKey)
+	require.Equal(t, "trigger", received.EventAction)
+	require.Equal(t, alertDedupKey("title"), received.DedupKey)
+	require.Equal(t, "warning", received.Payload.Severity)
+	require.Equal(t, "message", received.Payload.CustomDetails["message"])
+
+	require.NoError(t, sender.sendResolved(t.Context(), "title", "resolved"))
+	require.Equal(t, "resolve", received.EventAction)
+	require.Equal(t, alertDedupKey("title"), received.DedupKey)
+
+	_, err = newPagerDutyAlertSender(&pagerDutyAlertConfig{RoutingKey: "routing", Severity: "loud"})
+	require.Error(t, err)
+}
+
+func TestOpsgenieAlertSender(t *testing.T) {
+	var received opsgenieAlert
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "GenieKey key", r.Header.Get("Authorization"))
+		assert.NoError(t, json.NewDecoder(r.Body).Decode(&received))
+		w.WriteHeader(http.StatusAccepted)
+	}))
+	defer server.Close()
+
+	sender, err := newOpsgenieAlertSender(&opsgenieAlertConfig{APIKey: "key", Region: "eu"})
+	require.NoError(t, err)
+	require.Equal(t, opsgenieEUAlertsURL, sender.alertsURL)
+	sender.alertsURL = server.URL
+	require.NoError(t, sender.sendAlert(t.Context(), "title", "message"))
+	require.Equal(t, "title", received.Message)
+	require.Equal(t, alertDedupKey("title"), received.Alias)
+	require.Equal(t, "P1", received.Priority)
+}
+
+func TestTruncateStringKeepsRunes(t *testing.T) {
+	require.Equal(t, "short", truncateString("short", 10))
+	require.Equal(t, "ab", truncateString("abc", 2))
+	// é is two bytes, cutting through it steps back to the previous rune
+	truncated := truncateString("aé", 2)
+	require.Equal(t, "a", truncated)
+	require.True(t, utf8.ValidString(truncated))
+	require.Equal(t, "日", truncateString("日本", 5))
+}
@@ -4,6 +4,7 @@ import "context"
 
 type AlertSender interface {
 	sendAlert(ctx context.Context, alertTitle string, alertMessage string) error
+	sendResolved(ctx context.Context, alertTitle string, alertMessage string) error
 	getSlotLagMBAlertThreshold() uint32
 	getOpenConnectionsAlertThreshold() uint32
 }
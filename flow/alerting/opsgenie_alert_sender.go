@@ -0,0 +1,144 @@
+package alerting
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+
+	"github.com/PeerDB-io/peerdb/flow/internal"
+)
+
+const (
+	opsgenieAlertsURL   = "https://api.opsgenie.com/v2/alerts"
+	opsgenieEUAlertsURL = "https://api.eu.opsgenie.com/v2/alerts"
+)
+
+type OpsgenieAlertSender struct {
+	AlertSender
+	client                        *http.Client
+	alertsURL                     string
+	apiKey                        string
+	priority                      string
+	responders                    []opsgenieResponder
+	slotLagMBAlertThreshold       uint32
+	openConnectionsAlertThreshold uint32
+}
+
+type opsgenieResponder struct {
+	Name string `json:"name,omitempty"`
+	ID   string `json:"id,omitempty"`
+	// one of team, user, escalation, schedule
+	Type string `json:"type"`
+}
+
+type opsgenieAlertConfig struct {
+	APIKey string `json:"api_key"`
+	// "us" (default) or "eu"
+	Region string `json:"region"`
+	// one of P1..P5; defaults to P1
+	Priority                      string              `json:"priority"`
+	Responders                    []opsgenieResponder `json:"responders"`
+	SlotLagMBAlertThreshold       uint32              `json:"slot_lag_mb_alert_threshold"`
+	OpenConnectionsAlertThreshold uint32              `json:"open_connections_alert_threshold"`
+}
+
+type opsgenieCloseAlert struct {
+	Source string `json:"source"`
+	Note   string `json:"note"`
+}
+
+type opsgenieAlert struct {
+	Details     map[string]string   `json:"details,omitempty"`
+	Message     string              `json:"message"`
+	Alias       string              `json:"alias"`
+	Description string              `json:"description"`
+	Source      string              `json:"source"`
+	Priority    string              `json:"priority"`
+	Responders  []opsgenieResponder `json:"responders,omitempty"`
+	Tags        []string            `json:"tags,omitempty"`
+}
+
+func newOpsgenieAlertSender(config *opsgenieAlertConfig) (*OpsgenieAlertSender, error) {
+	if config.APIKey == "" {
+		return nil, errors.New("opsgenie api key is required")
+	}
+	var alertsURL string
+	switch config.Region {
+	case "", "us":
+		alertsURL = opsgenieAlertsURL
+	case "eu":
+		alertsURL = opsgenieEUAlertsURL
+	default:
+		return nil, fmt.Errorf("invalid opsgenie region: %s", config.Region)
+	}
+	priority := config.Priority
+	switch priority {
+	case "":
+		priority = "P1"
+	case "P1", "P2", "P3", "P4", "P5":
+	default:
+		return nil, fmt.Errorf("invalid opsgenie priority: %s", priority)
+	}
+	return &OpsgenieAlertSender{
+		client:                        newAlertHTTPClient(),
+		alertsURL:                     alertsURL,
+		apiKey:                        config.APIKey,
+		priority:                      priority,
+		responders:                    config.Responders,
+		slotLagMBAlertThreshold:       config.SlotLagMBAlertThreshold,
+		openConnectionsAlertThreshold: config.OpenConnectionsAlertThreshold,
+	}, nil
+}
+
+func (o *OpsgenieAlertSender) getSlotLagMBAlertThreshold() uint32 {
+	return o.slotLagMBAlertThreshold
+}
+
+func (o *OpsgenieAlertSender) getOpenConnectionsAlertThreshold() uint32 {
+	return o.openConnectionsAlertThreshold
+}
+
+func (o *OpsgenieAlertSender) sendAlert(ctx context.Context, alertTitle string, alertMessage string) error {
+	body, err := json.Marshal(opsgenieAlert{
+		// Opsgenie limits message to 130 characters and description to 15000
+		Message:     truncateString(alertTitle, 130),
+		Alias:       alertDedupKey(alertTitle),
+		Description: truncateString(alertMessage, 15000),
+		Source:      "peerdb",
+		Priority:    o.priority,
+		Responders:  o.responders,
+		Tags:        []string{"peerdb"},
+		Details:     map[string]string{"deploymentUID": internal.PeerDBDeploymentUID()},
+	})
+	if err != nil {
+		return fmt.Errorf("error serializing opsgenie alert: %w", err)
+	}
+
+	if err := postWithRetry(ctx, o.client, o.alertsURL, body, func(header http.Header) {
+		header.Set("Authorization", "GenieKey "+o.apiKey)
+	}); err != nil {
+		return fmt.Errorf("failed to send opsgenie alert: %w", err)
+	}
+	return nil
+}
+
+func (o *OpsgenieAlertSender) sendResolved(ctx context.Context, alertTitle string, alertMessage string) error {
+	body, err := json.Marshal(opsgenieCloseAlert{
+		Source: "peerdb",
+		Note:   truncateString(alertMessage, 25000),
+	})
+	if err != nil {
+		return fmt.Errorf("error serializing opsgenie close request: %w", err)
+	}
+
+	closeURL := o.alertsURL + "/" + url.PathEscape(alertDedupKey(alertTitle)) + "/close?identifierType=alias"
+	if err := postWithRetry(ctx, o.client, closeURL, body, func(header http.Header) {
+		header.Set("Authorization", "GenieKey "+o.apiKey)
+	}); err != nil {
+		return fmt.Errorf("failed to close opsgenie alert: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,137 @@
+package alerting
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"unicode/utf8"
+
+	"github.com/PeerDB-io/peerdb/flow/internal"
+)
+
+const pagerDutyEventsURL = "https://events.pagerduty.com/v2/enqueue"
+
+type PagerDutyAlertSender struct {
+	AlertSender
+	client                        *http.Client
+	eventsURL                     string
+	routingKey                    string
+	severity                      string
+	slotLagMBAlertThreshold       uint32
+	openConnectionsAlertThreshold uint32
+}
+
+type pagerDutyAlertConfig struct {
+	RoutingKey string `json:"routing_key"`
+	// one of critical, error, warning, info; defaults to critical
+	Severity                      string `json:"severity"`
+	EventsURL                     string `json:"events_url"`
+	SlotLagMBAlertThreshold       uint32 `json:"slot_lag_mb_alert_threshold"`
+	OpenConnectionsAlertThreshold uint32 `json:"open_connections_alert_threshold"`
+}
+
+type pagerDutyPayload struct {
+	CustomDetails map[string]string `json:"custom_details,omitempty"`
+	Summary       string            `json:"summary"`
+	Source        string            `json:"source"`
+	Severity      string            `json:"severity"`
+	Component     string            `json:"component,omitempty"`
+}
+
+type pagerDutyEvent struct {
+	Payload     *pagerDutyPayload `json:"payload,omitempty"`
+	RoutingKey  string            `json:"routing_key"`
+	EventAction string            `json:"event_action"`
+	DedupKey    string            `json:"dedup_key"`
+}
+
+func newPagerDutyAlertSender(config *pagerDutyAlertConfig) (*PagerDutyAlertSender, error) {
+	if config.RoutingKey == "" {
+		return nil, errors.New("pagerduty routing key is required")
+	}
+	severity := config.Severity
+	switch severity {
+	case "":
+		severity = "critical"
+	case "critical", "error", "warning", "info":
+	default:
+		return nil, fmt.Errorf("invalid pagerduty severity: %s", severity)
+	}
+	eventsURL := config.EventsURL
+	if eventsURL == "" {
+		eventsURL = pagerDutyEventsURL
+	}
+	return &PagerDutyAlertSender{
+		client:                        newAlertHTTPClient(),
+		eventsURL:                     eventsURL,
+		routingKey:                    config.RoutingKey,
+		severity:                      severity,
+		slotLagMBAlertThreshold:       config.SlotLagMBAlertThreshold,
+		openConnectionsAlertThreshold: config.OpenConnectionsAlertThreshold,
+	}, nil
+}
+
+func (p *PagerDutyAlertSender) getSlotLagMBAlertThreshold() uint32 {
+	return p.slotLagMBAlertThreshold
+}
+
+func (p *PagerDutyAlertSender) getOpenConnectionsAlertThreshold() uint32 {
+	return p.openConnectionsAlertThreshold
+}
+
+func (p *PagerDutyAlertSender) sendAlert(ctx context.Context, alertTitle string, alertMessage string) error {
+	source := internal.PeerDBDeploymentUID()
+	if source == "" {
+		source = "peerdb"
+	}
+	body, err := json.Marshal(pagerDutyEvent{
+		RoutingKey:  p.routingKey,
+		EventAction: "trigger",
+		DedupKey:    alertDedupKey(alertTitle),
+		Payload: &pagerDutyPayload{
+			// PagerDuty truncates summaries at 1024 characters
+			Summary:       truncateString(alertTitle, 1024),
+			Source:        source,
+			Severity:      p.severity,
+			Component:     "peerdb",
+			CustomDetails: map[string]string{"message": alertMessage},
+		},
+	})
+	if err != nil {
+		return fmt.Errorf("error serializing pagerduty event: %w", err)
+	}
+
+	if err := postWithRetry(ctx, p.client, p.eventsURL, body, nil); err != nil {
+		return fmt.Errorf("failed to send pagerduty event: %w", err)
+	}
+	return nil
+}
+
+func (p *PagerDutyAlertSender) sendResolved(ctx context.Context, alertTitle string, _ string) error {
+	body, err := json.Marshal(pagerDutyEvent{
+		RoutingKey:  p.routingKey,
+		EventAction: "resolve",
+		DedupKey:    alertDedupKey(alertTitle),
+	})
+	if err != nil {
+		return fmt.Errorf("error serializing pagerduty event: %w", err)
+	}
+
+	if err := postWithRetry(ctx, p.client, p.eventsURL, body, nil); err != nil {
+		return fmt.Errorf("failed to send pagerduty resolve event: %w", err)
+	}
+	return nil
+}
+
+func truncateString(s string, maxLen int) string {
+	if len(s) <= maxLen {
+		return s
+	}
+	// step back to a rune boundary so the payload stays valid UTF-8
+	for maxLen > 0 && !utf8.RuneStart(s[maxLen]) {
+		maxLen -= 1
+	}
+	return s[:maxLen]
+}
@@ -0,0 +1,202 @@
+package alerting
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/PeerDB-io/peerdb/flow/generated/protos"
+)
+
+type AlertSeverity string
+
+const (
+	AlertSeverityCritical AlertSeverity = "critical"
+	AlertSeverityWarning  AlertSeverity = "warning"
+)
+
+// AlertRoutingRule matches an alert when every non-empty field matches,
+// an alert config with routing rules only receives alerts matched by at least one of its rules
+type AlertRoutingRule struct {
+	// mirror must have all of these tags
+	Tags map[string]string `json:"tags,omitempty"`
+	// mirror must have none of these tags
+	ExcludeTags map[string]string `json:"exclude_tags,omitempty"`
+	// only applies to flow errors, matched against ErrorClass.Class
+	ErrorClasses []string `json:"error_classes,omitempty"`
+	// only applies to flow errors, matched against ErrorClass.ErrorAction
+	ErrorActions []ErrorAction   `json:"error_actions,omitempty"`
+	AlertTypes   []AlertType     `json:"alert_types,omitempty"`
+	Severities   []AlertSeverity `json:"severities,omitempty"`
+}
+
+// alertRoute describes an alert for matching against routing rules
+type alertRoute struct {
+	tags        map[string]string
+	errorClass  string
+	errorAction ErrorAction
+	alertType   AlertType
+	severity    AlertSeverity
+}
+
+func (t AlertType) severity() AlertSeverity {
+	switch t {
+	case AlertTypeSlotLag, AlertTypeBadWALStatus:
+		return AlertSeverityCritical
+	default:
+		return AlertSeverityWarning
+	}
+}
+
+func newAlertRoute(tags map[string]string, alertType AlertType) *alertRoute {
+	return &alertRoute{
+		tags:      tags,
+		alertType: alertType,
+		severity:  alertType.severity(),
+	}
+}
+
+func newFlowErrorAlertRoute(tags map[string]string, errorClass ErrorClass) *alertRoute {
+	severity := AlertSeverityWarning
+	if errorClass.ErrorAction() == NotifyUser {
+		severity = AlertSeverityCritical
+	}
+	return &alertRoute{
+		tags:        tags,
+		errorClass:  errorClass.Class,
+		errorAction: errorClass.ErrorAction(),
+		alertType:   AlertTypeFlowError,
+		severity:    severity,
+	}
+}
+
+func (r *AlertRoutingRule) matches(route *alertRoute) bool {
+	for key, value := range r.Tags {
+		if tagValue, ok := route.tags[key]; !ok || tagValue != value {
+			return false
+		}
+	}
+	for key, value := range r.ExcludeTags {
+		if tagValue, ok := route.tags[key]; ok && tagValue == value {
+			return false
+		}
+	}
+	if len(r.ErrorClasses) > 0 && (route.alertType != AlertTypeFlowError ||
+		!slices.ContainsFunc(r.ErrorClasses, func(class string) bool { return strings.EqualFold(class, route.errorClass) })) {
+		return false
+	}
+	if len(r.ErrorActions) > 0 && (route.alertType != AlertTypeFlowError || !slices.Contains(r.ErrorActions, route.errorAction)) {
+		return false
+	}
+	if len(r.AlertTypes) > 0 && !slices.Contains(r.AlertTypes, route.alertType) {
+		return false
+	}
+	if len(r.Severities) > 0 && !slices.Contains(r.Severities, route.severity) {
+		return false
+	}
+	return true
+}
+
+// shouldAlert decides if an alert for flowName is sent to this config. Configs without routing rules
+// keep the behavior from before routing rules existed: everything but flow errors, filtered by AlertForMirrors
+func (c *AlertSenderConfig) shouldAlert(flowName string, route *alertRoute) bool {
+	if len(c.AlertForMirrors) > 0 && !slices.Contains(c.AlertForMirrors, flowName) {
+		return false
+	}
+	if len(c.RoutingRules) == 0 {
+		return route.alertType != AlertTypeFlowError
+	}
+	return slices.ContainsFunc(c.RoutingRules, func(rule AlertRoutingRule) bool {
+		return rule.matches(route)
+	})
+}
+
+func hasRoutingRules(alertSenderConfigs []AlertSenderConfig) bool {
+	return slices.ContainsFunc(alertSenderConfigs, func(c AlertSenderConfig) bool {
+		return len(c.RoutingRules) > 0
+	})
+}
+
+func ValidateRoutingRules(rules []AlertRoutingRule) error {
+	for _, rule := range rules {
+		for _, alertType := range rule.AlertTypes {
+			switch alertType {
+			case AlertTypeSlotLag, AlertTypeBadWALStatus, AlertTypeOpenConnections, AlertTypeNormalizeDelay, AlertTypeFlowError:
+			default:
+				return fmt.Errorf("unknown alert type in routing rule: %s", alertType)
+			}
+		}
+		for _, errorAction := range rule.ErrorActions {
+			switch errorAction {
+			case NotifyUser, NotifyTelemetry:
+			default:
+				return fmt.Errorf("unknown error action in routing rule: %s", errorAction)
+			}
+		}
+		for _, severity := range rule.Severities {
+			switch severity {
+			case AlertSeverityCritical, AlertSeverityWarning:
+			default:
+				return fmt.Errorf("unknown severity in routing rule: %s", severity)
+			}
+		}
+	}
+	return nil
+}
+
+func RoutingRulesFromProto(rules []*protos.AlertRoutingRule) []AlertRoutingRule {
+	if len(rules) == 0 {
+		return nil
+	}
+	res := make([]AlertRoutingRule, 0, len(rules))
+	for _, rule := range rules {
+		alertTypes := make([]AlertType, 0, len(rule.AlertTypes))
+		for _, alertType := range rule.AlertTypes {
+			alertTypes = append(alertTypes, AlertType(alertType))
+		}
+		severities := make([]AlertSeverity, 0, len(rule.Severities))
+		for _, severity := range rule.Severities {
+			severities = append(severities, AlertSeverity(severity))
+		}
+		errorActions := make([]ErrorAction, 0, len(rule.ErrorActions))
+		for _, errorAction := range rule.ErrorActions {
+			errorActions = append(errorActions, ErrorAction(errorAction))
+		}
+		res = append(res, AlertRoutingRule{
+			Tags:         rule.Tags,
+			ExcludeTags:  rule.ExcludeTags,
+			ErrorClasses: rule.ErrorClasses,
+			ErrorActions: errorActions,
+			AlertTypes:   alertTypes,
+			Severities:   severities,
+		})
+	}
+	return res
+}
+
+func RoutingRulesToProto(rules []AlertRoutingRule) []*protos.AlertRoutingRule {
+	res := make([]*protos.AlertRoutingRule, 0, len(rules))
+	for _, rule := range rules {
+		alertTypes := make([]string, 0, len(rule.AlertTypes))
+		for _, alertType := range rule.AlertTypes {
+			alertTypes = append(alertTypes, string(alertType))
+		}
+		severities := make([]string, 0, len(rule.Severities))
+		for _, severity := range rule.Severities {
+			severities = append(severities, string(severity))
+		}
+		errorActions := make([]string, 0, len(rule.ErrorActions))
+		for _, errorAction := range rule.ErrorActions {
+			errorActions = append(errorActions, string(errorAction))
+		}
+		res = append(res, &protos.AlertRoutingRule{
+			Tags:         rule.Tags,
+			ExcludeTags:  rule.ExcludeTags,
+			ErrorClasses: rule.ErrorClasses,
+			ErrorActions: errorActions,
+			AlertTypes:   alertTypes,
+			Severities:   severities,
+		})
+	}
+	return res
+}
@@ -0,0 +1,68 @@
+package alerting
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestShouldAlertWithoutRoutingRules(t *testing.T) {
+	config := AlertSenderConfig{}
+	require.True(t, config.shouldAlert("mirror", newAlertRoute(nil, AlertTypeSlotLag)))
+	require.False(t, config.shouldAlert("mirror", newFlowErrorAlertRoute(nil, ErrorNotifyConnectivity)))
+
+	config.AlertForMirrors = []string{"other"}
+	require.False(t, config.shouldAlert("mirror", newAlertRoute(nil, AlertTypeSlotLag)))
+}
+
+func TestShouldAlertRoutingRules(t *testing.T) {
+	payments := map[string]string{"team": "payments"}
+	search := map[string]string{"team": "search"}
+
+	paymentsOnCall := AlertSenderConfig{RoutingRules: []AlertRoutingRule{{
+		Tags:       payments,
+		Severities: []AlertSeverity{AlertSeverityCritical},
+	}}}
+	sharedChannel := AlertSenderConfig{RoutingRules: []AlertRoutingRule{{
+		ExcludeTags: payments,
+	}}}
+
+	require.True(t, paymentsOnCall.shouldAlert("m", newAlertRoute(payments, AlertTypeSlotLag)))
+	require.False(t, paymentsOnCall.shouldAlert("m", newAlertRoute(payments, AlertTypeNormalizeDelay)))
+	require.False(t, paymentsOnCall.shouldAlert("m", newAlertRoute(search, AlertTypeSlotLag)))
+	require.True(t, paymentsOnCall.shouldAlert("m", newFlowErrorAlertRoute(payments, ErrorNotifySlotInvalid)))
+
+	require.False(t, sharedChannel.shouldAlert("m", newAlertRoute(payments, AlertTypeSlotLag)))
+	require.True(t, sharedChannel.shouldAlert("m", newAlertRoute(search, AlertTypeSlotLag)))
+	require.True(t, sharedChannel.shouldAlert("m", newAlertRoute(nil, AlertTypeNormalizeDelay)))
+}
+
+func TestShouldAlertErrorClassRule(t *testing.T) {
+	config := AlertSenderConfig{RoutingRules: []AlertRoutingRule{{
+		AlertTypes:   []AlertType{AlertTypeFlowError},
+		ErrorClasses: []string{"notify_slot_invalid"},
+	}}}
+	require.True(t, config.shouldAlert("m", newFlowErrorAlertRoute(nil, ErrorNotifySlotInvalid)))
+	require.False(t, config.shouldAlert("m", newFlowErrorAlertRoute(nil, ErrorInternal)))
+	require.False(t, config.shouldAlert("m", newAlertRoute(nil, AlertTypeSlotLag)))
+}
+
+func TestShouldAlertErrorActionRule(t *testing.T) {
+	config := AlertSenderConfig{RoutingRules: []AlertRoutingRule{{
+		ErrorActions: []ErrorAction{NotifyUser},
+	}}}
+	require.True(t, config.shouldAlert("m", newFlowErrorAlertRoute(nil, ErrorNotifySlotInvalid)))
+	require.False(t, config.shouldAlert("m", newFlowErrorAlertRoute(nil, ErrorInternal)))
+	require.False(t, config.shouldAlert("m", newAlertRoute(nil, AlertTypeSlotLag)))
+
+	rules := RoutingRulesFromProto(RoutingRulesToProto(config.RoutingRules))
+	require.Equal(t, config.RoutingRules[0].ErrorActions, rules[0].ErrorActions)
+}
+
+func TestValidateRoutingRules(t *testing.T) {
+	require.NoError(t, ValidateRoutingRules([]AlertRoutingRule{{AlertTypes: []AlertType{AlertTypeFlowError}}}))
+	require.Error(t, ValidateRoutingRules([]AlertRoutingRule{{AlertTypes: []AlertType{"nope"}}}))
+	require.Error(t, ValidateRoutingRules([]AlertRoutingRule{{Severities: []AlertSeverity{"nope"}}}))
+	require.NoError(t, ValidateRoutingRules([]AlertRoutingRule{{ErrorActions: []ErrorAction{NotifyTelemetry}}}))
+	require.Error(t, ValidateRoutingRules([]AlertRoutingRule{{ErrorActions: []ErrorAction{Ignore}}}))
+}
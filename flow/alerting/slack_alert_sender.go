@@ -65,3 +65,17 @@ func (s *SlackAlertSender) sendAlert(ctx context.Context, alertTitle string, ale
 	}
 	return nil
 }
+
+func (s *SlackAlertSender) sendResolved(ctx context.Context, alertTitle string, alertMessage string) error {
+	for _, channelID := range s.channelIDs {
+		_, _, _, err := s.client.SendMessageContext(ctx, channelID, slack.MsgOptionBlocks(
+			slack.NewHeaderBlock(slack.NewTextBlockObject("plain_text",
+				":white_check_mark:Resolved:white_check_mark:: "+alertTitle, true, false)),
+			slack.NewSectionBlock(slack.NewTextBlockObject("mrkdwn", alertMessage, false, false), nil, nil),
+		))
+		if err != nil {
+			return fmt.Errorf("failed to send message to Slack channel %s: %w", channelID, err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,100 @@
+package alerting
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/PeerDB-io/peerdb/flow/internal"
+	"github.com/PeerDB-io/peerdb/flow/shared"
+)
+
+type WebhookAlertSender struct {
+	AlertSender
+	client                        *http.Client
+	headers                       map[string]string
+	url                           string
+	secret                        string
+	slotLagMBAlertThreshold       uint32
+	openConnectionsAlertThreshold uint32
+}
+
+type webhookAlertConfig struct {
+	Headers                       map[string]string `json:"headers"`
+	URL                           string            `json:"url"`
+	SigningSecret                 string            `json:"signing_secret"`
+	SlotLagMBAlertThreshold       uint32            `json:"slot_lag_mb_alert_threshold"`
+	OpenConnectionsAlertThreshold uint32            `json:"open_connections_alert_threshold"`
+}
+
+type webhookAlertPayload struct {
+	Timestamp     time.Time `json:"timestamp"`
+	Status        string    `json:"status"`
+	Title         string    `json:"title"`
+	Message       string    `json:"message"`
+	DedupKey      string    `json:"dedup_key"`
+	DeploymentUID string    `json:"deployment_uid"`
+}
+
+func newWebhookAlertSender(config *webhookAlertConfig) (*WebhookAlertSender, error) {
+	if config.URL == "" {
+		return nil, errors.New("webhook url is required")
+	}
+	return &WebhookAlertSender{
+		client:                        newAlertHTTPClient(),
+		url:                           config.URL,
+		secret:                        config.SigningSecret,
+		headers:                       config.Headers,
+		slotLagMBAlertThreshold:       config.SlotLagMBAlertThreshold,
+		openConnectionsAlertThreshold: config.OpenConnectionsAlertThreshold,
+	}, nil
+}
+
+func (w *WebhookAlertSender) getSlotLagMBAlertThreshold() uint32 {
+	return w.slotLagMBAlertThreshold
+}
+
+func (w *WebhookAlertSender) getOpenConnectionsAlertThreshold() uint32 {
+	return w.openConnectionsAlertThreshold
+}
+
+func (w *WebhookAlertSender) sendAlert(ctx context.Context, alertTitle string, alertMessage string) error {
+	return w.send(ctx, "firing", alertTitle, alertMessage)
+}
+
+func (w *WebhookAlertSender) sendResolved(ctx context.Context, alertTitle string, alertMessage string) error {
+	return w.send(ctx, "resolved", alertTitle, alertMessage)
+}
+
+func (w *WebhookAlertSender) send(ctx context.Context, status string, alertTitle string, alertMessage string) error {
+	now := time.Now().UTC()
+	body, err := json.Marshal(webhookAlertPayload{
+		Status:        status,
+		Title:         alertTitle,
+		Message:       alertMessage,
+		DedupKey:      alertDedupKey(alertTitle),
+		DeploymentUID: internal.PeerDBDeploymentUID(),
+		Timestamp:     now,
+	})
+	if err != nil {
+		return fmt.Errorf("error serializing webhook alert: %w", err)
+	}
+
+	timestamp := strconv.FormatInt(now.Unix(), 10)
+	if err := postWithRetry(ctx, w.client, w.url, body, func(header http.Header) {
+		for key, value := range w.headers {
+			header.Set(key, value)
+		}
+		header.Set(shared.WebhookTimestampHeader, timestamp)
+		if w.secret != "" {
+			header.Set(shared.WebhookSignatureHeader, shared.SignWebhookPayload(w.secret, timestamp, body))
+		}
+	}); err != nil {
+		return fmt.Errorf("failed to send webhook alert: %w", err)
+	}
+	return nil
+}
@@ -2,16 +2,22 @@ package cmd
 
 import (
 	"context"
+	"fmt"
+	"time"
 
 	"github.com/jackc/pgx/v5"
+	"google.golang.org/protobuf/types/known/timestamppb"
 
+	"github.com/PeerDB-io/peerdb/flow/alerting"
 	"github.com/PeerDB-io/peerdb/flow/generated/protos"
 	"github.com/PeerDB-io/peerdb/flow/internal"
+	"github.com/PeerDB-io/peerdb/flow/middleware"
 	"github.com/PeerDB-io/peerdb/flow/shared"
 )
 
 func (h *FlowRequestHandler) GetAlertConfigs(ctx context.Context, req *protos.GetAlertConfigsRequest) (*protos.GetAlertConfigsResponse, error) {
-	rows, err := h.pool.Query(ctx, "SELECT id,service_type,service_config,enc_key_id,alert_for_mirrors from peerdb_stats.alerting_config")
+	rows, err := h.pool.Query(ctx,
+		"SELECT id,service_type,service_config,enc_key_id,alert_for_mirrors,routing_rules from peerdb_stats.alerting_config")
 	if err != nil {
 		return nil, err
 	}
@@ -19,10 +25,14 @@ func (h *FlowRequestHandler) GetAlertConfigs(ctx context.Context, req *protos.Ge
 	configs, err := pgx.CollectRows(rows, func(row pgx.CollectableRow) (*protos.AlertConfig, error) {
 		var serviceConfigPayload []byte
 		var encKeyID string
+		var routingRules []alerting.AlertRoutingRule
 		config := &protos.AlertConfig{}
-		if err := row.Scan(&config.Id, &config.ServiceType, &serviceConfigPayload, &encKeyID, &config.AlertForMirrors); err != nil {
+		if err := row.Scan(
+			&config.Id, &config.ServiceType, &serviceConfigPayload, &encKeyID, &config.AlertForMirrors, &routingRules,
+		); err != nil {
 			return nil, err
 		}
+		config.RoutingRules = alerting.RoutingRulesToProto(routingRules)
 		serviceConfig, err := internal.Decrypt(ctx, encKeyID, serviceConfigPayload)
 		if err != nil {
 			return nil, err
@@ -38,6 +48,11 @@ func (h *FlowRequestHandler) GetAlertConfigs(ctx context.Context, req *protos.Ge
 }
 
 func (h *FlowRequestHandler) PostAlertConfig(ctx context.Context, req *protos.PostAlertConfigRequest) (*protos.PostAlertConfigResponse, error) {
+	routingRules := alerting.RoutingRulesFromProto(req.Config.RoutingRules)
+	if err := alerting.ValidateRoutingRules(routingRules); err != nil {
+		return nil, err
+	}
+
 	key, err := internal.PeerDBCurrentEncKey(ctx)
 	if err != nil {
 		return nil, err
@@ -55,28 +70,33 @@ func (h *FlowRequestHandler) PostAlertConfig(ctx context.Context, req *protos.Po
 				service_type,
 				service_config,
 				enc_key_id,
-				alert_for_mirrors
+				alert_for_mirrors,
+				routing_rules
 			) VALUES (
 				$1,
 				$2,
 				$3,
-				$4
+				$4,
+				$5
 			) RETURNING id`,
 			req.Config.ServiceType,
 			serviceConfig,
 			key.ID,
 			req.Config.AlertForMirrors,
+			routingRules,
 		).Scan(&id); err != nil {
 			return nil, err
 		}
 		return &protos.PostAlertConfigResponse{Id: id}, nil
 	} else if _, err := h.pool.Exec(
 		ctx,
-		"update peerdb_stats.alerting_config set service_type = $1, service_config = $2, enc_key_id = $3, alert_for_mirrors = $4 where id = $5",
+		`update peerdb_stats.alerting_config set service_type = $1, service_config = $2, enc_key_id = $3, alert_for_mirrors = $4,
+		routing_rules = $5 where id = $6`,
 		req.Config.ServiceType,
 		serviceConfig,
 		key.ID,
 		req.Config.AlertForMirrors,
+		routingRules,
 		req.Config.Id,
 	); err != nil {
 		return nil, err
@@ -88,8 +108,88 @@ func (h *FlowRequestHandler) DeleteAlertConfig(
 	ctx context.Context,
 	req *protos.DeleteAlertConfigRequest,
 ) (*protos.DeleteAlertConfigResponse, error) {
-	if _, err := h.pool.Exec(ctx, "delete from peerdb_stats.alerting_config where id = $1", req.Id); err != nil {
+	tx, err := h.pool.Begin(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer shared.RollbackTx(tx, internal.LoggerFromCtx(ctx))
+
+	if _, err := tx.Exec(ctx, "delete from peerdb_stats.alerting_config where id = $1", req.Id); err != nil {
+		return nil, err
+	}
+	if err := alerting.DeleteAlertConfigAlerts(ctx, tx, int64(req.Id)); err != nil {
+		return nil, fmt.Errorf("failed to delete alerts of alert config: %w", err)
+	}
+	if err := tx.Commit(ctx); err != nil {
 		return nil, err
 	}
 	return &protos.DeleteAlertConfigResponse{}, nil
 }
+
+func (h *FlowRequestHandler) ListActiveAlerts(
+	ctx context.Context,
+	req *protos.ListActiveAlertsRequest,
+) (*protos.ListActiveAlertsResponse, error) {
+	scopes, err := callerMirrorScopes(ctx)
+	if err != nil {
+		return nil, err
+	}
+	// scoped callers see alerts of their mirrors and of the peers those use
+	rows, err := h.pool.Query(ctx,
+		`SELECT id,alert_config_id,alert_key,alert_type,flow_name,peer_name,status,alert_message,
+		opened_at,last_notified_at,acknowledged_at,coalesce(acknowledged_by,'')
+		FROM peerdb_stats.alert_incidents
+		WHERE status<>'resolved' AND ($1='' OR flow_name=$1)
+		AND ($2::jsonb[] IS NULL OR flow_name IN (SELECT name FROM flows WHERE tags @> ANY($2::jsonb[]))
+			OR peer_name IN (SELECT p.name FROM peers p JOIN flows f ON p.id IN (f.source_peer, f.destination_peer)
+			WHERE f.tags @> ANY($2::jsonb[])))
+		ORDER BY opened_at DESC`,
+		req.FlowJobName, scopes)
+	if err != nil {
+		return nil, err
+	}
+
+	alerts, err := pgx.CollectRows(rows, func(row pgx.CollectableRow) (*protos.ActiveAlert, error) {
+		var openedAt, lastNotifiedAt time.Time
+		var acknowledgedAt *time.Time
+		alert := &protos.ActiveAlert{}
+		if err := row.Scan(&alert.Id, &alert.AlertConfigId, &alert.AlertKey, &alert.AlertType, &alert.FlowName,
+			&alert.PeerName, &alert.Status, &alert.AlertMessage, &openedAt, &lastNotifiedAt, &acknowledgedAt, &alert.AcknowledgedBy,
+		); err != nil {
+			return nil, err
+		}
+		alert.OpenedAt = timestamppb.New(openedAt)
+		alert.LastNotifiedAt = timestamppb.New(lastNotifiedAt)
+		if acknowledgedAt != nil {
+			alert.AcknowledgedAt = timestamppb.New(*acknowledgedAt)
+		}
+		return alert, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &protos.ListActiveAlertsResponse{Alerts: alerts}, nil
+}
+
+func (h *FlowRequestHandler) AcknowledgeAlert(
+	ctx context.Context,
+	req *protos.AcknowledgeAlertRequest,
+) (*protos.AcknowledgeAlertResponse, error) {
+	acknowledged, err := alerting.AcknowledgeActiveAlert(ctx, h.pool, req.Id, alertAcknowledgedBy(ctx, req))
+	if err != nil {
+		return nil, err
+	}
+	if !acknowledged {
+		return nil, fmt.Errorf("no open alert with id %d", req.Id)
+	}
+	return &protos.AcknowledgeAlertResponse{}, nil
+}
+
+// alertAcknowledgedBy is the authenticated caller, the name in the request is only trusted without authentication
+func alertAcknowledgedBy(ctx context.Context, req *protos.AcknowledgeAlertRequest) string {
+	if principal, ok := middleware.PrincipalFromContext(ctx); ok {
+		return principal.Subject
+	}
+	return req.AcknowledgedBy
+}
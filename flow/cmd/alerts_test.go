@@ -0,0 +1,21 @@
+package cmd
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/PeerDB-io/peerdb/flow/generated/protos"
+	"github.com/PeerDB-io/peerdb/flow/middleware"
+)
+
+func TestAlertAcknowledgedBy(t *testing.T) {
+	t.Parallel()
+
+	req := &protos.AcknowledgeAlertRequest{Id: 1, AcknowledgedBy: "someone else"}
+	require.Equal(t, "someone else", alertAcknowledgedBy(t.Context(), req), "without authentication the request is trusted")
+
+	ctx := middleware.ContextWithPrincipal(t.Context(),
+		middleware.Principal{Subject: "oncall", Roles: []middleware.Role{middleware.RoleOperator}})
+	require.Equal(t, "oncall", alertAcknowledgedBy(ctx, req))
+}
@@ -34,9 +34,11 @@ import (
 type APIServerParams struct {
 	TemporalHostPort  string
 	TemporalNamespace string
+	PrometheusPort    int
 	Port              uint16
 	GatewayPort       uint16
 	EnableOtelMetrics bool
+	EnableOtelTraces  bool
 }
 
 type RecryptItem struct {
@@ -185,6 +187,8 @@ func killExistingScheduleFlows(
 }
 
 func APIMain(ctx context.Context, args *APIServerParams) error {
+	setupPrometheus(args.EnableOtelMetrics, args.PrometheusPort)
+
 	clientOptions := client.Options{
 		HostPort:  args.TemporalHostPort,
 		Namespace: args.TemporalNamespace,
@@ -198,16 +202,30 @@ func APIMain(ctx context.Context, args *APIServerParams) error {
 	clientOptions.MetricsHandler = temporalotel.NewMetricsHandler(temporalotel.MetricsHandlerOptions{
 		Meter: metricsProvider.Meter("temporal-sdk-go"),
 	})
+	tracerProvider, err := setupTracing(ctx, otel_metrics.FlowApiServiceName, args.EnableOtelTraces, &clientOptions)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err := otel_metrics.CloseTracerProvider(context.Background(), tracerProvider); err != nil {
+			slog.Error("Failed to shutdown tracer provider", slog.Any("error", err))
+		}
+	}()
 
 	tc, err := setupTemporalClient(ctx, clientOptions)
 	if err != nil {
 		return fmt.Errorf("unable to create Temporal client: %w", err)
 	}
 
+	catalogPool, err := internal.GetCatalogConnectionPoolFromEnv(ctx)
+	if err != nil {
+		return fmt.Errorf("unable to get catalog connection pool: %w", err)
+	}
+
 	authGrpcMiddleware, err := middleware.AuthGrpcMiddleware([]string{
 		grpc_health_v1.Health_Check_FullMethodName,
 		grpc_health_v1.Health_Watch_FullMethodName,
-	})
+	}, catalogPool)
 	if err != nil {
 		return err
 	}
@@ -215,8 +233,9 @@ func APIMain(ctx context.Context, args *APIServerParams) error {
 	requestLoggingMiddleware := middleware.RequestLoggingMiddleWare()
 
 	serverOptions := []grpc.ServerOption{
-		// Interceptors are executed in the order they are passed to, so unauthorized requests are not logged
+		// Interceptors are executed in the order they are passed to, so denied requests are audited but not logged
 		grpc.ChainUnaryInterceptor(
+			auditGrpcMiddleware(catalogPool),
 			authGrpcMiddleware,
 			requestLoggingMiddleware,
 		),
@@ -230,15 +249,11 @@ func APIMain(ctx context.Context, args *APIServerParams) error {
 	}
 	serverOptions = append(serverOptions, grpc.StatsHandler(otelgrpc.NewServerHandler(
 		otelgrpc.WithMeterProvider(componentManager),
+		otelgrpc.WithTracerProvider(tracerProvider),
 	)))
 
 	grpcServer := grpc.NewServer(serverOptions...)
 
-	catalogPool, err := internal.GetCatalogConnectionPoolFromEnv(ctx)
-	if err != nil {
-		return fmt.Errorf("unable to get catalog connection pool: %w", err)
-	}
-
 	taskQueue := internal.PeerFlowTaskQueueName(shared.PeerFlowTaskQueue)
 	flowHandler := NewFlowRequestHandler(ctx, tc, catalogPool, taskQueue)
 
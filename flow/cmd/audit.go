@@ -0,0 +1,187 @@
+package cmd
+
+import (
+	"context"
+	"log/slog"
+	"path"
+	"strconv"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/timestamppb"
+
+	"github.com/PeerDB-io/peerdb/flow/generated/protos"
+	"github.com/PeerDB-io/peerdb/flow/middleware"
+	"github.com/PeerDB-io/peerdb/flow/shared"
+)
+
+// FlowService methods that change state, every call to these is recorded in audit_events
+var auditedMethods = map[string]struct{}{
+	protos.FlowService_CreatePeer_FullMethodName:              {},
+	protos.FlowService_UpdatePeer_FullMethodName:              {},
+	protos.FlowService_DropPeer_FullMethodName:                {},
+	protos.FlowService_CreateCDCFlow_FullMethodName:           {},
+	protos.FlowService_CreateQRepFlow_FullMethodName:          {},
+	protos.FlowService_CustomSyncFlow_FullMethodName:          {},
+	protos.FlowService_FlowStateChange_FullMethodName:         {},
+	protos.FlowService_PostAlertConfig_FullMethodName:         {},
+	protos.FlowService_DeleteAlertConfig_FullMethodName:       {},
+	protos.FlowService_AcknowledgeAlert_FullMethodName:        {},
+	protos.FlowService_PostDynamicSetting_FullMethodName:      {},
+	protos.FlowService_PostScript_FullMethodName:              {},
+	protos.FlowService_DeleteScript_FullMethodName:            {},
+	protos.FlowService_Maintenance_FullMethodName:             {},
+	protos.FlowService_CreateOrReplaceFlowTags_FullMethodName: {},
+}
+
+// auditResource names the peer, mirror or setting a request acts on
+func auditResource(req any) string {
+	switch r := req.(type) {
+	case *protos.CreatePeerRequest:
+		return r.GetPeer().GetName()
+	case *protos.UpdatePeerRequest:
+		return r.GetPeer().GetName()
+	case *protos.CreateCDCFlowRequest:
+		return r.GetConnectionConfigs().GetFlowJobName()
+	case *protos.CreateQRepFlowRequest:
+		return r.GetQrepConfig().GetFlowJobName()
+	case *protos.PostDynamicSettingRequest:
+		return r.Name
+	case *protos.PostScriptRequest:
+		return r.GetScript().GetName()
+	case *protos.DeleteScriptRequest:
+		return strconv.Itoa(int(r.Id))
+	case *protos.PostAlertConfigRequest:
+		return r.GetConfig().GetServiceType()
+	case *protos.DeleteAlertConfigRequest:
+		return strconv.Itoa(int(r.Id))
+	case *protos.AcknowledgeAlertRequest:
+		return strconv.FormatInt(r.Id, 10)
+	case *protos.MaintenanceRequest:
+		return r.Status.String()
+	case interface{ GetFlowJobName() string }:
+		return r.GetFlowJobName()
+	case interface{ GetFlowName() string }:
+		return r.GetFlowName()
+	case interface{ GetPeerName() string }:
+		return r.GetPeerName()
+	default:
+		return ""
+	}
+}
+
+func auditRequestJson(req any) []byte {
+	message, ok := req.(proto.Message)
+	if !ok {
+		return nil
+	}
+	// redactProto modifies in place, the handler may still be using the request
+	message = proto.Clone(message)
+	redactProto(message)
+	requestJson, err := protojson.Marshal(message)
+	if err != nil {
+		slog.Warn("failed to marshal request for audit event", slog.Any("error", err))
+		return nil
+	}
+	return requestJson
+}
+
+type auditEvent struct {
+	method   string
+	resource string
+	subject  string
+	outcome  string
+	error    string
+	roles    []string
+	request  []byte
+	success  bool
+}
+
+// auditGrpcMiddleware records mutating FlowService calls, it runs before AuthGrpcMiddleware so denied calls are recorded too
+func auditGrpcMiddleware(pool shared.CatalogPool) grpc.UnaryServerInterceptor {
+	return auditInterceptor(func(ctx context.Context, event *auditEvent) error {
+		_, err := pool.Exec(ctx,
+			`INSERT INTO audit_events (method, resource, subject, roles, request, success, error, outcome)
+			VALUES ($1, $2, $3, $4, $5, $6, $7, $8)`,
+			event.method, event.resource, event.subject, event.roles, event.request, event.success, event.error, event.outcome)
+		return err
+	})
+}
+
+func auditInterceptor(record func(context.Context, *auditEvent) error) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		if _, audited := auditedMethods[info.FullMethod]; !audited {
+			return handler(ctx, req)
+		}
+
+		ctx, callerPrincipal := middleware.ContextWithPrincipalRecorder(ctx)
+		resp, err := handler(ctx, req)
+
+		event := &auditEvent{
+			method:   path.Base(info.FullMethod),
+			resource: auditResource(req),
+			roles:    []string{},
+			request:  auditRequestJson(req),
+			success:  err == nil,
+			outcome:  status.Code(err).String(),
+		}
+		if principal, ok := callerPrincipal(); ok {
+			event.subject = principal.Subject
+			for _, role := range principal.Roles {
+				event.roles = append(event.roles, string(role))
+			}
+		}
+		if err != nil {
+			event.error = err.Error()
+		}
+
+		// request may have been canceled by the client after the change was made, still record it
+		if recordErr := record(context.WithoutCancel(ctx), event); recordErr != nil {
+			slog.Error("failed to record audit event", slog.String("method", info.FullMethod), slog.Any("error", recordErr))
+		}
+
+		return resp, err
+	}
+}
+
+func (h *FlowRequestHandler) ListAuditEvents(
+	ctx context.Context,
+	req *protos.ListAuditEventsRequest,
+) (*protos.ListAuditEventsResponse, error) {
+	limit := req.Limit
+	if limit <= 0 {
+		limit = 100
+	}
+
+	rows, err := h.pool.Query(ctx,
+		`SELECT id,event_time,method,resource,subject,roles,coalesce(request::text,''),success,error,outcome
+		FROM audit_events
+		WHERE ($1='' OR method=$1) AND ($2='' OR resource=$2) AND ($3='' OR subject=$3) AND ($4=0 OR id<$4)
+		ORDER BY id DESC
+		LIMIT $5`,
+		req.Method, req.Resource, req.Subject, req.BeforeId, limit)
+	if err != nil {
+		return nil, err
+	}
+
+	events, err := pgx.CollectRows(rows, func(row pgx.CollectableRow) (*protos.AuditEvent, error) {
+		var eventTime time.Time
+		event := &protos.AuditEvent{}
+		if err := row.Scan(&event.Id, &eventTime, &event.Method, &event.Resource, &event.Subject, &event.Roles,
+			&event.RequestJson, &event.Success, &event.Error, &event.Outcome,
+		); err != nil {
+			return nil, err
+		}
+		event.EventTime = timestamppb.New(eventTime)
+		return event, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &protos.ListAuditEventsResponse{Events: events}, nil
+}
@@ -0,0 +1,49 @@
+package cmd
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/PeerDB-io/peerdb/flow/generated/protos"
+)
+
+func TestAuditInterceptorRecordsDenied(t *testing.T) {
+	t.Parallel()
+
+	var events []*auditEvent
+	audit := auditInterceptor(func(_ context.Context, event *auditEvent) error {
+		events = append(events, event)
+		return nil
+	})
+	// stands in for AuthGrpcMiddleware refusing the call, the handler is never reached
+	deny := func(context.Context, any) (any, error) {
+		return nil, status.Error(codes.PermissionDenied, "role admin required")
+	}
+
+	req := &protos.DropPeerRequest{PeerName: "p"}
+	_, err := audit(t.Context(), req, &grpc.UnaryServerInfo{FullMethod: protos.FlowService_DropPeer_FullMethodName}, deny)
+	require.Equal(t, codes.PermissionDenied, status.Code(err))
+	require.Len(t, events, 1)
+	require.Equal(t, "DropPeer", events[0].method)
+	require.Equal(t, "p", events[0].resource)
+	require.False(t, events[0].success)
+	require.Equal(t, codes.PermissionDenied.String(), events[0].outcome)
+
+	_, err = audit(t.Context(), req, &grpc.UnaryServerInfo{FullMethod: protos.FlowService_DropPeer_FullMethodName},
+		func(context.Context, any) (any, error) { return &protos.DropPeerResponse{}, nil })
+	require.NoError(t, err)
+	require.Len(t, events, 2)
+	require.True(t, events[1].success)
+	require.Equal(t, codes.OK.String(), events[1].outcome)
+
+	// reads aren't audited
+	_, err = audit(t.Context(), &protos.ListPeersRequest{}, &grpc.UnaryServerInfo{FullMethod: protos.FlowService_ListPeers_FullMethodName},
+		deny)
+	require.Error(t, err)
+	require.Len(t, events, 2)
+}
@@ -178,6 +178,9 @@ func (h *FlowRequestHandler) CreateQRepFlow(
 ) (*protos.CreateQRepFlowResponse, error) {
 	cfg := req.QrepConfig
 	cfg.Version = shared.InternalVersion_Latest
+	if err := internal.ValidateMirrorSchedule(cfg.Schedule, false); err != nil {
+		return nil, fmt.Errorf("invalid schedule: %w", err)
+	}
 
 	workflowID := fmt.Sprintf("%s-qrepflow-%s", cfg.FlowJobName, uuid.New())
 	workflowOptions := client.StartWorkflowOptions{
@@ -245,6 +248,80 @@ func (h *FlowRequestHandler) updateQRepConfigInCatalog(
 	return nil
 }
 
+func (h *FlowRequestHandler) getQRepConfigFromCatalog(
+	ctx context.Context,
+	flowJobName string,
+) (*protos.QRepConfig, error) {
+	var configBytes []byte
+	if err := h.pool.QueryRow(ctx,
+		"SELECT config_proto FROM flows WHERE name=$1", flowJobName,
+	).Scan(&configBytes); err != nil {
+		return nil, fmt.Errorf("unable to query qrep config from catalog: %w", err)
+	}
+
+	var cfg protos.QRepConfig
+	if err := proto.Unmarshal(configBytes, &cfg); err != nil {
+		return nil, fmt.Errorf("unable to unmarshal qrep config: %w", err)
+	}
+	return &cfg, nil
+}
+
+// updateQRepFlowConfig signals the workflow, which applies the update at its next partition round,
+// and updates the config in the catalog the same way. The mirror's row stays locked until the workflow is signalled,
+// so concurrent updates are applied one after the other, and the catalog is left unchanged when signalling fails
+func (h *FlowRequestHandler) updateQRepFlowConfig(
+	ctx context.Context,
+	flowJobName string,
+	workflowID string,
+	update *protos.QRepFlowConfigUpdate,
+) error {
+	if isCDC, err := h.isCDCFlow(ctx, flowJobName); err != nil {
+		return err
+	} else if isCDC {
+		return errors.New("qrep config updates are only supported for qrep mirrors")
+	}
+
+	tx, err := h.pool.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("unable to begin transaction: %w", err)
+	}
+	defer shared.RollbackTx(tx, internal.LoggerFromCtx(ctx))
+
+	var configBytes []byte
+	if err := tx.QueryRow(ctx,
+		"SELECT config_proto FROM flows WHERE name=$1 FOR UPDATE", flowJobName,
+	).Scan(&configBytes); err != nil {
+		return fmt.Errorf("unable to query qrep config from catalog: %w", err)
+	}
+	var cfg protos.QRepConfig
+	if err := proto.Unmarshal(configBytes, &cfg); err != nil {
+		return fmt.Errorf("unable to unmarshal qrep config: %w", err)
+	}
+
+	internal.ApplyQRepFlowConfigUpdate(&cfg, update)
+	if err := internal.ValidateQRepConfig(&cfg); err != nil {
+		return fmt.Errorf("invalid qrep config update: %w", err)
+	}
+
+	cfgBytes, err := proto.Marshal(&cfg)
+	if err != nil {
+		return fmt.Errorf("unable to marshal qrep config: %w", err)
+	}
+	if _, err := tx.Exec(ctx,
+		"UPDATE flows SET config_proto=$1,updated_at=now() WHERE name=$2", cfgBytes, flowJobName,
+	); err != nil {
+		return fmt.Errorf("unable to update qrep config in catalog: %w", err)
+	}
+
+	if err := model.QRepDynamicPropertiesSignal.SignalClientWorkflow(ctx, h.temporalClient, workflowID, "", update); err != nil {
+		return fmt.Errorf("unable to signal workflow: %w", err)
+	}
+	if err := tx.Commit(ctx); err != nil {
+		return fmt.Errorf("unable to commit qrep config update: %w", err)
+	}
+	return nil
+}
+
 func (h *FlowRequestHandler) shutdownFlow(
 	ctx context.Context,
 	flowJobName string,
@@ -358,6 +435,12 @@ func (h *FlowRequestHandler) FlowStateChange(
 			return nil, fmt.Errorf("unable to signal workflow: %w", err)
 		}
 	}
+	if req.FlowConfigUpdate != nil && req.FlowConfigUpdate.GetQrepFlowConfigUpdate() != nil {
+		if err := h.updateQRepFlowConfig(ctx, req.FlowJobName, workflowID, req.FlowConfigUpdate.GetQrepFlowConfigUpdate()); err != nil {
+			slog.Error("unable to update qrep flow config", logs, slog.Any("error", err))
+			return nil, err
+		}
+	}
 
 	slog.Info("[flow-state-change] received request", logs,
 		slog.Any("requestedFlowState", req.RequestedFlowState), slog.Any("currState", currState))
@@ -3,6 +3,7 @@ package cmd
 import (
 	"context"
 	"database/sql"
+	"encoding/json"
 	"fmt"
 	"log/slog"
 	"slices"
@@ -17,14 +18,37 @@ import (
 	"github.com/PeerDB-io/peerdb/flow/connectors"
 	"github.com/PeerDB-io/peerdb/flow/generated/protos"
 	"github.com/PeerDB-io/peerdb/flow/internal"
+	"github.com/PeerDB-io/peerdb/flow/middleware"
 	"github.com/PeerDB-io/peerdb/flow/shared"
 	peerflow "github.com/PeerDB-io/peerdb/flow/workflows"
 )
 
+// callerMirrorScopes returns the tags the caller's mirrors are limited to as jsonb for matching flows.tags with
+// tags @> any($n::jsonb[]), nil when the caller can see every mirror
+func callerMirrorScopes(ctx context.Context) ([]string, error) {
+	principal, ok := middleware.PrincipalFromContext(ctx)
+	if !ok || principal.Scopes == nil {
+		return nil, nil
+	}
+	scopes := make([]string, 0, len(principal.Scopes))
+	for _, tags := range principal.Scopes {
+		scope, err := json.Marshal(tags)
+		if err != nil {
+			return nil, fmt.Errorf("failed to marshal mirror scope: %w", err)
+		}
+		scopes = append(scopes, string(scope))
+	}
+	return scopes, nil
+}
+
 func (h *FlowRequestHandler) ListMirrors(
 	ctx context.Context,
 	req *protos.ListMirrorsRequest,
 ) (*protos.ListMirrorsResponse, error) {
+	scopes, err := callerMirrorScopes(ctx)
+	if err != nil {
+		return nil, err
+	}
 	rows, err := h.pool.Query(ctx, `select distinct on(f.name)
 	  f.id, f.workflow_id, f.name,
 	  sp.name source_name, sp.type source_type,
@@ -32,7 +56,8 @@ func (h *FlowRequestHandler) ListMirrors(
 	  f.created_at, coalesce(f.query_string, '')='' is_cdc
 	from flows f
 	join peers sp on sp.id = f.source_peer
-	join peers dp on dp.id = f.destination_peer`)
+	join peers dp on dp.id = f.destination_peer
+	where $1::jsonb[] is null or f.tags @> any($1::jsonb[])`, scopes)
 	if err != nil {
 		return nil, err
 	}
@@ -94,13 +119,24 @@ func (h *FlowRequestHandler) MirrorStatus(
 				return nil, fmt.Errorf("unable to obtain CDC information for mirror %s: %w", req.FlowJobName, err)
 			}
 
+			var nextScheduledEvent *timestamppb.Timestamp
+			if schedule := cdcStatus.Config.GetSchedule(); schedule != nil {
+				spec := schedule.PauseCron
+				if currState == protos.FlowStatus_STATUS_PAUSED {
+					spec = schedule.ResumeCron
+				}
+				nextScheduledEvent = nextScheduledEventTime(spec)
+			}
+
 			return &protos.MirrorStatusResponse{
 				FlowJobName: req.FlowJobName,
 				Status: &protos.MirrorStatusResponse_CdcStatus{
 					CdcStatus: cdcStatus,
 				},
-				CurrentFlowState: currState,
-				CreatedAt:        timestamppb.New(*createdAt),
+				CurrentFlowState:   currState,
+				CreatedAt:          timestamppb.New(*createdAt),
+				Schedule:           cdcStatus.Config.GetSchedule(),
+				NextScheduledEvent: nextScheduledEvent,
 			}, nil
 		} else {
 			qrepStatus, err := h.qrepFlowStatus(ctx, req)
@@ -109,13 +145,25 @@ func (h *FlowRequestHandler) MirrorStatus(
 				return nil, fmt.Errorf("unable to obtain snapshot information for mirror %s: %w", req.FlowJobName, err)
 			}
 
+			// clone tables of CDC snapshots have no config entry
+			var schedule *protos.MirrorSchedule
+			var nextScheduledEvent *timestamppb.Timestamp
+			if qrepConfig, err := h.getQRepConfigFromCatalog(ctx, req.FlowJobName); err == nil {
+				schedule = qrepConfig.Schedule
+				if currState != protos.FlowStatus_STATUS_PAUSED {
+					nextScheduledEvent = nextScheduledEventTime(schedule.GetRunCron())
+				}
+			}
+
 			return &protos.MirrorStatusResponse{
 				FlowJobName: req.FlowJobName,
 				Status: &protos.MirrorStatusResponse_QrepStatus{
 					QrepStatus: qrepStatus,
 				},
-				CurrentFlowState: currState,
-				CreatedAt:        timestamppb.New(*createdAt),
+				CurrentFlowState:   currState,
+				CreatedAt:          timestamppb.New(*createdAt),
+				Schedule:           schedule,
+				NextScheduledEvent: nextScheduledEvent,
 			}, nil
 		}
 	}
@@ -127,6 +175,18 @@ func (h *FlowRequestHandler) MirrorStatus(
 	}, nil
 }
 
+// nextScheduledEventTime returns when spec next fires, or nil if spec is empty or invalid
+func nextScheduledEventTime(spec string) *timestamppb.Timestamp {
+	if spec == "" {
+		return nil
+	}
+	next, err := shared.NextCronTime(spec, time.Now())
+	if err != nil {
+		return nil
+	}
+	return timestamppb.New(next)
+}
+
 func (h *FlowRequestHandler) cdcFlowStatus(
 	ctx context.Context,
 	req *protos.MirrorStatusRequest,
@@ -660,11 +720,16 @@ func (h *FlowRequestHandler) ListMirrorNames(
 	ctx context.Context,
 	req *protos.ListMirrorNamesRequest,
 ) (*protos.ListMirrorNamesResponse, error) {
-	// selects from flow_errors to still list dropped mirrors
+	scopes, err := callerMirrorScopes(ctx)
+	if err != nil {
+		return nil, err
+	}
+	// selects from flow_errors to still list dropped mirrors, which scoped callers can't see as they no longer have tags
 	rows, err := h.pool.Query(ctx, `select distinct flow_name
 		from peerdb_stats.flow_errors
 		where flow_name not like 'clone_%'
-		order by flow_name`)
+		and ($1::jsonb[] is null or flow_name in (select name from flows where tags @> any($1::jsonb[])))
+		order by flow_name`, scopes)
 	if err != nil {
 		return nil, err
 	}
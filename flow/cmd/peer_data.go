@@ -11,7 +11,6 @@ import (
 	"github.com/jackc/pgx/v5"
 	"google.golang.org/protobuf/proto"
 	"google.golang.org/protobuf/reflect/protoreflect"
-	"google.golang.org/protobuf/types/descriptorpb"
 
 	"github.com/PeerDB-io/peerdb/flow/connectors"
 	connclickhouse "github.com/PeerDB-io/peerdb/flow/connectors/clickhouse"
@@ -22,16 +21,11 @@ import (
 )
 
 func redactProto(message proto.Message) {
-	message.ProtoReflect().Range(func(fd protoreflect.FieldDescriptor, v protoreflect.Value) bool {
-		if fd.Kind() == protoreflect.MessageKind {
-			redactProto(v.Message().Interface())
-		} else if fd.Kind() == protoreflect.StringKind {
-			redacted := proto.GetExtension(fd.Options().(*descriptorpb.FieldOptions), protos.E_PeerdbRedacted).(bool)
-			if redacted {
-				message.ProtoReflect().Set(fd, protoreflect.ValueOfString("********"))
-			}
+	internal.RangeRedactedFields(message.ProtoReflect(), func(m protoreflect.Message, fd protoreflect.FieldDescriptor, value string) {
+		// references to a secret manager aren't secrets themselves
+		if !internal.IsSecretReference(value) {
+			m.Set(fd, protoreflect.ValueOfString("********"))
 		}
-		return true
 	})
 }
 
@@ -93,13 +87,19 @@ func (h *FlowRequestHandler) ListPeers(
 	ctx context.Context,
 	req *protos.ListPeersRequest,
 ) (*protos.ListPeersResponse, error) {
-	query := "SELECT name, type FROM peers"
+	scopes, err := callerMirrorScopes(ctx)
+	if err != nil {
+		return nil, err
+	}
+	// scoped callers only see the peers of their mirrors
+	query := `SELECT name, type FROM peers WHERE ($1::jsonb[] IS NULL OR id IN (
+		SELECT unnest(array[source_peer, destination_peer]) FROM flows WHERE tags @> ANY($1::jsonb[])))`
 	if internal.PeerDBOnlyClickHouseAllowed() {
 		// only postgres, mysql, mongo,and clickhouse
-		query += fmt.Sprintf(" WHERE type IN (%d,%d,%d,%d)",
+		query += fmt.Sprintf(" AND type IN (%d,%d,%d,%d)",
 			protos.DBType_POSTGRES, protos.DBType_MYSQL, protos.DBType_MONGO, protos.DBType_CLICKHOUSE)
 	}
-	rows, err := h.pool.Query(ctx, query)
+	rows, err := h.pool.Query(ctx, query, scopes)
 	if err != nil {
 		slog.Error("failed to query for peers", slog.Any("error", err))
 		return nil, err
@@ -23,10 +23,14 @@ import (
 type SnapshotWorkerOptions struct {
 	TemporalHostPort  string
 	TemporalNamespace string
+	PrometheusPort    int
 	EnableOtelMetrics bool
+	EnableOtelTraces  bool
 }
 
 func SnapshotWorkerMain(ctx context.Context, opts *SnapshotWorkerOptions) (*WorkerSetupResponse, error) {
+	setupPrometheus(opts.EnableOtelMetrics, opts.PrometheusPort)
+
 	clientOptions := client.Options{
 		HostPort:  opts.TemporalHostPort,
 		Namespace: opts.TemporalNamespace,
@@ -49,6 +53,10 @@ func SnapshotWorkerMain(ctx context.Context, opts *SnapshotWorkerOptions) (*Work
 	clientOptions.MetricsHandler = temporalotel.NewMetricsHandler(temporalotel.MetricsHandlerOptions{
 		Meter: metricsProvider.Meter("temporal-sdk-go"),
 	})
+	tracerProvider, err := setupTracing(ctx, otel_metrics.FlowSnapshotWorkerServiceName, opts.EnableOtelTraces, &clientOptions)
+	if err != nil {
+		return nil, err
+	}
 
 	c, err := setupTemporalClient(ctx, clientOptions)
 	if err != nil {
@@ -78,8 +86,9 @@ func SnapshotWorkerMain(ctx context.Context, opts *SnapshotWorkerOptions) (*Work
 	})
 
 	return &WorkerSetupResponse{
-		Client:      c,
-		Worker:      w,
-		OtelManager: otelManager,
+		Client:         c,
+		Worker:         w,
+		TracerProvider: tracerProvider,
+		OtelManager:    otelManager,
 	}, nil
 }
@@ -0,0 +1,154 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/jackc/pgx/v5"
+
+	"github.com/PeerDB-io/peerdb/flow/connectors"
+	"github.com/PeerDB-io/peerdb/flow/connectors/utils"
+	"github.com/PeerDB-io/peerdb/flow/generated/protos"
+	"github.com/PeerDB-io/peerdb/flow/internal"
+)
+
+type peerIdentityField struct {
+	name    string
+	current string
+	updated string
+}
+
+// peerIdentity lists what identifies the database behind a peer, changing any of it would leave
+// replication slots, binlog positions and raw tables of existing mirrors pointing at a different database
+func peerIdentity(current *protos.Peer, updated *protos.Peer) []peerIdentityField {
+	switch currentConfig := current.Config.(type) {
+	case *protos.Peer_PostgresConfig:
+		updatedConfig := updated.GetPostgresConfig()
+		return []peerIdentityField{
+			{name: "host", current: currentConfig.PostgresConfig.Host, updated: updatedConfig.GetHost()},
+			{name: "port", current: strconv.Itoa(int(currentConfig.PostgresConfig.Port)), updated: strconv.Itoa(int(updatedConfig.GetPort()))},
+			{name: "database", current: currentConfig.PostgresConfig.Database, updated: updatedConfig.GetDatabase()},
+		}
+	case *protos.Peer_MysqlConfig:
+		updatedConfig := updated.GetMysqlConfig()
+		return []peerIdentityField{
+			{name: "host", current: currentConfig.MysqlConfig.Host, updated: updatedConfig.GetHost()},
+			{name: "port", current: strconv.Itoa(int(currentConfig.MysqlConfig.Port)), updated: strconv.Itoa(int(updatedConfig.GetPort()))},
+		}
+	case *protos.Peer_MongoConfig:
+		// the uri carries credentials, so only its hosts are compared, and only when both are given inline
+		currentUri, updatedUri := currentConfig.MongoConfig.Uri, updated.GetMongoConfig().GetUri()
+		if internal.IsSecretReference(currentUri) || internal.IsSecretReference(updatedUri) {
+			return nil
+		}
+		return []peerIdentityField{{name: "uri hosts", current: mongoUriHosts(currentUri), updated: mongoUriHosts(updatedUri)}}
+	case *protos.Peer_ClickhouseConfig:
+		updatedConfig := updated.GetClickhouseConfig()
+		return []peerIdentityField{
+			{name: "host", current: currentConfig.ClickhouseConfig.Host, updated: updatedConfig.GetHost()},
+			{name: "database", current: currentConfig.ClickhouseConfig.Database, updated: updatedConfig.GetDatabase()},
+		}
+	case *protos.Peer_SnowflakeConfig:
+		updatedConfig := updated.GetSnowflakeConfig()
+		return []peerIdentityField{
+			{name: "account_id", current: currentConfig.SnowflakeConfig.AccountId, updated: updatedConfig.GetAccountId()},
+			{name: "database", current: currentConfig.SnowflakeConfig.Database, updated: updatedConfig.GetDatabase()},
+		}
+	case *protos.Peer_BigqueryConfig:
+		updatedConfig := updated.GetBigqueryConfig()
+		return []peerIdentityField{
+			{name: "project_id", current: currentConfig.BigqueryConfig.ProjectId, updated: updatedConfig.GetProjectId()},
+			{name: "dataset_id", current: currentConfig.BigqueryConfig.DatasetId, updated: updatedConfig.GetDatasetId()},
+		}
+	default:
+		return nil
+	}
+}
+
+func mongoUriHosts(uri string) string {
+	parsed, err := url.Parse(uri)
+	if err != nil {
+		return ""
+	}
+	return parsed.Host
+}
+
+func (h *FlowRequestHandler) UpdatePeer(
+	ctx context.Context,
+	req *protos.UpdatePeerRequest,
+) (*protos.UpdatePeerResponse, error) {
+	if req.Peer == nil || req.Peer.Name == "" {
+		return &protos.UpdatePeerResponse{
+			Status:  protos.CreatePeerStatus_FAILED,
+			Message: "no peer name provided",
+		}, nil
+	}
+
+	current, err := connectors.LoadPeer(ctx, h.pool, req.Peer.Name)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load peer %s: %w", req.Peer.Name, err)
+	}
+	if current.Type != req.Peer.Type {
+		return &protos.UpdatePeerResponse{
+			Status:  protos.CreatePeerStatus_FAILED,
+			Message: fmt.Sprintf("peer %s is a %s peer, cannot change it to %s", req.Peer.Name, current.Type, req.Peer.Type),
+		}, nil
+	}
+
+	var changed []string
+	for _, field := range peerIdentity(current, req.Peer) {
+		if field.current != field.updated {
+			changed = append(changed, field.name)
+		}
+	}
+	if len(changed) > 0 {
+		return &protos.UpdatePeerResponse{
+			Status: protos.CreatePeerStatus_FAILED,
+			Message: fmt.Sprintf("cannot change %s of peer %s, existing mirrors depend on it: create a new peer instead",
+				strings.Join(changed, ", "), req.Peer.Name),
+		}, nil
+	}
+
+	validation, err := h.ValidatePeer(ctx, &protos.ValidatePeerRequest{Peer: req.Peer})
+	if err != nil {
+		return nil, err
+	}
+	if validation.Status != protos.ValidatePeerStatus_VALID {
+		return &protos.UpdatePeerResponse{
+			Status:  protos.CreatePeerStatus_FAILED,
+			Message: validation.Message,
+		}, nil
+	}
+
+	created, err := utils.CreatePeerNoValidate(ctx, h.pool, req.Peer, true)
+	if err != nil {
+		return nil, err
+	}
+	if created.Status != protos.CreatePeerStatus_CREATED {
+		return &protos.UpdatePeerResponse{
+			Status:  created.Status,
+			Message: created.Message,
+		}, nil
+	}
+
+	// running mirrors see config_version change at their next batch and reconnect, nothing else to do for them
+	rows, err := h.pool.Query(ctx,
+		`SELECT f.name FROM flows f JOIN peers p ON p.id IN (f.source_peer, f.destination_peer)
+		WHERE p.name = $1 ORDER BY f.name`, req.Peer.Name)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list mirrors of peer %s: %w", req.Peer.Name, err)
+	}
+	mirrors, err := pgx.CollectRows(rows, pgx.RowTo[string])
+	if err != nil {
+		return nil, fmt.Errorf("failed to list mirrors of peer %s: %w", req.Peer.Name, err)
+	}
+
+	return &protos.UpdatePeerResponse{
+		Status:  protos.CreatePeerStatus_CREATED,
+		Message: fmt.Sprintf("%s peer %s updated", req.Peer.Type, req.Peer.Name),
+		Mirrors: mirrors,
+	}, nil
+}
@@ -0,0 +1,64 @@
+package cmd
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/PeerDB-io/peerdb/flow/generated/protos"
+)
+
+func changedIdentity(current *protos.Peer, updated *protos.Peer) []string {
+	var changed []string
+	for _, field := range peerIdentity(current, updated) {
+		if field.current != field.updated {
+			changed = append(changed, field.name)
+		}
+	}
+	return changed
+}
+
+func TestPeerIdentity(t *testing.T) {
+	t.Parallel()
+
+	pg := func(host string, port uint32, database string, password string) *protos.Peer {
+		return &protos.Peer{Type: protos.DBType_POSTGRES, Config: &protos.Peer_PostgresConfig{PostgresConfig: &protos.PostgresConfig{
+			Host: host, Port: port, Database: database, Password: password,
+		}}}
+	}
+	mongo := func(uri string) *protos.Peer {
+		return &protos.Peer{Type: protos.DBType_MONGO, Config: &protos.Peer_MongoConfig{MongoConfig: &protos.MongoConfig{Uri: uri}}}
+	}
+	mysql := &protos.Peer{Type: protos.DBType_MYSQL, Config: &protos.Peer_MysqlConfig{MysqlConfig: &protos.MySqlConfig{
+		Host: "db", Port: 3306,
+	}}}
+
+	for _, tc := range []struct {
+		current *protos.Peer
+		updated *protos.Peer
+		name    string
+		changed []string
+	}{
+		{name: "credentials only", current: pg("db", 5432, "app", "old"), updated: pg("db", 5432, "app", "new")},
+		{
+			name: "different database", current: pg("db", 5432, "app", "old"), updated: pg("db2", 5433, "other", "old"),
+			changed: []string{"host", "port", "database"},
+		},
+		{name: "different peer type", current: pg("db", 5432, "app", "old"), updated: mysql, changed: []string{"host", "port", "database"}},
+		{
+			name:    "mongo credentials only",
+			current: mongo("mongodb://user:old@a:27017,b:27017/"), updated: mongo("mongodb://user:new@a:27017,b:27017/"),
+		},
+		{
+			name:    "mongo hosts",
+			current: mongo("mongodb://user:old@a:27017/"), updated: mongo("mongodb://user:old@c:27017/"),
+			changed: []string{"uri hosts"},
+		},
+		{name: "mongo secret reference", current: mongo("mongodb://user:old@a:27017/"), updated: mongo("file://mongo-uri")},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			require.Equal(t, tc.changed, changedIdentity(tc.current, tc.updated))
+		})
+	}
+}
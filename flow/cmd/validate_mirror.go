@@ -10,8 +10,11 @@ import (
 	"github.com/jackc/pgx/v5/pgtype"
 
 	"github.com/PeerDB-io/peerdb/flow/connectors"
+	connpostgres "github.com/PeerDB-io/peerdb/flow/connectors/postgres"
+	"github.com/PeerDB-io/peerdb/flow/connectors/utils"
 	"github.com/PeerDB-io/peerdb/flow/generated/protos"
 	"github.com/PeerDB-io/peerdb/flow/internal"
+	"github.com/PeerDB-io/peerdb/flow/shared"
 	"github.com/PeerDB-io/peerdb/flow/shared/exceptions"
 	"github.com/PeerDB-io/peerdb/flow/shared/telemetry"
 )
@@ -59,6 +62,10 @@ func (h *FlowRequestHandler) ValidateCDCMirror(
 		}
 	}
 
+	if err := internal.ValidateMirrorSchedule(req.ConnectionConfigs.Schedule, true); err != nil {
+		return nil, fmt.Errorf("invalid schedule: %w", err)
+	}
+
 	srcConn, err := connectors.GetByNameAs[connectors.MirrorSourceValidationConnector](
 		ctx, req.ConnectionConfigs.Env, h.pool, req.ConnectionConfigs.SourceName,
 	)
@@ -81,6 +88,51 @@ func (h *FlowRequestHandler) ValidateCDCMirror(
 		return nil, fmt.Errorf("failed to validate source connector %s: %w", req.ConnectionConfigs.SourceName, err)
 	}
 
+	if req.ConnectionConfigs.SnapshotPeerName != "" {
+		if err := h.validateSnapshotPeer(ctx, req.ConnectionConfigs); err != nil {
+			h.alerter.LogNonFlowWarning(ctx, telemetry.CreateMirror, req.ConnectionConfigs.FlowJobName,
+				err.Error(),
+			)
+			return nil, fmt.Errorf("failed to validate snapshot peer %s: %w", req.ConnectionConfigs.SnapshotPeerName, err)
+		}
+	}
+
+	if req.ConnectionConfigs.ResumableSnapshot {
+		if err := h.validateResumableSnapshot(ctx, req.ConnectionConfigs); err != nil {
+			h.alerter.LogNonFlowWarning(ctx, telemetry.CreateMirror, req.ConnectionConfigs.FlowJobName,
+				err.Error(),
+			)
+			return nil, fmt.Errorf("invalid resumable snapshot: %w", err)
+		}
+	}
+
+	if req.ConnectionConfigs.ConsistentSnapshot {
+		if err := h.validateConsistentSnapshot(ctx, req.ConnectionConfigs); err != nil {
+			h.alerter.LogNonFlowWarning(ctx, telemetry.CreateMirror, req.ConnectionConfigs.FlowJobName,
+				err.Error(),
+			)
+			return nil, fmt.Errorf("invalid consistent snapshot: %w", err)
+		}
+	}
+
+	if req.ConnectionConfigs.IncrementalSnapshot {
+		if err := h.validateIncrementalSnapshot(ctx, req.ConnectionConfigs); err != nil {
+			h.alerter.LogNonFlowWarning(ctx, telemetry.CreateMirror, req.ConnectionConfigs.FlowJobName,
+				err.Error(),
+			)
+			return nil, fmt.Errorf("invalid incremental snapshot: %w", err)
+		}
+	}
+
+	if req.ConnectionConfigs.DurableCdcBuffer {
+		if err := h.validateDurableCDCBuffer(ctx, req.ConnectionConfigs); err != nil {
+			h.alerter.LogNonFlowWarning(ctx, telemetry.CreateMirror, req.ConnectionConfigs.FlowJobName,
+				err.Error(),
+			)
+			return nil, fmt.Errorf("invalid durable CDC buffer: %w", err)
+		}
+	}
+
 	dstConn, err := connectors.GetByNameAs[connectors.MirrorDestinationValidationConnector](
 		ctx, req.ConnectionConfigs.Env, h.pool, req.ConnectionConfigs.DestinationName,
 	)
@@ -112,6 +164,171 @@ func (h *FlowRequestHandler) ValidateCDCMirror(
 	return &protos.ValidateCDCMirrorResponse{}, nil
 }
 
+func (h *FlowRequestHandler) validateSnapshotPeer(ctx context.Context, cfg *protos.FlowConnectionConfigs) error {
+	peerTypes, err := connectors.LoadPeerTypes(ctx, h.pool, []string{cfg.SourceName, cfg.SnapshotPeerName, cfg.DestinationName})
+	if err != nil {
+		return err
+	}
+	if err := validateSnapshotPeerTypes(cfg, peerTypes); err != nil {
+		return err
+	}
+
+	snapshotConn, err := connectors.GetByNameAs[*connpostgres.PostgresConnector](ctx, cfg.Env, h.pool, cfg.SnapshotPeerName)
+	if err != nil {
+		return fmt.Errorf("failed to create snapshot peer connector: %w", err)
+	}
+	defer connectors.CloseConnector(ctx, snapshotConn)
+
+	if err := snapshotConn.ValidateSnapshotReplica(ctx, cfg); err != nil {
+		return err
+	}
+	// the standby has the same tables as the source
+	if err := snapshotConn.CheckTablesHaveUniqueKey(ctx, cfg.TableMappings); err != nil {
+		return fmt.Errorf("initial load from snapshot peer upserts rows by key, %w", err)
+	}
+	return nil
+}
+
+// validateSnapshotPeerTypes checks the mirror's peers can use a snapshot peer. Even a snapshot exported on the standby
+// is taken wherever it has replayed to, which may be past the slot's consistent point, so rows can be read twice
+// whatever its version and the destination must deduplicate them by key
+func validateSnapshotPeerTypes(cfg *protos.FlowConnectionConfigs, peerTypes map[string]protos.DBType) error {
+	if !cfg.DoInitialSnapshot || cfg.InitialSnapshotOnly {
+		return errors.New("snapshot peer is only used for the initial snapshot of a CDC mirror")
+	}
+	if _, ok := peerTypes[cfg.SnapshotPeerName]; !ok {
+		return fmt.Errorf("snapshot peer %s does not exist", cfg.SnapshotPeerName)
+	}
+	if peerTypes[cfg.SourceName] != protos.DBType_POSTGRES || peerTypes[cfg.SnapshotPeerName] != protos.DBType_POSTGRES {
+		return errors.New("snapshot peer is only supported for Postgres sources")
+	}
+	if !upsertsInitialLoad(peerTypes[cfg.DestinationName]) {
+		return errors.New("snapshot peer needs a destination that upserts the initial load")
+	}
+	return nil
+}
+
+// validateResumableSnapshot checks that an initial load can be resumed from the slot's consistent point,
+// where partitions are read without a shared snapshot and reconciled with CDC by key
+func (h *FlowRequestHandler) validateResumableSnapshot(ctx context.Context, cfg *protos.FlowConnectionConfigs) error {
+	if !cfg.DoInitialSnapshot || cfg.InitialSnapshotOnly {
+		return errors.New("resumable snapshot is only used for the initial snapshot of a CDC mirror")
+	}
+
+	peerTypes, err := connectors.LoadPeerTypes(ctx, h.pool, []string{cfg.SourceName, cfg.DestinationName})
+	if err != nil {
+		return err
+	}
+	if peerTypes[cfg.SourceName] != protos.DBType_POSTGRES {
+		return errors.New("resumable snapshot is only supported for Postgres sources")
+	}
+	if !upsertsInitialLoad(peerTypes[cfg.DestinationName]) {
+		return errors.New("resumable snapshot needs a destination that upserts the initial load")
+	}
+
+	srcConn, err := connectors.GetByNameAs[*connpostgres.PostgresConnector](ctx, cfg.Env, h.pool, cfg.SourceName)
+	if err != nil {
+		return fmt.Errorf("failed to create source connector: %w", err)
+	}
+	defer connectors.CloseConnector(ctx, srcConn)
+
+	if err := srcConn.CheckTablesHaveUniqueKey(ctx, cfg.TableMappings); err != nil {
+		return fmt.Errorf("resumable snapshot upserts rows by key, %w", err)
+	}
+	return nil
+}
+
+// validateConsistentSnapshot checks that tables can be loaded at binlog positions CDC reconciles with,
+// which are only known for the source itself
+func (h *FlowRequestHandler) validateConsistentSnapshot(ctx context.Context, cfg *protos.FlowConnectionConfigs) error {
+	if !cfg.DoInitialSnapshot || cfg.InitialSnapshotOnly {
+		return errors.New("consistent snapshot is only used for the initial snapshot of a CDC mirror")
+	}
+	if cfg.SnapshotPeerName != "" {
+		return errors.New("consistent snapshot cannot be combined with a snapshot peer")
+	}
+
+	peerTypes, err := connectors.LoadPeerTypes(ctx, h.pool, []string{cfg.SourceName})
+	if err != nil {
+		return err
+	}
+	if peerTypes[cfg.SourceName] != protos.DBType_MYSQL {
+		return errors.New("consistent snapshot is only supported for MySQL sources")
+	}
+
+	return nil
+}
+
+// validateIncrementalSnapshot checks that tables can be loaded in chunks delimited by watermarks,
+// which are logical decoding messages only decoded from Postgres 14
+func (h *FlowRequestHandler) validateIncrementalSnapshot(ctx context.Context, cfg *protos.FlowConnectionConfigs) error {
+	if cfg.InitialSnapshotOnly {
+		return errors.New("incremental snapshot loads tables alongside CDC, so cannot be used for snapshot only mirrors")
+	}
+
+	peerTypes, err := connectors.LoadPeerTypes(ctx, h.pool, []string{cfg.SourceName})
+	if err != nil {
+		return err
+	}
+	if peerTypes[cfg.SourceName] != protos.DBType_POSTGRES {
+		return errors.New("incremental snapshot is only supported for Postgres sources")
+	}
+
+	srcConn, err := connectors.GetByNameAs[*connpostgres.PostgresConnector](ctx, cfg.Env, h.pool, cfg.SourceName)
+	if err != nil {
+		return fmt.Errorf("failed to create source connector: %w", err)
+	}
+	defer connectors.CloseConnector(ctx, srcConn)
+
+	pgVersion, err := srcConn.MajorVersion(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to get source version: %w", err)
+	}
+	if pgVersion < shared.POSTGRES_14 {
+		return errors.New("incremental snapshot needs a source on Postgres 14 or later")
+	}
+
+	return nil
+}
+
+// validateDurableCDCBuffer checks that there is somewhere to keep changes pulled ahead of the destination
+func (h *FlowRequestHandler) validateDurableCDCBuffer(ctx context.Context, cfg *protos.FlowConnectionConfigs) error {
+	if cfg.InitialSnapshotOnly {
+		return errors.New("durable CDC buffer holds changes pulled by CDC, so cannot be used for snapshot only mirrors")
+	}
+
+	bufferDir, err := internal.PeerDBCDCBufferDir(ctx, cfg.Env)
+	if err != nil {
+		return fmt.Errorf("failed to get CDC buffer directory: %w", err)
+	}
+	if bufferDir == "" {
+		return errors.New("durable CDC buffer requires PEERDB_CDC_BUFFER_DIR to be set")
+	}
+
+	// the directory is checked from here, so it only says something about workers when they mount the same volume
+	store, err := utils.NewCDCBufferStore(ctx, bufferDir, cfg.FlowJobName)
+	if err != nil {
+		return err
+	}
+	if err := store.Probe(ctx); err != nil {
+		return fmt.Errorf("CDC buffer at %s is not usable: %w", bufferDir, err)
+	}
+
+	return nil
+}
+
+// upsertsInitialLoad is whether rows read more than once by the initial load end up deduplicated by key,
+// either by upserting partitions or by the destination merging them with CDC
+func upsertsInitialLoad(dbtype protos.DBType) bool {
+	switch dbtype {
+	case protos.DBType_POSTGRES, protos.DBType_SNOWFLAKE, protos.DBType_CLICKHOUSE,
+		protos.DBType_ELASTICSEARCH, protos.DBType_OPENSEARCH, protos.DBType_REDIS:
+		return true
+	default:
+		return false
+	}
+}
+
 func (h *FlowRequestHandler) CheckIfMirrorNameExists(ctx context.Context, mirrorName string) (bool, error) {
 	var nameExists pgtype.Bool
 	err := h.pool.QueryRow(ctx, "SELECT EXISTS(SELECT * FROM flows WHERE name = $1)", mirrorName).Scan(&nameExists)
@@ -0,0 +1,75 @@
+package cmd
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/PeerDB-io/peerdb/flow/generated/protos"
+)
+
+func TestValidateSnapshotPeerTypes(t *testing.T) {
+	t.Parallel()
+
+	cfg := func(update func(*protos.FlowConnectionConfigs)) *protos.FlowConnectionConfigs {
+		cfg := &protos.FlowConnectionConfigs{
+			SourceName:        "source",
+			SnapshotPeerName:  "replica",
+			DestinationName:   "destination",
+			DoInitialSnapshot: true,
+		}
+		if update != nil {
+			update(cfg)
+		}
+		return cfg
+	}
+	peerTypes := func(source protos.DBType, destination protos.DBType) map[string]protos.DBType {
+		return map[string]protos.DBType{"source": source, "replica": source, "destination": destination}
+	}
+
+	require.NoError(t, validateSnapshotPeerTypes(cfg(nil), peerTypes(protos.DBType_POSTGRES, protos.DBType_CLICKHOUSE)))
+
+	for _, tc := range []struct {
+		cfg       *protos.FlowConnectionConfigs
+		peerTypes map[string]protos.DBType
+		name      string
+		err       string
+	}{
+		{
+			name:      "no initial snapshot",
+			cfg:       cfg(func(cfg *protos.FlowConnectionConfigs) { cfg.DoInitialSnapshot = false }),
+			peerTypes: peerTypes(protos.DBType_POSTGRES, protos.DBType_CLICKHOUSE),
+			err:       "only used for the initial snapshot",
+		},
+		{
+			name:      "initial snapshot only",
+			cfg:       cfg(func(cfg *protos.FlowConnectionConfigs) { cfg.InitialSnapshotOnly = true }),
+			peerTypes: peerTypes(protos.DBType_POSTGRES, protos.DBType_CLICKHOUSE),
+			err:       "only used for the initial snapshot",
+		},
+		{
+			name:      "missing snapshot peer",
+			cfg:       cfg(func(cfg *protos.FlowConnectionConfigs) { cfg.SnapshotPeerName = "missing" }),
+			peerTypes: peerTypes(protos.DBType_POSTGRES, protos.DBType_CLICKHOUSE),
+			err:       "does not exist",
+		},
+		{
+			name:      "mysql source",
+			cfg:       cfg(nil),
+			peerTypes: peerTypes(protos.DBType_MYSQL, protos.DBType_CLICKHOUSE),
+			err:       "only supported for Postgres sources",
+		},
+		{
+			// also with an exported snapshot, which is taken past the consistent point
+			name:      "destination appending the initial load",
+			cfg:       cfg(nil),
+			peerTypes: peerTypes(protos.DBType_POSTGRES, protos.DBType_BIGQUERY),
+			err:       "needs a destination that upserts",
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			require.ErrorContains(t, validateSnapshotPeerTypes(tc.cfg, tc.peerTypes), tc.err)
+		})
+	}
+}
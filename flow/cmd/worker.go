@@ -12,6 +12,7 @@ import (
 	"runtime"
 	"time"
 
+	"go.opentelemetry.io/otel/trace"
 	"go.temporal.io/sdk/client"
 	temporalotel "go.temporal.io/sdk/contrib/opentelemetry"
 	"go.temporal.io/sdk/worker"
@@ -33,14 +34,17 @@ type WorkerSetupOptions struct {
 	TemporalMaxConcurrentWorkflowTasks int
 	EnableProfiling                    bool
 	EnableOtelMetrics                  bool
+	EnableOtelTraces                   bool
 	UseMaintenanceTaskQueue            bool
 	PprofPort                          int // Port for pprof HTTP server
+	PrometheusPort                     int // Port for Prometheus scrape endpoint, used when OTEL_METRICS_EXPORTER=prometheus
 }
 
 type WorkerSetupResponse struct {
-	Client      client.Client
-	Worker      worker.Worker
-	OtelManager *otel_metrics.OtelManager
+	Client         client.Client
+	Worker         worker.Worker
+	TracerProvider trace.TracerProvider
+	OtelManager    *otel_metrics.OtelManager
 }
 
 func (w *WorkerSetupResponse) Close(ctx context.Context) {
@@ -49,6 +53,9 @@ func (w *WorkerSetupResponse) Close(ctx context.Context) {
 	if err := w.OtelManager.Close(ctx); err != nil {
 		slog.Error("Failed to shutdown metrics provider", slog.Any("error", err))
 	}
+	if err := otel_metrics.CloseTracerProvider(ctx, w.TracerProvider); err != nil {
+		slog.Error("Failed to shutdown tracer provider", slog.Any("error", err))
+	}
 }
 
 func setupPprof(opts *WorkerSetupOptions) {
@@ -75,10 +82,56 @@ func setupPprof(opts *WorkerSetupOptions) {
 	}()
 }
 
+// setupPrometheus serves metrics for scraping when metrics are enabled with the prometheus exporter
+func setupPrometheus(enableOtelMetrics bool, prometheusPort int) {
+	if !enableOtelMetrics || !otel_metrics.PrometheusExporterEnabled() {
+		return
+	}
+
+	go func() {
+		prometheusAddr := fmt.Sprintf(":%d", prometheusPort)
+		slog.Info("Starting Prometheus metrics HTTP server on " + prometheusAddr)
+		mux := http.NewServeMux()
+		mux.Handle("/metrics", otel_metrics.PrometheusHandler())
+		server := &http.Server{
+			Addr:         prometheusAddr,
+			Handler:      mux,
+			ReadTimeout:  1 * time.Minute,
+			WriteTimeout: 1 * time.Minute,
+		}
+
+		if err := server.ListenAndServe(); err != nil {
+			log.Fatalf("Failed to start Prometheus metrics HTTP server: %v", err)
+		}
+	}()
+}
+
+// setupTracing installs the tracer provider, and when tracing is enabled adds a Temporal interceptor
+// that starts spans for workflows and activities and propagates them through Temporal headers
+func setupTracing(
+	ctx context.Context, otelServiceName string, enableOtelTraces bool, clientOptions *client.Options,
+) (trace.TracerProvider, error) {
+	tracerProvider, err := otel_metrics.SetupTracerProvider(ctx, otelServiceName, enableOtelTraces)
+	if err != nil {
+		return nil, err
+	}
+	if enableOtelTraces {
+		tracingInterceptor, err := temporalotel.NewTracingInterceptor(temporalotel.TracerOptions{
+			Tracer: tracerProvider.Tracer("temporal-sdk-go"),
+		})
+		if err != nil {
+			return nil, fmt.Errorf("unable to create tracing interceptor: %w", err)
+		}
+		clientOptions.Interceptors = append(clientOptions.Interceptors, tracingInterceptor)
+	}
+	return tracerProvider, nil
+}
+
 func WorkerSetup(ctx context.Context, opts *WorkerSetupOptions) (*WorkerSetupResponse, error) {
 	if opts.EnableProfiling {
 		setupPprof(opts)
 	}
+	setupPrometheus(opts.EnableOtelMetrics, opts.PrometheusPort)
 
 	conn, err := internal.GetCatalogConnectionPoolFromEnv(ctx)
 	if err != nil {
@@ -103,6 +156,10 @@ func WorkerSetup(ctx context.Context, opts *WorkerSetupOptions) (*WorkerSetupRes
 	clientOptions.MetricsHandler = temporalotel.NewMetricsHandler(temporalotel.MetricsHandlerOptions{
 		Meter: metricsProvider.Meter("temporal-sdk-go"),
 	})
+	tracerProvider, err := setupTracing(ctx, otel_metrics.FlowWorkerServiceName, opts.EnableOtelTraces, &clientOptions)
+	if err != nil {
+		return nil, err
+	}
 
 	c, err := setupTemporalClient(ctx, clientOptions)
 	if err != nil {
@@ -151,8 +208,9 @@ func WorkerSetup(ctx context.Context, opts *WorkerSetupOptions) (*WorkerSetupRes
 	})
 
 	return &WorkerSetupResponse{
-		Client:      c,
-		Worker:      w,
-		OtelManager: otelManager,
+		Client:         c,
+		Worker:         w,
+		TracerProvider: tracerProvider,
+		OtelManager:    otelManager,
 	}, nil
 }
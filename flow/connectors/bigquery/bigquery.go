@@ -20,6 +20,7 @@ import (
 	"github.com/PeerDB-io/peerdb/flow/generated/protos"
 	"github.com/PeerDB-io/peerdb/flow/internal"
 	"github.com/PeerDB-io/peerdb/flow/model"
+	"github.com/PeerDB-io/peerdb/flow/otel_metrics"
 	"github.com/PeerDB-io/peerdb/flow/shared"
 )
 
@@ -433,11 +434,16 @@ func (c *BigQueryConnector) NormalizeRecords(ctx context.Context, req *model.Nor
 	}, nil
 }
 
-func (c *BigQueryConnector) runMergeStatement(ctx context.Context, datasetID string, mergeStmt string) error {
+func (c *BigQueryConnector) runMergeStatement(
+	ctx context.Context, flowName string, batchId int64, tableName string, datasetID string, mergeStmt string,
+) error {
+	ctx, span := otel_metrics.StartStatementSpan(ctx, flowName, tableName, batchId, mergeStmt)
 	q := c.client.Query(mergeStmt)
 	q.DefaultProjectID = c.projectID
 	q.DefaultDatasetID = datasetID
-	if _, err := q.Read(ctx); err != nil {
+	_, err := q.Read(ctx)
+	otel_metrics.EndSpan(span, err)
+	if err != nil {
 		return fmt.Errorf("failed to execute merge statement %s: %v", mergeStmt, err)
 	}
 	return nil
@@ -494,7 +500,7 @@ func (c *BigQueryConnector) mergeTablesInThisBatch(
 		if len(unchangedToastColumns) == 0 {
 			c.logger.Info("running single merge statement", slog.String("table", tableName))
 			mergeStmt := mergeGen.generateMergeStmt(tableName, dstDatasetTable, nil)
-			if err := c.runMergeStatement(ctx, dstDatasetTable.dataset, mergeStmt); err != nil {
+			if err := c.runMergeStatement(ctx, flowName, batchId, tableName, dstDatasetTable.dataset, mergeStmt); err != nil {
 				return err
 			}
 		} else {
@@ -505,7 +511,7 @@ func (c *BigQueryConnector) mergeTablesInThisBatch(
 				chunkNumber += 1
 				c.logger.Info("running merge statement", slog.Int("chunk", chunkNumber), slog.String("table", tableName))
 				mergeStmt := mergeGen.generateMergeStmt(tableName, dstDatasetTable, chunk)
-				if err := c.runMergeStatement(ctx, dstDatasetTable.dataset, mergeStmt); err != nil {
+				if err := c.runMergeStatement(ctx, flowName, batchId, tableName, dstDatasetTable.dataset, mergeStmt); err != nil {
 					return err
 				}
 			}
@@ -16,6 +16,7 @@ import (
 	"github.com/PeerDB-io/peerdb/flow/internal"
 	"github.com/PeerDB-io/peerdb/flow/model"
 	"github.com/PeerDB-io/peerdb/flow/model/qvalue"
+	"github.com/PeerDB-io/peerdb/flow/otel_metrics"
 	"github.com/PeerDB-io/peerdb/flow/shared"
 	peerdb_clickhouse "github.com/PeerDB-io/peerdb/flow/shared/clickhouse"
 	"github.com/PeerDB-io/peerdb/flow/shared/types"
@@ -351,7 +352,11 @@ func (c *ClickHouseConnector) NormalizeRecords(
 					slog.String("destinationTable", insertIntoSelectQuery.TableName),
 					slog.String("query", insertIntoSelectQuery.Query))
 
-				if err := c.execWithConnection(errCtx, chConn, insertIntoSelectQuery.Query); err != nil {
+				stmtCtx, span := otel_metrics.StartStatementSpan(
+					errCtx, req.FlowJobName, insertIntoSelectQuery.TableName, req.SyncBatchID, insertIntoSelectQuery.Query)
+				err := c.execWithConnection(stmtCtx, chConn, insertIntoSelectQuery.Query)
+				otel_metrics.EndSpan(span, err)
+				if err != nil {
 					c.logger.Error("[clickhouse] error while inserting into target clickhouse table",
 						slog.String("table", insertIntoSelectQuery.TableName),
 						slog.Int64("syncBatchID", req.SyncBatchID),
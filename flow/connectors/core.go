@@ -16,10 +16,14 @@ import (
 	connkafka "github.com/PeerDB-io/peerdb/flow/connectors/kafka"
 	connmongo "github.com/PeerDB-io/peerdb/flow/connectors/mongo"
 	connmysql "github.com/PeerDB-io/peerdb/flow/connectors/mysql"
+	connnats "github.com/PeerDB-io/peerdb/flow/connectors/nats"
+	connopensearch "github.com/PeerDB-io/peerdb/flow/connectors/opensearch"
 	connpostgres "github.com/PeerDB-io/peerdb/flow/connectors/postgres"
 	connpubsub "github.com/PeerDB-io/peerdb/flow/connectors/pubsub"
+	connredis "github.com/PeerDB-io/peerdb/flow/connectors/redis"
 	conns3 "github.com/PeerDB-io/peerdb/flow/connectors/s3"
 	connsnowflake "github.com/PeerDB-io/peerdb/flow/connectors/snowflake"
+	connwebhook "github.com/PeerDB-io/peerdb/flow/connectors/webhook"
 	"github.com/PeerDB-io/peerdb/flow/generated/protos"
 	"github.com/PeerDB-io/peerdb/flow/internal"
 	"github.com/PeerDB-io/peerdb/flow/model"
@@ -318,6 +322,13 @@ func LoadPeerType(ctx context.Context, catalogPool shared.CatalogPool, peerName
 	return dbtype, err
 }
 
+// LoadPeerConfigVersion returns a counter that increases every time the peer's configuration is updated
+func LoadPeerConfigVersion(ctx context.Context, catalogPool shared.CatalogPool, peerName string) (int64, error) {
+	var version int64
+	err := catalogPool.QueryRow(ctx, "SELECT config_version FROM peers WHERE name = $1", peerName).Scan(&version)
+	return version, err
+}
+
 func LoadPeerTypes(ctx context.Context, catalogPool shared.CatalogPool, peerNames []string) (map[string]protos.DBType, error) {
 	if len(peerNames) == 0 {
 		return nil, nil
@@ -432,6 +443,30 @@ func LoadPeer(ctx context.Context, catalogPool shared.CatalogPool, peerName stri
 			return nil, fmt.Errorf("failed to unmarshal Elasticsearch config: %w", err)
 		}
 		peer.Config = &protos.Peer_ElasticsearchConfig{ElasticsearchConfig: &config}
+	case protos.DBType_OPENSEARCH:
+		var config protos.OpenSearchConfig
+		if err := proto.Unmarshal(peerOptions, &config); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal OpenSearch config: %w", err)
+		}
+		peer.Config = &protos.Peer_OpensearchConfig{OpensearchConfig: &config}
+	case protos.DBType_REDIS:
+		var config protos.RedisConfig
+		if err := proto.Unmarshal(peerOptions, &config); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal Redis config: %w", err)
+		}
+		peer.Config = &protos.Peer_RedisConfig{RedisConfig: &config}
+	case protos.DBType_NATS:
+		var config protos.NatsConfig
+		if err := proto.Unmarshal(peerOptions, &config); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal NATS config: %w", err)
+		}
+		peer.Config = &protos.Peer_NatsConfig{NatsConfig: &config}
+	case protos.DBType_WEBHOOK:
+		var config protos.WebhookConfig
+		if err := proto.Unmarshal(peerOptions, &config); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal webhook config: %w", err)
+		}
+		peer.Config = &protos.Peer_WebhookConfig{WebhookConfig: &config}
 	default:
 		return nil, fmt.Errorf("unsupported peer type: %s", peer.Type)
 	}
@@ -440,6 +475,11 @@ func LoadPeer(ctx context.Context, catalogPool shared.CatalogPool, peerName stri
 }
 
 func GetConnector(ctx context.Context, env map[string]string, config *protos.Peer) (Connector, error) {
+	config, err := internal.ResolveSecretReferences(ctx, config)
+	if err != nil {
+		return nil, err
+	}
+
 	switch inner := config.Config.(type) {
 	case *protos.Peer_PostgresConfig:
 		return connpostgres.NewPostgresConnector(ctx, env, inner.PostgresConfig)
@@ -463,6 +503,14 @@ func GetConnector(ctx context.Context, env map[string]string, config *protos.Pee
 		return connpubsub.NewPubSubConnector(ctx, env, inner.PubsubConfig)
 	case *protos.Peer_ElasticsearchConfig:
 		return connelasticsearch.NewElasticsearchConnector(ctx, inner.ElasticsearchConfig)
+	case *protos.Peer_OpensearchConfig:
+		return connopensearch.NewOpenSearchConnector(ctx, inner.OpensearchConfig)
+	case *protos.Peer_RedisConfig:
+		return connredis.NewRedisConnector(ctx, inner.RedisConfig)
+	case *protos.Peer_NatsConfig:
+		return connnats.NewNatsConnector(ctx, inner.NatsConfig)
+	case *protos.Peer_WebhookConfig:
+		return connwebhook.NewWebhookConnector(ctx, inner.WebhookConfig)
 	default:
 		return nil, errors.ErrUnsupported
 	}
@@ -515,6 +563,10 @@ var (
 	_ CDCSyncConnector = &conns3.S3Connector{}
 	_ CDCSyncConnector = &connclickhouse.ClickHouseConnector{}
 	_ CDCSyncConnector = &connelasticsearch.ElasticsearchConnector{}
+	_ CDCSyncConnector = &connopensearch.OpenSearchConnector{}
+	_ CDCSyncConnector = &connredis.RedisConnector{}
+	_ CDCSyncConnector = &connnats.NatsConnector{}
+	_ CDCSyncConnector = &connwebhook.WebhookConnector{}
 
 	_ CDCSyncPgConnector = &connpostgres.PostgresConnector{}
 
@@ -536,6 +588,8 @@ var (
 	_ NormalizedTablesConnector = &connbigquery.BigQueryConnector{}
 	_ NormalizedTablesConnector = &connsnowflake.SnowflakeConnector{}
 	_ NormalizedTablesConnector = &connclickhouse.ClickHouseConnector{}
+	_ NormalizedTablesConnector = &connelasticsearch.ElasticsearchConnector{}
+	_ NormalizedTablesConnector = &connopensearch.OpenSearchConnector{}
 
 	_ CreateTablesFromExistingConnector = &connbigquery.BigQueryConnector{}
 	_ CreateTablesFromExistingConnector = &connsnowflake.SnowflakeConnector{}
@@ -553,6 +607,10 @@ var (
 	_ QRepSyncConnector = &conns3.S3Connector{}
 	_ QRepSyncConnector = &connclickhouse.ClickHouseConnector{}
 	_ QRepSyncConnector = &connelasticsearch.ElasticsearchConnector{}
+	_ QRepSyncConnector = &connopensearch.OpenSearchConnector{}
+	_ QRepSyncConnector = &connredis.RedisConnector{}
+	_ QRepSyncConnector = &connnats.NatsConnector{}
+	_ QRepSyncConnector = &connwebhook.WebhookConnector{}
 
 	_ QRepSyncPgConnector = &connpostgres.PostgresConnector{}
 
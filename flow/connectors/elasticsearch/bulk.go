@@ -0,0 +1,339 @@
+package connelasticsearch
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"slices"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"go.temporal.io/sdk/log"
+
+	metadataStore "github.com/PeerDB-io/peerdb/flow/connectors/external_metadata"
+	"github.com/PeerDB-io/peerdb/flow/connectors/utils"
+	"github.com/PeerDB-io/peerdb/flow/generated/protos"
+	"github.com/PeerDB-io/peerdb/flow/internal"
+	"github.com/PeerDB-io/peerdb/flow/model"
+	"github.com/PeerDB-io/peerdb/flow/shared"
+)
+
+// BulkDocument is an index or delete operation of a bulk request
+type BulkDocument struct {
+	Version    *int64
+	Action     string
+	DocumentID string
+	// nil when deleting
+	Body []byte
+}
+
+// BulkItemFailure is a failed operation of a bulk request, Err is set when the request itself failed
+type BulkItemFailure struct {
+	Err         error
+	Action      string
+	DocumentID  string
+	ErrorType   string
+	ErrorReason string
+	CauseType   string
+	CauseReason string
+	Status      int
+}
+
+func (f BulkItemFailure) AsError() error {
+	if f.Err != nil {
+		return f.Err
+	}
+	if f.ErrorType == "" {
+		return fmt.Errorf("id:%s action:%s status:%d", f.DocumentID, f.Action, f.Status)
+	}
+	causeString := ""
+	if f.CauseType != "" || f.CauseReason != "" {
+		causeString = fmt.Sprintf("(caused by type:%s reason:%s)", f.CauseType, f.CauseReason)
+	}
+	return fmt.Errorf("id:%s action:%s type:%s reason:%s %s", f.DocumentID, f.Action, f.ErrorType,
+		f.ErrorReason, causeString)
+}
+
+// BulkIndexer adds documents to the bulk requests of one index,
+// implemented over the bulk indexer of each Elasticsearch compatible client
+type BulkIndexer interface {
+	Add(ctx context.Context, doc BulkDocument, onSuccess func(), onFailure func(BulkItemFailure)) error
+	Close(ctx context.Context) error
+	NumFlushed() uint64
+}
+
+// BulkSyncer syncs CDC records and QRep partitions through bulk requests,
+// shared by the Elasticsearch and OpenSearch connectors which only differ in their client
+type BulkSyncer struct {
+	*metadataStore.PostgresMetadata
+	Logger         log.Logger
+	NewBulkIndexer func(index string) (BulkIndexer, error)
+	// prefixes logs and errors
+	Name string
+}
+
+func (s BulkSyncer) SyncRecords(ctx context.Context,
+	req *model.SyncRecordsRequest[model.RecordItems],
+) (*model.SyncResponse, error) {
+	tableNameRowsMapping := utils.InitialiseTableRowsMap(req.TableMappings)
+	var lastSeenLSN atomic.Int64
+	var numRecords int64
+
+	bulkIndexerCache := make(map[string]BulkIndexer)
+	bulkIndexersHaveShutdown := false
+	// true if we saw errors while closing
+	cacheCloser := func() bool {
+		closeHasErrors := false
+		if !bulkIndexersHaveShutdown {
+			for _, bulkIndexer := range bulkIndexerCache {
+				if err := bulkIndexer.Close(context.Background()); err != nil {
+					s.Logger.Error(s.Name+" failed to close bulk indexer", slog.Any("error", err))
+					closeHasErrors = true
+				}
+				numRecords += int64(bulkIndexer.NumFlushed())
+			}
+			bulkIndexersHaveShutdown = true
+		}
+		return closeHasErrors
+	}
+	defer cacheCloser()
+
+	flushLoopDone := make(chan struct{})
+	go func() {
+		flushTimeout, err := internal.PeerDBQueueFlushTimeoutSeconds(ctx, req.Env)
+		if err != nil {
+			s.Logger.Warn(s.Name+" failed to get flush timeout, no periodic flushing", slog.Any("error", err))
+			return
+		}
+		ticker := time.NewTicker(flushTimeout)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-flushLoopDone:
+				return
+			case <-ticker.C:
+				lastSeen := lastSeenLSN.Load()
+				if lastSeen > req.ConsumedOffset.Load() {
+					if err := s.SetLastOffset(ctx, req.FlowJobName, model.CdcCheckpoint{ID: lastSeen}); err != nil {
+						s.Logger.Warn(s.Name+" SetLastOffset error", slog.Any("error", err))
+					} else {
+						shared.AtomicInt64Max(req.ConsumedOffset, lastSeen)
+						s.Logger.Info("processBatch", slog.Int64("updated last offset", lastSeen))
+					}
+				}
+			}
+		}
+	}()
+
+	var failures bulkFailures
+
+	for record := range req.Records.GetRecords() {
+		if _, ok := record.(*model.MessageRecord[model.RecordItems]); ok {
+			continue
+		}
+
+		doc := BulkDocument{Action: actionIndex}
+		var err error
+		switch record.(type) {
+		case *model.InsertRecord[model.RecordItems], *model.UpdateRecord[model.RecordItems]:
+			doc.Body, err = RecordItemsProcessor(record.GetItems())
+			if err != nil {
+				s.Logger.Error(s.Name+" failed to json.Marshal record", slog.Any("error", err))
+				return nil, fmt.Errorf("%s failed to json.Marshal record: %w", s.Name, err)
+			}
+		case *model.DeleteRecord[model.RecordItems]:
+			// no need to supply the document since we are deleting
+			doc.Action = actionDelete
+		}
+
+		bulkIndexer, ok := bulkIndexerCache[record.GetDestinationTableName()]
+		if !ok {
+			bulkIndexer, err = s.NewBulkIndexer(record.GetDestinationTableName())
+			if err != nil {
+				s.Logger.Error(s.Name+" failed to initialize bulk indexer", slog.Any("error", err))
+				return nil, fmt.Errorf("%s failed to initialize bulk indexer: %w", s.Name, err)
+			}
+			bulkIndexerCache[record.GetDestinationTableName()] = bulkIndexer
+		}
+
+		doc.DocumentID, err = RecordDocumentID(req.TableNameSchemaMapping, record)
+		if err != nil {
+			s.Logger.Error(s.Name+" failed to process record", slog.Any("error", err))
+			return nil, fmt.Errorf("%s failed to process record: %w", s.Name, err)
+		}
+		// only Postgres records carry a checkpoint that orders changes
+		if checkpointID := record.GetCheckpointID(); checkpointID > 0 {
+			doc.Version = &checkpointID
+		}
+
+		if err := bulkIndexer.Add(ctx, doc, func() {
+			shared.AtomicInt64Max(&lastSeenLSN, record.GetCheckpointID())
+			record.PopulateCountMap(tableNameRowsMapping)
+		}, func(failure BulkItemFailure) {
+			if failure.Err == nil {
+				// attempt to delete a record that wasn't present, possible from no initial load
+				if failure.Action == actionDelete && failure.Status == http.StatusNotFound {
+					return
+				}
+				// document already holds this or a newer change, from an earlier attempt at this batch
+				if failure.Status == http.StatusConflict && failure.ErrorType == "version_conflict_engine_exception" {
+					return
+				}
+			}
+			failures.record(failure)
+		}); err != nil {
+			s.Logger.Error(s.Name+" failed to add record to bulk indexer", slog.Any("error", err))
+			return nil, fmt.Errorf("%s failed to add record to bulk indexer: %w", s.Name, err)
+		}
+		if fatalError := failures.fatalError(); fatalError != nil {
+			s.Logger.Error(s.Name+" fatal error while indexing record", slog.Any("error", fatalError))
+			return nil, fmt.Errorf("%s fatal error while indexing record: %w", s.Name, fatalError)
+		}
+	}
+	close(flushLoopDone)
+
+	if cacheCloser() {
+		s.Logger.Error(s.Name + " failed to close bulk indexer(s)")
+		return nil, errors.New(s.Name + " failed to close bulk indexer(s)")
+	}
+	for _, err := range failures.errors {
+		s.Logger.Error(s.Name+" failed to index record", slog.Any("err", err))
+	}
+
+	lastCheckpoint := req.Records.GetLastCheckpoint()
+	if err := s.FinishBatch(ctx, req.FlowJobName, req.SyncBatchID, lastCheckpoint); err != nil {
+		return nil, err
+	}
+
+	return &model.SyncResponse{
+		CurrentSyncBatchID:   req.SyncBatchID,
+		LastSyncedCheckpoint: lastCheckpoint,
+		NumRecordsSynced:     numRecords,
+		TableNameRowsMapping: tableNameRowsMapping,
+		TableSchemaDeltas:    req.Records.SchemaDeltas,
+	}, nil
+}
+
+func (s BulkSyncer) SyncQRepRecords(ctx context.Context, config *protos.QRepConfig,
+	partition *protos.QRepPartition, stream *model.QRecordStream,
+) (int64, shared.QRepWarnings, error) {
+	startTime := time.Now()
+
+	schema, err := stream.Schema()
+	if err != nil {
+		return 0, nil, err
+	}
+
+	var failures bulkFailures
+	var numRecords int64
+	bulkIndexerHasShutdown := false
+
+	// len == 0 means use UUID
+	// len == 1 means single column, use value directly
+	// len > 1 means SHA256 hash of upsert key columns
+	// ordered such that we preserve order of UpsertKeyColumns
+	var upsertKeyColIndices []int
+	if config.WriteMode.WriteType == protos.QRepWriteType_QREP_WRITE_MODE_UPSERT {
+		schemaColNames := schema.GetColumnNames()
+		for _, upsertCol := range config.WriteMode.UpsertKeyColumns {
+			idx := slices.Index(schemaColNames, upsertCol)
+			if idx != -1 {
+				upsertKeyColIndices = append(upsertKeyColIndices, idx)
+			}
+		}
+	}
+
+	bulkIndexer, err := s.NewBulkIndexer(config.DestinationTableIdentifier)
+	if err != nil {
+		s.Logger.Error(s.Name+" failed to initialize bulk indexer", slog.Any("error", err))
+		return 0, nil, fmt.Errorf("%s failed to initialize bulk indexer: %w", s.Name, err)
+	}
+	defer func() {
+		if !bulkIndexerHasShutdown {
+			if err := bulkIndexer.Close(context.Background()); err != nil {
+				s.Logger.Error(s.Name+" failed to close bulk indexer", slog.Any("error", err))
+			}
+		}
+	}()
+
+	for qRecord := range stream.Records {
+		doc := BulkDocument{Action: actionIndex}
+		switch len(upsertKeyColIndices) {
+		case 0:
+			// relying on autogeneration of document ID
+		case 1:
+			doc.DocumentID = fmt.Sprint(qRecord[upsertKeyColIndices[0]].Value())
+		default:
+			doc.DocumentID = UpsertKeyColsHash(qRecord, upsertKeyColIndices)
+		}
+		doc.Body, err = QRecordDocument(schema, qRecord)
+		if err != nil {
+			s.Logger.Error(s.Name+" failed to json.Marshal record", slog.Any("error", err))
+			return 0, nil, fmt.Errorf("%s failed to json.Marshal record: %w", s.Name, err)
+		}
+
+		// failures are collected and logged once the bulk indexer is closed
+		if err := bulkIndexer.Add(ctx, doc, nil, func(failure BulkItemFailure) {
+			failures.record(failure)
+		}); err != nil {
+			s.Logger.Error(s.Name+" failed to add record to bulk indexer", slog.Any("error", err))
+			return 0, nil, fmt.Errorf("%s failed to add record to bulk indexer: %w", s.Name, err)
+		}
+		if fatalError := failures.fatalError(); fatalError != nil {
+			s.Logger.Error(s.Name+" fatal error while indexing record", slog.Any("error", fatalError))
+			return 0, nil, fmt.Errorf("%s fatal error while indexing record: %w", s.Name, fatalError)
+		}
+
+		// update here instead of OnSuccess, if we close successfully it should match
+		numRecords++
+	}
+
+	if err := stream.Err(); err != nil {
+		s.Logger.Error(s.Name+" failed to get record from stream", slog.Any("error", err))
+		return 0, nil, fmt.Errorf("%s failed to get record from stream: %w", s.Name, err)
+	}
+	if err := bulkIndexer.Close(ctx); err != nil {
+		s.Logger.Error(s.Name+" failed to close bulk indexer", slog.Any("error", err))
+		return 0, nil, fmt.Errorf("%s failed to close bulk indexer: %w", s.Name, err)
+	}
+	bulkIndexerHasShutdown = true
+	for _, err := range failures.errors {
+		s.Logger.Error(s.Name+" failed to index record", slog.Any("err", err))
+	}
+
+	if err := s.FinishQRepPartition(ctx, partition, config.FlowJobName, startTime); err != nil {
+		s.Logger.Error(s.Name+" failed to log partition info", slog.Any("error", err))
+		return 0, nil, fmt.Errorf("%s failed to log partition info: %w", s.Name, err)
+	}
+	return numRecords, nil, nil
+}
+
+// bulkFailures collects failed operations from the bulk indexer's workers
+type bulkFailures struct {
+	fatal  error
+	errors []error
+	mutex  sync.Mutex
+}
+
+// record keeps a failed operation, an illegal argument fails the whole sync
+func (f *bulkFailures) record(failure BulkItemFailure) {
+	err := failure.AsError()
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	f.errors = append(f.errors, err)
+	if failure.Err == nil && failure.ErrorType == "illegal_argument_exception" {
+		f.fatal = err
+	}
+}
+
+func (f *bulkFailures) fatalError() error {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	return f.fatal
+}
@@ -0,0 +1,31 @@
+package connelasticsearch
+
+import (
+	"errors"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestBulkFailures(t *testing.T) {
+	t.Parallel()
+
+	var failures bulkFailures
+	failures.record(BulkItemFailure{Action: actionIndex, DocumentID: "1", Status: http.StatusTooManyRequests})
+	requestErr := errors.New("connection reset")
+	failures.record(BulkItemFailure{Err: requestErr, ErrorType: "illegal_argument_exception"})
+	require.NoError(t, failures.fatalError(), "only failures reported by the cluster can be fatal")
+
+	failures.record(BulkItemFailure{
+		Action: actionIndex, DocumentID: "2", Status: http.StatusBadRequest,
+		ErrorType: "illegal_argument_exception", ErrorReason: "bad value",
+		CauseType: "number_format_exception", CauseReason: "not a number",
+	})
+	require.EqualError(t, failures.fatalError(),
+		"id:2 action:index type:illegal_argument_exception reason:bad value "+
+			"(caused by type:number_format_exception reason:not a number)")
+	require.Len(t, failures.errors, 3)
+	require.EqualError(t, failures.errors[0], "id:1 action:index status:429")
+	require.ErrorIs(t, failures.errors[1], requestErr)
+}
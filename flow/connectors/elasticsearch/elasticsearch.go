@@ -6,13 +6,8 @@ import (
 	"crypto/tls"
 	"encoding/base64"
 	"encoding/json"
-	"errors"
 	"fmt"
-	"log/slog"
-	"maps"
 	"net/http"
-	"sync"
-	"sync/atomic"
 	"time"
 
 	"github.com/elastic/go-elasticsearch/v8"
@@ -20,7 +15,6 @@ import (
 	"go.temporal.io/sdk/log"
 
 	metadataStore "github.com/PeerDB-io/peerdb/flow/connectors/external_metadata"
-	"github.com/PeerDB-io/peerdb/flow/connectors/utils"
 	"github.com/PeerDB-io/peerdb/flow/generated/protos"
 	"github.com/PeerDB-io/peerdb/flow/internal"
 	"github.com/PeerDB-io/peerdb/flow/model"
@@ -31,12 +25,17 @@ import (
 const (
 	actionIndex  = "index"
 	actionDelete = "delete"
+
+	// documents carry the checkpoint of the change that wrote them as their version,
+	// so a retried batch can't overwrite a document with an older change
+	versionTypeExternal = "external"
 )
 
 type ElasticsearchConnector struct {
 	*metadataStore.PostgresMetadata
-	client *elasticsearch.Client
-	logger log.Logger
+	client           *elasticsearch.Client
+	logger           log.Logger
+	explicitMappings bool
 }
 
 func NewElasticsearchConnector(ctx context.Context,
@@ -69,6 +68,7 @@ func NewElasticsearchConnector(ctx context.Context,
 		PostgresMetadata: pgMetadata,
 		client:           esClient,
 		logger:           internal.LoggerFromCtx(ctx),
+		explicitMappings: config.ExplicitMappings,
 	}, nil
 }
 
@@ -99,7 +99,8 @@ func (esc *ElasticsearchConnector) ReplayTableSchemaDeltas(ctx context.Context,
 	return nil
 }
 
-func recordItemsProcessor(items model.RecordItems) ([]byte, error) {
+// RecordItemsProcessor converts the row of a CDC record to the JSON document indexed for it
+func RecordItemsProcessor(items model.RecordItems) ([]byte, error) {
 	qRecordJsonMap := make(map[string]any)
 
 	for key, val := range items.ColToVal {
@@ -114,190 +115,91 @@ func recordItemsProcessor(items model.RecordItems) ([]byte, error) {
 	return json.Marshal(qRecordJsonMap)
 }
 
-func (esc *ElasticsearchConnector) SyncRecords(ctx context.Context,
-	req *model.SyncRecordsRequest[model.RecordItems],
-) (*model.SyncResponse, error) {
-	tableNameRowsMapping := utils.InitialiseTableRowsMap(req.TableMappings)
-	var lastSeenLSN atomic.Int64
-	var numRecords int64
-
-	// no I don't like this either
-	esBulkIndexerCache := make(map[string]esutil.BulkIndexer)
-	bulkIndexersHaveShutdown := false
-	// true if we saw errors while closing
-	cacheCloser := func() bool {
-		closeHasErrors := false
-		if !bulkIndexersHaveShutdown {
-			for esBulkIndexer := range maps.Values(esBulkIndexerCache) {
-				err := esBulkIndexer.Close(context.Background())
-				if err != nil {
-					esc.logger.Error("[es] failed to close bulk indexer", slog.Any("error", err))
-					closeHasErrors = true
-				}
-				numRecords += int64(esBulkIndexer.Stats().NumFlushed)
-			}
-			bulkIndexersHaveShutdown = true
-		}
-		return closeHasErrors
-	}
-	defer cacheCloser()
-
-	flushLoopDone := make(chan struct{})
-	go func() {
-		flushTimeout, err := internal.PeerDBQueueFlushTimeoutSeconds(ctx, req.Env)
+// RecordDocumentID identifies the document of a CDC record's row by its primary key,
+// the value itself for single column keys, so it matches the document written by initial load
+func RecordDocumentID(
+	tableNameSchemaMapping map[string]*protos.TableSchema,
+	record model.Record[model.RecordItems],
+) (string, error) {
+	if len(tableNameSchemaMapping[record.GetDestinationTableName()].PrimaryKeyColumns) == 1 {
+		qValue, err := record.GetItems().GetValueByColName(
+			tableNameSchemaMapping[record.GetDestinationTableName()].PrimaryKeyColumns[0])
 		if err != nil {
-			esc.logger.Warn("[elasticsearch] failed to get flush timeout, no periodic flushing", slog.Any("error", err))
-			return
+			return "", err
 		}
-		ticker := time.NewTicker(flushTimeout)
-		defer ticker.Stop()
-
-		for {
-			select {
-			case <-ctx.Done():
-				return
-			case <-flushLoopDone:
-				return
-			case <-ticker.C:
-				lastSeen := lastSeenLSN.Load()
-				if lastSeen > req.ConsumedOffset.Load() {
-					if err := esc.SetLastOffset(ctx, req.FlowJobName, model.CdcCheckpoint{ID: lastSeen}); err != nil {
-						esc.logger.Warn("[es] SetLastOffset error", slog.Any("error", err))
-					} else {
-						shared.AtomicInt64Max(req.ConsumedOffset, lastSeen)
-						esc.logger.Info("processBatch", slog.Int64("updated last offset", lastSeen))
-					}
-				}
-			}
-		}
-	}()
+		return fmt.Sprint(qValue.Value()), nil
+	}
+	tablePkey, err := model.RecToTablePKey(tableNameSchemaMapping, record)
+	if err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(tablePkey.PkeyColVal[:]), nil
+}
 
-	var docId string
-	var bulkIndexFatalError error
-	var bulkIndexErrors []error
-	var bulkIndexOnFailureMutex sync.Mutex
+// esBulkIndexer is a BulkIndexer over the bulk indexer of the Elasticsearch client
+type esBulkIndexer struct {
+	esutil.BulkIndexer
+}
 
-	for record := range req.Records.GetRecords() {
-		if _, ok := record.(*model.MessageRecord[model.RecordItems]); ok {
-			continue
+func (bi esBulkIndexer) Add(ctx context.Context, doc BulkDocument, onSuccess func(), onFailure func(BulkItemFailure)) error {
+	item := esutil.BulkIndexerItem{
+		Action:     doc.Action,
+		DocumentID: doc.DocumentID,
+		Version:    doc.Version,
+		OnFailure: func(_ context.Context, item esutil.BulkIndexerItem, res esutil.BulkIndexerResponseItem, err error) {
+			onFailure(BulkItemFailure{
+				Err:         err,
+				Action:      item.Action,
+				DocumentID:  item.DocumentID,
+				ErrorType:   res.Error.Type,
+				ErrorReason: res.Error.Reason,
+				CauseType:   res.Error.Cause.Type,
+				CauseReason: res.Error.Cause.Reason,
+				Status:      res.Status,
+			})
+		},
+	}
+	if doc.Body != nil {
+		item.Body = bytes.NewReader(doc.Body)
+	}
+	if doc.Version != nil {
+		item.VersionType = versionTypeExternal
+	}
+	if onSuccess != nil {
+		item.OnSuccess = func(context.Context, esutil.BulkIndexerItem, esutil.BulkIndexerResponseItem) {
+			onSuccess()
 		}
+	}
+	return bi.BulkIndexer.Add(ctx, item)
+}
 
-		var bodyBytes []byte
-		var err error
-		action := actionIndex
-
-		switch record.(type) {
-		case *model.InsertRecord[model.RecordItems], *model.UpdateRecord[model.RecordItems]:
-			bodyBytes, err = recordItemsProcessor(record.GetItems())
-			if err != nil {
-				esc.logger.Error("[es] failed to json.Marshal record", slog.Any("error", err))
-				return nil, fmt.Errorf("[es] failed to json.Marshal record: %w", err)
-			}
-		case *model.DeleteRecord[model.RecordItems]:
-			action = actionDelete
-			// no need to supply the document since we are deleting
-			bodyBytes = nil
-		}
+func (bi esBulkIndexer) NumFlushed() uint64 {
+	return bi.Stats().NumFlushed
+}
 
-		bulkIndexer, ok := esBulkIndexerCache[record.GetDestinationTableName()]
-		if !ok {
-			bulkIndexer, err = esutil.NewBulkIndexer(esutil.BulkIndexerConfig{
-				Index:  record.GetDestinationTableName(),
+func (esc *ElasticsearchConnector) bulkSyncer() BulkSyncer {
+	return BulkSyncer{
+		PostgresMetadata: esc.PostgresMetadata,
+		Logger:           esc.logger,
+		Name:             "[es]",
+		NewBulkIndexer: func(index string) (BulkIndexer, error) {
+			bulkIndexer, err := esutil.NewBulkIndexer(esutil.BulkIndexerConfig{
+				Index:  index,
 				Client: esc.client,
-				// can't really ascertain how many tables present to provide a reasonable value
+				// parallelism comes from the workflow design itself, no need for this
 				NumWorkers:    1,
 				FlushInterval: 10 * time.Second,
 			})
 			if err != nil {
-				esc.logger.Error("[es] failed to initialize bulk indexer", slog.Any("error", err))
-				return nil, fmt.Errorf("[es] failed to initialize bulk indexer: %w", err)
-			}
-			esBulkIndexerCache[record.GetDestinationTableName()] = bulkIndexer
-		}
-
-		if len(req.TableNameSchemaMapping[record.GetDestinationTableName()].PrimaryKeyColumns) == 1 {
-			qValue, err := record.GetItems().GetValueByColName(
-				req.TableNameSchemaMapping[record.GetDestinationTableName()].PrimaryKeyColumns[0])
-			if err != nil {
-				esc.logger.Error("[es] failed to process record", slog.Any("error", err))
-				return nil, fmt.Errorf("[es] failed to process record: %w", err)
-			}
-			docId = fmt.Sprint(qValue.Value())
-		} else {
-			tablePkey, err := model.RecToTablePKey(req.TableNameSchemaMapping, record)
-			if err != nil {
-				esc.logger.Error("[es] failed to process record", slog.Any("error", err))
-				return nil, fmt.Errorf("[es] failed to process record: %w", err)
+				return nil, err
 			}
-			docId = base64.RawURLEncoding.EncodeToString(tablePkey.PkeyColVal[:])
-		}
-
-		if err := bulkIndexer.Add(ctx, esutil.BulkIndexerItem{
-			Action:     action,
-			DocumentID: docId,
-			Body:       bytes.NewReader(bodyBytes),
-
-			OnSuccess: func(_ context.Context, _ esutil.BulkIndexerItem, _ esutil.BulkIndexerResponseItem) {
-				shared.AtomicInt64Max(&lastSeenLSN, record.GetCheckpointID())
-				record.PopulateCountMap(tableNameRowsMapping)
-			},
-			// OnFailure is called for each failed operation, log and let parent handle
-			OnFailure: func(ctx context.Context, item esutil.BulkIndexerItem,
-				res esutil.BulkIndexerResponseItem, err error,
-			) {
-				// attempt to delete a record that wasn't present, possible from no initial load
-				if item.Action == actionDelete && res.Status == 404 {
-					return
-				}
-				bulkIndexOnFailureMutex.Lock()
-				defer bulkIndexOnFailureMutex.Unlock()
-				if err != nil {
-					bulkIndexErrors = append(bulkIndexErrors, err)
-				} else {
-					causeString := ""
-					if res.Error.Cause.Type != "" || res.Error.Cause.Reason != "" {
-						causeString = fmt.Sprintf("(caused by type:%s reason:%s)", res.Error.Cause.Type, res.Error.Cause.Reason)
-					}
-					cbErr := fmt.Errorf("id:%s action:%s type:%s reason:%s %s", item.DocumentID, item.Action, res.Error.Type,
-						res.Error.Reason, causeString)
-					bulkIndexErrors = append(bulkIndexErrors, cbErr)
-					if res.Error.Type == "illegal_argument_exception" {
-						bulkIndexFatalError = cbErr
-					}
-				}
-			},
-		}); err != nil {
-			esc.logger.Error("[es] failed to add record to bulk indexer", slog.Any("error", err))
-			return nil, fmt.Errorf("[es] failed to add record to bulk indexer: %w", err)
-		}
-		if bulkIndexFatalError != nil {
-			esc.logger.Error("[es] fatal error while indexing record", slog.Any("error", bulkIndexFatalError))
-			return nil, fmt.Errorf("[es] fatal error while indexing record: %w", bulkIndexFatalError)
-		}
-	}
-	// "Receive on a closed channel yields the zero value after all elements in the channel are received."
-	close(flushLoopDone)
-
-	if cacheCloser() {
-		esc.logger.Error("[es] failed to close bulk indexer(s)")
-		return nil, errors.New("[es] failed to close bulk indexer(s)")
-	}
-	if len(bulkIndexErrors) > 0 {
-		for _, err := range bulkIndexErrors {
-			esc.logger.Error("[es] failed to index record", slog.Any("err", err))
-		}
-	}
-
-	lastCheckpoint := req.Records.GetLastCheckpoint()
-	if err := esc.FinishBatch(ctx, req.FlowJobName, req.SyncBatchID, lastCheckpoint); err != nil {
-		return nil, err
+			return esBulkIndexer{BulkIndexer: bulkIndexer}, nil
+		},
 	}
+}
 
-	return &model.SyncResponse{
-		CurrentSyncBatchID:   req.SyncBatchID,
-		LastSyncedCheckpoint: lastCheckpoint,
-		NumRecordsSynced:     numRecords,
-		TableNameRowsMapping: tableNameRowsMapping,
-		TableSchemaDeltas:    req.Records.SchemaDeltas,
-	}, nil
+func (esc *ElasticsearchConnector) SyncRecords(ctx context.Context,
+	req *model.SyncRecordsRequest[model.RecordItems],
+) (*model.SyncResponse, error) {
+	return esc.bulkSyncer().SyncRecords(ctx, req)
 }
@@ -0,0 +1,139 @@
+package connelasticsearch
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/PeerDB-io/peerdb/flow/generated/protos"
+	"github.com/PeerDB-io/peerdb/flow/shared/types"
+)
+
+// fieldMapping returns the mapping for a column of the given kind,
+// nil leaves the field to dynamic mapping, which also handles JSON and geospatial values
+func fieldMapping(kind types.QValueKind) map[string]any {
+	// arrays are implicit in Elasticsearch, map them as their elements
+	if kind.IsArray() {
+		kind = types.QValueKind(strings.TrimPrefix(string(kind), "array_"))
+	}
+
+	switch kind {
+	case types.QValueKindBoolean:
+		return map[string]any{"type": "boolean"}
+	case types.QValueKindInt8:
+		return map[string]any{"type": "byte"}
+	case types.QValueKindInt16, types.QValueKindUInt8:
+		return map[string]any{"type": "short"}
+	case types.QValueKindInt32, types.QValueKindUInt16:
+		return map[string]any{"type": "integer"}
+	case types.QValueKindInt64, types.QValueKindUInt32:
+		return map[string]any{"type": "long"}
+	case types.QValueKindUInt64:
+		return map[string]any{"type": "unsigned_long"}
+	case types.QValueKindFloat32:
+		return map[string]any{"type": "float"}
+	case types.QValueKindFloat64, types.QValueKindNumeric:
+		return map[string]any{"type": "double"}
+	case types.QValueKindDate, types.QValueKindTimestamp, types.QValueKindTimestampTZ:
+		return map[string]any{"type": "date"}
+	case types.QValueKindString:
+		// same as what dynamic mapping does for strings
+		return map[string]any{
+			"type":   "text",
+			"fields": map[string]any{"keyword": map[string]any{"type": "keyword", "ignore_above": 256}},
+		}
+	case types.QValueKindQChar, types.QValueKindEnum, types.QValueKindUUID, types.QValueKindCIDR,
+		types.QValueKindINET, types.QValueKindMacaddr, types.QValueKindTime, types.QValueKindTimeTZ, types.QValueKindInterval:
+		return map[string]any{"type": "keyword"}
+	case types.QValueKindBytes:
+		return map[string]any{"type": "binary"}
+	default:
+		return nil
+	}
+}
+
+// IndexMappings returns the body of a create index request with mappings for the columns of tableSchema
+func IndexMappings(tableSchema *protos.TableSchema) map[string]any {
+	properties := make(map[string]any, len(tableSchema.Columns))
+	for _, column := range tableSchema.Columns {
+		if mapping := fieldMapping(types.QValueKind(column.Type)); mapping != nil {
+			properties[column.Name] = mapping
+		}
+	}
+	return map[string]any{"mappings": map[string]any{"properties": properties}}
+}
+
+// QRecordSchemaMappings is IndexMappings for the schema of rows read by a QRep partition
+func QRecordSchemaMappings(schema types.QRecordSchema) map[string]any {
+	properties := make(map[string]any, len(schema.Fields))
+	for _, field := range schema.Fields {
+		if mapping := fieldMapping(field.Type); mapping != nil {
+			properties[field.Name] = mapping
+		}
+	}
+	return map[string]any{"mappings": map[string]any{"properties": properties}}
+}
+
+// createIndexWithMappings creates index with the given mappings unless it already exists,
+// returns whether the index already existed
+func (esc *ElasticsearchConnector) createIndexWithMappings(ctx context.Context, index string, mappings map[string]any) (bool, error) {
+	existsRes, err := esc.client.Indices.Exists([]string{index},
+		esc.client.Indices.Exists.WithContext(ctx))
+	if err != nil {
+		return false, fmt.Errorf("failed to check if index %s exists: %w", index, err)
+	}
+	existsRes.Body.Close()
+	if existsRes.StatusCode == http.StatusOK {
+		return true, nil
+	}
+
+	body, err := json.Marshal(mappings)
+	if err != nil {
+		return false, fmt.Errorf("failed to marshal mappings for index %s: %w", index, err)
+	}
+	createRes, err := esc.client.Indices.Create(index,
+		esc.client.Indices.Create.WithContext(ctx),
+		esc.client.Indices.Create.WithBody(bytes.NewReader(body)))
+	if err != nil {
+		return false, fmt.Errorf("failed to create index %s: %w", index, err)
+	}
+	defer createRes.Body.Close()
+	if createRes.IsError() {
+		// a concurrent partition may have created it first
+		if createRes.StatusCode == http.StatusBadRequest && strings.Contains(createRes.String(), "resource_already_exists_exception") {
+			return true, nil
+		}
+		return false, fmt.Errorf("failed to create index %s: %s", index, createRes.String())
+	}
+	return false, nil
+}
+
+func (esc *ElasticsearchConnector) StartSetupNormalizedTables(_ context.Context) (any, error) {
+	return nil, nil
+}
+
+func (esc *ElasticsearchConnector) CleanupSetupNormalizedTables(_ context.Context, _ any) {
+}
+
+func (esc *ElasticsearchConnector) FinishSetupNormalizedTables(_ context.Context, _ any) error {
+	return nil
+}
+
+// SetupNormalizedTable creates the index with mappings derived from the source schema when explicit mappings are enabled,
+// otherwise indices are created by the first bulk request using dynamic mapping and there is nothing to set up
+func (esc *ElasticsearchConnector) SetupNormalizedTable(
+	ctx context.Context,
+	_ any,
+	_ *protos.SetupNormalizedTableBatchInput,
+	destinationTableIdentifier string,
+	sourceTableSchema *protos.TableSchema,
+) (bool, error) {
+	if !esc.explicitMappings {
+		return true, nil
+	}
+
+	return esc.createIndexWithMappings(ctx, destinationTableIdentifier, IndexMappings(sourceTableSchema))
+}
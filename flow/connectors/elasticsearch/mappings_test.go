@@ -0,0 +1,51 @@
+package connelasticsearch
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/PeerDB-io/peerdb/flow/generated/protos"
+	"github.com/PeerDB-io/peerdb/flow/shared/types"
+)
+
+func TestIndexMappings(t *testing.T) {
+	t.Parallel()
+
+	mappings := IndexMappings(&protos.TableSchema{
+		Columns: []*protos.FieldDescription{
+			{Name: "id", Type: string(types.QValueKindInt64)},
+			{Name: "name", Type: string(types.QValueKindString)},
+			{Name: "tags", Type: string(types.QValueKindArrayString)},
+			{Name: "scores", Type: string(types.QValueKindArrayFloat64)},
+			{Name: "created_at", Type: string(types.QValueKindTimestampTZ)},
+			{Name: "external_id", Type: string(types.QValueKindUUID)},
+			{Name: "attributes", Type: string(types.QValueKindJSONB)},
+		},
+	})
+
+	properties := mappings["mappings"].(map[string]any)["properties"].(map[string]any)
+	require.Equal(t, map[string]any{"type": "long"}, properties["id"])
+	require.Equal(t, "text", properties["name"].(map[string]any)["type"])
+	require.Equal(t, properties["name"], properties["tags"])
+	require.Equal(t, map[string]any{"type": "double"}, properties["scores"])
+	require.Equal(t, map[string]any{"type": "date"}, properties["created_at"])
+	require.Equal(t, map[string]any{"type": "keyword"}, properties["external_id"])
+	require.NotContains(t, properties, "attributes")
+}
+
+func TestQRecordSchemaMappings(t *testing.T) {
+	t.Parallel()
+
+	schema := types.NewQRecordSchema([]types.QField{
+		{Name: "id", Type: types.QValueKindInt64},
+		{Name: "name", Type: types.QValueKindString},
+		{Name: "attributes", Type: types.QValueKindJSONB},
+	})
+	tableSchema := &protos.TableSchema{Columns: []*protos.FieldDescription{
+		{Name: "id", Type: string(types.QValueKindInt64)},
+		{Name: "name", Type: string(types.QValueKindString)},
+		{Name: "attributes", Type: string(types.QValueKindJSONB)},
+	}}
+	require.Equal(t, IndexMappings(tableSchema), QRecordSchemaMappings(schema))
+}
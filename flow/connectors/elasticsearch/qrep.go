@@ -1,18 +1,11 @@
 package connelasticsearch
 
 import (
-	"bytes"
 	"context"
 	"crypto/sha256"
 	"encoding/base64"
 	"encoding/json"
 	"fmt"
-	"log/slog"
-	"slices"
-	"sync"
-	"time"
-
-	"github.com/elastic/go-elasticsearch/v8/esutil"
 
 	"github.com/PeerDB-io/peerdb/flow/generated/protos"
 	"github.com/PeerDB-io/peerdb/flow/model"
@@ -26,7 +19,8 @@ func (esc *ElasticsearchConnector) SetupQRepMetadataTables(ctx context.Context,
 	return nil
 }
 
-func upsertKeyColsHash(qRecord []types.QValue, upsertColIndices []int) string {
+// UpsertKeyColsHash identifies the document of a row by its upsert key columns when there is more than one
+func UpsertKeyColsHash(qRecord []types.QValue, upsertColIndices []int) string {
 	hasher := sha256.New()
 
 	for _, upsertColIndex := range upsertColIndices {
@@ -37,139 +31,32 @@ func upsertKeyColsHash(qRecord []types.QValue, upsertColIndices []int) string {
 	return base64.RawURLEncoding.EncodeToString(hashBytes)
 }
 
-func (esc *ElasticsearchConnector) SyncQRepRecords(ctx context.Context, config *protos.QRepConfig,
-	partition *protos.QRepPartition, stream *model.QRecordStream,
-) (int64, shared.QRepWarnings, error) {
-	startTime := time.Now()
-
-	schema, err := stream.Schema()
-	if err != nil {
-		return 0, nil, err
-	}
-
-	var bulkIndexFatalError error
-	var bulkIndexErrors []error
-	var bulkIndexOnFailureMutex sync.Mutex
-	var docId string
-	var numRecords int64
-	bulkIndexerHasShutdown := false
-
-	// len == 0 means use UUID
-	// len == 1 means single column, use value directly
-	// len > 1 means SHA256 hash of upsert key columns
-	// ordered such that we preserve order of UpsertKeyColumns
-	var upsertKeyColIndices []int
-	if config.WriteMode.WriteType == protos.QRepWriteType_QREP_WRITE_MODE_UPSERT {
-		schemaColNames := schema.GetColumnNames()
-		for _, upsertCol := range config.WriteMode.UpsertKeyColumns {
-			idx := slices.Index(schemaColNames, upsertCol)
-			if idx != -1 {
-				upsertKeyColIndices = append(upsertKeyColIndices, idx)
-			}
+// QRecordDocument converts a row read by initial load or a QRep partition to the JSON document indexed for it
+func QRecordDocument(schema types.QRecordSchema, qRecord []types.QValue) ([]byte, error) {
+	qRecordJsonMap := make(map[string]any, len(schema.Fields))
+	for i, field := range schema.Fields {
+		if r, ok := qRecord[i].(types.QValueJSON); ok { // JSON is stored as a string, fix that
+			qRecordJsonMap[field.Name] = json.RawMessage(
+				shared.UnsafeFastStringToReadOnlyBytes(r.Val))
+		} else {
+			qRecordJsonMap[field.Name] = qRecord[i].Value()
 		}
 	}
+	return json.Marshal(qRecordJsonMap)
+}
 
-	esBulkIndexer, err := esutil.NewBulkIndexer(esutil.BulkIndexerConfig{
-		Index:  config.DestinationTableIdentifier,
-		Client: esc.client,
-		// parallelism comes from the workflow design itself, no need for this
-		NumWorkers:    1,
-		FlushInterval: 10 * time.Second,
-	})
-	if err != nil {
-		esc.logger.Error("[es] failed to initialize bulk indexer", slog.Any("error", err))
-		return 0, nil, fmt.Errorf("[es] failed to initialize bulk indexer: %w", err)
-	}
-	defer func() {
-		if !bulkIndexerHasShutdown {
-			if err := esBulkIndexer.Close(context.Background()); err != nil {
-				esc.logger.Error("[es] failed to close bulk indexer", slog.Any("error", err))
-			}
-		}
-	}()
-
-	for qRecord := range stream.Records {
-		qRecordJsonMap := make(map[string]any)
-
-		switch len(upsertKeyColIndices) {
-		case 0:
-			// relying on autogeneration of document ID
-		case 1:
-			docId = fmt.Sprint(qRecord[upsertKeyColIndices[0]].Value())
-		default:
-			docId = upsertKeyColsHash(qRecord, upsertKeyColIndices)
-		}
-		for i, field := range schema.Fields {
-			if r, ok := qRecord[i].(types.QValueJSON); ok { // JSON is stored as a string, fix that
-				qRecordJsonMap[field.Name] = json.RawMessage(
-					shared.UnsafeFastStringToReadOnlyBytes(r.Val))
-			} else {
-				qRecordJsonMap[field.Name] = qRecord[i].Value()
-			}
-		}
-		qRecordJsonBytes, err := json.Marshal(qRecordJsonMap)
+func (esc *ElasticsearchConnector) SyncQRepRecords(ctx context.Context, config *protos.QRepConfig,
+	partition *protos.QRepPartition, stream *model.QRecordStream,
+) (int64, shared.QRepWarnings, error) {
+	// QRep mirrors don't set up normalized tables, create the index before the first bulk request does
+	if esc.explicitMappings {
+		schema, err := stream.Schema()
 		if err != nil {
-			esc.logger.Error("[es] failed to json.Marshal record", slog.Any("error", err))
-			return 0, nil, fmt.Errorf("[es] failed to json.Marshal record: %w", err)
-		}
-
-		if err := esBulkIndexer.Add(ctx, esutil.BulkIndexerItem{
-			Action:     actionIndex,
-			DocumentID: docId,
-			Body:       bytes.NewReader(qRecordJsonBytes),
-
-			// OnFailure is called for each failed operation, log and let parent handle
-			OnFailure: func(ctx context.Context, item esutil.BulkIndexerItem,
-				res esutil.BulkIndexerResponseItem, err error,
-			) {
-				bulkIndexOnFailureMutex.Lock()
-				defer bulkIndexOnFailureMutex.Unlock()
-				if err != nil {
-					bulkIndexErrors = append(bulkIndexErrors, err)
-				} else {
-					causeString := ""
-					if res.Error.Cause.Type != "" || res.Error.Cause.Reason != "" {
-						causeString = fmt.Sprintf("(caused by type:%s reason:%s)", res.Error.Cause.Type, res.Error.Cause.Reason)
-					}
-					cbErr := fmt.Errorf("id:%s type:%s reason:%s %s", item.DocumentID, res.Error.Type,
-						res.Error.Reason, causeString)
-					bulkIndexErrors = append(bulkIndexErrors, cbErr)
-					if res.Error.Type == "illegal_argument_exception" {
-						bulkIndexFatalError = cbErr
-					}
-				}
-			},
-		}); err != nil {
-			esc.logger.Error("[es] failed to add record to bulk indexer", slog.Any("error", err))
-			return 0, nil, fmt.Errorf("[es] failed to add record to bulk indexer: %w", err)
-		}
-		if bulkIndexFatalError != nil {
-			esc.logger.Error("[es] fatal error while indexing record", slog.Any("error", bulkIndexFatalError))
-			return 0, nil, fmt.Errorf("[es] fatal error while indexing record: %w", bulkIndexFatalError)
+			return 0, nil, err
 		}
-
-		// update here instead of OnSuccess, if we close successfully it should match
-		numRecords++
-	}
-
-	if err := stream.Err(); err != nil {
-		esc.logger.Error("[es] failed to get record from stream", slog.Any("error", err))
-		return 0, nil, fmt.Errorf("[es] failed to get record from stream: %w", err)
-	}
-	if err := esBulkIndexer.Close(ctx); err != nil {
-		esc.logger.Error("[es] failed to close bulk indexer", slog.Any("error", err))
-		return 0, nil, fmt.Errorf("[es] failed to close bulk indexer: %w", err)
-	}
-	bulkIndexerHasShutdown = true
-	if len(bulkIndexErrors) > 0 {
-		for _, err := range bulkIndexErrors {
-			esc.logger.Error("[es] failed to index record", slog.Any("err", err))
+		if _, err := esc.createIndexWithMappings(ctx, config.DestinationTableIdentifier, QRecordSchemaMappings(schema)); err != nil {
+			return 0, nil, err
 		}
 	}
-
-	if err := esc.FinishQRepPartition(ctx, partition, config.FlowJobName, startTime); err != nil {
-		esc.logger.Error("[es] failed to log partition info", slog.Any("error", err))
-		return 0, nil, fmt.Errorf("[es] failed to log partition info: %w", err)
-	}
-	return numRecords, nil, nil
+	return esc.bulkSyncer().SyncQRepRecords(ctx, config, partition, stream)
 }
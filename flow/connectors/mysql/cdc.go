@@ -141,7 +141,8 @@ func (c *MySqlConnector) EnsurePullability(
 }
 
 func (c *MySqlConnector) ExportTxSnapshot(context.Context, map[string]string) (*protos.ExportTxSnapshotOutput, any, error) {
-	// https://dev.mysql.com/doc/refman/8.4/en/replication-howto-masterstatus.html
+	// MySQL snapshots cannot be shared across connections,
+	// with consistent_snapshot each table is read in its own snapshot and CDC skips what it saw, see PullQRepRecords
 	return nil, nil, nil
 }
 
@@ -153,15 +154,9 @@ func (c *MySqlConnector) SetupReplication(
 	ctx context.Context,
 	req *protos.SetupReplicationInput,
 ) (model.SetupReplicationResult, error) {
-	var gtidModeOn bool
-	if c.config.ReplicationMechanism == protos.MySqlReplicationMechanism_MYSQL_AUTO {
-		var err error
-		gtidModeOn, err = c.GetGtidModeOn(ctx)
-		if err != nil {
-			return model.SetupReplicationResult{}, fmt.Errorf("[mysql] SetupReplication failed to get gtid_mode: %w", err)
-		}
-	} else {
-		gtidModeOn = c.config.ReplicationMechanism == protos.MySqlReplicationMechanism_MYSQL_GTID
+	gtidModeOn, err := c.replicatesByGtid(ctx)
+	if err != nil {
+		return model.SetupReplicationResult{}, fmt.Errorf("[mysql] SetupReplication failed to get gtid_mode: %w", err)
 	}
 	var lastOffsetText string
 	if gtidModeOn {
@@ -186,6 +181,14 @@ func (c *MySqlConnector) SetupReplication(
 	return model.SetupReplicationResult{}, nil
 }
 
+// replicatesByGtid is whether offsets are GTID sets rather than binlog file positions
+func (c *MySqlConnector) replicatesByGtid(ctx context.Context) (bool, error) {
+	if c.config.ReplicationMechanism == protos.MySqlReplicationMechanism_MYSQL_AUTO {
+		return c.GetGtidModeOn(ctx)
+	}
+	return c.config.ReplicationMechanism == protos.MySqlReplicationMechanism_MYSQL_GTID, nil
+}
+
 func (c *MySqlConnector) SetupReplConn(ctx context.Context) error {
 	// mysql code will spin up new connection for each normalize for now
 	return nil
@@ -244,16 +247,10 @@ func (c *MySqlConnector) startStreaming(
 	ctx context.Context,
 	pos string,
 ) (*replication.BinlogSyncer, *replication.BinlogStreamer, mysql.GTIDSet, mysql.Position, error) {
-	if rest, isFile := strings.CutPrefix(pos, "!f:"); isFile {
-		comma := strings.LastIndexByte(rest, ',')
-		if comma == -1 {
-			return nil, nil, nil, mysql.Position{}, fmt.Errorf("no comma in file/pos offset %s", pos)
-		}
-		offset, err := strconv.ParseUint(rest[comma+1:], 16, 32)
-		if err != nil {
-			return nil, nil, nil, mysql.Position{}, fmt.Errorf("invalid offset in file/pos offset %s: %w", pos, err)
-		}
-		return c.startCdcStreamingFilePos(ctx, mysql.Position{Name: rest[:comma], Pos: uint32(offset)})
+	if filePos, isFile, err := offsetTextToPos(pos); err != nil {
+		return nil, nil, nil, mysql.Position{}, err
+	} else if isFile {
+		return c.startCdcStreamingFilePos(ctx, filePos)
 	} else {
 		gset, err := mysql.ParseGTIDSet(c.Flavor(), pos)
 		if err != nil {
@@ -347,7 +344,14 @@ func (c *MySqlConnector) PullRecords(
 	}
 	defer syncer.Close()
 
+	snapshotOffsets, err := c.loadSnapshotOffsets(ctx, catalogPool, req.FlowJobName, gset, pos)
+	if err != nil {
+		return err
+	}
+
 	var skewLossReported bool
+	// only tracked while initial loads may have read changes still ahead in the binlog
+	var trxGtid mysql.GTIDSet
 	var updatedOffset string
 	var inTx bool
 	var recordCount uint32
@@ -432,6 +436,17 @@ func (c *MySqlConnector) PullRecords(
 				otelManager.Metrics.CommitLagGauge.Record(ctx,
 					time.Now().UTC().Sub(time.UnixMicro(int64(ev.ImmediateCommitTimestamp))).Microseconds())
 			}
+			if len(snapshotOffsets) > 0 && gset != nil {
+				if trxGtid, err = ev.GTIDNext(); err != nil {
+					return fmt.Errorf("failed to get gtid of transaction: %w", err)
+				}
+			}
+		case *replication.MariadbGTIDEvent:
+			if len(snapshotOffsets) > 0 && gset != nil {
+				if trxGtid, err = ev.GTIDNext(); err != nil {
+					return fmt.Errorf("failed to get gtid of transaction: %w", err)
+				}
+			}
 		case *replication.XIDEvent:
 			if gset != nil {
 				gset = ev.GSet
@@ -480,6 +495,12 @@ func (c *MySqlConnector) PullRecords(
 			destinationTableName := req.TableNameMapping[sourceTableName].Name
 			exclusion := req.TableNameMapping[sourceTableName].Exclude
 			schema := req.TableNameSchemaMapping[destinationTableName]
+			if offset, ok := snapshotOffsets[sourceTableName]; ok && schema != nil &&
+				offset.covers(trxGtid, mysql.Position{Name: pos.Name, Pos: event.Header.LogPos}) {
+				// initial load of the table already read this change
+				inTx = true
+				break
+			}
 			if schema != nil {
 				otelManager.Metrics.FetchedBytesCounter.Add(ctx, int64(len(event.RawData)))
 				inTx = true
@@ -730,3 +751,20 @@ func (c *MySqlConnector) processAlterTableQuery(ctx context.Context, catalogPool
 func posToOffsetText(pos mysql.Position) string {
 	return fmt.Sprintf("!f:%s,%x", pos.Name, pos.Pos)
 }
+
+// offsetTextToPos parses offsets written by posToOffsetText, reporting false for GTID set offsets
+func offsetTextToPos(text string) (mysql.Position, bool, error) {
+	rest, isFile := strings.CutPrefix(text, "!f:")
+	if !isFile {
+		return mysql.Position{}, false, nil
+	}
+	comma := strings.LastIndexByte(rest, ',')
+	if comma == -1 {
+		return mysql.Position{}, true, fmt.Errorf("no comma in file/pos offset %s", text)
+	}
+	offset, err := strconv.ParseUint(rest[comma+1:], 16, 32)
+	if err != nil {
+		return mysql.Position{}, true, fmt.Errorf("invalid offset in file/pos offset %s: %w", text, err)
+	}
+	return mysql.Position{Name: rest[:comma], Pos: uint32(offset)}, true, nil
+}
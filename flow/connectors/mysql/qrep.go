@@ -7,6 +7,7 @@ import (
 	"fmt"
 	"log/slog"
 	"strconv"
+	"strings"
 	"text/template"
 
 	"github.com/go-mysql-org/go-mysql/mysql"
@@ -14,11 +15,16 @@ import (
 
 	"github.com/PeerDB-io/peerdb/flow/connectors/utils"
 	"github.com/PeerDB-io/peerdb/flow/generated/protos"
+	"github.com/PeerDB-io/peerdb/flow/internal"
 	"github.com/PeerDB-io/peerdb/flow/model"
+	"github.com/PeerDB-io/peerdb/flow/shared"
 	shared_mysql "github.com/PeerDB-io/peerdb/flow/shared/mysql"
 	"github.com/PeerDB-io/peerdb/flow/shared/types"
 )
 
+// keys sampled per partition when splitting a table into keyset ranges
+const keysetSamplesPerPartition = 100
+
 func (c *MySqlConnector) GetDataTypeOfWatermarkColumn(
 	ctx context.Context,
 	watermarkTableName string,
@@ -62,6 +68,10 @@ func (c *MySqlConnector) GetQRepPartitions(
 		return nil, errors.New("num rows per partition must be greater than 0")
 	}
 
+	if len(config.KeysetColumns) > 0 {
+		return c.getKeysetPartitions(ctx, config)
+	}
+
 	var err error
 	numRowsPerPartition := int64(config.NumRowsPerPartition)
 	quotedWatermarkColumn := fmt.Sprintf("`%s`", config.WatermarkColumn)
@@ -282,7 +292,28 @@ func (c *MySqlConnector) PullQRepRecords(
 		return nil
 	}
 
-	if partition.FullTablePartition {
+	if config.ConsistentSnapshot {
+		if !partition.FullTablePartition {
+			return 0, 0, errors.New("consistent snapshot reads a table as a single partition")
+		}
+		readBytes, offsetText, err := c.executeSelectStreamingInSnapshot(ctx, config.Query, &rs, onRow, onResult)
+		if err != nil {
+			return 0, 0, err
+		}
+		totalBytes += readBytes
+
+		catalogPool, err := internal.GetCatalogConnectionPoolFromEnv(ctx)
+		if err != nil {
+			return 0, 0, err
+		}
+		if err := internal.SaveSnapshotTableOffset(
+			ctx, catalogPool, config.ParentMirrorName, config.WatermarkTable, offsetText,
+		); err != nil {
+			return 0, 0, err
+		}
+		c.logger.Info("[mysql] read table in consistent snapshot",
+			slog.String("table", config.WatermarkTable), slog.String("offset", offsetText))
+	} else if partition.FullTablePartition {
 		// this is a full table partition, so just run the query
 		readBytes, err := c.ExecuteSelectStreaming(ctx, config.Query, &rs, onRow, onResult)
 		if err != nil {
@@ -304,6 +335,18 @@ func (c *MySqlConnector) PullQRepRecords(
 		case *protos.PartitionRange_TimestampRange:
 			rangeStart = "'" + x.TimestampRange.Start.AsTime().Format("2006-01-02 15:04:05.999999") + "'"
 			rangeEnd = "'" + x.TimestampRange.End.AsTime().Format("2006-01-02 15:04:05.999999") + "'"
+		case *protos.PartitionRange_KeysetRange:
+			parsedWatermarkTable, err := utils.ParseSchemaTable(config.WatermarkTable)
+			if err != nil {
+				return 0, 0, fmt.Errorf("failed to parse watermark table %s: %w", config.WatermarkTable, err)
+			}
+			kinds, err := c.getKeysetColumnKinds(ctx, parsedWatermarkTable.MySQL(), config.KeysetColumns)
+			if err != nil {
+				return 0, 0, err
+			}
+			// left empty for open ended ranges, for queries like `WHERE TRUE{{if .start}} AND (a,b) > {{.start}}{{end}}`
+			rangeStart = keysetBound(kinds, x.KeysetRange.Start)
+			rangeEnd = keysetBound(kinds, x.KeysetRange.End)
 		default:
 			return 0, 0, fmt.Errorf("unknown range type: %v", x)
 		}
@@ -326,6 +369,112 @@ func (c *MySqlConnector) PullQRepRecords(
 	return totalRecords, totalBytes, nil
 }
 
+func (c *MySqlConnector) getKeysetColumnKinds(ctx context.Context, table string, columns []string) ([]types.QValueKind, error) {
+	quotedColumns := make([]string, 0, len(columns))
+	for _, col := range columns {
+		quotedColumns = append(quotedColumns, fmt.Sprintf("`%s`", col))
+	}
+	rs, err := c.Execute(ctx, fmt.Sprintf("SELECT %s FROM %s LIMIT 0", strings.Join(quotedColumns, ","), table))
+	if err != nil {
+		return nil, fmt.Errorf("failed to get types of keyset columns: %w", err)
+	}
+
+	kinds := make([]types.QValueKind, 0, len(rs.Fields))
+	for _, field := range rs.Fields {
+		qk, err := qkindFromMysql(field)
+		if err != nil {
+			return nil, err
+		}
+		kinds = append(kinds, qk)
+	}
+	return kinds, nil
+}
+
+// getKeysetPartitions splits the table at evenly spaced keys of a sample taken in key order.
+// Binary keys are sampled as hex, other keys as text
+func (c *MySqlConnector) getKeysetPartitions(ctx context.Context, config *protos.QRepConfig) ([]*protos.QRepPartition, error) {
+	parsedWatermarkTable, err := utils.ParseSchemaTable(config.WatermarkTable)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse watermark table %s: %w", config.WatermarkTable, err)
+	}
+	table := parsedWatermarkTable.MySQL()
+
+	rs, err := c.Execute(ctx, "SELECT COUNT(*) FROM "+table)
+	if err != nil {
+		return nil, err
+	}
+	totalRows, err := rs.GetInt(0, 0)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query for total rows: %w", err)
+	}
+	if totalRows == 0 {
+		c.logger.Warn("no records to replicate, returning")
+		return make([]*protos.QRepPartition, 0), nil
+	}
+
+	kinds, err := c.getKeysetColumnKinds(ctx, table, config.KeysetColumns)
+	if err != nil {
+		return nil, err
+	}
+	quotedColumns := make([]string, 0, len(config.KeysetColumns))
+	sampleColumns := make([]string, 0, len(config.KeysetColumns))
+	for i, col := range config.KeysetColumns {
+		quotedColumn := fmt.Sprintf("`%s`", col)
+		quotedColumns = append(quotedColumns, quotedColumn)
+		if kinds[i] == types.QValueKindBytes {
+			sampleColumns = append(sampleColumns, "HEX("+quotedColumn+")")
+		} else {
+			sampleColumns = append(sampleColumns, "CAST("+quotedColumn+" AS CHAR)")
+		}
+	}
+
+	numPartitions := shared.DivCeil(totalRows, int64(config.NumRowsPerPartition))
+	whereClause := ""
+	if sampleRows := numPartitions * keysetSamplesPerPartition; sampleRows < totalRows {
+		whereClause = " WHERE RAND() < " + strconv.FormatFloat(float64(sampleRows)/float64(totalRows), 'f', -1, 64)
+	}
+	samplesQuery := fmt.Sprintf("SELECT %s FROM %s%s ORDER BY %s",
+		strings.Join(sampleColumns, ","), table, whereClause, strings.Join(quotedColumns, ","))
+	c.logger.Info("keyset samples query", slog.String("query", samplesQuery),
+		slog.Int64("totalRows", totalRows), slog.Int64("numPartitions", numPartitions))
+
+	rs, err = c.Execute(ctx, samplesQuery)
+	if err != nil {
+		return nil, fmt.Errorf("failed to sample keys: %w", err)
+	}
+	samples := make([][]string, 0, len(rs.Values))
+	for _, row := range rs.Values {
+		sample := make([]string, 0, len(row))
+		for _, val := range row {
+			sample = append(sample, string(val.AsString()))
+		}
+		samples = append(samples, sample)
+	}
+
+	return utils.KeysetPartitions(samples, numPartitions), nil
+}
+
+// keysetBound renders sampled key values as a row of literals, compared in the order and collation of their columns
+func keysetBound(kinds []types.QValueKind, values []string) string {
+	if len(values) == 0 {
+		return ""
+	}
+	literals := make([]string, 0, len(values))
+	for i, value := range values {
+		switch kinds[i] {
+		case types.QValueKindBytes:
+			literals = append(literals, "X'"+value+"'")
+		case types.QValueKindInt8, types.QValueKindInt16, types.QValueKindInt32, types.QValueKindInt64,
+			types.QValueKindUInt8, types.QValueKindUInt16, types.QValueKindUInt32, types.QValueKindUInt64,
+			types.QValueKindFloat32, types.QValueKindFloat64, types.QValueKindNumeric:
+			literals = append(literals, value)
+		default:
+			literals = append(literals, "'"+mysql.Escape(value)+"'")
+		}
+	}
+	return "(" + strings.Join(literals, ",") + ")"
+}
+
 func BuildQuery(logger log.Logger, query string, start string, end string) (string, error) {
 	tmpl, err := template.New("query").Parse(query)
 	if err != nil {
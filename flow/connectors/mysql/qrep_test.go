@@ -0,0 +1,15 @@
+package connmysql
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/PeerDB-io/peerdb/flow/shared/types"
+)
+
+func TestKeysetBound(t *testing.T) {
+	kinds := []types.QValueKind{types.QValueKindString, types.QValueKindInt64, types.QValueKindBytes}
+	require.Equal(t, `('it\'s',42,X'0A1B')`, keysetBound(kinds, []string{"it's", "42", "0A1B"}))
+	require.Empty(t, keysetBound(kinds, nil))
+}
@@ -0,0 +1,264 @@
+package connmysql
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strconv"
+	"time"
+
+	"github.com/go-mysql-org/go-mysql/client"
+	"github.com/go-mysql-org/go-mysql/mysql"
+
+	"github.com/PeerDB-io/peerdb/flow/internal"
+	"github.com/PeerDB-io/peerdb/flow/shared"
+)
+
+const consistentSnapshotAttempts = 50
+
+// executeSelectStreamingInSnapshot streams cmd from a consistent snapshot,
+// returning the offset that the snapshot saw every change up to and none after
+func (c *MySqlConnector) executeSelectStreamingInSnapshot(ctx context.Context, cmd string, result *mysql.Result,
+	rowCb client.SelectPerRowCallback,
+	resultCb client.SelectPerResultCallback,
+) (int64, string, error) {
+	gtidModeOn, err := c.replicatesByGtid(ctx)
+	if err != nil {
+		return 0, "", fmt.Errorf("failed to get gtid_mode: %w", err)
+	}
+
+	var connectionErr error
+	for conn, err := range c.withRetries(ctx) {
+		if err != nil {
+			return 0, "", err
+		}
+		mc := conn.Conn.Conn.(*MeteredConn)
+		bytesReadAtStart := mc.BytesRead.Load()
+
+		offsetText, err := c.beginConsistentSnapshot(ctx, conn, gtidModeOn)
+		if err == nil {
+			err = conn.ExecuteSelectStreaming(cmd, result, rowCb, resultCb)
+			if _, rollbackErr := conn.Execute("ROLLBACK"); rollbackErr != nil && err == nil {
+				// rows were all read, only drop the connection so the snapshot does not outlive the read
+				c.logger.Warn("[mysql] failed to end consistent snapshot", slog.Any("error", rollbackErr))
+				c.conn.CompareAndSwap(conn, nil)
+				conn.Close()
+			}
+		}
+		if err != nil {
+			if mysql.ErrorEqual(err, mysql.ErrBadConn) {
+				connectionErr = err
+				continue
+			}
+			return 0, "", err
+		}
+		return mc.BytesRead.Load() - bytesReadAtStart, offsetText, nil
+	}
+	return 0, "", connectionErr
+}
+
+// beginConsistentSnapshot starts a read only transaction on conn without taking global locks,
+// retrying while commits in flight leave its binlog position ambiguous
+func (c *MySqlConnector) beginConsistentSnapshot(ctx context.Context, conn *client.Conn, gtidModeOn bool) (string, error) {
+	if _, err := conn.Execute("SET SESSION TRANSACTION ISOLATION LEVEL REPEATABLE READ"); err != nil {
+		return "", fmt.Errorf("failed to set isolation level: %w", err)
+	}
+	for attempt := range consistentSnapshotAttempts {
+		var executedBefore string
+		if gtidModeOn && c.Flavor() == mysql.MySQLFlavor {
+			rs, err := conn.Execute("SELECT @@GLOBAL.gtid_executed")
+			if err != nil {
+				return "", fmt.Errorf("failed to read gtid_executed: %w", err)
+			}
+			if executedBefore, err = rs.GetString(0, 0); err != nil {
+				return "", fmt.Errorf("failed to read gtid_executed: %w", err)
+			}
+		}
+		if _, err := conn.Execute("START TRANSACTION WITH CONSISTENT SNAPSHOT, READ ONLY"); err != nil {
+			return "", fmt.Errorf("failed to start consistent snapshot: %w", err)
+		}
+		offsetText, err := c.consistentSnapshotOffset(conn, gtidModeOn, executedBefore)
+		if err == nil && offsetText != "" {
+			return offsetText, nil
+		}
+		if _, rollbackErr := conn.Execute("ROLLBACK"); rollbackErr != nil {
+			return "", errors.Join(err, rollbackErr)
+		} else if err != nil {
+			return "", err
+		}
+
+		c.logger.Info("[mysql] commits in flight while starting consistent snapshot, retrying", slog.Int("attempt", attempt))
+		select {
+		case <-ctx.Done():
+			return "", ctx.Err()
+		case <-time.After(time.Duration(attempt+1) * 10 * time.Millisecond):
+		}
+	}
+	return "", errors.New("could not start a consistent snapshot at a known binlog position, source is too busy")
+}
+
+// consistentSnapshotOffset returns the offset of the snapshot just started on conn, or "" if it cannot be determined exactly yet.
+// executedBefore is gtid_executed read right before the snapshot started
+func (c *MySqlConnector) consistentSnapshotOffset(conn *client.Conn, gtidModeOn bool, executedBefore string) (string, error) {
+	rs, err := conn.Execute("SHOW STATUS LIKE 'binlog_snapshot_%'")
+	if err != nil {
+		return "", fmt.Errorf("failed to show binlog snapshot status: %w", err)
+	}
+	status := make(map[string]string, rs.RowNumber())
+	for idx := range rs.RowNumber() {
+		name, err := rs.GetString(idx, 0)
+		if err != nil {
+			return "", err
+		}
+		value, err := rs.GetString(idx, 1)
+		if err != nil {
+			return "", err
+		}
+		status[name] = value
+	}
+
+	// MariaDB and Percona Server report where in the binlog the current snapshot was taken
+	if file := status["binlog_snapshot_file"]; file != "" {
+		pos, err := strconv.ParseUint(status["binlog_snapshot_position"], 10, 32)
+		if err != nil {
+			return "", fmt.Errorf("invalid binlog_snapshot_position: %w", err)
+		}
+		if !gtidModeOn {
+			return posToOffsetText(mysql.Position{Name: file, Pos: uint32(pos)}), nil
+		}
+		if executed := status["binlog_snapshot_gtid_executed"]; executed != "" {
+			return c.normalizeGtidSet(executed)
+		}
+		if c.Flavor() == mysql.MariaDBFlavor {
+			rs, err := conn.Execute("SELECT BINLOG_GTID_POS(?, ?)", file, pos)
+			if err != nil {
+				return "", fmt.Errorf("failed to get gtid position of binlog snapshot: %w", err)
+			}
+			gtidPos, err := rs.GetString(0, 0)
+			if err != nil {
+				return "", fmt.Errorf("failed to get gtid position of binlog snapshot: %w", err)
+			} else if gtidPos == "" {
+				return "", fmt.Errorf("no gtid position for binlog snapshot at %s:%d", file, pos)
+			}
+			return c.normalizeGtidSet(gtidPos)
+		}
+	}
+	if !gtidModeOn || c.Flavor() != mysql.MySQLFlavor {
+		return "", errors.New("consistent snapshot needs gtid_mode, or a server reporting binlog_snapshot_file like MariaDB or Percona Server")
+	}
+
+	// transactions are visible once committed in the storage engine, before they are added to gtid_executed,
+	// and stay in gtid_owned until then. With nothing owned after the snapshot started,
+	// and gtid_executed unchanged since before, the snapshot saw exactly gtid_executed
+	rs, err = conn.Execute("SELECT @@GLOBAL.gtid_owned")
+	if err != nil {
+		return "", fmt.Errorf("failed to read gtid_owned: %w", err)
+	}
+	owned, err := rs.GetString(0, 0)
+	if err != nil {
+		return "", fmt.Errorf("failed to read gtid_owned: %w", err)
+	}
+	rs, err = conn.Execute("SELECT @@GLOBAL.gtid_executed")
+	if err != nil {
+		return "", fmt.Errorf("failed to read gtid_executed: %w", err)
+	}
+	executedAfter, err := rs.GetString(0, 0)
+	if err != nil {
+		return "", fmt.Errorf("failed to read gtid_executed: %w", err)
+	}
+	if owned != "" || executedAfter != executedBefore {
+		return "", nil
+	}
+	return c.normalizeGtidSet(executedBefore)
+}
+
+func (c *MySqlConnector) normalizeGtidSet(gtidSet string) (string, error) {
+	gset, err := mysql.ParseGTIDSet(c.Flavor(), gtidSet)
+	if err != nil {
+		return "", fmt.Errorf("failed to parse gtid set %s: %w", gtidSet, err)
+	}
+	return gset.String(), nil
+}
+
+// snapshotOffset is where a table's initial load was read, changes to the table up to it are already loaded
+type snapshotOffset struct {
+	gset mysql.GTIDSet
+	pos  mysql.Position
+}
+
+// covers is whether a change made by transaction trxGtid, or ending at rowPos when replicating by file position, was loaded
+func (o snapshotOffset) covers(trxGtid mysql.GTIDSet, rowPos mysql.Position) bool {
+	if o.gset != nil {
+		return trxGtid != nil && gtidSetCovers(o.gset, trxGtid)
+	}
+	return rowPos.Compare(o.pos) <= 0
+}
+
+// loadSnapshotOffsets returns offsets recorded by consistent initial loads which CDC starting at gset or pos has not passed yet
+func (c *MySqlConnector) loadSnapshotOffsets(
+	ctx context.Context,
+	catalogPool shared.CatalogPool,
+	flowJobName string,
+	gset mysql.GTIDSet,
+	pos mysql.Position,
+) (map[string]snapshotOffset, error) {
+	offsetTexts, err := internal.LoadSnapshotTableOffsets(ctx, catalogPool, flowJobName)
+	if err != nil {
+		return nil, err
+	}
+	offsets := make(map[string]snapshotOffset, len(offsetTexts))
+	for table, offsetText := range offsetTexts {
+		filePos, isFile, err := offsetTextToPos(offsetText)
+		if err != nil {
+			return nil, err
+		}
+		if isFile != (gset == nil) {
+			c.logger.Warn("[mysql] ignoring initial load offset of a different replication mechanism",
+				slog.String("table", table), slog.String("offset", offsetText))
+			continue
+		}
+		if isFile {
+			if filePos.Compare(pos) > 0 {
+				offsets[table] = snapshotOffset{pos: filePos}
+			}
+		} else {
+			offsetGset, err := mysql.ParseGTIDSet(c.Flavor(), offsetText)
+			if err != nil {
+				return nil, fmt.Errorf("failed to parse initial load offset of %s: %w", table, err)
+			}
+			if !gtidSetCovers(gset, offsetGset) {
+				offsets[table] = snapshotOffset{gset: offsetGset}
+			}
+		}
+	}
+	return offsets, nil
+}
+
+// gtidSetCovers is whether every transaction of other is in set.
+// MariaDB positions keep the last sequence number of each domain, so are compared regardless of server id
+func gtidSetCovers(set mysql.GTIDSet, other mysql.GTIDSet) bool {
+	mariaSet, ok := set.(*mysql.MariadbGTIDSet)
+	if !ok {
+		return set.Contain(other)
+	}
+	mariaOther, ok := other.(*mysql.MariadbGTIDSet)
+	if !ok {
+		return false
+	}
+	for domainID, otherServers := range mariaOther.Sets {
+		for _, otherGtid := range otherServers {
+			covered := false
+			for _, gtid := range mariaSet.Sets[domainID] {
+				if gtid.SequenceNumber >= otherGtid.SequenceNumber {
+					covered = true
+					break
+				}
+			}
+			if !covered {
+				return false
+			}
+		}
+	}
+	return true
+}
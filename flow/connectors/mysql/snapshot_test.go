@@ -0,0 +1,51 @@
+package connmysql
+
+import (
+	"testing"
+
+	"github.com/go-mysql-org/go-mysql/mysql"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSnapshotOffsetCoversGtid(t *testing.T) {
+	gset, err := mysql.ParseMysqlGTIDSet("3e11fa47-71ca-11e1-9e33-c80aa9429562:1-100")
+	require.NoError(t, err)
+	offset := snapshotOffset{gset: gset}
+
+	loaded, err := mysql.ParseMysqlGTIDSet("3e11fa47-71ca-11e1-9e33-c80aa9429562:100")
+	require.NoError(t, err)
+	require.True(t, offset.covers(loaded, mysql.Position{}))
+	notLoaded, err := mysql.ParseMysqlGTIDSet("3e11fa47-71ca-11e1-9e33-c80aa9429562:101")
+	require.NoError(t, err)
+	require.False(t, offset.covers(notLoaded, mysql.Position{}))
+	require.False(t, offset.covers(nil, mysql.Position{}))
+}
+
+func TestSnapshotOffsetCoversMariadbGtid(t *testing.T) {
+	gset, err := mysql.ParseMariadbGTIDSet("0-1-100,1-2-5")
+	require.NoError(t, err)
+	offset := snapshotOffset{gset: gset}
+
+	// sequence numbers are ordered within a domain whichever server wrote them
+	loaded, err := mysql.ParseMariadbGTIDSet("0-3-99")
+	require.NoError(t, err)
+	require.True(t, offset.covers(loaded, mysql.Position{}))
+	notLoaded, err := mysql.ParseMariadbGTIDSet("1-2-6")
+	require.NoError(t, err)
+	require.False(t, offset.covers(notLoaded, mysql.Position{}))
+	otherDomain, err := mysql.ParseMariadbGTIDSet("2-1-1")
+	require.NoError(t, err)
+	require.False(t, offset.covers(otherDomain, mysql.Position{}))
+}
+
+func TestSnapshotOffsetCoversFilePos(t *testing.T) {
+	filePos, isFile, err := offsetTextToPos(posToOffsetText(mysql.Position{Name: "binlog.000002", Pos: 0x400}))
+	require.NoError(t, err)
+	require.True(t, isFile)
+	offset := snapshotOffset{pos: filePos}
+
+	require.True(t, offset.covers(nil, mysql.Position{Name: "binlog.000001", Pos: 0x800}))
+	require.True(t, offset.covers(nil, mysql.Position{Name: "binlog.000002", Pos: 0x400}))
+	require.False(t, offset.covers(nil, mysql.Position{Name: "binlog.000002", Pos: 0x401}))
+	require.False(t, offset.covers(nil, mysql.Position{Name: "binlog.000003", Pos: 4}))
+}
@@ -0,0 +1,423 @@
+package connnats
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/nats-io/nats.go"
+	"github.com/nats-io/nats.go/jetstream"
+	"github.com/nats-io/nkeys"
+	lua "github.com/yuin/gopher-lua"
+	"go.temporal.io/sdk/log"
+
+	metadataStore "github.com/PeerDB-io/peerdb/flow/connectors/external_metadata"
+	"github.com/PeerDB-io/peerdb/flow/connectors/utils"
+	"github.com/PeerDB-io/peerdb/flow/generated/protos"
+	"github.com/PeerDB-io/peerdb/flow/internal"
+	"github.com/PeerDB-io/peerdb/flow/model"
+	"github.com/PeerDB-io/peerdb/flow/pua"
+	"github.com/PeerDB-io/peerdb/flow/shared"
+)
+
+type NatsConnector struct {
+	*metadataStore.PostgresMetadata
+	conn           *nats.Conn
+	js             jetstream.JetStream
+	logger         log.Logger
+	streams        sync.Map
+	streamReplicas int
+}
+
+func NewNatsConnector(
+	ctx context.Context,
+	config *protos.NatsConfig,
+) (*NatsConnector, error) {
+	opts := []nats.Option{nats.Name("peerdb")}
+	if config.Credentials != nil {
+		opts = append(opts, nats.UserCredentialBytes(shared.UnsafeFastStringToReadOnlyBytes(*config.Credentials)))
+	} else if config.NkeySeed != nil {
+		kp, err := nkeys.FromSeed(shared.UnsafeFastStringToReadOnlyBytes(*config.NkeySeed))
+		if err != nil {
+			return nil, fmt.Errorf("invalid nkey seed: %w", err)
+		}
+		publicKey, err := kp.PublicKey()
+		if err != nil {
+			return nil, fmt.Errorf("invalid nkey seed: %w", err)
+		}
+		opts = append(opts, nats.Nkey(publicKey, kp.Sign))
+	}
+	if config.Username != nil {
+		opts = append(opts, nats.UserInfo(*config.Username, config.GetPassword()))
+	}
+	if !config.DisableTls {
+		tlsConfig, err := shared.CreateTlsConfig(tls.VersionTLS12, config.RootCa, "", config.TlsHost, false)
+		if err != nil {
+			return nil, err
+		}
+		opts = append(opts, nats.Secure(tlsConfig))
+	}
+
+	conn, err := nats.Connect(strings.Join(config.Servers, ","), opts...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to nats: %w", err)
+	}
+	js, err := jetstream.New(conn)
+	if err != nil {
+		conn.Close()
+		return nil, fmt.Errorf("failed to create jetstream context: %w", err)
+	}
+
+	pgMetadata, err := metadataStore.NewPostgresMetadata(ctx)
+	if err != nil {
+		conn.Close()
+		return nil, err
+	}
+
+	streamReplicas := 1
+	if config.StreamReplicas > 0 {
+		streamReplicas = int(config.StreamReplicas)
+	}
+
+	return &NatsConnector{
+		PostgresMetadata: pgMetadata,
+		conn:             conn,
+		js:               js,
+		logger:           internal.LoggerFromCtx(ctx),
+		streamReplicas:   streamReplicas,
+	}, nil
+}
+
+func (c *NatsConnector) Close() error {
+	if c != nil {
+		c.conn.Close()
+	}
+	return nil
+}
+
+func (c *NatsConnector) ConnectionActive(ctx context.Context) error {
+	if _, err := c.js.AccountInfo(ctx); err != nil {
+		return fmt.Errorf("failed to get jetstream account info: %w", err)
+	}
+	return nil
+}
+
+func (c *NatsConnector) CreateRawTable(ctx context.Context, req *protos.CreateRawTableInput) (*protos.CreateRawTableOutput, error) {
+	return &protos.CreateRawTableOutput{TableIdentifier: "n/a"}, nil
+}
+
+func (c *NatsConnector) ReplayTableSchemaDeltas(_ context.Context, _ map[string]string,
+	flowJobName string, schemaDeltas []*protos.TableSchemaDelta,
+) error {
+	return nil
+}
+
+// natsMessage is a message returned by onRecord, id overrides the Nats-Msg-Id derived from the record
+type natsMessage struct {
+	*nats.Msg
+	id string
+}
+
+func lvalueToNatsMessage(ls *lua.LState, value lua.LValue) (natsMessage, error) {
+	switch v := value.(type) {
+	case lua.LString:
+		return natsMessage{Msg: &nats.Msg{Data: shared.UnsafeFastStringToReadOnlyBytes(string(v))}}, nil
+	case *lua.LTable:
+		value, err := utils.LVAsReadOnlyBytes(ls, ls.GetField(v, "value"))
+		if err != nil {
+			return natsMessage{}, fmt.Errorf("invalid value, %w", err)
+		}
+		subject, err := utils.LVAsStringOrNil(ls, ls.GetField(v, "subject"))
+		if err != nil {
+			return natsMessage{}, fmt.Errorf("invalid subject, %w", err)
+		}
+		id, err := utils.LVAsStringOrNil(ls, ls.GetField(v, "id"))
+		if err != nil {
+			return natsMessage{}, fmt.Errorf("invalid id, %w", err)
+		}
+		msg := &nats.Msg{
+			Subject: subject,
+			Data:    value,
+		}
+		lheaders := ls.GetField(v, "headers")
+		if headers, ok := lheaders.(*lua.LTable); ok {
+			msg.Header = make(nats.Header)
+			headers.ForEach(func(k, v lua.LValue) {
+				msg.Header.Add(k.String(), v.String())
+			})
+		} else if lua.LVAsBool(lheaders) {
+			return natsMessage{}, fmt.Errorf("invalid headers, must be nil or table: %s", lheaders)
+		}
+		return natsMessage{Msg: msg, id: id}, nil
+	case *lua.LNilType:
+		return natsMessage{}, nil
+	default:
+		return natsMessage{}, fmt.Errorf("script returned invalid value: %s", value)
+	}
+}
+
+// streamName derives the name of the stream created for a subject,
+// stream names can't contain the separators and wildcards subjects are made of
+func streamName(subject string) string {
+	return strings.Map(func(r rune) rune {
+		if (r >= 'a' && r <= 'z') || (r >= 'A' && r <= 'Z') || (r >= '0' && r <= '9') || r == '-' || r == '_' {
+			return r
+		}
+		return '_'
+	}, subject)
+}
+
+// ensureStream creates a stream for subject unless some stream already captures it
+func (c *NatsConnector) ensureStream(ctx context.Context, subject string) error {
+	if _, ok := c.streams.Load(subject); ok {
+		return nil
+	}
+	if _, err := c.js.StreamNameBySubject(ctx, subject); err != nil {
+		if !errors.Is(err, jetstream.ErrStreamNotFound) {
+			return fmt.Errorf("failed to look up stream for subject %s: %w", subject, err)
+		}
+		name := streamName(subject)
+		c.logger.Info("[nats] creating stream", slog.String("stream", name), slog.String("subject", subject))
+		if _, err := c.js.CreateStream(ctx, jetstream.StreamConfig{
+			Name:     name,
+			Subjects: []string{subject},
+			Replicas: c.streamReplicas,
+		}); err != nil && !errors.Is(err, jetstream.ErrStreamNameAlreadyInUse) {
+			return fmt.Errorf("failed to create stream %s: %w", name, err)
+		}
+	}
+	c.streams.Store(subject, struct{}{})
+	return nil
+}
+
+type poolResult struct {
+	messages []natsMessage
+	lsn      int64
+}
+
+type publishResult struct {
+	jetstream.PubAckFuture
+	lsn int64
+}
+
+func (c *NatsConnector) createPool(
+	ctx context.Context,
+	env map[string]string,
+	script string,
+	flowJobName string,
+	publish chan<- publishResult,
+	queueErr func(error),
+) (*utils.LPool[poolResult], error) {
+	maxSize, err := internal.PeerDBQueueParallelism(ctx, env)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get parallelism: %w", err)
+	}
+
+	return utils.LuaPool(int(maxSize), func() (*lua.LState, error) {
+		ls, err := utils.LoadScript(ctx, script, utils.LuaPrintFn(func(s string) {
+			_ = c.LogFlowInfo(ctx, flowJobName, s)
+		}))
+		if err != nil {
+			return nil, fmt.Errorf("[nats] error loading script: %w", err)
+		}
+		if script == "" {
+			ls.Env.RawSetString("onRecord", ls.NewFunction(utils.DefaultOnRecord))
+		}
+		return ls, nil
+	}, func(result poolResult) {
+		for _, message := range result.messages {
+			if err := c.ensureStream(ctx, message.Subject); err != nil {
+				queueErr(fmt.Errorf("[nats] %w", err))
+				return
+			}
+			var opts []jetstream.PublishOpt
+			if message.id != "" {
+				opts = append(opts, jetstream.WithMsgID(message.id))
+			}
+			future, err := c.js.PublishMsgAsync(message.Msg, opts...)
+			if err != nil {
+				queueErr(fmt.Errorf("[nats] error publishing message: %w", err))
+				return
+			}
+			publish <- publishResult{PubAckFuture: future}
+		}
+		publish <- publishResult{lsn: result.lsn}
+	})
+}
+
+// waitForAcks consumes publish until it is closed, updating lastSeenLSN as records before an LSN marker are acked
+func (c *NatsConnector) waitForAcks(
+	ctx context.Context,
+	publish <-chan publishResult,
+	lastSeenLSN *atomic.Int64,
+	queueErr func(error),
+) <-chan struct{} {
+	waitChan := make(chan struct{})
+	go func() {
+		defer close(waitChan)
+		for curpub := range publish {
+			if curpub.PubAckFuture == nil {
+				if lastSeenLSN != nil {
+					shared.AtomicInt64Max(lastSeenLSN, curpub.lsn)
+				}
+				continue
+			}
+			select {
+			case <-curpub.Ok():
+			case err := <-curpub.Err():
+				queueErr(fmt.Errorf("[nats] error publishing message: %w", err))
+				return
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+	return waitChan
+}
+
+// messageID derives Nats-Msg-Id from where a message comes from, so messages republished by a retried batch
+// are dropped by the stream's duplicate window; records without a checkpoint can't be identified this way
+func messageID(flowJobName string, checkpointID int64, index int) string {
+	if checkpointID <= 0 {
+		return ""
+	}
+	return fmt.Sprintf("%s:%d:%d", flowJobName, checkpointID, index)
+}
+
+func (c *NatsConnector) SyncRecords(ctx context.Context, req *model.SyncRecordsRequest[model.RecordItems]) (*model.SyncResponse, error) {
+	numRecords := atomic.Int64{}
+	lastSeenLSN := atomic.Int64{}
+	tableNameRowsMapping := utils.InitialiseTableRowsMap(req.TableMappings)
+	publish := make(chan publishResult, 32)
+
+	queueCtx, queueErr := context.WithCancelCause(ctx)
+
+	pool, err := c.createPool(queueCtx, req.Env, req.Script, req.FlowJobName, publish, queueErr)
+	if err != nil {
+		return nil, err
+	}
+	defer pool.Close()
+
+	waitChan := c.waitForAcks(queueCtx, publish, &lastSeenLSN, queueErr)
+
+	flushLoopDone := make(chan struct{})
+	go func() {
+		flushTimeout, err := internal.PeerDBQueueFlushTimeoutSeconds(ctx, req.Env)
+		if err != nil {
+			c.logger.Warn("[nats] failed to get flush timeout, no periodic flushing", slog.Any("error", err))
+			return
+		}
+		ticker := time.NewTicker(flushTimeout)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-flushLoopDone:
+				return
+			// flush loop doesn't block processing new messages
+			case <-ticker.C:
+				lastSeen := lastSeenLSN.Load()
+				if lastSeen > req.ConsumedOffset.Load() {
+					if err := c.SetLastOffset(ctx, req.FlowJobName, model.CdcCheckpoint{ID: lastSeen}); err != nil {
+						c.logger.Warn("[nats] SetLastOffset error", slog.Any("error", err))
+					} else {
+						shared.AtomicInt64Max(req.ConsumedOffset, lastSeen)
+						c.logger.Info("processBatch", slog.Int64("updated last offset", lastSeen))
+					}
+				}
+			}
+		}
+	}()
+
+Loop:
+	for {
+		select {
+		case record, ok := <-req.Records.GetRecords():
+			if !ok {
+				c.logger.Info("flushing batches because no more records")
+				break Loop
+			}
+
+			pool.Run(func(ls *lua.LState) poolResult {
+				lfn := ls.Env.RawGetString("onRecord")
+				fn, ok := lfn.(*lua.LFunction)
+				if !ok {
+					queueErr(fmt.Errorf("script should define `onRecord` as function, not %s", lfn))
+					return poolResult{}
+				}
+
+				ls.Push(fn)
+				ls.Push(pua.LuaRecord.New(ls, record))
+				err := ls.PCall(1, -1, nil)
+				if err != nil {
+					queueErr(fmt.Errorf("script failed: %w", err))
+					return poolResult{}
+				}
+
+				args := ls.GetTop()
+				results := make([]natsMessage, 0, args)
+				for i := range args {
+					msg, err := lvalueToNatsMessage(ls, ls.Get(i-args))
+					if err != nil {
+						queueErr(fmt.Errorf("[nats] error creating message: %w", err))
+						return poolResult{}
+					}
+					if msg.Msg != nil {
+						if msg.Subject == "" {
+							msg.Subject = record.GetDestinationTableName()
+						}
+						if msg.id == "" {
+							msg.id = messageID(req.FlowJobName, record.GetCheckpointID(), i)
+						}
+						results = append(results, msg)
+						record.PopulateCountMap(tableNameRowsMapping)
+					}
+				}
+				ls.SetTop(0)
+				numRecords.Add(1)
+				return poolResult{
+					messages: results,
+					lsn:      record.GetCheckpointID(),
+				}
+			})
+
+		case <-queueCtx.Done():
+			break Loop
+		}
+	}
+
+	close(flushLoopDone)
+	if err := pool.Wait(queueCtx); err != nil {
+		return nil, fmt.Errorf("[nats] pool.Wait error: %w", err)
+	}
+	close(publish)
+	select {
+	case <-queueCtx.Done():
+		return nil, fmt.Errorf("[nats] queueCtx.Done: %w", context.Cause(queueCtx))
+	case <-waitChan:
+	}
+	if err := context.Cause(queueCtx); err != nil {
+		return nil, err
+	}
+
+	lastCheckpoint := req.Records.GetLastCheckpoint()
+	if err := c.FinishBatch(ctx, req.FlowJobName, req.SyncBatchID, lastCheckpoint); err != nil {
+		return nil, fmt.Errorf("[nats] FinishBatch error: %w", err)
+	}
+
+	return &model.SyncResponse{
+		CurrentSyncBatchID:   req.SyncBatchID,
+		LastSyncedCheckpoint: lastCheckpoint,
+		NumRecordsSynced:     numRecords.Load(),
+		TableNameRowsMapping: tableNameRowsMapping,
+		TableSchemaDeltas:    req.Records.SchemaDeltas,
+	}, nil
+}
@@ -0,0 +1,51 @@
+package connnats
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	lua "github.com/yuin/gopher-lua"
+)
+
+func TestStreamName(t *testing.T) {
+	t.Parallel()
+
+	require.Equal(t, "public_users", streamName("public.users"))
+	require.Equal(t, "orders_eu-west_created", streamName("orders.eu-west.created"))
+	require.Equal(t, "events____", streamName("events.*.>"))
+}
+
+func TestMessageID(t *testing.T) {
+	t.Parallel()
+
+	require.Equal(t, "mirror:1234:0", messageID("mirror", 1234, 0))
+	require.Empty(t, messageID("mirror", 0, 0))
+}
+
+func TestLvalueToNatsMessage(t *testing.T) {
+	t.Parallel()
+
+	ls := lua.NewState()
+	defer ls.Close()
+	require.NoError(t, ls.DoString(`
+return { subject = "users.updated", value = "payload", id = "custom", headers = { source = "peerdb" } }, "raw", nil`))
+
+	msg, err := lvalueToNatsMessage(ls, ls.Get(-3))
+	require.NoError(t, err)
+	require.Equal(t, "users.updated", msg.Subject)
+	require.Equal(t, []byte("payload"), msg.Data)
+	require.Equal(t, "custom", msg.id)
+	require.Equal(t, "peerdb", msg.Header.Get("source"))
+
+	msg, err = lvalueToNatsMessage(ls, ls.Get(-2))
+	require.NoError(t, err)
+	require.Empty(t, msg.Subject)
+	require.Equal(t, []byte("raw"), msg.Data)
+
+	msg, err = lvalueToNatsMessage(ls, ls.Get(-1))
+	require.NoError(t, err)
+	require.Nil(t, msg.Msg)
+
+	_, err = lvalueToNatsMessage(ls, lua.LNumber(1))
+	require.Error(t, err)
+}
@@ -0,0 +1,133 @@
+package connnats
+
+import (
+	"context"
+	"fmt"
+	"sync/atomic"
+	"time"
+
+	lua "github.com/yuin/gopher-lua"
+
+	"github.com/PeerDB-io/peerdb/flow/generated/protos"
+	"github.com/PeerDB-io/peerdb/flow/model"
+	"github.com/PeerDB-io/peerdb/flow/pua"
+	"github.com/PeerDB-io/peerdb/flow/shared"
+)
+
+func (*NatsConnector) SetupQRepMetadataTables(_ context.Context, _ *protos.QRepConfig) error {
+	return nil
+}
+
+func (c *NatsConnector) SyncQRepRecords(
+	ctx context.Context,
+	config *protos.QRepConfig,
+	partition *protos.QRepPartition,
+	stream *model.QRecordStream,
+) (int64, shared.QRepWarnings, error) {
+	startTime := time.Now()
+	schema, err := stream.Schema()
+	if err != nil {
+		return 0, nil, err
+	}
+	publish := make(chan publishResult, 32)
+	numRecords := atomic.Int64{}
+
+	queueCtx, queueErr := context.WithCancelCause(ctx)
+	pool, err := c.createPool(queueCtx, config.Env, config.Script, config.FlowJobName, publish, queueErr)
+	if err != nil {
+		return 0, nil, err
+	}
+	defer pool.Close()
+
+	waitChan := c.waitForAcks(queueCtx, publish, nil, queueErr)
+
+	// partitions are retried as a whole, so a row's position in its partition identifies its messages
+	var rowIndex int64
+Loop:
+	for {
+		select {
+		case qrecord, ok := <-stream.Records:
+			if !ok {
+				c.logger.Info("flushing batches because no more records")
+				break Loop
+			}
+
+			row := rowIndex
+			rowIndex++
+			pool.Run(func(ls *lua.LState) poolResult {
+				items := model.NewRecordItems(len(qrecord))
+				for i, val := range qrecord {
+					items.AddColumn(schema.Fields[i].Name, val)
+				}
+				record := &model.InsertRecord[model.RecordItems]{
+					BaseRecord:           model.BaseRecord{},
+					Items:                items,
+					SourceTableName:      config.WatermarkTable,
+					DestinationTableName: config.DestinationTableIdentifier,
+					CommitID:             0,
+				}
+
+				lfn := ls.Env.RawGetString("onRecord")
+				fn, ok := lfn.(*lua.LFunction)
+				if !ok {
+					queueErr(fmt.Errorf("script should define `onRecord` as function, not %s", lfn))
+					return poolResult{}
+				}
+
+				ls.Push(fn)
+				ls.Push(pua.LuaRecord.New(ls, record))
+				err := ls.PCall(1, -1, nil)
+				if err != nil {
+					queueErr(fmt.Errorf("script failed: %w", err))
+					return poolResult{}
+				}
+
+				args := ls.GetTop()
+				results := make([]natsMessage, 0, args)
+				for i := range args {
+					msg, err := lvalueToNatsMessage(ls, ls.Get(i-args))
+					if err != nil {
+						queueErr(err)
+						return poolResult{}
+					}
+					if msg.Msg != nil {
+						if msg.Subject == "" {
+							msg.Subject = record.GetDestinationTableName()
+						}
+						if msg.id == "" {
+							msg.id = fmt.Sprintf("%s:%s:%d:%d", config.FlowJobName, partition.PartitionId, row, i)
+						}
+						results = append(results, msg)
+					}
+				}
+				ls.SetTop(0)
+				numRecords.Add(1)
+				return poolResult{messages: results}
+			})
+
+		case <-queueCtx.Done():
+			break Loop
+		}
+	}
+	if err := stream.Err(); err != nil {
+		return 0, nil, fmt.Errorf("[nats] failed to get record from stream: %w", err)
+	}
+
+	if err := pool.Wait(queueCtx); err != nil {
+		return 0, nil, err
+	}
+	close(publish)
+	select {
+	case <-queueCtx.Done():
+		return 0, nil, context.Cause(queueCtx)
+	case <-waitChan:
+	}
+	if err := context.Cause(queueCtx); err != nil {
+		return 0, nil, err
+	}
+
+	if err := c.FinishQRepPartition(ctx, partition, config.FlowJobName, startTime); err != nil {
+		return 0, nil, err
+	}
+	return numRecords.Load(), nil, nil
+}
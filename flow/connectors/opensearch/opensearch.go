@@ -0,0 +1,207 @@
+package connopensearch
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/opensearch-project/opensearch-go/v4"
+	"github.com/opensearch-project/opensearch-go/v4/opensearchapi"
+	"github.com/opensearch-project/opensearch-go/v4/opensearchutil"
+	"github.com/opensearch-project/opensearch-go/v4/signer/awsv2"
+	"go.temporal.io/sdk/log"
+
+	connelasticsearch "github.com/PeerDB-io/peerdb/flow/connectors/elasticsearch"
+	metadataStore "github.com/PeerDB-io/peerdb/flow/connectors/external_metadata"
+	"github.com/PeerDB-io/peerdb/flow/connectors/utils"
+	"github.com/PeerDB-io/peerdb/flow/generated/protos"
+	"github.com/PeerDB-io/peerdb/flow/internal"
+	"github.com/PeerDB-io/peerdb/flow/model"
+	"github.com/PeerDB-io/peerdb/flow/shared"
+)
+
+const (
+	// same as Elasticsearch, documents carry the checkpoint of the change that wrote them as their version
+	versionTypeExternal = "external"
+
+	defaultSigningService = "es"
+)
+
+type OpenSearchConnector struct {
+	*metadataStore.PostgresMetadata
+	client           *opensearchapi.Client
+	logger           log.Logger
+	explicitMappings bool
+}
+
+func NewOpenSearchConnector(ctx context.Context,
+	config *protos.OpenSearchConfig,
+) (*OpenSearchConnector, error) {
+	// Amazon OpenSearch Service domains commonly only offer TLS 1.2
+	tlsConfig, err := shared.CreateTlsConfig(tls.VersionTLS12, config.RootCa, "", config.TlsHost, false)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create TLS config for opensearch: %w", err)
+	}
+	osCfg := opensearch.Config{
+		Addresses: config.Addresses,
+		Transport: &http.Transport{
+			MaxIdleConnsPerHost: 4,
+			TLSClientConfig:     tlsConfig,
+		},
+	}
+
+	switch config.AuthType {
+	case protos.OpenSearchAuthType_OPENSEARCH_AUTH_BASIC:
+		osCfg.Username = config.GetUsername()
+		osCfg.Password = config.GetPassword()
+	case protos.OpenSearchAuthType_OPENSEARCH_AUTH_SIGV4:
+		var peerCredentials utils.PeerAWSCredentials
+		if config.AwsAuth != nil {
+			peerCredentials = utils.BuildPeerAWSCredentials(config.AwsAuth)
+		}
+		credentialsProvider, err := utils.GetAWSCredentialsProvider(ctx, "opensearch", peerCredentials)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get AWS credentials for opensearch: %w", err)
+		}
+		region := config.AwsAuth.GetRegion()
+		if region == "" {
+			region = credentialsProvider.GetRegion()
+		}
+		signingService := config.SigningService
+		if signingService == "" {
+			signingService = defaultSigningService
+		}
+		signer, err := awsv2.NewSignerWithService(aws.Config{
+			Region:      region,
+			Credentials: credentialsProvider.GetUnderlyingProvider(),
+		}, signingService)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create SigV4 signer for opensearch: %w", err)
+		}
+		osCfg.Signer = signer
+	}
+
+	osClient, err := opensearchapi.NewClient(opensearchapi.Config{Client: osCfg})
+	if err != nil {
+		return nil, fmt.Errorf("error creating opensearch connector: %w", err)
+	}
+	pgMetadata, err := metadataStore.NewPostgresMetadata(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	return &OpenSearchConnector{
+		PostgresMetadata: pgMetadata,
+		client:           osClient,
+		logger:           internal.LoggerFromCtx(ctx),
+		explicitMappings: config.ExplicitMappings,
+	}, nil
+}
+
+func (osc *OpenSearchConnector) ConnectionActive(ctx context.Context) error {
+	// OpenSearch Serverless has no cluster info endpoint, listing indices works with both
+	if _, err := osc.client.Cat.Indices(ctx, nil); err != nil {
+		return fmt.Errorf("failed to check if opensearch peer is active: %w", err)
+	}
+	return nil
+}
+
+func (osc *OpenSearchConnector) Close() error {
+	// stateless connector
+	return nil
+}
+
+// OpenSearch is queue-like, no raw table staging needed
+func (osc *OpenSearchConnector) CreateRawTable(ctx context.Context,
+	req *protos.CreateRawTableInput,
+) (*protos.CreateRawTableOutput, error) {
+	return &protos.CreateRawTableOutput{TableIdentifier: "n/a"}, nil
+}
+
+// schema changes are left to dynamic mapping
+func (osc *OpenSearchConnector) ReplayTableSchemaDeltas(ctx context.Context, env map[string]string,
+	flowJobName string, schemaDeltas []*protos.TableSchemaDelta,
+) error {
+	return nil
+}
+
+// osBulkIndexer is a BulkIndexer over the bulk indexer of the OpenSearch client
+type osBulkIndexer struct {
+	opensearchutil.BulkIndexer
+}
+
+func (bi osBulkIndexer) Add(
+	ctx context.Context,
+	doc connelasticsearch.BulkDocument,
+	onSuccess func(),
+	onFailure func(connelasticsearch.BulkItemFailure),
+) error {
+	item := opensearchutil.BulkIndexerItem{
+		Action:     doc.Action,
+		DocumentID: doc.DocumentID,
+		Version:    doc.Version,
+		OnFailure: func(_ context.Context, item opensearchutil.BulkIndexerItem, res opensearchapi.BulkRespItem, err error) {
+			failure := connelasticsearch.BulkItemFailure{
+				Err:        err,
+				Action:     item.Action,
+				DocumentID: item.DocumentID,
+				Status:     res.Status,
+			}
+			if res.Error != nil {
+				failure.ErrorType = res.Error.Type
+				failure.ErrorReason = res.Error.Reason
+				failure.CauseType = res.Error.Cause.Type
+				failure.CauseReason = res.Error.Cause.Reason
+			}
+			onFailure(failure)
+		},
+	}
+	// no need to supply the document when deleting
+	if doc.Body != nil {
+		item.Body = bytes.NewReader(doc.Body)
+	}
+	if doc.Version != nil {
+		item.VersionType = shared.Ptr(versionTypeExternal)
+	}
+	if onSuccess != nil {
+		item.OnSuccess = func(context.Context, opensearchutil.BulkIndexerItem, opensearchapi.BulkRespItem) {
+			onSuccess()
+		}
+	}
+	return bi.BulkIndexer.Add(ctx, item)
+}
+
+func (bi osBulkIndexer) NumFlushed() uint64 {
+	return bi.Stats().NumFlushed
+}
+
+func (osc *OpenSearchConnector) bulkSyncer() connelasticsearch.BulkSyncer {
+	return connelasticsearch.BulkSyncer{
+		PostgresMetadata: osc.PostgresMetadata,
+		Logger:           osc.logger,
+		Name:             "[opensearch]",
+		NewBulkIndexer: func(index string) (connelasticsearch.BulkIndexer, error) {
+			bulkIndexer, err := opensearchutil.NewBulkIndexer(opensearchutil.BulkIndexerConfig{
+				Index:  index,
+				Client: osc.client,
+				// parallelism comes from the workflow design itself, no need for this
+				NumWorkers:    1,
+				FlushInterval: 10 * time.Second,
+			})
+			if err != nil {
+				return nil, err
+			}
+			return osBulkIndexer{BulkIndexer: bulkIndexer}, nil
+		},
+	}
+}
+
+func (osc *OpenSearchConnector) SyncRecords(ctx context.Context,
+	req *model.SyncRecordsRequest[model.RecordItems],
+) (*model.SyncResponse, error) {
+	return osc.bulkSyncer().SyncRecords(ctx, req)
+}
@@ -0,0 +1,97 @@
+package connopensearch
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/opensearch-project/opensearch-go/v4/opensearchapi"
+
+	connelasticsearch "github.com/PeerDB-io/peerdb/flow/connectors/elasticsearch"
+	"github.com/PeerDB-io/peerdb/flow/generated/protos"
+	"github.com/PeerDB-io/peerdb/flow/model"
+	"github.com/PeerDB-io/peerdb/flow/shared"
+)
+
+func (osc *OpenSearchConnector) SetupQRepMetadataTables(ctx context.Context,
+	config *protos.QRepConfig,
+) error {
+	return nil
+}
+
+func (osc *OpenSearchConnector) StartSetupNormalizedTables(_ context.Context) (any, error) {
+	return nil, nil
+}
+
+func (osc *OpenSearchConnector) CleanupSetupNormalizedTables(_ context.Context, _ any) {
+}
+
+func (osc *OpenSearchConnector) FinishSetupNormalizedTables(_ context.Context, _ any) error {
+	return nil
+}
+
+// SetupNormalizedTable creates the index with mappings derived from the source schema when explicit mappings are enabled,
+// otherwise indices are created by the first bulk request using dynamic mapping and there is nothing to set up
+func (osc *OpenSearchConnector) SetupNormalizedTable(
+	ctx context.Context,
+	_ any,
+	_ *protos.SetupNormalizedTableBatchInput,
+	destinationTableIdentifier string,
+	sourceTableSchema *protos.TableSchema,
+) (bool, error) {
+	if !osc.explicitMappings {
+		return true, nil
+	}
+
+	return osc.createIndexWithMappings(ctx, destinationTableIdentifier, connelasticsearch.IndexMappings(sourceTableSchema))
+}
+
+// createIndexWithMappings creates index with the given mappings unless it already exists,
+// returns whether the index already existed
+func (osc *OpenSearchConnector) createIndexWithMappings(ctx context.Context, index string, mappings map[string]any) (bool, error) {
+	existsRes, err := osc.client.Indices.Exists(ctx, opensearchapi.IndicesExistsReq{Indices: []string{index}})
+	if existsRes != nil {
+		existsRes.Body.Close()
+	}
+	if err == nil {
+		return true, nil
+	} else if existsRes == nil || existsRes.StatusCode != http.StatusNotFound {
+		return false, fmt.Errorf("failed to check if index %s exists: %w", index, err)
+	}
+
+	body, err := json.Marshal(mappings)
+	if err != nil {
+		return false, fmt.Errorf("failed to marshal mappings for index %s: %w", index, err)
+	}
+	if _, err := osc.client.Indices.Create(ctx, opensearchapi.IndicesCreateReq{
+		Index: index,
+		Body:  bytes.NewReader(body),
+	}); err != nil {
+		// a concurrent partition may have created it first
+		if strings.Contains(err.Error(), "resource_already_exists_exception") {
+			return true, nil
+		}
+		return false, fmt.Errorf("failed to create index %s: %w", index, err)
+	}
+	return false, nil
+}
+
+func (osc *OpenSearchConnector) SyncQRepRecords(ctx context.Context, config *protos.QRepConfig,
+	partition *protos.QRepPartition, stream *model.QRecordStream,
+) (int64, shared.QRepWarnings, error) {
+	// QRep mirrors don't set up normalized tables, create the index before the first bulk request does
+	if osc.explicitMappings {
+		schema, err := stream.Schema()
+		if err != nil {
+			return 0, nil, err
+		}
+		if _, err := osc.createIndexWithMappings(ctx, config.DestinationTableIdentifier,
+			connelasticsearch.QRecordSchemaMappings(schema)); err != nil {
+			return 0, nil, err
+		}
+	}
+	return osc.bulkSyncer().SyncQRepRecords(ctx, config, partition, stream)
+}
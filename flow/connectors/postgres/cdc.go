@@ -459,6 +459,20 @@ func PullCdcRecords[Items model.Items](
 		logger.Error("failed to get PeerDBPKMEmptyBatchThrottleThresholdSeconds", slog.Any("error", err))
 	}
 	lastEmptyBatchPkmSentTime := time.Now()
+
+	var incrementalSnapshotChunkSize uint64
+	if p.incrementalSnapshotSupported() {
+		incrementalSnapshotChunkSize, err = internal.PeerDBIncrementalSnapshotChunkSize(ctx, req.Env)
+		if err != nil {
+			return err
+		}
+		if incrementalSnapshotChunkSize > 0 {
+			if err := p.startIncrementalSnapshotChunk(ctx, req.LastOffset.ID, incrementalSnapshotChunkSize); err != nil {
+				return err
+			}
+		}
+	}
+
 	for {
 		if pkmRequiresResponse {
 			if cdcRecordsStorage.IsEmpty() && int64(clientXLogPos) > req.ConsumedOffset.Load() {
@@ -678,7 +692,13 @@ func PullCdcRecords[Items model.Items](
 					case *model.MessageRecord[Items]:
 						// if cdc store empty, we can move lsn,
 						// otherwise push to records so destination can ack once all previous messages processed
-						if cdcRecordsStorage.IsEmpty() {
+						if r.Prefix == incrementalSnapshotMessagePrefix {
+							if err := processIncrementalSnapshotWatermark(ctx, p, processor, r,
+								req.TableNameSchemaMapping, addRecordWithKey, incrementalSnapshotChunkSize,
+							); err != nil {
+								return err
+							}
+						} else if cdcRecordsStorage.IsEmpty() {
 							if int64(clientXLogPos) > req.ConsumedOffset.Load() {
 								if err := p.updateConsumedOffset(ctx, logger, req.FlowJobName, req.ConsumedOffset, clientXLogPos); err != nil {
 									return err
@@ -829,6 +849,7 @@ func processInsertMessage[Items model.Items](
 	if err != nil {
 		return nil, fmt.Errorf("error converting tuple to map: %w", err)
 	}
+	p.takeIncrementalSnapshotRow(tableName, rel, msg.Tuple)
 
 	return &model.InsertRecord[Items]{
 		BaseRecord:           p.baseRecord(lsn),
@@ -890,6 +911,22 @@ func processUpdateMessage[Items model.Items](
 		oldItems.DeleteColName(col)
 	}
 
+	// a row of an open incremental snapshot chunk has the values of unchanged TOAST columns
+	oldChunkRow := p.takeIncrementalSnapshotRow(tableName, rel, msg.OldTuple)
+	if chunkRow := p.takeIncrementalSnapshotRow(tableName, rel, msg.NewTuple); chunkRow != nil {
+		oldChunkRow = chunkRow
+	}
+	if oldChunkRow != nil && len(unchangedToastColumns) > 0 {
+		chunkItems, _, err := processTuple(
+			processor, p, oldChunkRow, p.incrementalSnapshot.rel, p.tableNameMapping[tableName], customTypeMapping, "")
+		if err != nil {
+			return nil, fmt.Errorf("error converting incremental snapshot row: %w", err)
+		}
+		for _, col := range newItems.UpdateIfNotExists(chunkItems) {
+			delete(unchangedToastColumns, col)
+		}
+	}
+
 	return &model.UpdateRecord[Items]{
 		BaseRecord:            p.baseRecord(lsn),
 		OldItems:              oldItems,
@@ -932,6 +969,7 @@ func processDeleteMessage[Items model.Items](
 	if err != nil {
 		return nil, fmt.Errorf("error converting tuple to map: %w", err)
 	}
+	p.takeIncrementalSnapshotRow(tableName, rel, msg.OldTuple)
 
 	return &model.DeleteRecord[Items]{
 		BaseRecord:           p.baseRecord(lsn),
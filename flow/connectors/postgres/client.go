@@ -7,6 +7,7 @@ import (
 	"log/slog"
 	"slices"
 	"strings"
+	"time"
 
 	"github.com/jackc/pgerrcode"
 	"github.com/jackc/pglogrepl"
@@ -426,6 +427,7 @@ func (c *PostgresConnector) createSlotAndPublication(
 				Conn:             nil,
 				SlotName:         res.SlotName,
 				SnapshotName:     "",
+				ConsistentPoint:  res.ConsistentPoint,
 				SupportsTIDScans: pgversion >= shared.POSTGRES_13,
 			}, nil
 		}
@@ -433,6 +435,7 @@ func (c *PostgresConnector) createSlotAndPublication(
 			Conn:             conn,
 			SlotName:         res.SlotName,
 			SnapshotName:     res.SnapshotName,
+			ConsistentPoint:  res.ConsistentPoint,
 			SupportsTIDScans: pgversion >= shared.POSTGRES_13,
 		}, nil
 	} else {
@@ -665,6 +668,44 @@ func (c *PostgresConnector) getCurrentLSN(ctx context.Context) (NullableLSN, err
 	return NullableLSN{LSN: lsn}, nil
 }
 
+// WaitForReplayLSN blocks until this standby has replayed WAL up to lsn
+func (c *PostgresConnector) WaitForReplayLSN(ctx context.Context, lsn string) error {
+	target, err := pglogrepl.ParseLSN(lsn)
+	if err != nil {
+		return fmt.Errorf("error while parsing LSN %s: %w", lsn, err)
+	}
+
+	for {
+		var inRecovery bool
+		var replayed pgtype.Text
+		if err := c.conn.QueryRow(ctx,
+			"SELECT pg_is_in_recovery(), pg_last_wal_replay_lsn()::text",
+		).Scan(&inRecovery, &replayed); err != nil {
+			return fmt.Errorf("error while running query for replay LSN: %w", err)
+		}
+		if !inRecovery {
+			return errors.New("server is not a standby")
+		}
+		if replayed.Valid {
+			replayedLSN, err := pglogrepl.ParseLSN(replayed.String)
+			if err != nil {
+				return fmt.Errorf("error while parsing LSN %s: %w", replayed.String, err)
+			}
+			if replayedLSN >= target {
+				return nil
+			}
+			c.logger.Info("waiting for standby to replay WAL",
+				slog.String("replayedLSN", replayedLSN.String()), slog.String("targetLSN", target.String()))
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(5 * time.Second):
+		}
+	}
+}
+
 func (c *PostgresConnector) getDefaultPublicationName(jobName string) string {
 	return "peerflow_pub_" + jobName
 }
@@ -0,0 +1,393 @@
+package connpostgres
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+
+	"github.com/jackc/pgx/v5"
+	"google.golang.org/protobuf/proto"
+
+	"github.com/PeerDB-io/peerdb/flow/connectors/utils"
+	"github.com/PeerDB-io/peerdb/flow/generated/protos"
+	"github.com/PeerDB-io/peerdb/flow/internal"
+	"github.com/PeerDB-io/peerdb/flow/model"
+	"github.com/PeerDB-io/peerdb/flow/shared"
+)
+
+// statements are pipelined to the destination in batches of this size
+const directApplyBatchSize = 1000
+
+const directApplySource = "(SELECT $1::JSONB AS _peerdb_data) AS src"
+
+type directApplyKind int8
+
+const (
+	directApplyUpsert directApplyKind = iota
+	directApplyToastUpdate
+	directApplyDelete
+)
+
+type directApplyKey struct {
+	table                 string
+	unchangedToastColumns string
+	kind                  directApplyKind
+}
+
+// canDirectApply checks whether a batch can skip the raw table. Batches staged before direct apply was enabled
+// have to be normalized first, otherwise records applied now would be overwritten by older ones.
+func (c *PostgresConnector) canDirectApply(ctx context.Context, env map[string]string, flowJobName string, syncBatchID int64) (bool, error) {
+	directApply, err := internal.PeerDBPostgresDirectApply(ctx, env)
+	if err != nil || !directApply {
+		return false, err
+	}
+	normBatchID, err := c.GetLastNormalizeBatchID(ctx, flowJobName)
+	if err != nil {
+		return false, fmt.Errorf("failed to get normalize batch for direct apply: %w", err)
+	}
+	if normBatchID < syncBatchID-1 {
+		c.logger.Info(fmt.Sprintf("staging batch %d in raw table until normalize catches up from batch %d",
+			syncBatchID, normBatchID))
+		return false, nil
+	}
+	return true, nil
+}
+
+// syncRecordsDirect applies records to destination tables in the order they were pulled, in the same transaction
+// that advances the sync and normalize batch, so the destination only ever reflects whole source transactions.
+func syncRecordsDirect[Items model.Items](
+	ctx context.Context,
+	c *PostgresConnector,
+	req *model.SyncRecordsRequest[Items],
+) (*model.SyncResponse, error) {
+	c.logger.Info("applying records directly to destination tables")
+
+	syncRecordsTx, err := c.conn.Begin(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("error starting transaction for applying records: %w", err)
+	}
+	defer shared.RollbackTx(syncRecordsTx, c.logger)
+
+	applier := &directApplier{
+		conn: c,
+		tx:   syncRecordsTx,
+		stmtGen: &normalizeStmtGenerator{
+			Logger:             c.logger,
+			tableSchemaMapping: req.TableNameSchemaMapping,
+			peerdbCols: &protos.PeerDBColumns{
+				SoftDeleteColName: req.SoftDeleteColName,
+				SyncedAtColName:   req.SyncedAtColName,
+			},
+			metadataSchema: c.metadataSchema,
+		},
+		statements: make(map[directApplyKey]string),
+		batch:      &pgx.Batch{},
+	}
+
+	numRecords := int64(0)
+	tableNameRowsMapping := utils.InitialiseTableRowsMap(req.TableMappings)
+	for record := range req.Records.GetRecords() {
+		if err := applier.applySchemaDeltas(ctx, req.Records.SchemaDeltasSince(applier.appliedDeltas)); err != nil {
+			return nil, err
+		}
+
+		switch typedRecord := record.(type) {
+		case *model.InsertRecord[Items]:
+			if err := applier.queue(ctx, typedRecord.DestinationTableName, directApplyUpsert, "", typedRecord.Items); err != nil {
+				return nil, err
+			}
+
+		case *model.UpdateRecord[Items]:
+			schema, err := applier.tableSchema(typedRecord.DestinationTableName)
+			if err != nil {
+				return nil, err
+			}
+			// normalize matches on the new key and would leave the old row behind
+			if primaryKeyChanged(schema, typedRecord.OldItems, typedRecord.NewItems) {
+				if err := applier.queue(ctx, typedRecord.DestinationTableName, directApplyDelete, "", typedRecord.OldItems); err != nil {
+					return nil, err
+				}
+			}
+			kind := directApplyUpsert
+			unchangedToastColumns := utils.KeysToString(typedRecord.UnchangedToastColumns)
+			if unchangedToastColumns != "" {
+				kind = directApplyToastUpdate
+			}
+			if err := applier.queue(ctx, typedRecord.DestinationTableName, kind, unchangedToastColumns,
+				typedRecord.NewItems); err != nil {
+				return nil, err
+			}
+
+		case *model.DeleteRecord[Items]:
+			if err := applier.queue(ctx, typedRecord.DestinationTableName, directApplyDelete, "", typedRecord.Items); err != nil {
+				return nil, err
+			}
+
+		case *model.MessageRecord[Items]:
+			continue
+
+		default:
+			return nil, fmt.Errorf("unsupported record type for Postgres flow connector: %T", typedRecord)
+		}
+
+		record.PopulateCountMap(tableNameRowsMapping)
+		numRecords += 1
+	}
+	// columns can be added after the last record of the batch
+	if err := applier.applySchemaDeltas(ctx, req.Records.SchemaDeltasSince(applier.appliedDeltas)); err != nil {
+		return nil, err
+	}
+	if err := applier.flush(ctx); err != nil {
+		return nil, err
+	}
+	c.logger.Info(fmt.Sprintf("applied %d records directly to destination tables", numRecords))
+
+	// normalize has nothing left to do for this batch
+	lastCP := req.Records.GetLastCheckpoint()
+	if err := c.updateSyncMetadata(ctx, req.FlowJobName, lastCP, req.SyncBatchID, syncRecordsTx); err != nil {
+		return nil, err
+	}
+	if err := c.updateNormalizeMetadata(ctx, req.FlowJobName, req.SyncBatchID, syncRecordsTx); err != nil {
+		return nil, err
+	}
+	if err := syncRecordsTx.Commit(ctx); err != nil {
+		return nil, err
+	}
+
+	return &model.SyncResponse{
+		LastSyncedCheckpoint: lastCP,
+		NumRecordsSynced:     numRecords,
+		CurrentSyncBatchID:   req.SyncBatchID,
+		TableNameRowsMapping: tableNameRowsMapping,
+		TableSchemaDeltas:    req.Records.SchemaDeltas,
+	}, nil
+}
+
+type directApplier struct {
+	conn       *PostgresConnector
+	tx         pgx.Tx
+	stmtGen    *normalizeStmtGenerator
+	statements map[directApplyKey]string
+	batch      *pgx.Batch
+	// number of schema deltas already applied
+	appliedDeltas     int
+	ownsSchemaMapping bool
+}
+
+func (a *directApplier) tableSchema(table string) (*protos.TableSchema, error) {
+	schema, ok := a.stmtGen.tableSchemaMapping[table]
+	if !ok {
+		return nil, fmt.Errorf("no schema found for destination table %s", table)
+	}
+	if len(schema.PrimaryKeyColumns) == 0 {
+		return nil, fmt.Errorf("direct apply requires a primary key on destination table %s", table)
+	}
+	return schema, nil
+}
+
+// applySchemaDeltas adds columns within the transaction before any record carrying them is applied,
+// the schema mapping is copied on first change since it is shared with the caller
+func (a *directApplier) applySchemaDeltas(ctx context.Context, deltas []*protos.TableSchemaDelta) error {
+	if len(deltas) == 0 {
+		return nil
+	}
+	if err := a.flush(ctx); err != nil {
+		return err
+	}
+	for _, delta := range deltas {
+		if err := a.conn.replaySchemaDeltaTx(ctx, delta, a.tx); err != nil {
+			return err
+		}
+		a.appliedDeltas++
+
+		schema, ok := a.stmtGen.tableSchemaMapping[delta.DstTableName]
+		if !ok {
+			continue
+		}
+		schema = proto.CloneOf(schema)
+		for _, column := range delta.AddedColumns {
+			if !slices.ContainsFunc(schema.Columns, func(existing *protos.FieldDescription) bool {
+				return existing.Name == column.Name
+			}) {
+				schema.Columns = append(schema.Columns, column)
+			}
+		}
+		if !a.ownsSchemaMapping {
+			a.stmtGen.tableSchemaMapping = maps.Clone(a.stmtGen.tableSchemaMapping)
+			a.ownsSchemaMapping = true
+		}
+		a.stmtGen.tableSchemaMapping[delta.DstTableName] = schema
+		maps.DeleteFunc(a.statements, func(key directApplyKey, _ string) bool {
+			return key.table == delta.DstTableName
+		})
+	}
+	return nil
+}
+
+func (a *directApplier) queue(
+	ctx context.Context,
+	table string,
+	kind directApplyKind,
+	unchangedToastColumns string,
+	items model.Items,
+) error {
+	key := directApplyKey{table: table, kind: kind, unchangedToastColumns: unchangedToastColumns}
+	stmt, ok := a.statements[key]
+	if !ok {
+		schema, err := a.tableSchema(table)
+		if err != nil {
+			return err
+		}
+		switch kind {
+		case directApplyUpsert:
+			stmt = a.stmtGen.generateDirectUpsertStatement(table, schema)
+		case directApplyToastUpdate:
+			stmt = a.stmtGen.generateDirectToastUpdateStatement(table, schema, unchangedToastColumns)
+		case directApplyDelete:
+			stmt = a.stmtGen.generateDirectDeleteStatement(table, schema)
+		}
+		a.statements[key] = stmt
+	}
+
+	itemsJSON, err := items.ToJSONWithOptions(model.ToJSONOptions{
+		UnnestColumns: nil,
+		HStoreAsJSON:  false,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to serialize record items to JSON: %w", err)
+	}
+	a.batch.Queue(stmt, itemsJSON)
+	if a.batch.Len() >= directApplyBatchSize {
+		return a.flush(ctx)
+	}
+	return nil
+}
+
+func (a *directApplier) flush(ctx context.Context) error {
+	if a.batch.Len() == 0 {
+		return nil
+	}
+	if err := a.tx.SendBatch(ctx, a.batch).Close(); err != nil {
+		return fmt.Errorf("error applying records: %w", err)
+	}
+	a.batch = &pgx.Batch{}
+	return nil
+}
+
+// primaryKeyChanged compares key columns when the old values were sent, which Postgres does when the key changes
+func primaryKeyChanged[Items model.Items](schema *protos.TableSchema, oldItems Items, newItems Items) bool {
+	for _, col := range schema.PrimaryKeyColumns {
+		oldValue, err := oldItems.GetBytesByColName(col)
+		if err != nil {
+			return false
+		}
+		newValue, err := newItems.GetBytesByColName(col)
+		if err != nil {
+			return false
+		}
+		if !bytes.Equal(oldValue, newValue) {
+			return true
+		}
+	}
+	return false
+}
+
+func (n *normalizeStmtGenerator) directColumnExprs(schema *protos.TableSchema) ([]string, []string, []string) {
+	quotedCols := make([]string, 0, len(schema.Columns))
+	exprs := make([]string, 0, len(schema.Columns))
+	primaryKeyConds := make([]string, 0, len(schema.PrimaryKeyColumns))
+	for _, column := range schema.Columns {
+		quotedCol := utils.QuoteIdentifier(column.Name)
+		pgType := n.columnTypeToPg(schema, column.Type)
+		expr := n.generateExpr(schema, column.Type, utils.QuoteLiteral(column.Name), pgType)
+		quotedCols = append(quotedCols, quotedCol)
+		exprs = append(exprs, expr)
+		if slices.Contains(schema.PrimaryKeyColumns, column.Name) {
+			primaryKeyConds = append(primaryKeyConds, fmt.Sprintf("dst.%s=%s", quotedCol, expr))
+		}
+	}
+	return quotedCols, exprs, primaryKeyConds
+}
+
+func quotedPrimaryKeyColumns(schema *protos.TableSchema) string {
+	quotedCols := make([]string, 0, len(schema.PrimaryKeyColumns))
+	for _, col := range schema.PrimaryKeyColumns {
+		quotedCols = append(quotedCols, utils.QuoteIdentifier(col))
+	}
+	return strings.Join(quotedCols, ",")
+}
+
+// generateDirectUpsertStatement writes a full row, used for inserts and updates without unchanged TOAST columns
+func (n *normalizeStmtGenerator) generateDirectUpsertStatement(dstTableName string, schema *protos.TableSchema) string {
+	parsedDstTable, _ := utils.ParseSchemaTable(dstTableName)
+	quotedCols, exprs, _ := n.directColumnExprs(schema)
+	if n.peerdbCols.SyncedAtColName != "" {
+		quotedCols = append(quotedCols, utils.QuoteIdentifier(n.peerdbCols.SyncedAtColName))
+		exprs = append(exprs, "CURRENT_TIMESTAMP")
+	}
+	// tackles insert after soft-delete
+	if n.peerdbCols.SoftDeleteColName != "" {
+		quotedCols = append(quotedCols, utils.QuoteIdentifier(n.peerdbCols.SoftDeleteColName))
+		exprs = append(exprs, "FALSE")
+	}
+	updates := make([]string, 0, len(quotedCols))
+	for _, quotedCol := range quotedCols {
+		updates = append(updates, fmt.Sprintf("%s=EXCLUDED.%s", quotedCol, quotedCol))
+	}
+	return fmt.Sprintf("INSERT INTO %s (%s) SELECT %s FROM %s ON CONFLICT (%s) DO UPDATE SET %s",
+		parsedDstTable.String(), strings.Join(quotedCols, ","), strings.Join(exprs, ","), directApplySource,
+		quotedPrimaryKeyColumns(schema), strings.Join(updates, ","))
+}
+
+// generateDirectToastUpdateStatement updates an existing row leaving unchanged TOAST columns as they are
+func (n *normalizeStmtGenerator) generateDirectToastUpdateStatement(
+	dstTableName string,
+	schema *protos.TableSchema,
+	unchangedToastColumns string,
+) string {
+	parsedDstTable, _ := utils.ParseSchemaTable(dstTableName)
+	quotedCols, exprs, primaryKeyConds := n.directColumnExprs(schema)
+	unchanged := strings.Split(unchangedToastColumns, ",")
+	updates := make([]string, 0, len(quotedCols)+2)
+	for i, column := range schema.Columns {
+		if !slices.Contains(unchanged, column.Name) {
+			updates = append(updates, fmt.Sprintf("%s=%s", quotedCols[i], exprs[i]))
+		}
+	}
+	if n.peerdbCols.SyncedAtColName != "" {
+		updates = append(updates, utils.QuoteIdentifier(n.peerdbCols.SyncedAtColName)+"=CURRENT_TIMESTAMP")
+	}
+	if n.peerdbCols.SoftDeleteColName != "" {
+		updates = append(updates, utils.QuoteIdentifier(n.peerdbCols.SoftDeleteColName)+"=FALSE")
+	}
+	return fmt.Sprintf("UPDATE %s AS dst SET %s FROM %s WHERE %s",
+		parsedDstTable.String(), strings.Join(updates, ","), directApplySource, strings.Join(primaryKeyConds, " AND "))
+}
+
+// generateDirectDeleteStatement deletes a row, or marks it deleted when soft delete is enabled,
+// inserting it like normalize does when it doesn't exist yet
+func (n *normalizeStmtGenerator) generateDirectDeleteStatement(dstTableName string, schema *protos.TableSchema) string {
+	parsedDstTable, _ := utils.ParseSchemaTable(dstTableName)
+	quotedCols, exprs, primaryKeyConds := n.directColumnExprs(schema)
+	if n.peerdbCols.SoftDeleteColName == "" {
+		return fmt.Sprintf("DELETE FROM %s AS dst USING %s WHERE %s",
+			parsedDstTable.String(), directApplySource, strings.Join(primaryKeyConds, " AND "))
+	}
+
+	softDeleteCol := utils.QuoteIdentifier(n.peerdbCols.SoftDeleteColName)
+	updates := []string{softDeleteCol + "=TRUE"}
+	if n.peerdbCols.SyncedAtColName != "" {
+		syncedAtCol := utils.QuoteIdentifier(n.peerdbCols.SyncedAtColName)
+		quotedCols = append(quotedCols, syncedAtCol)
+		exprs = append(exprs, "CURRENT_TIMESTAMP")
+		updates = append(updates, syncedAtCol+"=CURRENT_TIMESTAMP")
+	}
+	quotedCols = append(quotedCols, softDeleteCol)
+	exprs = append(exprs, "TRUE")
+	return fmt.Sprintf("INSERT INTO %s (%s) SELECT %s FROM %s ON CONFLICT (%s) DO UPDATE SET %s",
+		parsedDstTable.String(), strings.Join(quotedCols, ","), strings.Join(exprs, ","), directApplySource,
+		quotedPrimaryKeyColumns(schema), strings.Join(updates, ","))
+}
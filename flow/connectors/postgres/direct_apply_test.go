@@ -0,0 +1,79 @@
+package connpostgres
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/PeerDB-io/peerdb/flow/generated/protos"
+	"github.com/PeerDB-io/peerdb/flow/model"
+	"github.com/PeerDB-io/peerdb/flow/shared/types"
+)
+
+var directApplyTestSchema = &protos.TableSchema{
+	TableIdentifier:   "public.users",
+	PrimaryKeyColumns: []string{"id"},
+	System:            protos.TypeSystem_PG,
+	Columns: []*protos.FieldDescription{
+		{Name: "id", Type: "integer"},
+		{Name: "name", Type: "text"},
+		{Name: "bio", Type: "text"},
+	},
+}
+
+func TestGenerateDirectUpsertStatement(t *testing.T) {
+	t.Parallel()
+
+	stmtGen := normalizeStmtGenerator{peerdbCols: &protos.PeerDBColumns{SyncedAtColName: "_peerdb_synced_at"}}
+	require.Equal(t,
+		`INSERT INTO "public"."users" ("id","name","bio","_peerdb_synced_at") `+
+			`SELECT (_peerdb_data->>'id')::integer,(_peerdb_data->>'name')::text,(_peerdb_data->>'bio')::text,CURRENT_TIMESTAMP `+
+			`FROM (SELECT $1::JSONB AS _peerdb_data) AS src ON CONFLICT ("id") DO UPDATE SET `+
+			`"id"=EXCLUDED."id","name"=EXCLUDED."name","bio"=EXCLUDED."bio","_peerdb_synced_at"=EXCLUDED."_peerdb_synced_at"`,
+		stmtGen.generateDirectUpsertStatement("public.users", directApplyTestSchema))
+}
+
+func TestGenerateDirectToastUpdateStatement(t *testing.T) {
+	t.Parallel()
+
+	stmtGen := normalizeStmtGenerator{peerdbCols: &protos.PeerDBColumns{SoftDeleteColName: "_peerdb_is_deleted"}}
+	require.Equal(t,
+		`UPDATE "public"."users" AS dst SET "id"=(_peerdb_data->>'id')::integer,"name"=(_peerdb_data->>'name')::text,`+
+			`"_peerdb_is_deleted"=FALSE FROM (SELECT $1::JSONB AS _peerdb_data) AS src `+
+			`WHERE dst."id"=(_peerdb_data->>'id')::integer`,
+		stmtGen.generateDirectToastUpdateStatement("public.users", directApplyTestSchema, "bio"))
+}
+
+func TestGenerateDirectDeleteStatement(t *testing.T) {
+	t.Parallel()
+
+	stmtGen := normalizeStmtGenerator{peerdbCols: &protos.PeerDBColumns{}}
+	require.Equal(t,
+		`DELETE FROM "public"."users" AS dst USING (SELECT $1::JSONB AS _peerdb_data) AS src `+
+			`WHERE dst."id"=(_peerdb_data->>'id')::integer`,
+		stmtGen.generateDirectDeleteStatement("public.users", directApplyTestSchema))
+
+	stmtGen.peerdbCols = &protos.PeerDBColumns{SoftDeleteColName: "_peerdb_is_deleted", SyncedAtColName: "_peerdb_synced_at"}
+	require.Equal(t,
+		`INSERT INTO "public"."users" ("id","name","bio","_peerdb_synced_at","_peerdb_is_deleted") `+
+			`SELECT (_peerdb_data->>'id')::integer,(_peerdb_data->>'name')::text,(_peerdb_data->>'bio')::text,CURRENT_TIMESTAMP,TRUE `+
+			`FROM (SELECT $1::JSONB AS _peerdb_data) AS src ON CONFLICT ("id") DO UPDATE SET `+
+			`"_peerdb_is_deleted"=TRUE,"_peerdb_synced_at"=CURRENT_TIMESTAMP`,
+		stmtGen.generateDirectDeleteStatement("public.users", directApplyTestSchema))
+}
+
+func TestPrimaryKeyChanged(t *testing.T) {
+	t.Parallel()
+
+	items := func(id int32) model.RecordItems {
+		items := model.NewRecordItems(2)
+		items.AddColumn("id", types.QValueInt32{Val: id})
+		items.AddColumn("name", types.QValueString{Val: "peer"})
+		return items
+	}
+
+	require.False(t, primaryKeyChanged(directApplyTestSchema, items(1), items(1)))
+	require.True(t, primaryKeyChanged(directApplyTestSchema, items(1), items(2)))
+	// old values are only sent when the key changes or with REPLICA IDENTITY FULL
+	require.False(t, primaryKeyChanged(directApplyTestSchema, model.NewRecordItems(0), items(2)))
+}
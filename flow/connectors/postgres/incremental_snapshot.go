@@ -0,0 +1,351 @@
+package connpostgres
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"log/slog"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pglogrepl"
+	"github.com/jackc/pgx/v5"
+
+	"github.com/PeerDB-io/peerdb/flow/connectors/utils"
+	"github.com/PeerDB-io/peerdb/flow/generated/protos"
+	"github.com/PeerDB-io/peerdb/flow/internal"
+	"github.com/PeerDB-io/peerdb/flow/model"
+	"github.com/PeerDB-io/peerdb/flow/shared"
+)
+
+// prefix of the logical decoding messages delimiting incremental snapshot chunks
+const incrementalSnapshotMessagePrefix = "peerdb_incremental_snapshot"
+
+// incrementalSnapshotWindow is a chunk of a table read between a low and a high watermark written to the WAL.
+// Rows changed once the low watermark is decoded are dropped from the chunk as CDC carries a newer version of them,
+// the rest are emitted as inserts when the high watermark is decoded
+type incrementalSnapshotWindow struct {
+	rel         *pglogrepl.RelationMessage
+	rows        map[string]*pglogrepl.TupleData
+	sourceTable string
+	id          string
+	// primary key columns in order, rows are keyed by their text values
+	keyColumns []string
+	keys       []string
+	lastKey    []string
+	relID      uint32
+	// chunk came back short, so it is the last of the table
+	done    bool
+	lowSeen bool
+}
+
+// incrementalSnapshotKey is the text of a tuple's primary key, as both CDC and chunk queries decode in text format
+func incrementalSnapshotKey(rel *pglogrepl.RelationMessage, tuple *pglogrepl.TupleData, keyColumns []string) (string, bool) {
+	if tuple == nil {
+		return "", false
+	}
+	var key strings.Builder
+	for i, keyColumn := range keyColumns {
+		idx := -1
+		for j, col := range rel.Columns {
+			if col.Name == keyColumn {
+				idx = j
+				break
+			}
+		}
+		if idx == -1 || idx >= len(tuple.Columns) || tuple.Columns[idx].DataType != pglogrepl.TupleDataTypeText {
+			return "", false
+		}
+		if i > 0 {
+			// text values cannot contain NUL
+			key.WriteByte(0)
+		}
+		key.Write(tuple.Columns[idx].Data)
+	}
+	return key.String(), true
+}
+
+// takeIncrementalSnapshotRow removes and returns the chunk row a change to tableName supersedes, if any
+func (p *PostgresCDCSource) takeIncrementalSnapshotRow(
+	tableName string,
+	rel *pglogrepl.RelationMessage,
+	tuple *pglogrepl.TupleData,
+) *pglogrepl.TupleData {
+	window := p.incrementalSnapshot
+	if window == nil || !window.lowSeen || window.sourceTable != tableName {
+		return nil
+	}
+	key, ok := incrementalSnapshotKey(rel, tuple, window.keyColumns)
+	if !ok {
+		return nil
+	}
+	row, ok := window.rows[key]
+	if !ok {
+		return nil
+	}
+	delete(window.rows, key)
+	return row
+}
+
+// startIncrementalSnapshotChunk settles chunks emitted by earlier pulls against syncedOffset,
+// then reads the next chunk of the first table being snapshotted, unless a chunk is already open
+func (p *PostgresCDCSource) startIncrementalSnapshotChunk(ctx context.Context, syncedOffset int64, chunkSize uint64) error {
+	if p.incrementalSnapshot != nil {
+		return nil
+	}
+	snapshots, err := internal.LoadIncrementalSnapshots(ctx, p.catalogPool, p.flowJobName)
+	if err != nil {
+		return err
+	}
+	for i := range snapshots {
+		snapshot := &snapshots[i]
+		if _, ok := p.tableNameMapping[snapshot.SourceTable]; !ok {
+			p.logger.Warn("table of incremental snapshot is not replicated, dropping snapshot",
+				slog.String("table", snapshot.SourceTable))
+			if err := internal.DeleteIncrementalSnapshot(ctx, p.catalogPool, p.flowJobName, snapshot.SourceTable); err != nil {
+				return err
+			}
+			continue
+		}
+		if snapshot.PendingLSN != 0 {
+			synced := snapshot.PendingLSN <= syncedOffset
+			finished := synced && snapshot.PendingDone
+			if err := internal.SettleIncrementalSnapshotChunk(ctx, p.catalogPool, p.flowJobName, snapshot, synced); err != nil {
+				return err
+			}
+			if finished {
+				p.logger.Info("incremental snapshot finished", slog.String("table", snapshot.SourceTable))
+				continue
+			}
+		}
+		return p.readIncrementalSnapshotChunk(ctx, snapshot.SourceTable, snapshot.LastKey, chunkSize)
+	}
+	return nil
+}
+
+// continueIncrementalSnapshot reads the next chunk after one was emitted by this pull,
+// tables with chunks emitted by earlier pulls wait for the next pull to settle them
+func (p *PostgresCDCSource) continueIncrementalSnapshot(
+	ctx context.Context,
+	emitted *incrementalSnapshotWindow,
+	chunkSize uint64,
+) error {
+	if !emitted.done {
+		return p.readIncrementalSnapshotChunk(ctx, emitted.sourceTable, emitted.lastKey, chunkSize)
+	}
+	snapshots, err := internal.LoadIncrementalSnapshots(ctx, p.catalogPool, p.flowJobName)
+	if err != nil {
+		return err
+	}
+	for _, snapshot := range snapshots {
+		if _, ok := p.tableNameMapping[snapshot.SourceTable]; ok && snapshot.PendingLSN == 0 {
+			return p.readIncrementalSnapshotChunk(ctx, snapshot.SourceTable, snapshot.LastKey, chunkSize)
+		}
+	}
+	return nil
+}
+
+// readIncrementalSnapshotChunk reads rows past lastKey in primary key order between two watermarks
+func (p *PostgresCDCSource) readIncrementalSnapshotChunk(
+	ctx context.Context,
+	sourceTable string,
+	lastKey []string,
+	chunkSize uint64,
+) error {
+	schema := p.tableNameSchemaMapping[p.tableNameMapping[sourceTable].Name]
+	if schema == nil || len(schema.PrimaryKeyColumns) == 0 {
+		p.logger.Warn("incremental snapshot needs a table with a primary key, dropping snapshot",
+			slog.String("table", sourceTable))
+		return internal.DeleteIncrementalSnapshot(ctx, p.catalogPool, p.flowJobName, sourceTable)
+	}
+	var relID uint32
+	for id, name := range p.srcTableIDNameMapping {
+		if name == sourceTable {
+			relID = id
+			break
+		}
+	}
+	parsedTable, err := utils.ParseSchemaTable(sourceTable)
+	if err != nil {
+		return err
+	}
+
+	quotedColumns := make([]string, 0, len(schema.Columns))
+	for _, col := range schema.Columns {
+		quotedColumns = append(quotedColumns, utils.QuoteIdentifier(col.Name))
+	}
+	quotedKeys := make([]string, 0, len(schema.PrimaryKeyColumns))
+	for _, col := range schema.PrimaryKeyColumns {
+		quotedKeys = append(quotedKeys, utils.QuoteIdentifier(col))
+	}
+	keyList := strings.Join(quotedKeys, ",")
+	args := make([]any, 0, len(lastKey)+1)
+	args = append(args, pgx.QueryResultFormats{pgx.TextFormatCode})
+	where := ""
+	if len(lastKey) > 0 {
+		placeholders := make([]string, 0, len(lastKey))
+		for i, value := range lastKey {
+			placeholders = append(placeholders, fmt.Sprintf("$%d", i+1))
+			args = append(args, value)
+		}
+		where = fmt.Sprintf(" WHERE (%s) > (%s)", keyList, strings.Join(placeholders, ","))
+	}
+	query := fmt.Sprintf("SELECT %s FROM %s%s ORDER BY %s LIMIT %d",
+		strings.Join(quotedColumns, ","), parsedTable.String(), where, keyList, chunkSize)
+
+	window := &incrementalSnapshotWindow{
+		sourceTable: sourceTable,
+		id:          uuid.NewString(),
+		relID:       relID,
+		keyColumns:  schema.PrimaryKeyColumns,
+		lastKey:     lastKey,
+		rows:        make(map[string]*pglogrepl.TupleData),
+	}
+
+	if err := p.emitIncrementalSnapshotWatermark(ctx, "low", window.id); err != nil {
+		return err
+	}
+	rows, err := p.conn.Query(ctx, query, args...)
+	if err != nil {
+		return fmt.Errorf("failed to read incremental snapshot chunk of %s: %w", sourceTable, err)
+	}
+	fields := rows.FieldDescriptions()
+	window.rel = &pglogrepl.RelationMessage{
+		RelationID:   relID,
+		Namespace:    parsedTable.Schema,
+		RelationName: parsedTable.Table,
+		Columns:      make([]*pglogrepl.RelationMessageColumn, 0, len(fields)),
+	}
+	for _, field := range fields {
+		window.rel.Columns = append(window.rel.Columns, &pglogrepl.RelationMessageColumn{
+			Name:         field.Name,
+			DataType:     field.DataTypeOID,
+			TypeModifier: field.TypeModifier,
+		})
+	}
+	var rowCount uint64
+	for rows.Next() {
+		values := rows.RawValues()
+		tuple := &pglogrepl.TupleData{
+			ColumnNum: uint16(len(values)),
+			Columns:   make([]*pglogrepl.TupleDataColumn, 0, len(values)),
+		}
+		for _, value := range values {
+			if value == nil {
+				tuple.Columns = append(tuple.Columns, &pglogrepl.TupleDataColumn{DataType: pglogrepl.TupleDataTypeNull})
+			} else {
+				// raw values are only valid until the next row
+				tuple.Columns = append(tuple.Columns, &pglogrepl.TupleDataColumn{
+					DataType: pglogrepl.TupleDataTypeText,
+					Length:   uint32(len(value)),
+					Data:     bytes.Clone(value),
+				})
+			}
+		}
+		key, ok := incrementalSnapshotKey(window.rel, tuple, window.keyColumns)
+		if !ok {
+			rows.Close()
+			return fmt.Errorf("null primary key in incremental snapshot chunk of %s", sourceTable)
+		}
+		window.rows[key] = tuple
+		window.keys = append(window.keys, key)
+		window.lastKey = strings.Split(key, "\x00")
+		rowCount += 1
+	}
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("failed to read incremental snapshot chunk of %s: %w", sourceTable, err)
+	}
+	window.done = rowCount < chunkSize
+
+	if err := p.emitIncrementalSnapshotWatermark(ctx, "high", window.id); err != nil {
+		return err
+	}
+	p.incrementalSnapshot = window
+	p.logger.Info("read incremental snapshot chunk",
+		slog.String("table", sourceTable), slog.Uint64("rows", rowCount), slog.Bool("last", window.done))
+	return nil
+}
+
+func (p *PostgresCDCSource) emitIncrementalSnapshotWatermark(ctx context.Context, watermark string, id string) error {
+	// non transactional so the watermark is decoded at its position even while other transactions are open
+	if _, err := p.conn.Exec(ctx, "SELECT pg_logical_emit_message(false, $1, $2)",
+		incrementalSnapshotMessagePrefix, watermark+" "+id,
+	); err != nil {
+		return fmt.Errorf("failed to write %s watermark of incremental snapshot: %w", watermark, err)
+	}
+	return nil
+}
+
+// processIncrementalSnapshotWatermark opens the chunk's window at its low watermark,
+// and emits what is left of the chunk at its high watermark. Watermarks of chunks lost to a restart are ignored,
+// their tables continue from the last chunk known to be synced
+func processIncrementalSnapshotWatermark[Items model.Items](
+	ctx context.Context,
+	p *PostgresCDCSource,
+	processor replProcessor[Items],
+	msg *model.MessageRecord[Items],
+	tableNameSchemaMapping map[string]*protos.TableSchema,
+	addRecordWithKey func(model.TableWithPkey, model.Record[Items]) error,
+	chunkSize uint64,
+) error {
+	watermark, id, _ := strings.Cut(msg.Content, " ")
+	window := p.incrementalSnapshot
+	if window == nil || window.id != id {
+		return nil
+	}
+	if watermark == "low" {
+		window.lowSeen = true
+		return nil
+	} else if watermark != "high" {
+		return nil
+	}
+
+	customTypeMapping, err := p.fetchCustomTypeMapping(ctx)
+	if err != nil {
+		return err
+	}
+	schemaName, err := p.getSourceSchemaForDestinationColumn(window.relID, window.sourceTable)
+	if err != nil {
+		return err
+	}
+	nameAndExclude := p.tableNameMapping[window.sourceTable]
+	isFullReplica := tableNameSchemaMapping[nameAndExclude.Name].IsReplicaIdentityFull
+	for _, key := range window.keys {
+		tuple, ok := window.rows[key]
+		if !ok {
+			continue
+		}
+		items, _, err := processTuple(processor, p, tuple, window.rel, nameAndExclude, customTypeMapping, schemaName)
+		if err != nil {
+			return fmt.Errorf("error converting incremental snapshot row: %w", err)
+		}
+		rec := &model.InsertRecord[Items]{
+			BaseRecord:           msg.BaseRecord,
+			Items:                items,
+			DestinationTableName: nameAndExclude.Name,
+			SourceTableName:      window.sourceTable,
+		}
+		var tablePkeyVal model.TableWithPkey
+		if !isFullReplica {
+			if tablePkeyVal, err = model.RecToTablePKey(tableNameSchemaMapping, rec); err != nil {
+				return err
+			}
+		}
+		if err := addRecordWithKey(tablePkeyVal, rec); err != nil {
+			return err
+		}
+	}
+
+	if err := internal.SaveIncrementalSnapshotChunk(
+		ctx, p.catalogPool, p.flowJobName, window.sourceTable, window.lastKey, msg.CheckpointID, window.done,
+	); err != nil {
+		return err
+	}
+	p.incrementalSnapshot = nil
+	return p.continueIncrementalSnapshot(ctx, window, chunkSize)
+}
+
+// incrementalSnapshotSupported is whether watermark messages are decoded, which needs Postgres 14
+func (p *PostgresCDCSource) incrementalSnapshotSupported() bool {
+	return p.pgVersion >= shared.POSTGRES_14
+}
@@ -0,0 +1,246 @@
+package connpostgres
+
+import (
+	"log/slog"
+	"maps"
+	"slices"
+	"testing"
+
+	"github.com/jackc/pglogrepl"
+	"github.com/stretchr/testify/require"
+	"go.temporal.io/sdk/log"
+
+	"github.com/PeerDB-io/peerdb/flow/model"
+)
+
+func TestIncrementalSnapshotKey(t *testing.T) {
+	t.Parallel()
+
+	rel := &pglogrepl.RelationMessage{
+		Columns: []*pglogrepl.RelationMessageColumn{{Name: "id"}, {Name: "val"}, {Name: "tenant"}},
+	}
+	tuple := &pglogrepl.TupleData{
+		Columns: []*pglogrepl.TupleDataColumn{
+			{DataType: pglogrepl.TupleDataTypeText, Data: []byte("42")},
+			{DataType: pglogrepl.TupleDataTypeToast},
+			{DataType: pglogrepl.TupleDataTypeText, Data: []byte("acme")},
+		},
+	}
+
+	// key columns in primary key order, not table order
+	key, ok := incrementalSnapshotKey(rel, tuple, []string{"tenant", "id"})
+	require.True(t, ok)
+	require.Equal(t, "acme\x0042", key)
+
+	_, ok = incrementalSnapshotKey(rel, tuple, []string{"val"})
+	require.False(t, ok)
+	_, ok = incrementalSnapshotKey(rel, tuple, []string{"missing"})
+	require.False(t, ok)
+	_, ok = incrementalSnapshotKey(rel, nil, []string{"id"})
+	require.False(t, ok)
+}
+
+// incrementalSnapshotTestTuple builds a text tuple, an empty value stands for an unchanged TOAST column
+func incrementalSnapshotTestTuple(values ...string) *pglogrepl.TupleData {
+	tuple := &pglogrepl.TupleData{ColumnNum: uint16(len(values))}
+	for _, value := range values {
+		if value == "" {
+			tuple.Columns = append(tuple.Columns, &pglogrepl.TupleDataColumn{DataType: pglogrepl.TupleDataTypeToast})
+		} else {
+			tuple.Columns = append(tuple.Columns, &pglogrepl.TupleDataColumn{
+				DataType: pglogrepl.TupleDataTypeText,
+				Length:   uint32(len(value)),
+				Data:     []byte(value),
+			})
+		}
+	}
+	return tuple
+}
+
+// newIncrementalSnapshotTestSource replicates public.t(id, val) with chunk rows 1 and 2 read between watermarks
+func newIncrementalSnapshotTestSource() *PostgresCDCSource {
+	rel := &pglogrepl.RelationMessage{
+		RelationID:   1,
+		Namespace:    "public",
+		RelationName: "t",
+		Columns:      []*pglogrepl.RelationMessageColumn{{Name: "id"}, {Name: "val"}},
+	}
+	return &PostgresCDCSource{
+		PostgresConnector: &PostgresConnector{
+			logger: log.NewStructuredLogger(slog.Default()),
+			incrementalSnapshot: &incrementalSnapshotWindow{
+				rel:         rel,
+				sourceTable: "public.t",
+				id:          "chunk",
+				keyColumns:  []string{"id"},
+				keys:        []string{"1", "2"},
+				rows: map[string]*pglogrepl.TupleData{
+					"1": incrementalSnapshotTestTuple("1", "one"),
+					"2": incrementalSnapshotTestTuple("2", "two"),
+				},
+				relID: 1,
+			},
+		},
+		srcTableIDNameMapping:  map[uint32]string{1: "public.t"},
+		tableNameMapping:       map[string]model.NameAndExclude{"public.t": {Name: "public.t_dst"}},
+		relationMessageMapping: model.RelationMessageMapping{1: rel},
+	}
+}
+
+func TestIncrementalSnapshotWindow(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		name          string
+		table         string
+		key           string
+		watermarks    []string
+		wantRemaining []string
+		wantTaken     bool
+	}{
+		{
+			name:          "change before low watermark keeps row",
+			table:         "public.t",
+			key:           "1",
+			wantRemaining: []string{"1", "2"},
+		},
+		{
+			name:          "change after low watermark drops row",
+			table:         "public.t",
+			key:           "1",
+			watermarks:    []string{"low chunk"},
+			wantRemaining: []string{"2"},
+			wantTaken:     true,
+		},
+		{
+			name:          "low watermark of another chunk",
+			table:         "public.t",
+			key:           "1",
+			watermarks:    []string{"low lost"},
+			wantRemaining: []string{"1", "2"},
+		},
+		{
+			name:          "unknown watermark",
+			table:         "public.t",
+			key:           "1",
+			watermarks:    []string{"middle chunk"},
+			wantRemaining: []string{"1", "2"},
+		},
+		{
+			name:          "change to another table",
+			table:         "public.other",
+			key:           "1",
+			watermarks:    []string{"low chunk"},
+			wantRemaining: []string{"1", "2"},
+		},
+		{
+			name:          "change to row outside chunk",
+			table:         "public.t",
+			key:           "3",
+			watermarks:    []string{"low chunk"},
+			wantRemaining: []string{"1", "2"},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			p := newIncrementalSnapshotTestSource()
+			window := p.incrementalSnapshot
+			for _, watermark := range tc.watermarks {
+				// watermarks other than the chunk's high watermark don't touch the catalog
+				require.NoError(t, processIncrementalSnapshotWatermark(t.Context(), p, pgProcessor{},
+					&model.MessageRecord[model.PgItems]{Prefix: incrementalSnapshotMessagePrefix, Content: watermark},
+					nil, nil, 1000))
+			}
+
+			row := p.takeIncrementalSnapshotRow(tc.table, window.rel, incrementalSnapshotTestTuple(tc.key, "changed"))
+			require.Equal(t, tc.wantTaken, row != nil)
+			require.Equal(t, tc.wantRemaining, slices.Sorted(maps.Keys(window.rows)))
+			// a row is only superseded once
+			require.Nil(t, p.takeIncrementalSnapshotRow(tc.table, window.rel, incrementalSnapshotTestTuple(tc.key, "changed")))
+		})
+	}
+}
+
+func TestIncrementalSnapshotHooks(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		msg           pglogrepl.Message
+		name          string
+		wantVal       string
+		wantRemaining []string
+	}{
+		{
+			name:          "insert of chunk row",
+			msg:           &pglogrepl.InsertMessage{RelationID: 1, Tuple: incrementalSnapshotTestTuple("1", "new")},
+			wantRemaining: []string{"2"},
+		},
+		{
+			name:          "insert outside chunk",
+			msg:           &pglogrepl.InsertMessage{RelationID: 1, Tuple: incrementalSnapshotTestTuple("3", "new")},
+			wantRemaining: []string{"1", "2"},
+		},
+		{
+			name:          "update of chunk row",
+			msg:           &pglogrepl.UpdateMessage{RelationID: 1, NewTuple: incrementalSnapshotTestTuple("2", "new")},
+			wantVal:       "new",
+			wantRemaining: []string{"1"},
+		},
+		{
+			name: "update moving chunk row to another key",
+			msg: &pglogrepl.UpdateMessage{
+				RelationID: 1,
+				OldTuple:   incrementalSnapshotTestTuple("1", ""),
+				NewTuple:   incrementalSnapshotTestTuple("3", "new"),
+			},
+			wantVal:       "new",
+			wantRemaining: []string{"2"},
+		},
+		{
+			name:          "update with unchanged toast column of chunk row",
+			msg:           &pglogrepl.UpdateMessage{RelationID: 1, NewTuple: incrementalSnapshotTestTuple("1", "")},
+			wantVal:       "one",
+			wantRemaining: []string{"2"},
+		},
+		{
+			name:          "delete of chunk row",
+			msg:           &pglogrepl.DeleteMessage{RelationID: 1, OldTuple: incrementalSnapshotTestTuple("2", "")},
+			wantRemaining: []string{"1"},
+		},
+		{
+			name:          "delete of another table",
+			msg:           &pglogrepl.DeleteMessage{RelationID: 2, OldTuple: incrementalSnapshotTestTuple("2", "")},
+			wantRemaining: []string{"1", "2"},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			p := newIncrementalSnapshotTestSource()
+			window := p.incrementalSnapshot
+			window.lowSeen = true
+
+			var err error
+			switch msg := tc.msg.(type) {
+			case *pglogrepl.InsertMessage:
+				_, err = processInsertMessage(p, 0, msg, pgProcessor{}, nil)
+			case *pglogrepl.UpdateMessage:
+				var rec model.Record[model.PgItems]
+				rec, err = processUpdateMessage(p, 0, msg, pgProcessor{}, nil)
+				require.NoError(t, err)
+				update := rec.(*model.UpdateRecord[model.PgItems])
+				require.Equal(t, tc.wantVal, string(update.NewItems.GetColumnValue("val")))
+				require.Empty(t, update.UnchangedToastColumns)
+			case *pglogrepl.DeleteMessage:
+				_, err = processDeleteMessage(p, 0, msg, pgProcessor{}, nil)
+			}
+			require.NoError(t, err)
+			require.Equal(t, tc.wantRemaining, slices.Sorted(maps.Keys(window.rows)))
+		})
+	}
+}
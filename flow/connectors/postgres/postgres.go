@@ -51,10 +51,12 @@ type PostgresConnector struct {
 	relationMessageMapping model.RelationMessageMapping
 	typeMap                *pgtype.Map
 	rdsAuth                *utils.RDSAuth
-	connStr                string
-	metadataSchema         string
-	replLock               sync.Mutex
-	pgVersion              shared.PGVersion
+	// kept across pulls, watermarks of a chunk may be decoded by a later pull
+	incrementalSnapshot *incrementalSnapshotWindow
+	connStr             string
+	metadataSchema      string
+	replLock            sync.Mutex
+	pgVersion           shared.PGVersion
 }
 
 func NewPostgresConnector(ctx context.Context, env map[string]string, pgConfig *protos.PostgresConfig) (*PostgresConnector, error) {
@@ -500,6 +502,12 @@ func syncRecordsCore[Items model.Items](
 	c *PostgresConnector,
 	req *model.SyncRecordsRequest[Items],
 ) (*model.SyncResponse, error) {
+	if directApply, err := c.canDirectApply(ctx, req.Env, req.FlowJobName, req.SyncBatchID); err != nil {
+		return nil, err
+	} else if directApply {
+		return syncRecordsDirect(ctx, c, req)
+	}
+
 	rawTableIdentifier := getRawTableIdentifier(req.FlowJobName)
 	c.logger.Info(fmt.Sprintf("pushing records to Postgres table %s via COPY", rawTableIdentifier))
 
@@ -706,7 +714,10 @@ func (c *PostgresConnector) NormalizeRecords(
 	for _, destinationTableName := range destinationTableNames {
 		normalizeStatements := normalizeStmtGen.generateNormalizeStatements(destinationTableName)
 		for _, normalizeStatement := range normalizeStatements {
-			ct, err := normalizeRecordsTx.Exec(ctx, normalizeStatement, normBatchID, req.SyncBatchID, destinationTableName)
+			stmtCtx, span := otel_metrics.StartStatementSpan(
+				ctx, req.FlowJobName, destinationTableName, req.SyncBatchID, normalizeStatement)
+			ct, err := normalizeRecordsTx.Exec(stmtCtx, normalizeStatement, normBatchID, req.SyncBatchID, destinationTableName)
+			otel_metrics.EndSpan(span, err)
 			if err != nil {
 				c.logger.Error("error executing normalize statement",
 					slog.String("statement", normalizeStatement),
@@ -1035,40 +1046,47 @@ func (c *PostgresConnector) ReplayTableSchemaDeltas(
 	defer shared.RollbackTx(tableSchemaModifyTx, c.logger)
 
 	for _, schemaDelta := range schemaDeltas {
-		if schemaDelta == nil || len(schemaDelta.AddedColumns) == 0 {
-			continue
+		if err := c.replaySchemaDeltaTx(ctx, schemaDelta, tableSchemaModifyTx); err != nil {
+			return err
 		}
+	}
 
-		for _, addedColumn := range schemaDelta.AddedColumns {
-			columnType := addedColumn.Type
-			if schemaDelta.System == protos.TypeSystem_Q {
-				columnType = qValueKindToPostgresType(columnType)
-			}
+	if err := tableSchemaModifyTx.Commit(ctx); err != nil {
+		return fmt.Errorf("failed to commit transaction for table schema modification: %w", err)
+	}
+	return nil
+}
 
-			dstSchemaTable, err := utils.ParseSchemaTable(schemaDelta.DstTableName)
-			if err != nil {
-				return fmt.Errorf("error parsing schema and table for %s: %w", schemaDelta.DstTableName, err)
-			}
+func (c *PostgresConnector) replaySchemaDeltaTx(ctx context.Context, schemaDelta *protos.TableSchemaDelta, tx pgx.Tx) error {
+	if schemaDelta == nil || len(schemaDelta.AddedColumns) == 0 {
+		return nil
+	}
 
-			_, err = c.execWithLoggingTx(ctx, fmt.Sprintf(
-				"ALTER TABLE %s.%s ADD COLUMN IF NOT EXISTS %s %s",
-				utils.QuoteIdentifier(dstSchemaTable.Schema),
-				utils.QuoteIdentifier(dstSchemaTable.Table),
-				utils.QuoteIdentifier(addedColumn.Name), columnType), tableSchemaModifyTx)
-			if err != nil {
-				return fmt.Errorf("failed to add column %s for table %s: %w", addedColumn.Name,
-					schemaDelta.DstTableName, err)
-			}
-			c.logger.Info(fmt.Sprintf("[schema delta replay] added column %s with data type %s",
-				addedColumn.Name, addedColumn.Type),
-				slog.String("srcTableName", schemaDelta.SrcTableName),
-				slog.String("dstTableName", schemaDelta.DstTableName),
-			)
+	for _, addedColumn := range schemaDelta.AddedColumns {
+		columnType := addedColumn.Type
+		if schemaDelta.System == protos.TypeSystem_Q {
+			columnType = qValueKindToPostgresType(columnType)
 		}
-	}
 
-	if err := tableSchemaModifyTx.Commit(ctx); err != nil {
-		return fmt.Errorf("failed to commit transaction for table schema modification: %w", err)
+		dstSchemaTable, err := utils.ParseSchemaTable(schemaDelta.DstTableName)
+		if err != nil {
+			return fmt.Errorf("error parsing schema and table for %s: %w", schemaDelta.DstTableName, err)
+		}
+
+		_, err = c.execWithLoggingTx(ctx, fmt.Sprintf(
+			"ALTER TABLE %s.%s ADD COLUMN IF NOT EXISTS %s %s",
+			utils.QuoteIdentifier(dstSchemaTable.Schema),
+			utils.QuoteIdentifier(dstSchemaTable.Table),
+			utils.QuoteIdentifier(addedColumn.Name), columnType), tx)
+		if err != nil {
+			return fmt.Errorf("failed to add column %s for table %s: %w", addedColumn.Name,
+				schemaDelta.DstTableName, err)
+		}
+		c.logger.Info(fmt.Sprintf("[schema delta replay] added column %s with data type %s",
+			addedColumn.Name, addedColumn.Type),
+			slog.String("srcTableName", schemaDelta.SrcTableName),
+			slog.String("dstTableName", schemaDelta.DstTableName),
+		)
 	}
 	return nil
 }
@@ -1204,10 +1222,13 @@ func (c *PostgresConnector) SetupReplication(
 		return model.SetupReplicationResult{}, err
 	}
 
-	skipSnapshotExport, err := internal.PeerDBSkipSnapshotExport(ctx, req.Env)
-	if err != nil {
-		c.logger.Error("failed to check PEERDB_SKIP_SNAPSHOT_EXPORT, proceeding with export snapshot", slog.Any("error", err))
-		skipSnapshotExport = false
+	skipSnapshotExport := req.SkipSnapshotExport
+	if !skipSnapshotExport {
+		skipSnapshotExport, err = internal.PeerDBSkipSnapshotExport(ctx, req.Env)
+		if err != nil {
+			c.logger.Error("failed to check PEERDB_SKIP_SNAPSHOT_EXPORT, proceeding with export snapshot", slog.Any("error", err))
+			skipSnapshotExport = false
+		}
 	}
 
 	tableNameMapping := make(map[string]model.NameAndExclude, len(req.TableNameMapping))
@@ -23,7 +23,11 @@ import (
 	"github.com/PeerDB-io/peerdb/flow/shared"
 )
 
-const qRepMetadataTableName = "_peerdb_query_replication_metadata"
+const (
+	qRepMetadataTableName = "_peerdb_query_replication_metadata"
+	// keys sampled per partition when splitting a table into keyset ranges
+	keysetSamplesPerPartition = 100
+)
 
 type QRepPullSink interface {
 	Close(error)
@@ -65,6 +69,9 @@ func (c *PostgresConnector) GetQRepPartitions(
 		return nil, fmt.Errorf("failed to set transaction snapshot: %w", err)
 	}
 
+	if len(config.KeysetColumns) > 0 {
+		return c.getKeysetPartitions(ctx, getPartitionsTx, config)
+	}
 	return c.getNumRowsPartitions(ctx, getPartitionsTx, config, last)
 }
 
@@ -195,6 +202,67 @@ func (c *PostgresConnector) getNumRowsPartitions(
 	return partitionHelper.GetPartitions(), nil
 }
 
+// getKeysetPartitions splits the table at evenly spaced keys of a sample taken in key order
+func (c *PostgresConnector) getKeysetPartitions(
+	ctx context.Context,
+	tx pgx.Tx,
+	config *protos.QRepConfig,
+) ([]*protos.QRepPartition, error) {
+	parsedWatermarkTable, err := utils.ParseSchemaTable(config.WatermarkTable)
+	if err != nil {
+		return nil, fmt.Errorf("unable to parse watermark table: %w", err)
+	}
+
+	var totalRows pgtype.Int8
+	if err := tx.QueryRow(ctx, "SELECT COUNT(*) FROM "+parsedWatermarkTable.String()).Scan(&totalRows); err != nil {
+		return nil, fmt.Errorf("failed to query for total rows: %w", err)
+	}
+	if totalRows.Int64 == 0 {
+		c.logger.Warn("no records to replicate, returning")
+		return nil, nil
+	}
+
+	adjustedPartitions := shared.AdjustNumPartitions(totalRows.Int64, int64(config.NumRowsPerPartition))
+	sampleClause := ""
+	if sampleRows := adjustedPartitions.AdjustedNumPartitions * keysetSamplesPerPartition; sampleRows < totalRows.Int64 {
+		sampleClause = fmt.Sprintf(" TABLESAMPLE BERNOULLI(%s)",
+			strconv.FormatFloat(100*float64(sampleRows)/float64(totalRows.Int64), 'f', -1, 64))
+	}
+
+	quotedColumns := make([]string, 0, len(config.KeysetColumns))
+	textColumns := make([]string, 0, len(config.KeysetColumns))
+	for _, col := range config.KeysetColumns {
+		quotedColumns = append(quotedColumns, utils.QuoteIdentifier(col))
+		textColumns = append(textColumns, utils.QuoteIdentifier(col)+"::text")
+	}
+	samplesQuery := fmt.Sprintf("SELECT %s FROM %s%s ORDER BY %s",
+		strings.Join(textColumns, ","), parsedWatermarkTable.String(), sampleClause, strings.Join(quotedColumns, ","))
+	c.logger.Info("[keyset] samples query", slog.String("query", samplesQuery),
+		slog.Int64("totalRows", totalRows.Int64), slog.Int64("numPartitions", adjustedPartitions.AdjustedNumPartitions))
+
+	rows, err := tx.Query(ctx, samplesQuery)
+	if err != nil {
+		return nil, shared.LogError(c.logger, fmt.Errorf("failed to sample keys: %w", err))
+	}
+	samples, err := pgx.CollectRows(rows, func(row pgx.CollectableRow) ([]string, error) {
+		sample := make([]string, len(config.KeysetColumns))
+		scanArgs := make([]any, len(sample))
+		for i := range sample {
+			scanArgs[i] = &sample[i]
+		}
+		return sample, row.Scan(scanArgs...)
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to read sampled keys: %w", err)
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return nil, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return utils.KeysetPartitions(samples, adjustedPartitions.AdjustedNumPartitions), nil
+}
+
 func (c *PostgresConnector) getMinMaxValues(
 	ctx context.Context,
 	tx pgx.Tx,
@@ -310,6 +378,8 @@ func corePullQRepRecords(
 	sink QRepPullSink,
 ) (int64, int64, error) {
 	partitionIdLog := slog.String(string(shared.PartitionIDKey), partition.PartitionId)
+	throttle := c.newQRepThrottle(config)
+	defer throttle.Close()
 
 	if partition.FullTablePartition {
 		c.logger.Info("pulling full table partition", partitionIdLog)
@@ -318,10 +388,24 @@ func corePullQRepRecords(
 		if err != nil {
 			return 0, 0, fmt.Errorf("failed to create query executor: %w", err)
 		}
+		executor.throttle = throttle
 		return executor.ExecuteQueryIntoSink(ctx, sink, config.Query)
 	}
 	c.logger.Info("Obtained ranges for partition for PullQRepStream", partitionIdLog)
 
+	if keysetRange := partition.Range.GetKeysetRange(); keysetRange != nil {
+		query, queryArgs, err := BuildKeysetQuery(c.logger, config.Query, keysetRange)
+		if err != nil {
+			return 0, 0, err
+		}
+		executor, err := c.NewQRepQueryExecutorSnapshot(ctx, config.Version, config.SnapshotName, config.FlowJobName, partition.PartitionId)
+		if err != nil {
+			return 0, 0, fmt.Errorf("failed to create query executor: %w", err)
+		}
+		executor.throttle = throttle
+		return executor.ExecuteQueryIntoSink(ctx, sink, query, queryArgs...)
+	}
+
 	var rangeStart any
 	var rangeEnd any
 
@@ -359,6 +443,7 @@ func corePullQRepRecords(
 	if err != nil {
 		return 0, 0, fmt.Errorf("failed to create query executor: %w", err)
 	}
+	executor.throttle = throttle
 
 	numRecords, numBytes, err := executor.ExecuteQueryIntoSink(ctx, sink, query, rangeStart, rangeEnd)
 	if err != nil {
@@ -676,6 +761,40 @@ func BuildQuery(logger log.Logger, query string, flowJobName string) (string, er
 	return res, nil
 }
 
+// BuildKeysetQuery templates start and end as rows of parameters, left empty for open ended ranges,
+// for queries like `WHERE TRUE{{if .start}} AND (a,b) > {{.start}}{{end}}{{if .end}} AND (a,b) <= {{.end}}{{end}}`
+func BuildKeysetQuery(logger log.Logger, query string, keysetRange *protos.KeysetPartitionRange) (string, []any, error) {
+	tmpl, err := template.New("query").Parse(query)
+	if err != nil {
+		return "", nil, err
+	}
+
+	args := make([]any, 0, len(keysetRange.Start)+len(keysetRange.End))
+	paramRow := func(values []string) string {
+		if len(values) == 0 {
+			return ""
+		}
+		params := make([]string, 0, len(values))
+		for _, value := range values {
+			args = append(args, value)
+			params = append(params, "$"+strconv.Itoa(len(args)))
+		}
+		return "(" + strings.Join(params, ",") + ")"
+	}
+
+	buf := new(bytes.Buffer)
+	if err := tmpl.Execute(buf, map[string]any{
+		"start": paramRow(keysetRange.Start),
+		"end":   paramRow(keysetRange.End),
+	}); err != nil {
+		return "", nil, err
+	}
+	res := buf.String()
+
+	logger.Info("[pg] templated keyset query", slog.String("query", res))
+	return res, args, nil
+}
+
 // IsQRepPartitionSynced checks whether a specific partition is synced
 func (c *PostgresConnector) IsQRepPartitionSynced(ctx context.Context,
 	req *protos.IsQRepPartitionSyncedInput,
@@ -2,7 +2,10 @@ package connpostgres
 
 import (
 	"log/slog"
+	"reflect"
 	"testing"
+
+	"github.com/PeerDB-io/peerdb/flow/generated/protos"
 )
 
 func TestBuildQuery(t *testing.T) {
@@ -46,3 +49,48 @@ func TestBuildQuery(t *testing.T) {
 		})
 	}
 }
+
+func TestBuildKeysetQuery(t *testing.T) {
+	query := `SELECT * FROM t WHERE TRUE{{if .start}} AND ("a","b") > {{.start}}{{end}}{{if .end}} AND ("a","b") <= {{.end}}{{end}}`
+	testCases := []struct {
+		name         string
+		keysetRange  *protos.KeysetPartitionRange
+		expected     string
+		expectedArgs []any
+	}{
+		{
+			name:         "Open start",
+			keysetRange:  &protos.KeysetPartitionRange{End: []string{"x", "1"}},
+			expected:     `SELECT * FROM t WHERE TRUE AND ("a","b") <= ($1,$2)`,
+			expectedArgs: []any{"x", "1"},
+		},
+		{
+			name:         "Bounded",
+			keysetRange:  &protos.KeysetPartitionRange{Start: []string{"x", "1"}, End: []string{"y", "2"}},
+			expected:     `SELECT * FROM t WHERE TRUE AND ("a","b") > ($1,$2) AND ("a","b") <= ($3,$4)`,
+			expectedArgs: []any{"x", "1", "y", "2"},
+		},
+		{
+			name:         "Unbounded",
+			keysetRange:  &protos.KeysetPartitionRange{},
+			expected:     `SELECT * FROM t WHERE TRUE`,
+			expectedArgs: []any{},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			actual, args, err := BuildKeysetQuery(slog.Default(), query, tc.keysetRange)
+			if err != nil {
+				t.Fatalf("Error returned by BuildKeysetQuery: %v", err)
+			}
+
+			if actual != tc.expected {
+				t.Fatalf("Expected query %q, got %q", tc.expected, actual)
+			}
+			if !reflect.DeepEqual(args, tc.expectedArgs) {
+				t.Fatalf("Expected args %v, got %v", tc.expectedArgs, args)
+			}
+		})
+	}
+}
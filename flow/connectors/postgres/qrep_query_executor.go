@@ -19,6 +19,7 @@ import (
 type QRepQueryExecutor struct {
 	*PostgresConnector
 	logger      log.Logger
+	throttle    *qrepThrottle
 	snapshot    string
 	flowJobName string
 	partitionID string
@@ -119,11 +120,18 @@ func (qe *QRepQueryExecutor) processRowsStream(
 			qe.logger.Error("[pg_query_executor] failed to map row to QRecord", slog.Any("error", err))
 			return numRows, numBytes, fmt.Errorf("failed to map row to QRecord: %w", err)
 		}
-		stream.Records <- record
-		numRows++
+		var rowBytes int
 		for _, val := range rows.RawValues() {
-			numBytes += int64(len(val))
+			rowBytes += len(val)
+		}
+		if qe.throttle != nil {
+			if err := qe.throttle.Wait(ctx, 1, rowBytes); err != nil {
+				return numRows, numBytes, err
+			}
 		}
+		stream.Records <- record
+		numRows++
+		numBytes += int64(rowBytes)
 
 		if numRows%logPerRows == 0 {
 			qe.logger.Info("processing row stream", slog.String("cursor", cursorName),
@@ -0,0 +1,328 @@
+package connpostgres
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"log/slog"
+	"math"
+	"sync"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"go.temporal.io/sdk/log"
+	"golang.org/x/time/rate"
+
+	"github.com/PeerDB-io/peerdb/flow/generated/protos"
+	"github.com/PeerDB-io/peerdb/flow/internal"
+)
+
+const (
+	// how often settings are reloaded and source load is checked, so both can change while a partition is pulled
+	throttleRefreshInterval = 10 * time.Second
+	minThrottleBackoff      = 1.0 / 64
+)
+
+// sharedThrottle holds the token buckets of a mirror or source peer,
+// shared by all pulls on this worker that belong to it
+type sharedThrottle struct {
+	rows  *rate.Limiter
+	bytes *rate.Limiter
+	// last time source load was checked and the resulting state, only used for peers
+	loadCheckedAt time.Time
+	// fraction of configured caps in effect, halved while the source is overloaded
+	backoff    float64
+	overloaded bool
+	loadLock   sync.Mutex
+	refs       int
+}
+
+var (
+	sharedThrottles     = make(map[string]*sharedThrottle)
+	sharedThrottlesLock sync.Mutex
+)
+
+func acquireSharedThrottle(key string) *sharedThrottle {
+	sharedThrottlesLock.Lock()
+	defer sharedThrottlesLock.Unlock()
+	s, ok := sharedThrottles[key]
+	if !ok {
+		s = &sharedThrottle{
+			rows:    rate.NewLimiter(rate.Inf, 1),
+			bytes:   rate.NewLimiter(rate.Inf, 1),
+			backoff: 1,
+		}
+		sharedThrottles[key] = s
+	}
+	s.refs++
+	return s
+}
+
+func releaseSharedThrottle(key string) {
+	sharedThrottlesLock.Lock()
+	defer sharedThrottlesLock.Unlock()
+	if s, ok := sharedThrottles[key]; ok {
+		s.refs--
+		if s.refs <= 0 {
+			delete(sharedThrottles, key)
+		}
+	}
+}
+
+func (s *sharedThrottle) loadState() (float64, bool) {
+	s.loadLock.Lock()
+	defer s.loadLock.Unlock()
+	return s.backoff, s.overloaded
+}
+
+// checkLoad updates backoff from the source's replication lag and active connections,
+// skipping when another pull is already checking or the last check is recent
+func (s *sharedThrottle) checkLoad(
+	ctx context.Context, c *PostgresConnector, logger log.Logger, maxLagSeconds uint64, maxActiveConnections uint64,
+) {
+	if !s.loadLock.TryLock() {
+		return
+	}
+	defer s.loadLock.Unlock()
+	if time.Since(s.loadCheckedAt) < throttleRefreshInterval {
+		return
+	}
+	s.loadCheckedAt = time.Now()
+
+	lagSeconds, activeConnections, err := c.getSourceLoad(ctx)
+	if err != nil {
+		logger.Warn("[qrep-throttle] failed to check source load", slog.Any("error", err))
+		return
+	}
+	overloaded := (maxLagSeconds > 0 && lagSeconds > float64(maxLagSeconds)) ||
+		(maxActiveConnections > 0 && activeConnections > int64(maxActiveConnections))
+	if overloaded {
+		s.backoff = max(s.backoff/2, minThrottleBackoff)
+		logger.Info("[qrep-throttle] source overloaded, backing off",
+			slog.Float64("lagSeconds", lagSeconds), slog.Int64("activeConnections", activeConnections),
+			slog.Float64("backoff", s.backoff))
+	} else {
+		s.backoff = min(s.backoff*2, 1)
+	}
+	s.overloaded = overloaded
+}
+
+// getSourceLoad returns the replication lag in seconds, of this server if it is a standby or else of its slowest standby,
+// and the number of active client connections. Uses its own connection as the pull's connection is busy.
+// Logical walsenders, like those of CDC mirrors, aren't standbys, they're told apart by their slot as standbys may not use one
+func (c *PostgresConnector) getSourceLoad(ctx context.Context) (float64, int64, error) {
+	conn, err := pgx.ConnectConfig(ctx, c.conn.Config())
+	if err != nil {
+		return 0, 0, err
+	}
+	defer conn.Close(ctx)
+
+	var lagSeconds float64
+	var activeConnections int64
+	if err := conn.QueryRow(ctx, `SELECT
+		CASE WHEN pg_is_in_recovery() THEN
+			CASE WHEN pg_last_wal_receive_lsn() = pg_last_wal_replay_lsn() THEN 0
+			ELSE coalesce(extract(epoch FROM now() - pg_last_xact_replay_timestamp())::float8, 0) END
+		ELSE (SELECT coalesce(max(extract(epoch FROM r.replay_lag))::float8, 0) FROM pg_stat_replication r
+			WHERE NOT EXISTS (SELECT 1 FROM pg_replication_slots s WHERE s.active_pid = r.pid AND s.slot_type = 'logical')) END,
+		(SELECT count(*) FROM pg_stat_activity WHERE state = 'active' AND backend_type = 'client backend')`,
+	).Scan(&lagSeconds, &activeConnections); err != nil {
+		return 0, 0, err
+	}
+	return lagSeconds, activeConnections, nil
+}
+
+type throttleSettings struct {
+	mirrorRowsPerSecond  uint64
+	mirrorBytesPerSecond uint64
+	peerRowsPerSecond    uint64
+	peerBytesPerSecond   uint64
+	maxLagSeconds        uint64
+	maxActiveConnections uint64
+}
+
+func loadThrottleSettings(ctx context.Context, env map[string]string) (throttleSettings, error) {
+	var settings throttleSettings
+	var err error
+	if settings.mirrorRowsPerSecond, err = internal.PeerDBQRepPullMaxRowsPerSecond(ctx, env); err != nil {
+		return settings, err
+	}
+	if settings.mirrorBytesPerSecond, err = internal.PeerDBQRepPullMaxBytesPerSecond(ctx, env); err != nil {
+		return settings, err
+	}
+	// peer caps are shared across mirrors, so mirror env overrides do not apply to them
+	if settings.peerRowsPerSecond, err = internal.PeerDBQRepPullPeerMaxRowsPerSecond(ctx, nil); err != nil {
+		return settings, err
+	}
+	if settings.peerBytesPerSecond, err = internal.PeerDBQRepPullPeerMaxBytesPerSecond(ctx, nil); err != nil {
+		return settings, err
+	}
+	if settings.maxLagSeconds, err = internal.PeerDBQRepPullMaxSourceLagSeconds(ctx, env); err != nil {
+		return settings, err
+	}
+	if settings.maxActiveConnections, err = internal.PeerDBQRepPullMaxSourceActiveConnections(ctx, env); err != nil {
+		return settings, err
+	}
+	return settings, nil
+}
+
+// throttleLimit is the rate for a cap scaled by backoff, unlimited when the cap is 0
+func throttleLimit(limitPerSecond uint64, backoff float64) rate.Limit {
+	if limitPerSecond == 0 {
+		return rate.Inf
+	}
+	return rate.Limit(max(float64(limitPerSecond)*backoff, 1))
+}
+
+func setThrottleLimit(limiter *rate.Limiter, limit rate.Limit) {
+	if limiter.Limit() == limit {
+		return
+	}
+	limiter.SetLimit(limit)
+	// allow up to one second worth of tokens in a burst
+	limiter.SetBurst(int(min(float64(limit), math.MaxInt32)))
+}
+
+// throttleLimiter is the part of rate.Limiter waitThrottle uses
+type throttleLimiter interface {
+	Limit() rate.Limit
+	Burst() int
+	WaitN(ctx context.Context, n int) error
+}
+
+// waitThrottle waits for n tokens, in chunks as n may exceed the burst
+func waitThrottle(ctx context.Context, limiter throttleLimiter, n int) error {
+	if limiter.Limit() == rate.Inf {
+		return nil
+	}
+	for n > 0 {
+		chunk := min(n, limiter.Burst())
+		if err := limiter.WaitN(ctx, chunk); err != nil {
+			// another pull sharing the limiter lowered the burst after it was read, retry with the new one
+			if ctx.Err() == nil && chunk > limiter.Burst() {
+				continue
+			}
+			return err
+		}
+		n -= chunk
+	}
+	return nil
+}
+
+// qrepThrottle paces a single QRep pull against per mirror and per source peer caps,
+// and backs off while the source reports replication lag or too many active connections
+type qrepThrottle struct {
+	refreshedAt time.Time
+	conn        *PostgresConnector
+	logger      log.Logger
+	env         map[string]string
+	mirror      *sharedThrottle
+	peer        *sharedThrottle
+	mirrorKey   string
+	peerKey     string
+	settings    throttleSettings
+	paused      bool
+}
+
+func (c *PostgresConnector) newQRepThrottle(config *protos.QRepConfig) *qrepThrottle {
+	// tables of a CDC initial load are pulled under their own flow job names
+	mirrorName := config.ParentMirrorName
+	if mirrorName == "" {
+		mirrorName = config.FlowJobName
+	}
+	t := &qrepThrottle{
+		conn:      c,
+		logger:    c.logger,
+		env:       config.Env,
+		mirrorKey: "mirror:" + mirrorName,
+	}
+	t.mirror = acquireSharedThrottle(t.mirrorKey)
+	if config.SourceName != "" {
+		t.peerKey = "peer:" + config.SourceName
+		t.peer = acquireSharedThrottle(t.peerKey)
+	}
+	return t
+}
+
+func (t *qrepThrottle) Close() {
+	releaseSharedThrottle(t.mirrorKey)
+	if t.peer != nil {
+		releaseSharedThrottle(t.peerKey)
+	}
+}
+
+func (t *qrepThrottle) refresh(ctx context.Context) {
+	t.refreshedAt = time.Now()
+	if settings, err := loadThrottleSettings(ctx, t.env); err != nil {
+		t.logger.Warn("[qrep-throttle] failed to load settings, keeping previous", slog.Any("error", err))
+	} else {
+		t.settings = settings
+	}
+
+	backoff, overloaded := 1.0, false
+	if t.peer != nil && (t.settings.maxLagSeconds > 0 || t.settings.maxActiveConnections > 0) {
+		t.peer.checkLoad(ctx, t.conn, t.logger, t.settings.maxLagSeconds, t.settings.maxActiveConnections)
+		backoff, overloaded = t.peer.loadState()
+	}
+
+	setThrottleLimit(t.mirror.rows, throttleLimit(t.settings.mirrorRowsPerSecond, backoff))
+	setThrottleLimit(t.mirror.bytes, throttleLimit(t.settings.mirrorBytesPerSecond, backoff))
+	if t.peer != nil {
+		setThrottleLimit(t.peer.rows, throttleLimit(t.settings.peerRowsPerSecond, backoff))
+		setThrottleLimit(t.peer.bytes, throttleLimit(t.settings.peerBytesPerSecond, backoff))
+	}
+
+	// without any cap there is no rate to reduce, so stop pulling until the source recovers
+	t.paused = overloaded && t.settings.mirrorRowsPerSecond == 0 && t.settings.mirrorBytesPerSecond == 0 &&
+		t.settings.peerRowsPerSecond == 0 && t.settings.peerBytesPerSecond == 0
+}
+
+// Wait blocks until numRows rows totalling numBytes bytes may be pulled
+func (t *qrepThrottle) Wait(ctx context.Context, numRows int, numBytes int) error {
+	for {
+		if time.Since(t.refreshedAt) >= throttleRefreshInterval {
+			t.refresh(ctx)
+		}
+		if !t.paused {
+			break
+		}
+		t.logger.Info("[qrep-throttle] pausing pull until source load recovers")
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(time.Until(t.refreshedAt.Add(throttleRefreshInterval))):
+		}
+	}
+
+	if err := waitThrottle(ctx, t.mirror.rows, numRows); err != nil {
+		return err
+	}
+	if err := waitThrottle(ctx, t.mirror.bytes, numBytes); err != nil {
+		return err
+	}
+	if t.peer != nil {
+		if err := waitThrottle(ctx, t.peer.rows, numRows); err != nil {
+			return err
+		}
+		if err := waitThrottle(ctx, t.peer.bytes, numBytes); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+type writerFunc func(p []byte) (int, error)
+
+func (f writerFunc) Write(p []byte) (int, error) {
+	return f(p)
+}
+
+// throttledWriter paces COPY TO STDOUT output, where every row ends in a newline as newlines in values are escaped
+func throttledWriter(ctx context.Context, w io.Writer, throttle *qrepThrottle) io.Writer {
+	return writerFunc(func(p []byte) (int, error) {
+		if err := throttle.Wait(ctx, bytes.Count(p, []byte{'\n'}), len(p)); err != nil {
+			return 0, err
+		}
+		return w.Write(p)
+	})
+}
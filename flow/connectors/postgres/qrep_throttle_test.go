@@ -0,0 +1,66 @@
+package connpostgres
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"golang.org/x/time/rate"
+)
+
+func TestThrottleLimit(t *testing.T) {
+	t.Parallel()
+
+	require.Zero(t, rate.Inf-throttleLimit(0, 0.5))
+	require.InDelta(t, 1000, float64(throttleLimit(1000, 1)), 0)
+	require.InDelta(t, 250, float64(throttleLimit(1000, 0.25)), 0)
+	require.InDelta(t, 1, float64(throttleLimit(10, minThrottleBackoff)), 0)
+}
+
+func TestThrottledWriter(t *testing.T) {
+	t.Parallel()
+
+	throttle := &qrepThrottle{
+		refreshedAt: time.Now(),
+		mirror:      &sharedThrottle{rows: rate.NewLimiter(rate.Inf, 1), bytes: rate.NewLimiter(rate.Inf, 1)},
+	}
+	setThrottleLimit(throttle.mirror.rows, 2)
+
+	var out bytes.Buffer
+	tw := throttledWriter(t.Context(), &out, throttle)
+	start := time.Now()
+	// first two rows fit in the burst, the next two wait for a second of tokens
+	n, err := tw.Write([]byte("1\tpeer\n2\tdb\\nescaped\n3\tx\n4\ty\n"))
+	require.NoError(t, err)
+	require.Equal(t, out.Len(), n)
+	require.GreaterOrEqual(t, time.Since(start), 900*time.Millisecond)
+}
+
+// loweringLimiter lowers the limit right after the first burst is read, like a concurrent refresh would
+type loweringLimiter struct {
+	*rate.Limiter
+	lowered bool
+}
+
+func (l *loweringLimiter) Burst() int {
+	burst := l.Limiter.Burst()
+	if !l.lowered {
+		l.lowered = true
+		setThrottleLimit(l.Limiter, 100_000)
+	}
+	return burst
+}
+
+func TestWaitThrottleBurstLowered(t *testing.T) {
+	t.Parallel()
+
+	limiter := &loweringLimiter{Limiter: rate.NewLimiter(rate.Inf, 1)}
+	setThrottleLimit(limiter.Limiter, 1_000_000)
+
+	start := time.Now()
+	// the first chunk is read as 150k before the burst drops to 100k, it is retried as 100k and 50k chunks at 100k/s
+	require.NoError(t, waitThrottle(t.Context(), limiter, 150_000))
+	require.True(t, limiter.lowered)
+	require.GreaterOrEqual(t, time.Since(start), 1400*time.Millisecond)
+}
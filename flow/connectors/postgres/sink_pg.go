@@ -83,7 +83,11 @@ func (p PgCopyWriter) ExecuteQueryWithTx(
 
 	copyQuery := fmt.Sprintf("COPY (%s) TO STDOUT", query)
 	qe.logger.Info("[pg_query_executor] executing copy", slog.String("query", copyQuery))
-	ct, err := qe.conn.PgConn().CopyTo(ctx, p.PipeWriter, copyQuery)
+	var w io.Writer = p.PipeWriter
+	if qe.throttle != nil {
+		w = throttledWriter(ctx, w, qe.throttle)
+	}
+	ct, err := qe.conn.PgConn().CopyTo(ctx, w, copyQuery)
 	if err != nil {
 		qe.logger.Info("[pg_query_executor] failed to copy",
 			slog.String("copyQuery", copyQuery), slog.Any("error", err))
@@ -0,0 +1,24 @@
+package connpostgres
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/PeerDB-io/peerdb/flow/generated/protos"
+	"github.com/PeerDB-io/peerdb/flow/internal"
+)
+
+// the catalog is a primary, snapshot peers have to be standbys
+func TestSnapshotReplicaOnPrimary(t *testing.T) {
+	t.Parallel()
+
+	connector, err := NewPostgresConnector(t.Context(), nil, internal.GetCatalogPostgresConfigFromEnv(t.Context()))
+	require.NoError(t, err)
+	defer connector.Close()
+
+	require.ErrorContains(t, connector.ValidateSnapshotReplica(t.Context(), &protos.FlowConnectionConfigs{}),
+		"must be a streaming replica")
+	require.ErrorContains(t, connector.WaitForReplayLSN(t.Context(), "0/0"), "not a standby")
+	require.ErrorContains(t, connector.WaitForReplayLSN(t.Context(), "not an lsn"), "error while parsing LSN")
+}
@@ -257,3 +257,70 @@ func (c *PostgresConnector) ValidateMirrorSource(ctx context.Context, cfg *proto
 
 	return nil
 }
+
+// ValidateSnapshotReplica checks that this peer is a standby able to serve the initial load of a mirror on its primary
+func (c *PostgresConnector) ValidateSnapshotReplica(ctx context.Context, cfg *protos.FlowConnectionConfigs) error {
+	var inRecovery bool
+	if err := c.conn.QueryRow(ctx, "SELECT pg_is_in_recovery()").Scan(&inRecovery); err != nil {
+		return fmt.Errorf("failed to check if Postgres is in recovery: %w", err)
+	}
+	if !inRecovery {
+		return errors.New("snapshot peer must be a streaming replica of the source")
+	}
+
+	// long running snapshot queries are otherwise canceled by recovery conflicts
+	var hsFeedback bool
+	if err := c.conn.QueryRow(
+		ctx, "SELECT setting::bool FROM pg_settings WHERE name='hot_standby_feedback'",
+	).Scan(&hsFeedback); err != nil {
+		return fmt.Errorf("failed to check hot_standby_feedback: %w", err)
+	}
+	if !hsFeedback {
+		return errors.New("hot_standby_feedback setting must be enabled on the snapshot peer")
+	}
+
+	sourceTables := make([]*utils.SchemaTable, 0, len(cfg.TableMappings))
+	for _, tableMapping := range cfg.TableMappings {
+		parsedTable, err := utils.ParseSchemaTable(tableMapping.SourceTableIdentifier)
+		if err != nil {
+			return fmt.Errorf("invalid source table identifier: %w", err)
+		}
+		sourceTables = append(sourceTables, parsedTable)
+	}
+	if err := c.CheckSourceTables(ctx, sourceTables, cfg.TableMappings, "", true); err != nil {
+		return fmt.Errorf("provided source tables invalidated on snapshot peer: %w", err)
+	}
+
+	return nil
+}
+
+// CheckTablesHaveUniqueKey checks that rows of every source table are identified by a primary key
+// or replica identity index, which initial loads that upsert rows deduplicate them by
+func (c *PostgresConnector) CheckTablesHaveUniqueKey(ctx context.Context, tableMappings []*protos.TableMapping) error {
+	var keyless []string
+	for _, tableMapping := range tableMappings {
+		schemaTable, err := utils.ParseSchemaTable(tableMapping.SourceTableIdentifier)
+		if err != nil {
+			return fmt.Errorf("invalid source table identifier: %w", err)
+		}
+		relID, err := c.getRelIDForTable(ctx, schemaTable)
+		if err != nil {
+			return err
+		}
+		replicaIdentity, err := c.getReplicaIdentityType(ctx, relID, schemaTable)
+		if err != nil {
+			return fmt.Errorf("error getting replica identity for table %s: %w", schemaTable, err)
+		}
+		keyColumns, err := c.getUniqueColumns(ctx, relID, replicaIdentity, schemaTable)
+		if err != nil {
+			return fmt.Errorf("error getting primary key column for table %s: %w", schemaTable, err)
+		}
+		if len(keyColumns) == 0 {
+			keyless = append(keyless, schemaTable.String())
+		}
+	}
+	if len(keyless) > 0 {
+		return fmt.Errorf("tables without a primary key can't be deduplicated: %s", strings.Join(keyless, ", "))
+	}
+	return nil
+}
@@ -0,0 +1,115 @@
+package connredis
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/PeerDB-io/peerdb/flow/model"
+)
+
+const tablePlaceholder = "table"
+
+type keyPart struct {
+	literal string
+	column  string
+}
+
+// keyTemplate builds the key of a row from literal text and {placeholders},
+// {table} stands for the destination table and any other name for the value of that column
+type keyTemplate struct {
+	parts []keyPart
+}
+
+func parseKeyTemplate(template string) (*keyTemplate, error) {
+	var parts []keyPart
+	rest := template
+	for rest != "" {
+		start := strings.IndexByte(rest, '{')
+		if start == -1 {
+			parts = append(parts, keyPart{literal: rest})
+			break
+		}
+		if start > 0 {
+			parts = append(parts, keyPart{literal: rest[:start]})
+		}
+		end := strings.IndexByte(rest[start:], '}')
+		if end == -1 {
+			return nil, fmt.Errorf("unclosed placeholder in key template %q", template)
+		}
+		name := rest[start+1 : start+end]
+		if name == "" {
+			return nil, fmt.Errorf("empty placeholder in key template %q", template)
+		}
+		parts = append(parts, keyPart{column: name})
+		rest = rest[start+end+1:]
+	}
+	if len(parts) == 0 {
+		return nil, errors.New("key template is empty")
+	}
+	return &keyTemplate{parts: parts}, nil
+}
+
+// defaultKeyTemplate is {table}:{pk1}:{pk2}... over the given key columns
+func defaultKeyTemplate(keyColumns []string) *keyTemplate {
+	parts := make([]keyPart, 0, 2*len(keyColumns)+1)
+	parts = append(parts, keyPart{column: tablePlaceholder})
+	for _, column := range keyColumns {
+		parts = append(parts, keyPart{literal: ":"}, keyPart{column: column})
+	}
+	return &keyTemplate{parts: parts}
+}
+
+func (t *keyTemplate) render(table string, items model.RecordItems) (string, error) {
+	var key strings.Builder
+	for _, part := range t.parts {
+		switch part.column {
+		case "":
+			key.WriteString(part.literal)
+		case tablePlaceholder:
+			key.WriteString(table)
+		default:
+			value, err := items.GetValueByColName(part.column)
+			if err != nil {
+				return "", fmt.Errorf("cannot build key for table %s: %w", table, err)
+			}
+			if value == nil || value.Value() == nil {
+				return "", fmt.Errorf("cannot build key for table %s: column %s is null", table, part.column)
+			}
+			fmt.Fprint(&key, value.Value())
+		}
+	}
+	return key.String(), nil
+}
+
+// hashFields converts a row to hash fields, using the JSON representation of values so they read the same as in
+// JSON values and queue messages; strings are stored unquoted and null columns are returned separately to be removed
+func hashFields(items model.RecordItems) (map[string]any, []string, error) {
+	rowJSON, err := items.MarshalJSON()
+	if err != nil {
+		return nil, nil, err
+	}
+	var row map[string]json.RawMessage
+	if err := json.Unmarshal(rowJSON, &row); err != nil {
+		return nil, nil, err
+	}
+
+	fields := make(map[string]any, len(row))
+	var nullFields []string
+	for column, raw := range row {
+		switch {
+		case string(raw) == "null":
+			nullFields = append(nullFields, column)
+		case len(raw) > 0 && raw[0] == '"':
+			var value string
+			if err := json.Unmarshal(raw, &value); err != nil {
+				return nil, nil, fmt.Errorf("failed to decode column %s: %w", column, err)
+			}
+			fields[column] = value
+		default:
+			fields[column] = string(raw)
+		}
+	}
+	return fields, nullFields, nil
+}
@@ -0,0 +1,63 @@
+package connredis
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/PeerDB-io/peerdb/flow/model"
+	"github.com/PeerDB-io/peerdb/flow/shared/types"
+)
+
+func testItems() model.RecordItems {
+	items := model.NewRecordItems(5)
+	items.AddColumn("id", types.QValueInt64{Val: 42})
+	items.AddColumn("region", types.QValueString{Val: "eu"})
+	items.AddColumn("active", types.QValueBoolean{Val: true})
+	items.AddColumn("attributes", types.QValueJSON{Val: `{"a":1}`})
+	items.AddColumn("deleted_at", types.QValueNull(types.QValueKindTimestamp))
+	return items
+}
+
+func TestKeyTemplate(t *testing.T) {
+	t.Parallel()
+
+	tmpl, err := parseKeyTemplate("cache:{table}:{region}/{id}")
+	require.NoError(t, err)
+	key, err := tmpl.render("public.users", testItems())
+	require.NoError(t, err)
+	require.Equal(t, "cache:public.users:eu/42", key)
+
+	key, err = defaultKeyTemplate([]string{"region", "id"}).render("users", testItems())
+	require.NoError(t, err)
+	require.Equal(t, "users:eu:42", key)
+
+	_, err = parseKeyTemplate("user:{id")
+	require.Error(t, err)
+	_, err = parseKeyTemplate("user:{}")
+	require.Error(t, err)
+
+	tmpl, err = parseKeyTemplate("user:{missing}")
+	require.NoError(t, err)
+	_, err = tmpl.render("users", testItems())
+	require.Error(t, err)
+
+	tmpl, err = parseKeyTemplate("user:{deleted_at}")
+	require.NoError(t, err)
+	_, err = tmpl.render("users", testItems())
+	require.Error(t, err)
+}
+
+func TestHashFields(t *testing.T) {
+	t.Parallel()
+
+	fields, nullFields, err := hashFields(testItems())
+	require.NoError(t, err)
+	require.Equal(t, map[string]any{
+		"id":         "42",
+		"region":     "eu",
+		"active":     "true",
+		"attributes": `{"a":1}`,
+	}, fields)
+	require.Equal(t, []string{"deleted_at"}, nullFields)
+}
@@ -0,0 +1,85 @@
+package connredis
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/PeerDB-io/peerdb/flow/generated/protos"
+	"github.com/PeerDB-io/peerdb/flow/model"
+	"github.com/PeerDB-io/peerdb/flow/shared"
+)
+
+func (*RedisConnector) SetupQRepMetadataTables(_ context.Context, _ *protos.QRepConfig) error {
+	return nil
+}
+
+func (c *RedisConnector) SyncQRepRecords(
+	ctx context.Context,
+	config *protos.QRepConfig,
+	partition *protos.QRepPartition,
+	stream *model.QRecordStream,
+) (int64, shared.QRepWarnings, error) {
+	startTime := time.Now()
+	schema, err := stream.Schema()
+	if err != nil {
+		return 0, nil, err
+	}
+
+	// initial load runs in upsert mode over the primary key, so rows get the same keys as in CDC
+	tmpl, err := c.tableKeyTemplate(make(map[string]*keyTemplate), config.DestinationTableIdentifier,
+		config.GetWriteMode().GetUpsertKeyColumns())
+	if err != nil {
+		return 0, nil, err
+	}
+
+	ls, err := c.loadScript(ctx, config.Script, config.FlowJobName)
+	if err != nil {
+		return 0, nil, err
+	}
+	if ls != nil {
+		defer ls.Close()
+	}
+
+	var numRecords int64
+	pipe := c.client.Pipeline()
+	for qRecord := range stream.Records {
+		items := model.NewRecordItems(len(qRecord))
+		for i, val := range qRecord {
+			items.AddColumn(schema.Fields[i].Name, val)
+		}
+		record := &model.InsertRecord[model.RecordItems]{
+			BaseRecord:           model.BaseRecord{},
+			Items:                items,
+			SourceTableName:      config.WatermarkTable,
+			DestinationTableName: config.DestinationTableIdentifier,
+			CommitID:             0,
+		}
+
+		writes, err := c.recordWrites(ls, record, tmpl)
+		if err != nil {
+			return 0, nil, err
+		}
+		for _, write := range writes {
+			c.queueWrite(ctx, pipe, write)
+		}
+		numRecords++
+
+		if pipe.Len() >= c.pipelineSize {
+			if err := c.execPipeline(ctx, pipe); err != nil {
+				return 0, nil, err
+			}
+		}
+	}
+	if err := stream.Err(); err != nil {
+		return 0, nil, fmt.Errorf("[redis] failed to get record from stream: %w", err)
+	}
+	if err := c.execPipeline(ctx, pipe); err != nil {
+		return 0, nil, err
+	}
+
+	if err := c.FinishQRepPartition(ctx, partition, config.FlowJobName, startTime); err != nil {
+		return 0, nil, err
+	}
+	return numRecords, nil, nil
+}
@@ -0,0 +1,437 @@
+package connredis
+
+import (
+	"context"
+	"crypto/tls"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"maps"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+	lua "github.com/yuin/gopher-lua"
+	"go.temporal.io/sdk/log"
+
+	metadataStore "github.com/PeerDB-io/peerdb/flow/connectors/external_metadata"
+	"github.com/PeerDB-io/peerdb/flow/connectors/utils"
+	"github.com/PeerDB-io/peerdb/flow/generated/protos"
+	"github.com/PeerDB-io/peerdb/flow/internal"
+	"github.com/PeerDB-io/peerdb/flow/model"
+	"github.com/PeerDB-io/peerdb/flow/pua"
+	"github.com/PeerDB-io/peerdb/flow/shared"
+)
+
+const defaultPipelineSize = 1000
+
+type RedisConnector struct {
+	*metadataStore.PostgresMetadata
+	client       redis.UniversalClient
+	logger       log.Logger
+	keyTemplate  *keyTemplate
+	ttl          time.Duration
+	pipelineSize int
+	valueFormat  protos.RedisValueFormat
+}
+
+func NewRedisConnector(
+	ctx context.Context,
+	config *protos.RedisConfig,
+) (*RedisConnector, error) {
+	var tmpl *keyTemplate
+	if config.KeyTemplate != "" {
+		var err error
+		if tmpl, err = parseKeyTemplate(config.KeyTemplate); err != nil {
+			return nil, err
+		}
+	}
+
+	opts := &redis.UniversalOptions{
+		Addrs:    config.Addresses,
+		Username: config.GetUsername(),
+		Password: config.GetPassword(),
+		DB:       int(config.Database),
+	}
+	if !config.DisableTls {
+		tlsConfig, err := shared.CreateTlsConfig(tls.VersionTLS12, config.RootCa, "", config.TlsHost, false)
+		if err != nil {
+			return nil, err
+		}
+		opts.TLSConfig = tlsConfig
+	}
+
+	pgMetadata, err := metadataStore.NewPostgresMetadata(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	pipelineSize := defaultPipelineSize
+	if config.PipelineSize > 0 {
+		pipelineSize = int(config.PipelineSize)
+	}
+
+	return &RedisConnector{
+		PostgresMetadata: pgMetadata,
+		client:           redis.NewUniversalClient(opts),
+		logger:           internal.LoggerFromCtx(ctx),
+		keyTemplate:      tmpl,
+		ttl:              time.Duration(config.TtlSeconds) * time.Second,
+		pipelineSize:     pipelineSize,
+		valueFormat:      config.ValueFormat,
+	}, nil
+}
+
+func (c *RedisConnector) Close() error {
+	if c != nil {
+		return c.client.Close()
+	}
+	return nil
+}
+
+func (c *RedisConnector) ConnectionActive(ctx context.Context) error {
+	if err := c.client.Ping(ctx).Err(); err != nil {
+		return fmt.Errorf("failed to ping redis: %w", err)
+	}
+	return nil
+}
+
+// Redis is queue-like, no raw table staging needed
+func (c *RedisConnector) CreateRawTable(ctx context.Context, req *protos.CreateRawTableInput) (*protos.CreateRawTableOutput, error) {
+	return &protos.CreateRawTableOutput{TableIdentifier: "n/a"}, nil
+}
+
+// keys hold whatever columns a row had when it was written, so there is no schema to change
+func (c *RedisConnector) ReplayTableSchemaDeltas(_ context.Context, _ map[string]string,
+	flowJobName string, schemaDeltas []*protos.TableSchemaDelta,
+) error {
+	return nil
+}
+
+// redisWrite is what a single record turns into: a key to delete, or a hash or string to set
+type redisWrite struct {
+	fields     map[string]any
+	key        string
+	value      []byte
+	nullFields []string
+	ttl        time.Duration
+	delete     bool
+	// value lacks unchanged TOAST columns, which are kept from the current value
+	mergeJSON bool
+}
+
+func (c *RedisConnector) queueWrite(ctx context.Context, pipe redis.Pipeliner, write redisWrite) {
+	switch {
+	case write.delete:
+		pipe.Del(ctx, write.key)
+	case write.fields != nil:
+		if len(write.fields) > 0 {
+			pipe.HSet(ctx, write.key, write.fields)
+		}
+		if len(write.nullFields) > 0 {
+			pipe.HDel(ctx, write.key, write.nullFields...)
+		}
+		if write.ttl > 0 {
+			pipe.Expire(ctx, write.key, write.ttl)
+		}
+	default:
+		pipe.Set(ctx, write.key, write.value, write.ttl)
+	}
+}
+
+// mergeJSONValue overlays value on the JSON value currently at key, so columns missing from value keep theirs,
+// the pipeline is executed first as it may hold earlier writes to the same key
+func (c *RedisConnector) mergeJSONValue(ctx context.Context, pipe redis.Pipeliner, key string, value []byte) ([]byte, error) {
+	if pipe.Len() > 0 {
+		if err := c.execPipeline(ctx, pipe); err != nil {
+			return nil, err
+		}
+	}
+	current, err := c.client.Get(ctx, key).Bytes()
+	if errors.Is(err, redis.Nil) {
+		return value, nil
+	} else if err != nil {
+		return nil, fmt.Errorf("[redis] failed to get %s to merge unchanged columns: %w", key, err)
+	}
+	return mergeJSONObjects(current, value)
+}
+
+// mergeJSONObjects returns current with the columns of update replaced,
+// values are kept as raw JSON so numbers don't lose precision
+func mergeJSONObjects(current []byte, update []byte) ([]byte, error) {
+	var merged map[string]json.RawMessage
+	if json.Unmarshal(current, &merged) != nil || merged == nil {
+		// not a row written by us, replace it
+		return update, nil
+	}
+	var updated map[string]json.RawMessage
+	if err := json.Unmarshal(update, &updated); err != nil {
+		return nil, fmt.Errorf("failed to decode row: %w", err)
+	}
+	maps.Copy(merged, updated)
+	return json.Marshal(merged)
+}
+
+func (c *RedisConnector) execPipeline(ctx context.Context, pipe redis.Pipeliner) error {
+	if _, err := pipe.Exec(ctx); err != nil {
+		return fmt.Errorf("[redis] pipeline failed: %w", err)
+	}
+	return nil
+}
+
+// tableKeyTemplate returns the configured key template, or the default one over keyColumns of the table
+func (c *RedisConnector) tableKeyTemplate(
+	cache map[string]*keyTemplate,
+	table string,
+	keyColumns []string,
+) (*keyTemplate, error) {
+	if c.keyTemplate != nil {
+		return c.keyTemplate, nil
+	}
+	if tmpl, ok := cache[table]; ok {
+		return tmpl, nil
+	}
+	if len(keyColumns) == 0 {
+		return nil, fmt.Errorf("table %s has no primary key to build keys from, set a key template", table)
+	}
+	tmpl := defaultKeyTemplate(keyColumns)
+	cache[table] = tmpl
+	return tmpl, nil
+}
+
+// loadScript returns nil when there is no script, rows are then written as they are
+func (c *RedisConnector) loadScript(ctx context.Context, script string, flowJobName string) (*lua.LState, error) {
+	if script == "" {
+		return nil, nil
+	}
+	return utils.LoadScript(ctx, script, utils.LuaPrintFn(func(s string) {
+		_ = c.LogFlowInfo(ctx, flowJobName, s)
+	}))
+}
+
+func (c *RedisConnector) recordWrites(
+	ls *lua.LState,
+	record model.Record[model.RecordItems],
+	tmpl *keyTemplate,
+) ([]redisWrite, error) {
+	_, isDelete := record.(*model.DeleteRecord[model.RecordItems])
+	key, keyErr := tmpl.render(record.GetDestinationTableName(), record.GetItems())
+
+	if ls != nil {
+		return c.scriptWrites(ls, record, key, keyErr, isDelete)
+	}
+
+	switch record.(type) {
+	case *model.InsertRecord[model.RecordItems], *model.UpdateRecord[model.RecordItems]:
+		if keyErr != nil {
+			return nil, keyErr
+		}
+		var writes []redisWrite
+		var mergeJSON bool
+		if update, ok := record.(*model.UpdateRecord[model.RecordItems]); ok {
+			// the row moved to another key when its key columns changed, old items only carry those columns
+			// when they did change, or with replica identity full, so a failing render means the key is the same
+			if oldKey, err := tmpl.render(record.GetDestinationTableName(), update.OldItems); err == nil && oldKey != key {
+				writes = append(writes, redisWrite{key: oldKey, delete: true})
+			}
+			mergeJSON = len(update.UnchangedToastColumns) > 0
+		}
+		if c.valueFormat == protos.RedisValueFormat_REDIS_VALUE_JSON {
+			value, err := record.GetItems().MarshalJSON()
+			if err != nil {
+				return nil, fmt.Errorf("failed to serialize row: %w", err)
+			}
+			return append(writes, redisWrite{key: key, value: value, ttl: c.ttl, mergeJSON: mergeJSON}), nil
+		}
+		// hashes keep fields that aren't set, so unchanged TOAST columns need no special handling
+		fields, nullFields, err := hashFields(record.GetItems())
+		if err != nil {
+			return nil, fmt.Errorf("failed to serialize row: %w", err)
+		}
+		return append(writes, redisWrite{key: key, fields: fields, nullFields: nullFields, ttl: c.ttl}), nil
+	case *model.DeleteRecord[model.RecordItems]:
+		if keyErr != nil {
+			return nil, keyErr
+		}
+		return []redisWrite{{key: key, delete: true}}, nil
+	default:
+		return nil, nil
+	}
+}
+
+// scriptWrites calls onRecord, which returns any number of values:
+// a string is set at the key of the row, a table may override key and ttl and set either value or fields,
+// for deletes only the key matters and is deleted
+func (c *RedisConnector) scriptWrites(
+	ls *lua.LState,
+	record model.Record[model.RecordItems],
+	key string,
+	keyErr error,
+	isDelete bool,
+) ([]redisWrite, error) {
+	lfn := ls.Env.RawGetString("onRecord")
+	fn, ok := lfn.(*lua.LFunction)
+	if !ok {
+		return nil, fmt.Errorf("script should define `onRecord` as function, not %s", lfn)
+	}
+
+	ls.Push(fn)
+	ls.Push(pua.LuaRecord.New(ls, record))
+	if err := ls.PCall(1, -1, nil); err != nil {
+		return nil, fmt.Errorf("script failed: %w", err)
+	}
+	defer ls.SetTop(0)
+
+	args := ls.GetTop()
+	writes := make([]redisWrite, 0, args)
+	for i := range args {
+		write := redisWrite{key: key, ttl: c.ttl, delete: isDelete}
+		switch v := ls.Get(i - args).(type) {
+		case *lua.LNilType:
+			continue
+		case lua.LString:
+			write.value = shared.UnsafeFastStringToReadOnlyBytes(string(v))
+		case *lua.LTable:
+			if err := lvalueToRedisWrite(ls, v, &write); err != nil {
+				return nil, err
+			}
+		default:
+			return nil, fmt.Errorf("script returned invalid value: %s", v)
+		}
+		if write.key == "" {
+			if keyErr != nil {
+				return nil, keyErr
+			}
+			return nil, errors.New("script returned an empty key")
+		}
+		writes = append(writes, write)
+	}
+	return writes, nil
+}
+
+func lvalueToRedisWrite(ls *lua.LState, tbl *lua.LTable, write *redisWrite) error {
+	key, err := utils.LVAsStringOrNil(ls, ls.GetField(tbl, "key"))
+	if err != nil {
+		return fmt.Errorf("invalid key, %w", err)
+	}
+	if key != "" {
+		write.key = key
+	}
+	if ttl := ls.GetField(tbl, "ttl"); ttl != lua.LNil {
+		seconds, ok := ttl.(lua.LNumber)
+		if !ok || seconds < 0 {
+			return fmt.Errorf("invalid ttl, must be nil or a non-negative number: %s", ttl)
+		}
+		write.ttl = time.Duration(float64(seconds) * float64(time.Second))
+	}
+	if write.delete {
+		return nil
+	}
+
+	value, err := utils.LVAsReadOnlyBytes(ls, ls.GetField(tbl, "value"))
+	if err != nil {
+		return fmt.Errorf("invalid value, %w", err)
+	}
+	lfields := ls.GetField(tbl, "fields")
+	if fields, ok := lfields.(*lua.LTable); ok {
+		write.fields = make(map[string]any)
+		var fieldErr error
+		fields.ForEach(func(k, v lua.LValue) {
+			switch fv := v.(type) {
+			case lua.LString, lua.LNumber, lua.LBool:
+				write.fields[k.String()] = fv.String()
+			default:
+				fieldErr = fmt.Errorf("invalid value for field %s, must be string, number or boolean: %s", k, v)
+			}
+		})
+		if fieldErr != nil {
+			return fieldErr
+		}
+	} else if lfields != lua.LNil {
+		return fmt.Errorf("invalid fields, must be nil or table: %s", lfields)
+	} else if value == nil {
+		return errors.New("script returned a table with neither value nor fields")
+	} else {
+		write.value = value
+	}
+	return nil
+}
+
+func (c *RedisConnector) SyncRecords(ctx context.Context, req *model.SyncRecordsRequest[model.RecordItems]) (*model.SyncResponse, error) {
+	tableNameRowsMapping := utils.InitialiseTableRowsMap(req.TableMappings)
+	var numRecords int64
+
+	ls, err := c.loadScript(ctx, req.Script, req.FlowJobName)
+	if err != nil {
+		return nil, err
+	}
+	if ls != nil {
+		defer ls.Close()
+	}
+
+	flushTimeout, err := internal.PeerDBQueueFlushTimeoutSeconds(ctx, req.Env)
+	if err != nil {
+		c.logger.Warn("[redis] failed to get flush timeout, no periodic offset updates", slog.Any("error", err))
+	}
+	lastOffsetUpdate := time.Now()
+	var pendingLSN int64
+
+	keyTemplates := make(map[string]*keyTemplate)
+	pipe := c.client.Pipeline()
+	// records are applied in order, so once a pipeline succeeds every record queued before it is written
+	for record := range req.Records.GetRecords() {
+		tableSchema := req.TableNameSchemaMapping[record.GetDestinationTableName()]
+		tmpl, err := c.tableKeyTemplate(keyTemplates, record.GetDestinationTableName(), tableSchema.GetPrimaryKeyColumns())
+		if err != nil {
+			return nil, err
+		}
+		writes, err := c.recordWrites(ls, record, tmpl)
+		if err != nil {
+			return nil, err
+		}
+		if len(writes) > 0 {
+			for _, write := range writes {
+				if write.mergeJSON {
+					if write.value, err = c.mergeJSONValue(ctx, pipe, write.key, write.value); err != nil {
+						return nil, err
+					}
+				}
+				c.queueWrite(ctx, pipe, write)
+			}
+			record.PopulateCountMap(tableNameRowsMapping)
+			numRecords++
+		}
+		pendingLSN = max(pendingLSN, record.GetCheckpointID())
+
+		if pipe.Len() >= c.pipelineSize {
+			if err := c.execPipeline(ctx, pipe); err != nil {
+				return nil, err
+			}
+			if flushTimeout > 0 && time.Since(lastOffsetUpdate) >= flushTimeout && pendingLSN > req.ConsumedOffset.Load() {
+				if err := c.SetLastOffset(ctx, req.FlowJobName, model.CdcCheckpoint{ID: pendingLSN}); err != nil {
+					c.logger.Warn("[redis] SetLastOffset error", slog.Any("error", err))
+				} else {
+					shared.AtomicInt64Max(req.ConsumedOffset, pendingLSN)
+					c.logger.Info("processBatch", slog.Int64("updated last offset", pendingLSN))
+				}
+				lastOffsetUpdate = time.Now()
+			}
+		}
+	}
+	if err := c.execPipeline(ctx, pipe); err != nil {
+		return nil, err
+	}
+
+	lastCheckpoint := req.Records.GetLastCheckpoint()
+	if err := c.FinishBatch(ctx, req.FlowJobName, req.SyncBatchID, lastCheckpoint); err != nil {
+		return nil, err
+	}
+
+	return &model.SyncResponse{
+		CurrentSyncBatchID:   req.SyncBatchID,
+		LastSyncedCheckpoint: lastCheckpoint,
+		NumRecordsSynced:     numRecords,
+		TableNameRowsMapping: tableNameRowsMapping,
+		TableSchemaDeltas:    req.Records.SchemaDeltas,
+	}, nil
+}
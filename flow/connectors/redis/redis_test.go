@@ -0,0 +1,141 @@
+package connredis
+
+import (
+	"encoding/json"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/PeerDB-io/peerdb/flow/connectors/utils"
+	"github.com/PeerDB-io/peerdb/flow/generated/protos"
+	"github.com/PeerDB-io/peerdb/flow/model"
+	"github.com/PeerDB-io/peerdb/flow/shared/types"
+)
+
+func TestScriptWrites(t *testing.T) {
+	t.Parallel()
+
+	// scripts are loaded from the catalog by name, define onRecord directly instead
+	ls, err := utils.LoadScript(t.Context(), "", utils.LuaPrintFn(func(string) {}))
+	require.NoError(t, err)
+	defer ls.Close()
+	require.NoError(t, ls.DoString(`
+function onRecord(r)
+  if r.kind == "delete" then
+    return { key = "user:" .. tostring(r.old.id) }
+  end
+  return { key = "user:" .. tostring(r.row.id), fields = { region = r.row.region }, ttl = 60 }, "raw"
+end`))
+
+	c := &RedisConnector{ttl: time.Hour}
+	tmpl := defaultKeyTemplate([]string{"id"})
+
+	writes, err := c.recordWrites(ls, &model.InsertRecord[model.RecordItems]{
+		Items:                testItems(),
+		DestinationTableName: "users",
+	}, tmpl)
+	require.NoError(t, err)
+	require.Equal(t, []redisWrite{
+		{key: "user:42", fields: map[string]any{"region": "eu"}, ttl: time.Minute},
+		{key: "users:42", value: []byte("raw"), ttl: time.Hour},
+	}, writes)
+
+	writes, err = c.recordWrites(ls, &model.DeleteRecord[model.RecordItems]{
+		Items:                testItems(),
+		DestinationTableName: "users",
+	}, tmpl)
+	require.NoError(t, err)
+	require.Equal(t, []redisWrite{{key: "user:42", ttl: time.Hour, delete: true}}, writes)
+}
+
+func TestRecordWritesUpdate(t *testing.T) {
+	t.Parallel()
+
+	tmpl := defaultKeyTemplate([]string{"id"})
+	oldKeyItems := model.NewRecordItems(1)
+	oldKeyItems.AddColumn("id", types.QValueInt64{Val: 41})
+	newItems := testItems()
+	newItems.DeleteColName("attributes")
+
+	for _, tc := range []struct {
+		toast    map[string]struct{}
+		oldItems model.RecordItems
+		name     string
+		writes   []redisWrite
+		format   protos.RedisValueFormat
+	}{
+		{
+			name:     "key changed",
+			oldItems: oldKeyItems,
+			writes: []redisWrite{
+				{key: "users:41", delete: true},
+				{key: "users:42", fields: map[string]any{"id": "42", "region": "eu", "active": "true"}, nullFields: []string{"deleted_at"}},
+			},
+		},
+		{
+			name:     "key unchanged",
+			oldItems: model.NewRecordItems(0),
+			writes: []redisWrite{
+				{key: "users:42", fields: map[string]any{"id": "42", "region": "eu", "active": "true"}, nullFields: []string{"deleted_at"}},
+			},
+		},
+		{
+			name:     "json with unchanged toast columns",
+			oldItems: model.NewRecordItems(0),
+			toast:    map[string]struct{}{"attributes": {}},
+			format:   protos.RedisValueFormat_REDIS_VALUE_JSON,
+			writes: []redisWrite{
+				{key: "users:42", value: []byte(`{"active":true,"deleted_at":null,"id":42,"region":"eu"}`), mergeJSON: true},
+			},
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			c := &RedisConnector{valueFormat: tc.format}
+			writes, err := c.recordWrites(nil, &model.UpdateRecord[model.RecordItems]{
+				OldItems:              tc.oldItems,
+				NewItems:              newItems,
+				UnchangedToastColumns: tc.toast,
+				DestinationTableName:  "users",
+			}, tmpl)
+			require.NoError(t, err)
+			for i := range writes {
+				slices.Sort(writes[i].nullFields)
+				if writes[i].value != nil {
+					writes[i].value = normalizeJSON(t, writes[i].value)
+				}
+			}
+			require.Equal(t, tc.writes, writes)
+		})
+	}
+}
+
+func normalizeJSON(t *testing.T, value []byte) []byte {
+	t.Helper()
+	var decoded map[string]json.RawMessage
+	require.NoError(t, json.Unmarshal(value, &decoded))
+	normalized, err := json.Marshal(decoded)
+	require.NoError(t, err)
+	return normalized
+}
+
+func TestMergeJSONObjects(t *testing.T) {
+	t.Parallel()
+
+	merged, err := mergeJSONObjects(
+		[]byte(`{"id":9007199254740993,"doc":{"big":true},"name":"old"}`),
+		[]byte(`{"id":9007199254740993,"name":"new"}`),
+	)
+	require.NoError(t, err)
+	require.JSONEq(t, `{"id":9007199254740993,"doc":{"big":true},"name":"new"}`, string(merged))
+	require.Contains(t, string(merged), "9007199254740993", "numbers are kept as they are")
+
+	merged, err = mergeJSONObjects([]byte("not a row"), []byte(`{"id":1}`))
+	require.NoError(t, err)
+	require.JSONEq(t, `{"id":1}`, string(merged))
+
+	_, err = mergeJSONObjects([]byte(`{"id":1}`), []byte("not json"))
+	require.Error(t, err)
+}
@@ -23,6 +23,7 @@ import (
 	"github.com/PeerDB-io/peerdb/flow/internal"
 	"github.com/PeerDB-io/peerdb/flow/model"
 	"github.com/PeerDB-io/peerdb/flow/model/qvalue"
+	"github.com/PeerDB-io/peerdb/flow/otel_metrics"
 	"github.com/PeerDB-io/peerdb/flow/shared"
 	"github.com/PeerDB-io/peerdb/flow/shared/types"
 )
@@ -548,7 +549,9 @@ func (c *SnowflakeConnector) mergeTablesForBatch(
 			startTime := time.Now()
 			c.logger.Info("[merge] merging records...", "destTable", tableName, "batchId", batchId)
 
-			result, err := c.ExecContext(gCtx, mergeStatement, tableName)
+			stmtCtx, span := otel_metrics.StartStatementSpan(gCtx, flowName, tableName, batchId, mergeStatement)
+			result, err := c.ExecContext(stmtCtx, mergeStatement, tableName)
+			otel_metrics.EndSpan(span, err)
 			if err != nil {
 				return fmt.Errorf("failed to merge records into %s (statement: %s): %w",
 					tableName, mergeStatement, err)
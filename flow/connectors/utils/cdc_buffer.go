@@ -0,0 +1,271 @@
+package utils
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"hash"
+	"hash/crc32"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+
+	"google.golang.org/protobuf/proto"
+
+	"github.com/PeerDB-io/peerdb/flow/generated/protos"
+	"github.com/PeerDB-io/peerdb/flow/internal"
+	"github.com/PeerDB-io/peerdb/flow/model"
+)
+
+// a segment is the magic, records each prefixed by their length, a zero length,
+// the checkpoint and schema deltas of the batch, and a CRC32C of everything before it
+const (
+	cdcBufferSegmentMagic   = "PDBCDCBF"
+	cdcBufferSegmentVersion = 1
+)
+
+var crc32c = crc32.MakeTable(crc32.Castagnoli)
+
+// CDCBufferSegmentWriter writes a batch of records to a staging file, which only appears in the store once committed
+type CDCBufferSegmentWriter[T model.Items] struct {
+	store      CDCBufferStore
+	file       *os.File
+	crc        hash.Hash32
+	w          *bufio.Writer
+	fileName   string
+	encoder    cdcStoreEncoder
+	numRecords int64
+}
+
+func CreateCDCBufferSegment[T model.Items](store CDCBufferStore, segmentID int64) (*CDCBufferSegmentWriter[T], error) {
+	if err := os.MkdirAll(store.StagingDir(), 0o750); err != nil {
+		return nil, fmt.Errorf("failed to create CDC buffer directory: %w", err)
+	}
+	fileName := strconv.FormatInt(segmentID, 10) + ".seg"
+	file, err := os.Create(filepath.Join(store.StagingDir(), fileName+".tmp"))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create CDC buffer segment: %w", err)
+	}
+	crc := crc32.New(crc32c)
+	sw := &CDCBufferSegmentWriter[T]{
+		file:     file,
+		crc:      crc,
+		w:        bufio.NewWriterSize(io.MultiWriter(file, crc), 1<<20),
+		store:    store,
+		fileName: fileName,
+	}
+	if _, err := sw.w.WriteString(cdcBufferSegmentMagic); err != nil {
+		sw.Abort()
+		return nil, err
+	}
+	if err := sw.w.WriteByte(cdcBufferSegmentVersion); err != nil {
+		sw.Abort()
+		return nil, err
+	}
+	return sw, nil
+}
+
+func (sw *CDCBufferSegmentWriter[T]) FileName() string {
+	return sw.fileName
+}
+
+func (sw *CDCBufferSegmentWriter[T]) NumRecords() int64 {
+	return sw.numRecords
+}
+
+func (sw *CDCBufferSegmentWriter[T]) writeChunk(chunk []byte) error {
+	var lenBuf [binary.MaxVarintLen64]byte
+	if _, err := sw.w.Write(binary.AppendUvarint(lenBuf[:0], uint64(len(chunk)))); err != nil {
+		return err
+	}
+	_, err := sw.w.Write(chunk)
+	return err
+}
+
+func (sw *CDCBufferSegmentWriter[T]) Write(rec model.Record[T]) error {
+	sw.encoder.buf = sw.encoder.buf[:0]
+	if err := encodeCDCStoreRecord(&sw.encoder, rec); err != nil {
+		return fmt.Errorf("unable to encode record: %w", err)
+	}
+	if err := sw.writeChunk(sw.encoder.buf); err != nil {
+		return fmt.Errorf("failed to write CDC buffer segment: %w", err)
+	}
+	sw.numRecords += 1
+	return nil
+}
+
+// Commit ends the segment with the batch's checkpoint and schema deltas, syncing it to disk and committing it to the store.
+// It returns the size of the segment
+func (sw *CDCBufferSegmentWriter[T]) Commit(
+	ctx context.Context,
+	checkpoint model.CdcCheckpoint,
+	schemaDeltas []*protos.TableSchemaDelta,
+) (int64, error) {
+	sw.encoder.buf = sw.encoder.buf[:0]
+	// records are never empty, so a zero length ends them
+	sw.encoder.uvarint(0)
+	sw.encoder.varint(checkpoint.ID)
+	sw.encoder.string(checkpoint.Text)
+	sw.encoder.uvarint(uint64(len(schemaDeltas)))
+	for _, delta := range schemaDeltas {
+		deltaBytes, err := proto.Marshal(delta)
+		if err != nil {
+			sw.Abort()
+			return 0, fmt.Errorf("failed to encode schema delta: %w", err)
+		}
+		sw.encoder.uvarint(uint64(len(deltaBytes)))
+		sw.encoder.buf = append(sw.encoder.buf, deltaBytes...)
+	}
+	if _, err := sw.w.Write(sw.encoder.buf); err != nil {
+		sw.Abort()
+		return 0, fmt.Errorf("failed to write CDC buffer segment: %w", err)
+	}
+	if err := sw.w.Flush(); err != nil {
+		sw.Abort()
+		return 0, fmt.Errorf("failed to write CDC buffer segment: %w", err)
+	}
+	if _, err := sw.file.Write(sw.crc.Sum(nil)); err != nil {
+		sw.Abort()
+		return 0, fmt.Errorf("failed to write CDC buffer segment: %w", err)
+	}
+	if err := sw.file.Sync(); err != nil {
+		sw.Abort()
+		return 0, fmt.Errorf("failed to sync CDC buffer segment: %w", err)
+	}
+	size, err := sw.file.Seek(0, io.SeekCurrent)
+	if err != nil {
+		sw.Abort()
+		return 0, err
+	}
+	if err := sw.file.Close(); err != nil {
+		sw.Abort()
+		return 0, fmt.Errorf("failed to close CDC buffer segment: %w", err)
+	}
+	if err := sw.store.Commit(ctx, sw.file.Name(), sw.fileName); err != nil {
+		sw.Abort()
+		return 0, err
+	}
+	return size, nil
+}
+
+// Abort drops a segment that was not committed
+func (sw *CDCBufferSegmentWriter[T]) Abort() {
+	_ = sw.file.Close()
+	_ = os.Remove(sw.file.Name())
+}
+
+var errCDCBufferSegmentCorrupt = errors.New("CDC buffer segment is corrupt")
+
+// verifyCDCBufferSegment checks the segment's checksum before any of its records are replayed
+func verifyCDCBufferSegment(file *os.File) (int64, error) {
+	info, err := file.Stat()
+	if err != nil {
+		return 0, err
+	}
+	size := info.Size()
+	if size < int64(len(cdcBufferSegmentMagic))+1+crc32.Size {
+		return 0, errCDCBufferSegmentCorrupt
+	}
+	crc := crc32.New(crc32c)
+	if _, err := io.Copy(crc, io.NewSectionReader(file, 0, size-crc32.Size)); err != nil {
+		return 0, err
+	}
+	var stored [crc32.Size]byte
+	if _, err := file.ReadAt(stored[:], size-crc32.Size); err != nil {
+		return 0, err
+	}
+	if !bytes.Equal(crc.Sum(nil), stored[:]) {
+		return 0, errCDCBufferSegmentCorrupt
+	}
+	return size - crc32.Size, nil
+}
+
+// ReplayCDCBufferSegment adds the records of a segment to stream,
+// then moves the stream's checkpoint to the segment's and adds its schema deltas
+func ReplayCDCBufferSegment[T model.Items](
+	ctx context.Context,
+	store CDCBufferStore,
+	segment internal.CDCBufferSegment,
+	stream *model.CDCStream[T],
+) error {
+	path := segment.FileName
+	file, release, err := store.Open(ctx, path)
+	if err != nil {
+		// segments are registered after they are committed to the store, so a missing one means the buffer was lost
+		return fmt.Errorf("failed to open CDC buffer segment %s: %w", path, err)
+	}
+	defer release()
+
+	contentSize, err := verifyCDCBufferSegment(file)
+	if err != nil {
+		return fmt.Errorf("failed to verify CDC buffer segment %s: %w", path, err)
+	}
+	r := bufio.NewReaderSize(io.NewSectionReader(file, 0, contentSize), 1<<20)
+	header := make([]byte, len(cdcBufferSegmentMagic)+1)
+	if _, err := io.ReadFull(r, header); err != nil {
+		return fmt.Errorf("failed to read CDC buffer segment %s: %w", path, err)
+	}
+	if string(header[:len(cdcBufferSegmentMagic)]) != cdcBufferSegmentMagic {
+		return fmt.Errorf("failed to read CDC buffer segment %s: %w", path, errCDCBufferSegmentCorrupt)
+	} else if header[len(cdcBufferSegmentMagic)] != cdcBufferSegmentVersion {
+		return fmt.Errorf("unsupported CDC buffer segment version %d in %s", header[len(cdcBufferSegmentMagic)], path)
+	}
+
+	var chunk []byte
+	for {
+		n, err := binary.ReadUvarint(r)
+		if err != nil {
+			return fmt.Errorf("failed to read CDC buffer segment %s: %w", path, err)
+		}
+		if n == 0 {
+			break
+		}
+		if n > uint64(contentSize) {
+			return fmt.Errorf("failed to read CDC buffer segment %s: %w", path, errCDCBufferSegmentCorrupt)
+		}
+		if uint64(cap(chunk)) < n {
+			chunk = make([]byte, n)
+		}
+		chunk = chunk[:n]
+		if _, err := io.ReadFull(r, chunk); err != nil {
+			return fmt.Errorf("failed to read CDC buffer segment %s: %w", path, err)
+		}
+		rec, err := decodeCDCStoreRecord[T](chunk)
+		if err != nil {
+			return fmt.Errorf("failed to read CDC buffer segment %s: %w", path, err)
+		}
+		if err := stream.AddRecord(ctx, rec); err != nil {
+			return err
+		}
+	}
+
+	trailer, err := io.ReadAll(r)
+	if err != nil {
+		return fmt.Errorf("failed to read CDC buffer segment %s: %w", path, err)
+	}
+	d := &cdcStoreDecoder{buf: trailer}
+	checkpointID := d.varint()
+	checkpointText := d.string()
+	numDeltas := d.uvarint()
+	for range numDeltas {
+		deltaBytes := d.next(d.uvarint())
+		if d.err != nil {
+			break
+		}
+		delta := &protos.TableSchemaDelta{}
+		if err := proto.Unmarshal(deltaBytes, delta); err != nil {
+			return fmt.Errorf("failed to decode schema delta of CDC buffer segment %s: %w", path, err)
+		}
+		stream.AddSchemaDelta(nil, delta)
+	}
+	if d.err != nil {
+		return fmt.Errorf("failed to read CDC buffer segment %s: %w", path, d.err)
+	}
+	stream.UpdateLatestCheckpointID(checkpointID)
+	stream.UpdateLatestCheckpointText(checkpointText)
+	return nil
+}
@@ -0,0 +1,303 @@
+package utils
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/google/uuid"
+)
+
+// CDCBufferStore keeps the committed segments of a mirror's durable CDC buffer.
+// Segments are written to a local staging file, committing makes them durable in the store
+type CDCBufferStore interface {
+	// StagingDir is where segments are written before being committed
+	StagingDir() string
+	// Commit makes a segment synced to stagingPath durable under fileName
+	Commit(ctx context.Context, stagingPath string, fileName string) error
+	// Open returns a committed segment, release closes it and drops any local copy
+	Open(ctx context.Context, fileName string) (file *os.File, release func(), err error)
+	// Remove deletes a segment, removing one that does not exist is not an error
+	Remove(ctx context.Context, fileName string) error
+	// RemoveAll deletes every segment of the mirror
+	RemoveAll(ctx context.Context) error
+	// Probe writes, reads back and deletes an object to check segments can be kept
+	Probe(ctx context.Context) error
+}
+
+// directories wiped on restart or backed by memory, segments there are lost with the worker
+var ephemeralCDCBufferDirs = []string{"/tmp", "/var/tmp", "/dev/shm", "/run"}
+
+// NewCDCBufferStore returns the store of a mirror's segments under bufferDir,
+// which is either an absolute directory or an s3://bucket/prefix location
+func NewCDCBufferStore(ctx context.Context, bufferDir string, flowName string) (CDCBufferStore, error) {
+	if strings.HasPrefix(bufferDir, "s3://") {
+		return newS3CDCBufferStore(ctx, bufferDir, flowName)
+	}
+
+	if !filepath.IsAbs(bufferDir) {
+		return nil, fmt.Errorf("CDC buffer directory %s is not an absolute path", bufferDir)
+	}
+	dir := filepath.Clean(bufferDir)
+	for _, ephemeral := range append([]string{filepath.Clean(os.TempDir())}, ephemeralCDCBufferDirs...) {
+		if dir == ephemeral || strings.HasPrefix(dir, ephemeral+string(filepath.Separator)) {
+			return nil, fmt.Errorf("CDC buffer directory %s is under %s, which does not outlive workers", bufferDir, ephemeral)
+		}
+	}
+	return &localCDCBufferStore{dir: filepath.Join(dir, flowName)}, nil
+}
+
+type localCDCBufferStore struct {
+	dir string
+}
+
+func (s *localCDCBufferStore) StagingDir() string {
+	return s.dir
+}
+
+func (s *localCDCBufferStore) Commit(_ context.Context, stagingPath string, fileName string) error {
+	if err := os.Rename(stagingPath, filepath.Join(s.dir, fileName)); err != nil {
+		return fmt.Errorf("failed to rename CDC buffer segment: %w", err)
+	}
+	// the rename itself must survive a crash before the segment is registered
+	return syncCDCBufferDir(s.dir)
+}
+
+func (s *localCDCBufferStore) Open(_ context.Context, fileName string) (*os.File, func(), error) {
+	file, err := os.Open(filepath.Join(s.dir, fileName))
+	if err != nil {
+		return nil, nil, err
+	}
+	return file, func() { _ = file.Close() }, nil
+}
+
+func (s *localCDCBufferStore) Remove(_ context.Context, fileName string) error {
+	if err := os.Remove(filepath.Join(s.dir, fileName)); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return fmt.Errorf("failed to remove CDC buffer segment: %w", err)
+	}
+	return nil
+}
+
+func (s *localCDCBufferStore) RemoveAll(_ context.Context) error {
+	if err := os.RemoveAll(s.dir); err != nil {
+		return fmt.Errorf("failed to remove CDC buffer directory: %w", err)
+	}
+	return nil
+}
+
+func (s *localCDCBufferStore) Probe(_ context.Context) error {
+	if err := os.MkdirAll(s.dir, 0o750); err != nil {
+		return fmt.Errorf("failed to create CDC buffer directory: %w", err)
+	}
+	path := filepath.Join(s.dir, _peerDBCheck+uuid.NewString())
+	contents := []byte(path)
+	file, err := os.Create(path)
+	if err != nil {
+		return fmt.Errorf("failed to write to CDC buffer directory: %w", err)
+	}
+	defer os.Remove(path)
+	if _, err := file.Write(contents); err != nil {
+		_ = file.Close()
+		return fmt.Errorf("failed to write to CDC buffer directory: %w", err)
+	}
+	if err := file.Sync(); err != nil {
+		_ = file.Close()
+		return fmt.Errorf("failed to sync CDC buffer directory: %w", err)
+	}
+	if err := file.Close(); err != nil {
+		return fmt.Errorf("failed to write to CDC buffer directory: %w", err)
+	}
+	if err := syncCDCBufferDir(s.dir); err != nil {
+		return err
+	}
+	readBack, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("failed to read from CDC buffer directory: %w", err)
+	}
+	if !bytes.Equal(contents, readBack) {
+		return errors.New("CDC buffer directory did not read back what was written to it")
+	}
+	return nil
+}
+
+func syncCDCBufferDir(path string) error {
+	dir, err := os.Open(path)
+	if err != nil {
+		return fmt.Errorf("failed to sync CDC buffer directory: %w", err)
+	}
+	defer dir.Close()
+	if err := dir.Sync(); err != nil {
+		return fmt.Errorf("failed to sync CDC buffer directory: %w", err)
+	}
+	return nil
+}
+
+// s3CDCBufferStore uploads segments once written, and downloads them again to replay
+type s3CDCBufferStore struct {
+	client     *s3.Client
+	bucket     string
+	prefix     string
+	stagingDir string
+}
+
+func newS3CDCBufferStore(ctx context.Context, bufferDir string, flowName string) (*s3CDCBufferStore, error) {
+	s3o, err := NewS3BucketAndPrefix(bufferDir)
+	if err != nil {
+		return nil, err
+	}
+	provider, err := GetAWSCredentialsProvider(ctx, "cdc_buffer", PeerAWSCredentials{})
+	if err != nil {
+		return nil, err
+	}
+	client, err := CreateS3Client(ctx, provider)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create S3 client for CDC buffer: %w", err)
+	}
+	prefix := flowName
+	if s3o.Prefix != "" {
+		prefix = s3o.Prefix + "/" + flowName
+	}
+	return &s3CDCBufferStore{
+		client: client,
+		bucket: s3o.Bucket,
+		prefix: prefix,
+		// only holds segments until they are uploaded or replayed
+		stagingDir: filepath.Join(os.TempDir(), "peerdb_cdc_buffer", flowName),
+	}, nil
+}
+
+func (s *s3CDCBufferStore) key(fileName string) *string {
+	return aws.String(s.prefix + "/" + fileName)
+}
+
+func (s *s3CDCBufferStore) StagingDir() string {
+	return s.stagingDir
+}
+
+func (s *s3CDCBufferStore) Commit(ctx context.Context, stagingPath string, fileName string) error {
+	file, err := os.Open(stagingPath)
+	if err != nil {
+		return fmt.Errorf("failed to open CDC buffer segment: %w", err)
+	}
+	defer file.Close()
+	if _, err := s.client.PutObject(ctx, &s3.PutObjectInput{
+		Bucket: aws.String(s.bucket),
+		Key:    s.key(fileName),
+		Body:   file,
+	}); err != nil {
+		return fmt.Errorf("failed to upload CDC buffer segment: %w", err)
+	}
+	// a successful upload is durable, the staging file is no longer needed
+	_ = os.Remove(stagingPath)
+	return nil
+}
+
+func (s *s3CDCBufferStore) Open(ctx context.Context, fileName string) (*os.File, func(), error) {
+	obj, err := s.client.GetObject(ctx, &s3.GetObjectInput{
+		Bucket: aws.String(s.bucket),
+		Key:    s.key(fileName),
+	})
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to download CDC buffer segment: %w", err)
+	}
+	defer obj.Body.Close()
+
+	if err := os.MkdirAll(s.stagingDir, 0o750); err != nil {
+		return nil, nil, fmt.Errorf("failed to create CDC buffer staging directory: %w", err)
+	}
+	file, err := os.CreateTemp(s.stagingDir, fileName+".replay")
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to download CDC buffer segment: %w", err)
+	}
+	release := func() {
+		_ = file.Close()
+		_ = os.Remove(file.Name())
+	}
+	if _, err := io.Copy(file, obj.Body); err != nil {
+		release()
+		return nil, nil, fmt.Errorf("failed to download CDC buffer segment: %w", err)
+	}
+	return file, release, nil
+}
+
+func (s *s3CDCBufferStore) Remove(ctx context.Context, fileName string) error {
+	if _, err := s.client.DeleteObject(ctx, &s3.DeleteObjectInput{
+		Bucket: aws.String(s.bucket),
+		Key:    s.key(fileName),
+	}); err != nil {
+		return fmt.Errorf("failed to remove CDC buffer segment: %w", err)
+	}
+	return nil
+}
+
+func (s *s3CDCBufferStore) RemoveAll(ctx context.Context) error {
+	pages := s3.NewListObjectsV2Paginator(s.client, &s3.ListObjectsV2Input{
+		Bucket: aws.String(s.bucket),
+		Prefix: aws.String(s.prefix + "/"),
+	})
+	for pages.HasMorePages() {
+		page, err := pages.NextPage(ctx)
+		if err != nil {
+			return fmt.Errorf("failed to list CDC buffer segments: %w", err)
+		}
+		for _, object := range page.Contents {
+			if _, err := s.client.DeleteObject(ctx, &s3.DeleteObjectInput{
+				Bucket: aws.String(s.bucket),
+				Key:    object.Key,
+			}); err != nil {
+				return fmt.Errorf("failed to remove CDC buffer segment: %w", err)
+			}
+		}
+	}
+	if err := os.RemoveAll(s.stagingDir); err != nil {
+		return fmt.Errorf("failed to remove CDC buffer staging directory: %w", err)
+	}
+	return nil
+}
+
+func (s *s3CDCBufferStore) Probe(ctx context.Context) error {
+	key := s.key(_peerDBCheck + uuid.NewString())
+	contents := []byte(*key)
+	if _, err := s.client.PutObject(ctx, &s3.PutObjectInput{
+		Bucket: aws.String(s.bucket),
+		Key:    key,
+		Body:   bytes.NewReader(contents),
+	}); err != nil {
+		return fmt.Errorf("failed to write to CDC buffer bucket: %w", err)
+	}
+	readErr := s.readBack(ctx, key, contents)
+	// segments are deleted once synced, so deleting needs to be allowed too
+	if _, err := s.client.DeleteObject(ctx, &s3.DeleteObjectInput{
+		Bucket: aws.String(s.bucket),
+		Key:    key,
+	}); err != nil {
+		return errors.Join(readErr, fmt.Errorf("failed to delete from CDC buffer bucket: %w", err))
+	}
+	return readErr
+}
+
+func (s *s3CDCBufferStore) readBack(ctx context.Context, key *string, contents []byte) error {
+	obj, err := s.client.GetObject(ctx, &s3.GetObjectInput{
+		Bucket: aws.String(s.bucket),
+		Key:    key,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to read from CDC buffer bucket: %w", err)
+	}
+	defer obj.Body.Close()
+	readBack, err := io.ReadAll(obj.Body)
+	if err != nil {
+		return fmt.Errorf("failed to read from CDC buffer bucket: %w", err)
+	}
+	if !bytes.Equal(contents, readBack) {
+		return errors.New("CDC buffer bucket did not read back what was written to it")
+	}
+	return nil
+}
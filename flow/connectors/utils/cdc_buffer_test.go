@@ -0,0 +1,196 @@
+package utils
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/proto"
+
+	"github.com/PeerDB-io/peerdb/flow/generated/protos"
+	"github.com/PeerDB-io/peerdb/flow/internal"
+	"github.com/PeerDB-io/peerdb/flow/model"
+)
+
+func writeCDCBufferSegment(
+	t *testing.T,
+	store CDCBufferStore,
+	segmentID int64,
+	recs []model.Record[model.RecordItems],
+	checkpoint model.CdcCheckpoint,
+	deltas []*protos.TableSchemaDelta,
+) internal.CDCBufferSegment {
+	t.Helper()
+
+	writer, err := CreateCDCBufferSegment[model.RecordItems](store, segmentID)
+	require.NoError(t, err)
+	for _, rec := range recs {
+		require.NoError(t, writer.Write(rec))
+	}
+	size, err := writer.Commit(t.Context(), checkpoint, deltas)
+	require.NoError(t, err)
+	return internal.CDCBufferSegment{
+		FileName:       writer.FileName(),
+		LastOffsetText: checkpoint.Text,
+		SegmentID:      segmentID,
+		LastOffsetID:   checkpoint.ID,
+		NumRecords:     writer.NumRecords(),
+		SizeBytes:      size,
+	}
+}
+
+func replayCDCBufferSegment(
+	t *testing.T,
+	store CDCBufferStore,
+	segment internal.CDCBufferSegment,
+) (*model.CDCStream[model.RecordItems], []model.Record[model.RecordItems], error) {
+	t.Helper()
+
+	stream := model.NewCDCStream[model.RecordItems](int(segment.NumRecords) + 1)
+	err := ReplayCDCBufferSegment(t.Context(), store, segment, stream)
+	stream.Close()
+	var recs []model.Record[model.RecordItems]
+	for rec := range stream.GetRecords() {
+		recs = append(recs, rec)
+	}
+	return stream, recs, err
+}
+
+func TestCDCBufferSegmentRoundTrip(t *testing.T) {
+	t.Parallel()
+
+	store := &localCDCBufferStore{dir: filepath.Join(t.TempDir(), "test_flow")}
+	recs := benchmarkRecords()[:3]
+	delta := &protos.TableSchemaDelta{
+		SrcTableName: "public.src",
+		DstTableName: "dst",
+		AddedColumns: []*protos.FieldDescription{{Name: "c", Type: "text", Nullable: true}},
+	}
+	checkpoint := model.CdcCheckpoint{ID: 1 << 40, Text: "binlog.000001,4"}
+	segment := writeCDCBufferSegment(t, store, 7, recs, checkpoint, []*protos.TableSchemaDelta{delta})
+	require.Equal(t, "7.seg", segment.FileName)
+	require.EqualValues(t, 3, segment.NumRecords)
+
+	entries, err := os.ReadDir(store.dir)
+	require.NoError(t, err)
+	require.Len(t, entries, 1, "temporary file should be renamed on commit")
+
+	stream, replayed, err := replayCDCBufferSegment(t, store, segment)
+	require.NoError(t, err)
+	require.Equal(t, recs, replayed)
+	require.Equal(t, checkpoint, stream.GetLastCheckpoint())
+	require.Len(t, stream.SchemaDeltas, 1)
+	require.True(t, proto.Equal(delta, stream.SchemaDeltas[0]))
+
+	require.NoError(t, store.Remove(t.Context(), segment.FileName))
+	require.NoError(t, store.Remove(t.Context(), segment.FileName), "removing twice is not an error")
+}
+
+func TestCDCBufferSegmentSchemaDeltasOnly(t *testing.T) {
+	t.Parallel()
+
+	store := &localCDCBufferStore{dir: filepath.Join(t.TempDir(), "test_flow")}
+	delta := &protos.TableSchemaDelta{SrcTableName: "public.src", DstTableName: "dst"}
+	segment := writeCDCBufferSegment(t, store, 1, nil, model.CdcCheckpoint{ID: 42}, []*protos.TableSchemaDelta{delta})
+	require.Zero(t, segment.NumRecords)
+
+	stream, replayed, err := replayCDCBufferSegment(t, store, segment)
+	require.NoError(t, err)
+	require.Empty(t, replayed)
+	require.Equal(t, int64(42), stream.GetLastCheckpoint().ID)
+	require.Len(t, stream.SchemaDeltas, 1)
+}
+
+func TestCDCBufferSegmentDetectsCorruption(t *testing.T) {
+	t.Parallel()
+
+	store := &localCDCBufferStore{dir: filepath.Join(t.TempDir(), "test_flow")}
+	segment := writeCDCBufferSegment(t, store, 1, benchmarkRecords()[:2], model.CdcCheckpoint{ID: 1}, nil)
+	path := filepath.Join(store.dir, segment.FileName)
+	contents, err := os.ReadFile(path)
+	require.NoError(t, err)
+
+	for _, i := range []int{0, len(cdcBufferSegmentMagic) + 2, len(contents) / 2, len(contents) - 1} {
+		corrupted := slices.Clone(contents)
+		corrupted[i] ^= 0xff
+		//nolint:gosec // path is under the test's temporary directory
+		require.NoError(t, os.WriteFile(path, corrupted, 0o600))
+		_, replayed, err := replayCDCBufferSegment(t, store, segment)
+		require.ErrorIs(t, err, errCDCBufferSegmentCorrupt)
+		require.Empty(t, replayed, "no records should be replayed from a corrupt segment")
+	}
+
+	//nolint:gosec // path is under the test's temporary directory
+	require.NoError(t, os.WriteFile(path, contents[:len(contents)-3], 0o600))
+	_, _, err = replayCDCBufferSegment(t, store, segment)
+	require.ErrorIs(t, err, errCDCBufferSegmentCorrupt)
+
+	require.NoError(t, os.Remove(path))
+	_, _, err = replayCDCBufferSegment(t, store, segment)
+	require.ErrorIs(t, err, os.ErrNotExist)
+}
+
+func TestCDCBufferSegmentAbort(t *testing.T) {
+	t.Parallel()
+
+	store := &localCDCBufferStore{dir: filepath.Join(t.TempDir(), "test_flow")}
+	writer, err := CreateCDCBufferSegment[model.RecordItems](store, 1)
+	require.NoError(t, err)
+	require.NoError(t, writer.Write(benchmarkRecords()[0]))
+	writer.Abort()
+
+	entries, err := os.ReadDir(store.dir)
+	require.NoError(t, err)
+	require.Empty(t, entries)
+}
+
+func TestNewCDCBufferStore(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		bufferDir string
+		wantDir   string
+		wantErr   bool
+	}{
+		{bufferDir: "/var/lib/peerdb/cdc_buffer", wantDir: "/var/lib/peerdb/cdc_buffer/test_flow"},
+		{bufferDir: "/var/lib/peerdb/../peerdb/buffer/", wantDir: "/var/lib/peerdb/buffer/test_flow"},
+		{bufferDir: "/tmpfs_volume", wantDir: "/tmpfs_volume/test_flow"},
+		{bufferDir: "cdc_buffer", wantErr: true},
+		{bufferDir: "/tmp", wantErr: true},
+		{bufferDir: "/tmp/cdc_buffer", wantErr: true},
+		{bufferDir: "/var/tmp/cdc_buffer", wantErr: true},
+		{bufferDir: "/dev/shm/cdc_buffer", wantErr: true},
+		{bufferDir: filepath.Join(os.TempDir(), "cdc_buffer"), wantErr: true},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.bufferDir, func(t *testing.T) {
+			t.Parallel()
+
+			store, err := NewCDCBufferStore(t.Context(), tc.bufferDir, "test_flow")
+			if tc.wantErr {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, tc.wantDir, store.StagingDir())
+		})
+	}
+}
+
+func TestLocalCDCBufferStoreProbe(t *testing.T) {
+	t.Parallel()
+
+	store := &localCDCBufferStore{dir: filepath.Join(t.TempDir(), "test_flow")}
+	require.NoError(t, store.Probe(t.Context()))
+	entries, err := os.ReadDir(store.dir)
+	require.NoError(t, err)
+	require.Empty(t, entries, "probe should not leave files behind")
+
+	// a directory that cannot be created
+	parent := filepath.Join(t.TempDir(), "file")
+	require.NoError(t, os.WriteFile(parent, nil, 0o600))
+	require.Error(t, (&localCDCBufferStore{dir: filepath.Join(parent, "test_flow")}).Probe(t.Context()))
+}
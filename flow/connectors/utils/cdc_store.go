@@ -1,37 +1,22 @@
 package utils
 
 import (
-	"bytes"
 	"context"
-	"encoding/gob"
 	"errors"
 	"fmt"
 	"log/slog"
 	"os"
 	"runtime/metrics"
 	"sync/atomic"
-	"time"
 
 	"github.com/cockroachdb/pebble"
-	"github.com/shopspring/decimal"
 	"go.temporal.io/sdk/log"
 
 	"github.com/PeerDB-io/peerdb/flow/internal"
 	"github.com/PeerDB-io/peerdb/flow/model"
 	"github.com/PeerDB-io/peerdb/flow/shared"
-	"github.com/PeerDB-io/peerdb/flow/shared/types"
 )
 
-func encVal(val any) ([]byte, error) {
-	buf := new(bytes.Buffer)
-	enc := gob.NewEncoder(buf)
-	err := enc.Encode(val)
-	if err != nil {
-		return []byte{}, fmt.Errorf("unable to encode value %v: %w", val, err)
-	}
-	return buf.Bytes(), nil
-}
-
 type cdcStore[Items model.Items] struct {
 	inMemoryRecords           map[model.TableWithPkey]model.Record[Items]
 	pebbleDB                  *pebble.DB
@@ -39,6 +24,8 @@ type cdcStore[Items model.Items] struct {
 	dbFolderName              string
 	thresholdReason           string
 	memStats                  []metrics.Sample
+	encoder                   cdcStoreEncoder // reused across spilled records, pebble copies what is set
+	keyBuf                    []byte
 	memThresholdBytes         uint64
 	numRecords                atomic.Int32
 	numRecordsSwitchThreshold int
@@ -73,70 +60,11 @@ func NewCDCStore[Items model.Items](ctx context.Context, env map[string]string,
 	}, nil
 }
 
-func init() {
-	// register future record classes here as well, if they are passed/stored as interfaces
-	gob.Register(time.Time{})
-	gob.Register(decimal.Decimal{})
-	gob.Register(types.QValueNull(""))
-	gob.Register(types.QValueInvalid{})
-	gob.Register(types.QValueFloat32{})
-	gob.Register(types.QValueFloat64{})
-	gob.Register(types.QValueInt8{})
-	gob.Register(types.QValueInt16{})
-	gob.Register(types.QValueInt32{})
-	gob.Register(types.QValueInt64{})
-	gob.Register(types.QValueUInt8{})
-	gob.Register(types.QValueUInt16{})
-	gob.Register(types.QValueUInt32{})
-	gob.Register(types.QValueUInt64{})
-	gob.Register(types.QValueBoolean{})
-	gob.Register(types.QValueQChar{})
-	gob.Register(types.QValueString{})
-	gob.Register(types.QValueEnum{})
-	gob.Register(types.QValueTimestamp{})
-	gob.Register(types.QValueTimestampTZ{})
-	gob.Register(types.QValueDate{})
-	gob.Register(types.QValueTime{})
-	gob.Register(types.QValueTimeTZ{})
-	gob.Register(types.QValueInterval{})
-	gob.Register(types.QValueNumeric{})
-	gob.Register(types.QValueBytes{})
-	gob.Register(types.QValueUUID{})
-	gob.Register(types.QValueJSON{})
-	gob.Register(types.QValueHStore{})
-	gob.Register(types.QValueGeography{})
-	gob.Register(types.QValueGeometry{})
-	gob.Register(types.QValuePoint{})
-	gob.Register(types.QValueCIDR{})
-	gob.Register(types.QValueINET{})
-	gob.Register(types.QValueMacaddr{})
-	gob.Register(types.QValueArrayFloat32{})
-	gob.Register(types.QValueArrayFloat64{})
-	gob.Register(types.QValueArrayInt16{})
-	gob.Register(types.QValueArrayInt32{})
-	gob.Register(types.QValueArrayInt64{})
-	gob.Register(types.QValueArrayString{})
-	gob.Register(types.QValueArrayEnum{})
-	gob.Register(types.QValueArrayDate{})
-	gob.Register(types.QValueArrayInterval{})
-	gob.Register(types.QValueArrayTimestamp{})
-	gob.Register(types.QValueArrayTimestampTZ{})
-	gob.Register(types.QValueArrayBoolean{})
-	gob.Register(types.QValueArrayUUID{})
-	gob.Register(types.QValueArrayNumeric{})
-}
-
 func (c *cdcStore[T]) initPebbleDB() error {
 	if c.pebbleDB != nil {
 		return nil
 	}
 
-	gob.Register(&model.InsertRecord[T]{})
-	gob.Register(&model.UpdateRecord[T]{})
-	gob.Register(&model.DeleteRecord[T]{})
-	gob.Register(&model.RelationRecord[T]{})
-	gob.Register(&model.MessageRecord[T]{})
-
 	var err error
 	// we don't want a WAL since cache, we don't want to overwrite another DB either
 	c.pebbleDB, err = pebble.Open(c.dbFolderName, &pebble.Options{
@@ -182,18 +110,13 @@ func (c *cdcStore[T]) Set(logger log.Logger, key model.TableWithPkey, rec model.
 				}
 			}
 
-			encodedKey, err := encVal(key)
-			if err != nil {
-				return err
-			}
-			// necessary to point pointer to interface so the interface is exposed
-			// instead of the underlying type
-			encodedRec, err := encVal(&rec)
-			if err != nil {
-				return err
+			c.keyBuf = encodeCDCStoreKey(c.keyBuf[:0], key)
+			c.encoder.buf = c.encoder.buf[:0]
+			if err := encodeCDCStoreRecord(&c.encoder, rec); err != nil {
+				return fmt.Errorf("unable to encode record: %w", err)
 			}
 			// we're using Pebble as a cache, no need for durability here.
-			if err := c.pebbleDB.Set(encodedKey, encodedRec, &pebble.WriteOptions{
+			if err := c.pebbleDB.Set(c.keyBuf, c.encoder.buf, &pebble.WriteOptions{
 				Sync: false,
 			}); err != nil {
 				return fmt.Errorf("unable to store value in Pebble: %w", err)
@@ -211,11 +134,8 @@ func (c *cdcStore[T]) Get(key model.TableWithPkey) (model.Record[T], bool, error
 	if ok {
 		return rec, true, nil
 	} else if c.pebbleDB != nil {
-		encodedKey, err := encVal(key)
-		if err != nil {
-			return nil, false, err
-		}
-		encodedRec, closer, err := c.pebbleDB.Get(encodedKey)
+		c.keyBuf = encodeCDCStoreKey(c.keyBuf[:0], key)
+		encodedRec, closer, err := c.pebbleDB.Get(c.keyBuf)
 		if err != nil {
 			if errors.Is(err, pebble.ErrNotFound) {
 				return nil, false, nil
@@ -231,10 +151,9 @@ func (c *cdcStore[T]) Get(key model.TableWithPkey) (model.Record[T], bool, error
 			}
 		}()
 
-		dec := gob.NewDecoder(bytes.NewReader(encodedRec))
-		var rec model.Record[T]
-		if err := dec.Decode(&rec); err != nil {
-			return nil, false, fmt.Errorf("failed to decode record: %w", err)
+		rec, err := decodeCDCStoreRecord[T](encodedRec)
+		if err != nil {
+			return nil, false, err
 		}
 
 		return rec, true, nil
@@ -0,0 +1,863 @@
+package utils
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"math"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+	"google.golang.org/protobuf/proto"
+
+	"github.com/PeerDB-io/peerdb/flow/generated/protos"
+	"github.com/PeerDB-io/peerdb/flow/model"
+	"github.com/PeerDB-io/peerdb/flow/shared/types"
+)
+
+// cdcStoreCodecVersion is the first byte of every record spilled to disk,
+// bump it whenever the encoding below changes
+const cdcStoreCodecVersion byte = 1
+
+const (
+	recordTagInsert byte = iota + 1
+	recordTagUpdate
+	recordTagDelete
+	recordTagRelation
+	recordTagMessage
+)
+
+// tags of QValue types, only ever append to keep tags stable within a version
+const (
+	qvalueTagNull byte = iota + 1
+	qvalueTagInvalid
+	qvalueTagFloat32
+	qvalueTagFloat64
+	qvalueTagInt8
+	qvalueTagInt16
+	qvalueTagInt32
+	qvalueTagInt64
+	qvalueTagUInt8
+	qvalueTagUInt16
+	qvalueTagUInt32
+	qvalueTagUInt64
+	qvalueTagBoolean
+	qvalueTagQChar
+	qvalueTagString
+	qvalueTagEnum
+	qvalueTagTimestamp
+	qvalueTagTimestampTZ
+	qvalueTagDate
+	qvalueTagTime
+	qvalueTagTimeTZ
+	qvalueTagInterval
+	qvalueTagNumeric
+	qvalueTagBytes
+	qvalueTagUUID
+	qvalueTagJSON
+	qvalueTagHStore
+	qvalueTagGeography
+	qvalueTagGeometry
+	qvalueTagPoint
+	qvalueTagCIDR
+	qvalueTagINET
+	qvalueTagMacaddr
+	qvalueTagArrayFloat32
+	qvalueTagArrayFloat64
+	qvalueTagArrayInt16
+	qvalueTagArrayInt32
+	qvalueTagArrayInt64
+	qvalueTagArrayString
+	qvalueTagArrayEnum
+	qvalueTagArrayDate
+	qvalueTagArrayInterval
+	qvalueTagArrayTimestamp
+	qvalueTagArrayTimestampTZ
+	qvalueTagArrayBoolean
+	qvalueTagArrayUUID
+	qvalueTagArrayNumeric
+)
+
+// encodeCDCStoreKey appends key to buf, keys are only compared so need no version
+func encodeCDCStoreKey(buf []byte, key model.TableWithPkey) []byte {
+	buf = append(buf, key.TableName...)
+	return append(buf, key.PkeyColVal[:]...)
+}
+
+// cdcStoreEncoder appends records to buf, which can be reused across records
+type cdcStoreEncoder struct {
+	buf []byte
+}
+
+func (e *cdcStoreEncoder) uvarint(v uint64) {
+	e.buf = binary.AppendUvarint(e.buf, v)
+}
+
+func (e *cdcStoreEncoder) varint(v int64) {
+	e.buf = binary.AppendVarint(e.buf, v)
+}
+
+func (e *cdcStoreEncoder) bool(v bool) {
+	if v {
+		e.buf = append(e.buf, 1)
+	} else {
+		e.buf = append(e.buf, 0)
+	}
+}
+
+func (e *cdcStoreEncoder) string(v string) {
+	e.uvarint(uint64(len(v)))
+	e.buf = append(e.buf, v...)
+}
+
+// length writes the length of a slice or map shifted by one, so that nil and empty stay distinct
+func (e *cdcStoreEncoder) length(n int, isNil bool) {
+	if isNil {
+		e.uvarint(0)
+	} else {
+		e.uvarint(uint64(n) + 1)
+	}
+}
+
+func (e *cdcStoreEncoder) bytes(v []byte) {
+	e.length(len(v), v == nil)
+	e.buf = append(e.buf, v...)
+}
+
+func (e *cdcStoreEncoder) float32(v float32) {
+	e.buf = binary.LittleEndian.AppendUint32(e.buf, math.Float32bits(v))
+}
+
+func (e *cdcStoreEncoder) float64(v float64) {
+	e.buf = binary.LittleEndian.AppendUint64(e.buf, math.Float64bits(v))
+}
+
+// time keeps the zone offset, records read back equal to what was spilled
+func (e *cdcStoreEncoder) time(v time.Time) error {
+	lenIdx := len(e.buf)
+	e.buf = append(e.buf, 0)
+	var err error
+	if e.buf, err = v.AppendBinary(e.buf); err != nil {
+		return err
+	}
+	e.buf[lenIdx] = byte(len(e.buf) - lenIdx - 1)
+	return nil
+}
+
+func (e *cdcStoreEncoder) decimal(v decimal.Decimal) error {
+	b, err := v.MarshalBinary()
+	if err != nil {
+		return err
+	}
+	e.bytes(b)
+	return nil
+}
+
+func (e *cdcStoreEncoder) strings(v []string) {
+	e.length(len(v), v == nil)
+	for _, s := range v {
+		e.string(s)
+	}
+}
+
+func (e *cdcStoreEncoder) times(v []time.Time) error {
+	e.length(len(v), v == nil)
+	for _, t := range v {
+		if err := e.time(t); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (e *cdcStoreEncoder) columnSet(v map[string]struct{}) {
+	e.length(len(v), v == nil)
+	for col := range v {
+		e.string(col)
+	}
+}
+
+func (e *cdcStoreEncoder) baseRecord(v model.BaseRecord) {
+	e.varint(v.CheckpointID)
+	e.varint(v.CommitTimeNano)
+}
+
+func encodeCDCStoreItems[T model.Items](e *cdcStoreEncoder, items T) error {
+	switch items := any(items).(type) {
+	case model.RecordItems:
+		e.length(len(items.ColToVal), items.ColToVal == nil)
+		for col, qv := range items.ColToVal {
+			e.string(col)
+			if err := e.qvalue(qv); err != nil {
+				return fmt.Errorf("failed to encode column %s: %w", col, err)
+			}
+		}
+	case model.PgItems:
+		e.length(len(items.ColToVal), items.ColToVal == nil)
+		for col, val := range items.ColToVal {
+			e.string(col)
+			e.bytes(val)
+		}
+	default:
+		return fmt.Errorf("unsupported items type %T", items)
+	}
+	return nil
+}
+
+// encodeCDCStoreRecord appends rec to e.buf, prefixed by the codec version
+func encodeCDCStoreRecord[T model.Items](e *cdcStoreEncoder, rec model.Record[T]) error {
+	e.buf = append(e.buf, cdcStoreCodecVersion)
+	switch r := rec.(type) {
+	case *model.InsertRecord[T]:
+		e.buf = append(e.buf, recordTagInsert)
+		e.baseRecord(r.BaseRecord)
+		e.string(r.SourceTableName)
+		e.string(r.DestinationTableName)
+		e.varint(r.CommitID)
+		return encodeCDCStoreItems(e, r.Items)
+	case *model.UpdateRecord[T]:
+		e.buf = append(e.buf, recordTagUpdate)
+		e.baseRecord(r.BaseRecord)
+		e.string(r.SourceTableName)
+		e.string(r.DestinationTableName)
+		e.columnSet(r.UnchangedToastColumns)
+		if err := encodeCDCStoreItems(e, r.OldItems); err != nil {
+			return err
+		}
+		return encodeCDCStoreItems(e, r.NewItems)
+	case *model.DeleteRecord[T]:
+		e.buf = append(e.buf, recordTagDelete)
+		e.baseRecord(r.BaseRecord)
+		e.string(r.SourceTableName)
+		e.string(r.DestinationTableName)
+		e.columnSet(r.UnchangedToastColumns)
+		return encodeCDCStoreItems(e, r.Items)
+	case *model.RelationRecord[T]:
+		e.buf = append(e.buf, recordTagRelation)
+		e.baseRecord(r.BaseRecord)
+		e.bool(r.TableSchemaDelta != nil)
+		if r.TableSchemaDelta == nil {
+			return nil
+		}
+		delta, err := proto.Marshal(r.TableSchemaDelta)
+		if err != nil {
+			return fmt.Errorf("failed to encode table schema delta: %w", err)
+		}
+		e.uvarint(uint64(len(delta)))
+		e.buf = append(e.buf, delta...)
+		return nil
+	case *model.MessageRecord[T]:
+		e.buf = append(e.buf, recordTagMessage)
+		e.baseRecord(r.BaseRecord)
+		e.string(r.Prefix)
+		e.string(r.Content)
+		return nil
+	default:
+		return fmt.Errorf("unsupported record type %T", rec)
+	}
+}
+
+func (e *cdcStoreEncoder) qvalue(qv types.QValue) error {
+	switch v := qv.(type) {
+	case types.QValueNull:
+		e.buf = append(e.buf, qvalueTagNull)
+		e.string(string(v))
+	case types.QValueInvalid:
+		e.buf = append(e.buf, qvalueTagInvalid)
+		e.string(v.Val)
+	case types.QValueFloat32:
+		e.buf = append(e.buf, qvalueTagFloat32)
+		e.float32(v.Val)
+	case types.QValueFloat64:
+		e.buf = append(e.buf, qvalueTagFloat64)
+		e.float64(v.Val)
+	case types.QValueInt8:
+		e.buf = append(e.buf, qvalueTagInt8)
+		e.varint(int64(v.Val))
+	case types.QValueInt16:
+		e.buf = append(e.buf, qvalueTagInt16)
+		e.varint(int64(v.Val))
+	case types.QValueInt32:
+		e.buf = append(e.buf, qvalueTagInt32)
+		e.varint(int64(v.Val))
+	case types.QValueInt64:
+		e.buf = append(e.buf, qvalueTagInt64)
+		e.varint(v.Val)
+	case types.QValueUInt8:
+		e.buf = append(e.buf, qvalueTagUInt8)
+		e.uvarint(uint64(v.Val))
+	case types.QValueUInt16:
+		e.buf = append(e.buf, qvalueTagUInt16)
+		e.uvarint(uint64(v.Val))
+	case types.QValueUInt32:
+		e.buf = append(e.buf, qvalueTagUInt32)
+		e.uvarint(uint64(v.Val))
+	case types.QValueUInt64:
+		e.buf = append(e.buf, qvalueTagUInt64)
+		e.uvarint(v.Val)
+	case types.QValueBoolean:
+		e.buf = append(e.buf, qvalueTagBoolean)
+		e.bool(v.Val)
+	case types.QValueQChar:
+		e.buf = append(e.buf, qvalueTagQChar, v.Val)
+	case types.QValueString:
+		e.buf = append(e.buf, qvalueTagString)
+		e.string(v.Val)
+	case types.QValueEnum:
+		e.buf = append(e.buf, qvalueTagEnum)
+		e.string(v.Val)
+	case types.QValueTimestamp:
+		e.buf = append(e.buf, qvalueTagTimestamp)
+		return e.time(v.Val)
+	case types.QValueTimestampTZ:
+		e.buf = append(e.buf, qvalueTagTimestampTZ)
+		return e.time(v.Val)
+	case types.QValueDate:
+		e.buf = append(e.buf, qvalueTagDate)
+		return e.time(v.Val)
+	case types.QValueTime:
+		e.buf = append(e.buf, qvalueTagTime)
+		e.varint(int64(v.Val))
+	case types.QValueTimeTZ:
+		e.buf = append(e.buf, qvalueTagTimeTZ)
+		e.varint(int64(v.Val))
+	case types.QValueInterval:
+		e.buf = append(e.buf, qvalueTagInterval)
+		e.string(v.Val)
+	case types.QValueNumeric:
+		e.buf = append(e.buf, qvalueTagNumeric)
+		e.varint(int64(v.Precision))
+		e.varint(int64(v.Scale))
+		return e.decimal(v.Val)
+	case types.QValueBytes:
+		e.buf = append(e.buf, qvalueTagBytes)
+		e.bytes(v.Val)
+	case types.QValueUUID:
+		e.buf = append(e.buf, qvalueTagUUID)
+		e.buf = append(e.buf, v.Val[:]...)
+	case types.QValueJSON:
+		e.buf = append(e.buf, qvalueTagJSON)
+		e.string(v.Val)
+		e.bool(v.IsArray)
+	case types.QValueHStore:
+		e.buf = append(e.buf, qvalueTagHStore)
+		e.string(v.Val)
+	case types.QValueGeography:
+		e.buf = append(e.buf, qvalueTagGeography)
+		e.string(v.Val)
+	case types.QValueGeometry:
+		e.buf = append(e.buf, qvalueTagGeometry)
+		e.string(v.Val)
+	case types.QValuePoint:
+		e.buf = append(e.buf, qvalueTagPoint)
+		e.string(v.Val)
+	case types.QValueCIDR:
+		e.buf = append(e.buf, qvalueTagCIDR)
+		e.string(v.Val)
+	case types.QValueINET:
+		e.buf = append(e.buf, qvalueTagINET)
+		e.string(v.Val)
+	case types.QValueMacaddr:
+		e.buf = append(e.buf, qvalueTagMacaddr)
+		e.string(v.Val)
+	case types.QValueArrayFloat32:
+		e.buf = append(e.buf, qvalueTagArrayFloat32)
+		e.length(len(v.Val), v.Val == nil)
+		for _, f := range v.Val {
+			e.float32(f)
+		}
+	case types.QValueArrayFloat64:
+		e.buf = append(e.buf, qvalueTagArrayFloat64)
+		e.length(len(v.Val), v.Val == nil)
+		for _, f := range v.Val {
+			e.float64(f)
+		}
+	case types.QValueArrayInt16:
+		e.buf = append(e.buf, qvalueTagArrayInt16)
+		e.length(len(v.Val), v.Val == nil)
+		for _, i := range v.Val {
+			e.varint(int64(i))
+		}
+	case types.QValueArrayInt32:
+		e.buf = append(e.buf, qvalueTagArrayInt32)
+		e.length(len(v.Val), v.Val == nil)
+		for _, i := range v.Val {
+			e.varint(int64(i))
+		}
+	case types.QValueArrayInt64:
+		e.buf = append(e.buf, qvalueTagArrayInt64)
+		e.length(len(v.Val), v.Val == nil)
+		for _, i := range v.Val {
+			e.varint(i)
+		}
+	case types.QValueArrayString:
+		e.buf = append(e.buf, qvalueTagArrayString)
+		e.strings(v.Val)
+	case types.QValueArrayEnum:
+		e.buf = append(e.buf, qvalueTagArrayEnum)
+		e.strings(v.Val)
+	case types.QValueArrayDate:
+		e.buf = append(e.buf, qvalueTagArrayDate)
+		return e.times(v.Val)
+	case types.QValueArrayInterval:
+		e.buf = append(e.buf, qvalueTagArrayInterval)
+		e.strings(v.Val)
+	case types.QValueArrayTimestamp:
+		e.buf = append(e.buf, qvalueTagArrayTimestamp)
+		return e.times(v.Val)
+	case types.QValueArrayTimestampTZ:
+		e.buf = append(e.buf, qvalueTagArrayTimestampTZ)
+		return e.times(v.Val)
+	case types.QValueArrayBoolean:
+		e.buf = append(e.buf, qvalueTagArrayBoolean)
+		e.length(len(v.Val), v.Val == nil)
+		for _, b := range v.Val {
+			e.bool(b)
+		}
+	case types.QValueArrayUUID:
+		e.buf = append(e.buf, qvalueTagArrayUUID)
+		e.length(len(v.Val), v.Val == nil)
+		for _, u := range v.Val {
+			e.buf = append(e.buf, u[:]...)
+		}
+	case types.QValueArrayNumeric:
+		e.buf = append(e.buf, qvalueTagArrayNumeric)
+		e.varint(int64(v.Precision))
+		e.varint(int64(v.Scale))
+		e.length(len(v.Val), v.Val == nil)
+		for _, d := range v.Val {
+			if err := e.decimal(d); err != nil {
+				return err
+			}
+		}
+	default:
+		return fmt.Errorf("unsupported qvalue type %T", qv)
+	}
+	return nil
+}
+
+var errCDCStoreTruncated = errors.New("truncated record")
+
+// cdcStoreDecoder reads a record from buf, the first error sticks and zero values are returned after it.
+// Decoded values never alias buf, which pebble reuses once the value is closed
+type cdcStoreDecoder struct {
+	err error
+	buf []byte
+}
+
+func (d *cdcStoreDecoder) fail(err error) {
+	if d.err == nil {
+		d.err = err
+	}
+}
+
+func (d *cdcStoreDecoder) byte() byte {
+	if d.err != nil {
+		return 0
+	}
+	if len(d.buf) == 0 {
+		d.fail(errCDCStoreTruncated)
+		return 0
+	}
+	b := d.buf[0]
+	d.buf = d.buf[1:]
+	return b
+}
+
+func (d *cdcStoreDecoder) next(n uint64) []byte {
+	if d.err != nil {
+		return nil
+	}
+	if uint64(len(d.buf)) < n {
+		d.fail(errCDCStoreTruncated)
+		return nil
+	}
+	b := d.buf[:n]
+	d.buf = d.buf[n:]
+	return b
+}
+
+func (d *cdcStoreDecoder) uvarint() uint64 {
+	if d.err != nil {
+		return 0
+	}
+	v, n := binary.Uvarint(d.buf)
+	if n <= 0 {
+		d.fail(errCDCStoreTruncated)
+		return 0
+	}
+	d.buf = d.buf[n:]
+	return v
+}
+
+func (d *cdcStoreDecoder) varint() int64 {
+	if d.err != nil {
+		return 0
+	}
+	v, n := binary.Varint(d.buf)
+	if n <= 0 {
+		d.fail(errCDCStoreTruncated)
+		return 0
+	}
+	d.buf = d.buf[n:]
+	return v
+}
+
+func (d *cdcStoreDecoder) bool() bool {
+	return d.byte() != 0
+}
+
+func (d *cdcStoreDecoder) string() string {
+	return string(d.next(d.uvarint()))
+}
+
+// length returns the length written by cdcStoreEncoder.length, which is -1 for nil
+func (d *cdcStoreDecoder) length() int {
+	n := d.uvarint()
+	if n == 0 || d.err != nil {
+		return -1
+	}
+	// every element takes at least a byte, bounds allocations of corrupt lengths
+	if n-1 > uint64(len(d.buf)) {
+		d.fail(errCDCStoreTruncated)
+		return -1
+	}
+	return int(n - 1)
+}
+
+func (d *cdcStoreDecoder) bytes() []byte {
+	n := d.length()
+	if n < 0 {
+		return nil
+	}
+	return append(make([]byte, 0, n), d.next(uint64(n))...)
+}
+
+func (d *cdcStoreDecoder) float32() float32 {
+	b := d.next(4)
+	if b == nil {
+		return 0
+	}
+	return math.Float32frombits(binary.LittleEndian.Uint32(b))
+}
+
+func (d *cdcStoreDecoder) float64() float64 {
+	b := d.next(8)
+	if b == nil {
+		return 0
+	}
+	return math.Float64frombits(binary.LittleEndian.Uint64(b))
+}
+
+func (d *cdcStoreDecoder) time() time.Time {
+	var t time.Time
+	b := d.next(uint64(d.byte()))
+	if d.err == nil {
+		d.fail(t.UnmarshalBinary(b))
+	}
+	return t
+}
+
+func (d *cdcStoreDecoder) decimal() decimal.Decimal {
+	var v decimal.Decimal
+	b := d.bytes()
+	if d.err == nil {
+		d.fail(v.UnmarshalBinary(b))
+	}
+	return v
+}
+
+func (d *cdcStoreDecoder) uuid() uuid.UUID {
+	var u uuid.UUID
+	copy(u[:], d.next(uint64(len(u))))
+	return u
+}
+
+func (d *cdcStoreDecoder) strings() []string {
+	n := d.length()
+	if n < 0 {
+		return nil
+	}
+	v := make([]string, n)
+	for i := range v {
+		v[i] = d.string()
+	}
+	return v
+}
+
+func (d *cdcStoreDecoder) times() []time.Time {
+	n := d.length()
+	if n < 0 {
+		return nil
+	}
+	v := make([]time.Time, n)
+	for i := range v {
+		v[i] = d.time()
+	}
+	return v
+}
+
+func (d *cdcStoreDecoder) columnSet() map[string]struct{} {
+	n := d.length()
+	if n < 0 {
+		return nil
+	}
+	v := make(map[string]struct{}, n)
+	for range n {
+		v[d.string()] = struct{}{}
+	}
+	return v
+}
+
+func (d *cdcStoreDecoder) baseRecord() model.BaseRecord {
+	return model.BaseRecord{
+		CheckpointID:   d.varint(),
+		CommitTimeNano: d.varint(),
+	}
+}
+
+func decodeCDCStoreItems[T model.Items](d *cdcStoreDecoder) T {
+	var items T
+	switch p := any(&items).(type) {
+	case *model.RecordItems:
+		if n := d.length(); n >= 0 {
+			p.ColToVal = make(map[string]types.QValue, n)
+			for range n {
+				col := d.string()
+				p.ColToVal[col] = d.qvalue()
+			}
+		}
+	case *model.PgItems:
+		if n := d.length(); n >= 0 {
+			p.ColToVal = make(map[string][]byte, n)
+			for range n {
+				col := d.string()
+				p.ColToVal[col] = d.bytes()
+			}
+		}
+	default:
+		d.fail(fmt.Errorf("unsupported items type %T", items))
+	}
+	return items
+}
+
+// decodeCDCStoreRecord decodes a record written by encodeCDCStoreRecord
+func decodeCDCStoreRecord[T model.Items](buf []byte) (model.Record[T], error) {
+	d := &cdcStoreDecoder{buf: buf}
+	if version := d.byte(); d.err == nil && version != cdcStoreCodecVersion {
+		return nil, fmt.Errorf("unsupported record encoding version %d", version)
+	}
+	var rec model.Record[T]
+	switch tag := d.byte(); tag {
+	case recordTagInsert:
+		r := &model.InsertRecord[T]{BaseRecord: d.baseRecord()}
+		r.SourceTableName = d.string()
+		r.DestinationTableName = d.string()
+		r.CommitID = d.varint()
+		r.Items = decodeCDCStoreItems[T](d)
+		rec = r
+	case recordTagUpdate:
+		r := &model.UpdateRecord[T]{BaseRecord: d.baseRecord()}
+		r.SourceTableName = d.string()
+		r.DestinationTableName = d.string()
+		r.UnchangedToastColumns = d.columnSet()
+		r.OldItems = decodeCDCStoreItems[T](d)
+		r.NewItems = decodeCDCStoreItems[T](d)
+		rec = r
+	case recordTagDelete:
+		r := &model.DeleteRecord[T]{BaseRecord: d.baseRecord()}
+		r.SourceTableName = d.string()
+		r.DestinationTableName = d.string()
+		r.UnchangedToastColumns = d.columnSet()
+		r.Items = decodeCDCStoreItems[T](d)
+		rec = r
+	case recordTagRelation:
+		r := &model.RelationRecord[T]{BaseRecord: d.baseRecord()}
+		if d.bool() {
+			delta := d.next(d.uvarint())
+			if d.err != nil {
+				break
+			}
+			r.TableSchemaDelta = &protos.TableSchemaDelta{}
+			if err := proto.Unmarshal(delta, r.TableSchemaDelta); err != nil {
+				return nil, fmt.Errorf("failed to decode table schema delta: %w", err)
+			}
+		}
+		rec = r
+	case recordTagMessage:
+		r := &model.MessageRecord[T]{BaseRecord: d.baseRecord()}
+		r.Prefix = d.string()
+		r.Content = d.string()
+		rec = r
+	default:
+		d.fail(fmt.Errorf("unknown record tag %d", tag))
+	}
+	if d.err != nil {
+		return nil, fmt.Errorf("failed to decode record: %w", d.err)
+	}
+	return rec, nil
+}
+
+func (d *cdcStoreDecoder) qvalue() types.QValue {
+	switch tag := d.byte(); tag {
+	case qvalueTagNull:
+		return types.QValueNull(d.string())
+	case qvalueTagInvalid:
+		return types.QValueInvalid{Val: d.string()}
+	case qvalueTagFloat32:
+		return types.QValueFloat32{Val: d.float32()}
+	case qvalueTagFloat64:
+		return types.QValueFloat64{Val: d.float64()}
+	case qvalueTagInt8:
+		return types.QValueInt8{Val: int8(d.varint())}
+	case qvalueTagInt16:
+		return types.QValueInt16{Val: int16(d.varint())}
+	case qvalueTagInt32:
+		return types.QValueInt32{Val: int32(d.varint())}
+	case qvalueTagInt64:
+		return types.QValueInt64{Val: d.varint()}
+	case qvalueTagUInt8:
+		return types.QValueUInt8{Val: uint8(d.uvarint())}
+	case qvalueTagUInt16:
+		return types.QValueUInt16{Val: uint16(d.uvarint())}
+	case qvalueTagUInt32:
+		return types.QValueUInt32{Val: uint32(d.uvarint())}
+	case qvalueTagUInt64:
+		return types.QValueUInt64{Val: d.uvarint()}
+	case qvalueTagBoolean:
+		return types.QValueBoolean{Val: d.bool()}
+	case qvalueTagQChar:
+		return types.QValueQChar{Val: d.byte()}
+	case qvalueTagString:
+		return types.QValueString{Val: d.string()}
+	case qvalueTagEnum:
+		return types.QValueEnum{Val: d.string()}
+	case qvalueTagTimestamp:
+		return types.QValueTimestamp{Val: d.time()}
+	case qvalueTagTimestampTZ:
+		return types.QValueTimestampTZ{Val: d.time()}
+	case qvalueTagDate:
+		return types.QValueDate{Val: d.time()}
+	case qvalueTagTime:
+		return types.QValueTime{Val: time.Duration(d.varint())}
+	case qvalueTagTimeTZ:
+		return types.QValueTimeTZ{Val: time.Duration(d.varint())}
+	case qvalueTagInterval:
+		return types.QValueInterval{Val: d.string()}
+	case qvalueTagNumeric:
+		precision := int16(d.varint())
+		scale := int16(d.varint())
+		return types.QValueNumeric{Val: d.decimal(), Precision: precision, Scale: scale}
+	case qvalueTagBytes:
+		return types.QValueBytes{Val: d.bytes()}
+	case qvalueTagUUID:
+		return types.QValueUUID{Val: d.uuid()}
+	case qvalueTagJSON:
+		val := d.string()
+		return types.QValueJSON{Val: val, IsArray: d.bool()}
+	case qvalueTagHStore:
+		return types.QValueHStore{Val: d.string()}
+	case qvalueTagGeography:
+		return types.QValueGeography{Val: d.string()}
+	case qvalueTagGeometry:
+		return types.QValueGeometry{Val: d.string()}
+	case qvalueTagPoint:
+		return types.QValuePoint{Val: d.string()}
+	case qvalueTagCIDR:
+		return types.QValueCIDR{Val: d.string()}
+	case qvalueTagINET:
+		return types.QValueINET{Val: d.string()}
+	case qvalueTagMacaddr:
+		return types.QValueMacaddr{Val: d.string()}
+	case qvalueTagArrayFloat32:
+		var v []float32
+		if n := d.length(); n >= 0 {
+			v = make([]float32, n)
+			for i := range v {
+				v[i] = d.float32()
+			}
+		}
+		return types.QValueArrayFloat32{Val: v}
+	case qvalueTagArrayFloat64:
+		var v []float64
+		if n := d.length(); n >= 0 {
+			v = make([]float64, n)
+			for i := range v {
+				v[i] = d.float64()
+			}
+		}
+		return types.QValueArrayFloat64{Val: v}
+	case qvalueTagArrayInt16:
+		var v []int16
+		if n := d.length(); n >= 0 {
+			v = make([]int16, n)
+			for i := range v {
+				v[i] = int16(d.varint())
+			}
+		}
+		return types.QValueArrayInt16{Val: v}
+	case qvalueTagArrayInt32:
+		var v []int32
+		if n := d.length(); n >= 0 {
+			v = make([]int32, n)
+			for i := range v {
+				v[i] = int32(d.varint())
+			}
+		}
+		return types.QValueArrayInt32{Val: v}
+	case qvalueTagArrayInt64:
+		var v []int64
+		if n := d.length(); n >= 0 {
+			v = make([]int64, n)
+			for i := range v {
+				v[i] = d.varint()
+			}
+		}
+		return types.QValueArrayInt64{Val: v}
+	case qvalueTagArrayString:
+		return types.QValueArrayString{Val: d.strings()}
+	case qvalueTagArrayEnum:
+		return types.QValueArrayEnum{Val: d.strings()}
+	case qvalueTagArrayDate:
+		return types.QValueArrayDate{Val: d.times()}
+	case qvalueTagArrayInterval:
+		return types.QValueArrayInterval{Val: d.strings()}
+	case qvalueTagArrayTimestamp:
+		return types.QValueArrayTimestamp{Val: d.times()}
+	case qvalueTagArrayTimestampTZ:
+		return types.QValueArrayTimestampTZ{Val: d.times()}
+	case qvalueTagArrayBoolean:
+		var v []bool
+		if n := d.length(); n >= 0 {
+			v = make([]bool, n)
+			for i := range v {
+				v[i] = d.bool()
+			}
+		}
+		return types.QValueArrayBoolean{Val: v}
+	case qvalueTagArrayUUID:
+		var v []uuid.UUID
+		if n := d.length(); n >= 0 {
+			v = make([]uuid.UUID, n)
+			for i := range v {
+				v[i] = d.uuid()
+			}
+		}
+		return types.QValueArrayUUID{Val: v}
+	case qvalueTagArrayNumeric:
+		precision := int16(d.varint())
+		scale := int16(d.varint())
+		var v []decimal.Decimal
+		if n := d.length(); n >= 0 {
+			v = make([]decimal.Decimal, n)
+			for i := range v {
+				v[i] = d.decimal()
+			}
+		}
+		return types.QValueArrayNumeric{Val: v, Precision: precision, Scale: scale}
+	default:
+		d.fail(fmt.Errorf("unknown qvalue tag %d", tag))
+		return nil
+	}
+}
@@ -1,14 +1,19 @@
 package utils
 
 import (
+	"bytes"
 	"crypto/rand"
+	"encoding/gob"
 	"log/slog"
 	"testing"
 	"time"
 
+	"github.com/google/uuid"
 	"github.com/shopspring/decimal"
 	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/proto"
 
+	"github.com/PeerDB-io/peerdb/flow/generated/protos"
 	"github.com/PeerDB-io/peerdb/flow/model"
 	"github.com/PeerDB-io/peerdb/flow/shared/types"
 )
@@ -141,3 +146,202 @@ func TestNullKeyDoesntStore(t *testing.T) {
 
 	require.NoError(t, cdcRecordsStore.Close())
 }
+
+func allQValues() map[string]types.QValue {
+	ts := time.Date(2024, 2, 29, 13, 14, 15, 123456000, time.FixedZone("", 5*60*60+30*60))
+	return map[string]types.QValue{
+		"null":       types.QValueNull(types.QValueKindString),
+		"invalid":    types.QValueInvalid{Val: "?"},
+		"float32":    types.QValueFloat32{Val: 1.5},
+		"float64":    types.QValueFloat64{Val: -2.25},
+		"int8":       types.QValueInt8{Val: -8},
+		"int16":      types.QValueInt16{Val: -16},
+		"int32":      types.QValueInt32{Val: -32},
+		"int64":      types.QValueInt64{Val: -64},
+		"uint8":      types.QValueUInt8{Val: 8},
+		"uint16":     types.QValueUInt16{Val: 16},
+		"uint32":     types.QValueUInt32{Val: 32},
+		"uint64":     types.QValueUInt64{Val: 1 << 63},
+		"boolean":    types.QValueBoolean{Val: true},
+		"qchar":      types.QValueQChar{Val: 'c'},
+		"string":     types.QValueString{Val: "string"},
+		"enum":       types.QValueEnum{Val: "enum"},
+		"ts":         types.QValueTimestamp{Val: ts.UTC()},
+		"tstz":       types.QValueTimestampTZ{Val: ts},
+		"date":       types.QValueDate{Val: ts.Truncate(24 * time.Hour).UTC()},
+		"time":       types.QValueTime{Val: timeForTesting},
+		"timetz":     types.QValueTimeTZ{Val: timeForTesting},
+		"interval":   types.QValueInterval{Val: `{"days":1}`},
+		"numeric":    types.QValueNumeric{Val: decimalForTesting, Precision: 38, Scale: 9},
+		"bytes":      types.QValueBytes{Val: []byte{0, 1, 2}},
+		"nilbytes":   types.QValueBytes{},
+		"uuid":       types.QValueUUID{Val: uuid.New()},
+		"json":       types.QValueJSON{Val: "[1]", IsArray: true},
+		"hstore":     types.QValueHStore{Val: `"a"=>"b"`},
+		"geography":  types.QValueGeography{Val: "POINT(1 2)"},
+		"geometry":   types.QValueGeometry{Val: "POINT(1 2)"},
+		"point":      types.QValuePoint{Val: "POINT(1 2)"},
+		"cidr":       types.QValueCIDR{Val: "10.0.0.0/8"},
+		"inet":       types.QValueINET{Val: "10.0.0.1"},
+		"macaddr":    types.QValueMacaddr{Val: "08:00:2b:01:02:03"},
+		"afloat32":   types.QValueArrayFloat32{Val: []float32{1, 2}},
+		"afloat64":   types.QValueArrayFloat64{Val: []float64{}},
+		"aint16":     types.QValueArrayInt16{Val: []int16{-1, 1}},
+		"aint32":     types.QValueArrayInt32{Val: []int32{-1, 1}},
+		"aint64":     types.QValueArrayInt64{},
+		"astring":    types.QValueArrayString{Val: []string{"", "a"}},
+		"aenum":      types.QValueArrayEnum{Val: []string{"b"}},
+		"adate":      types.QValueArrayDate{Val: []time.Time{ts.UTC()}},
+		"ainterval":  types.QValueArrayInterval{Val: []string{`{"days":1}`}},
+		"ats":        types.QValueArrayTimestamp{Val: []time.Time{ts.UTC(), ts.UTC()}},
+		"atstz":      types.QValueArrayTimestampTZ{Val: []time.Time{ts}},
+		"aboolean":   types.QValueArrayBoolean{Val: []bool{true, false}},
+		"auuid":      types.QValueArrayUUID{Val: []uuid.UUID{uuid.New()}},
+		"anumeric":   types.QValueArrayNumeric{Val: []decimal.Decimal{decimalForTesting, decimal.Zero}, Precision: 10, Scale: 2},
+		"emptyarray": types.QValueArrayString{Val: []string{}},
+	}
+}
+
+func roundTrip[T model.Items](t *testing.T, rec model.Record[T]) model.Record[T] {
+	t.Helper()
+
+	var enc cdcStoreEncoder
+	require.NoError(t, encodeCDCStoreRecord(&enc, rec))
+	decoded, err := decodeCDCStoreRecord[T](enc.buf)
+	require.NoError(t, err)
+	return decoded
+}
+
+func TestCodecRoundTrip(t *testing.T) {
+	t.Parallel()
+
+	insert := &model.InsertRecord[model.RecordItems]{
+		BaseRecord:           model.BaseRecord{CheckpointID: 1 << 40, CommitTimeNano: time.Now().UnixNano()},
+		SourceTableName:      "public.src",
+		DestinationTableName: "dst",
+		CommitID:             -1,
+		Items:                model.RecordItems{ColToVal: allQValues()},
+	}
+	require.Equal(t, model.Record[model.RecordItems](insert), roundTrip(t, model.Record[model.RecordItems](insert)))
+
+	update := &model.UpdateRecord[model.RecordItems]{
+		BaseRecord:            model.BaseRecord{CheckpointID: 2},
+		SourceTableName:       "public.src",
+		DestinationTableName:  "dst",
+		UnchangedToastColumns: map[string]struct{}{"t1": {}, "t2": {}},
+		OldItems:              model.NewRecordItems(0),
+		NewItems:              model.RecordItems{ColToVal: allQValues()},
+	}
+	require.Equal(t, model.Record[model.RecordItems](update), roundTrip(t, model.Record[model.RecordItems](update)))
+
+	del := &model.DeleteRecord[model.PgItems]{
+		BaseRecord:           model.BaseRecord{CheckpointID: 3},
+		SourceTableName:      "public.src",
+		DestinationTableName: "dst",
+		Items:                model.PgItems{ColToVal: map[string][]byte{"id": []byte("1"), "empty": {}, "null": nil}},
+	}
+	require.Equal(t, model.Record[model.PgItems](del), roundTrip(t, model.Record[model.PgItems](del)))
+
+	msg := &model.MessageRecord[model.PgItems]{
+		BaseRecord: model.BaseRecord{CheckpointID: 4},
+		Prefix:     "prefix",
+		Content:    "content",
+	}
+	require.Equal(t, model.Record[model.PgItems](msg), roundTrip(t, model.Record[model.PgItems](msg)))
+
+	relation := &model.RelationRecord[model.RecordItems]{
+		BaseRecord: model.BaseRecord{CheckpointID: 5},
+		TableSchemaDelta: &protos.TableSchemaDelta{
+			SrcTableName: "public.src",
+			DstTableName: "dst",
+			AddedColumns: []*protos.FieldDescription{{Name: "c", Type: "text", Nullable: true}},
+		},
+	}
+	decodedRelation, ok := roundTrip(t, model.Record[model.RecordItems](relation)).(*model.RelationRecord[model.RecordItems])
+	require.True(t, ok)
+	require.Equal(t, relation.BaseRecord, decodedRelation.BaseRecord)
+	require.True(t, proto.Equal(relation.TableSchemaDelta, decodedRelation.TableSchemaDelta))
+}
+
+func TestCodecRejectsCorruptRecords(t *testing.T) {
+	t.Parallel()
+
+	_, rec := genKeyAndRec(t)
+	var enc cdcStoreEncoder
+	require.NoError(t, encodeCDCStoreRecord(&enc, rec))
+
+	for i := range len(enc.buf) {
+		_, err := decodeCDCStoreRecord[model.RecordItems](enc.buf[:i])
+		require.Error(t, err)
+	}
+	enc.buf[0] = cdcStoreCodecVersion + 1
+	_, err := decodeCDCStoreRecord[model.RecordItems](enc.buf)
+	require.ErrorContains(t, err, "unsupported record encoding version")
+}
+
+func benchmarkRecords() []model.Record[model.RecordItems] {
+	recs := make([]model.Record[model.RecordItems], 0, 1000)
+	for i := range cap(recs) {
+		items := model.NewRecordItems(8)
+		items.AddColumn("id", types.QValueInt64{Val: int64(i)})
+		items.AddColumn("name", types.QValueString{Val: "a reasonably sized text value of a row"})
+		items.AddColumn("amount", types.QValueNumeric{Val: decimalForTesting, Precision: 38, Scale: 9})
+		items.AddColumn("created_at", types.QValueTimestampTZ{Val: time.Unix(int64(i), 0).UTC()})
+		items.AddColumn("active", types.QValueBoolean{Val: i%2 == 0})
+		items.AddColumn("payload", types.QValueJSON{Val: `{"key":"value","list":[1,2,3]}`})
+		items.AddColumn("tags", types.QValueArrayString{Val: []string{"x", "y"}})
+		items.AddColumn("ref", types.QValueUUID{Val: uuid.New()})
+		recs = append(recs, &model.InsertRecord[model.RecordItems]{
+			BaseRecord:           model.BaseRecord{CheckpointID: int64(i), CommitTimeNano: int64(i)},
+			SourceTableName:      "public.src",
+			DestinationTableName: "dst",
+			Items:                items,
+		})
+	}
+	return recs
+}
+
+func BenchmarkCodecSpill(b *testing.B) {
+	recs := benchmarkRecords()
+	var enc cdcStoreEncoder
+	b.ReportAllocs()
+	for b.Loop() {
+		for _, rec := range recs {
+			enc.buf = enc.buf[:0]
+			if err := encodeCDCStoreRecord(&enc, rec); err != nil {
+				b.Fatal(err)
+			}
+			if _, err := decodeCDCStoreRecord[model.RecordItems](enc.buf); err != nil {
+				b.Fatal(err)
+			}
+		}
+	}
+}
+
+// BenchmarkGobSpill is the encoding the store used before its own codec, kept as a baseline
+func BenchmarkGobSpill(b *testing.B) {
+	recs := benchmarkRecords()
+	gob.Register(&model.InsertRecord[model.RecordItems]{})
+	gob.Register(decimal.Decimal{})
+	gob.Register(types.QValueInt64{})
+	gob.Register(types.QValueString{})
+	gob.Register(types.QValueNumeric{})
+	gob.Register(types.QValueTimestampTZ{})
+	gob.Register(types.QValueBoolean{})
+	gob.Register(types.QValueJSON{})
+	gob.Register(types.QValueArrayString{})
+	gob.Register(types.QValueUUID{})
+	b.ReportAllocs()
+	for b.Loop() {
+		for _, rec := range recs {
+			var buf bytes.Buffer
+			if err := gob.NewEncoder(&buf).Encode(&rec); err != nil {
+				b.Fatal(err)
+			}
+			var decoded model.Record[model.RecordItems]
+			if err := gob.NewDecoder(bytes.NewReader(buf.Bytes())).Decode(&decoded); err != nil {
+				b.Fatal(err)
+			}
+		}
+	}
+}
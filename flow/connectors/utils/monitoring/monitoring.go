@@ -6,6 +6,7 @@ import (
 	"fmt"
 	"log/slog"
 	"strconv"
+	"strings"
 	"time"
 
 	"github.com/jackc/pgx/v5"
@@ -324,6 +325,9 @@ func addPartitionToQRepRun(ctx context.Context, tx pgx.Tx, flowJobName string,
 		case *protos.PartitionRange_ObjectIdRange:
 			rangeStart = x.ObjectIdRange.Start
 			rangeEnd = x.ObjectIdRange.End
+		case *protos.PartitionRange_KeysetRange:
+			rangeStart = strings.Join(x.KeysetRange.Start, ",")
+			rangeEnd = strings.Join(x.KeysetRange.End, ",")
 		default:
 			return fmt.Errorf("unknown range type: %v", x)
 		}
@@ -5,6 +5,7 @@ import (
 	"errors"
 	"fmt"
 	"log/slog"
+	"slices"
 	"time"
 
 	"github.com/google/uuid"
@@ -14,6 +15,7 @@ import (
 	"google.golang.org/protobuf/types/known/timestamppb"
 
 	"github.com/PeerDB-io/peerdb/flow/generated/protos"
+	"github.com/PeerDB-io/peerdb/flow/shared/types"
 )
 
 type PartitionRangeType string
@@ -228,6 +230,65 @@ func createObjectIdPartition(start bson.ObjectID, end bson.ObjectID) *protos.QRe
 	}
 }
 
+func createKeysetPartition(start []string, end []string) *protos.QRepPartition {
+	return &protos.QRepPartition{
+		PartitionId: uuid.New().String(),
+		Range: &protos.PartitionRange{
+			Range: &protos.PartitionRange_KeysetRange{
+				KeysetRange: &protos.KeysetPartitionRange{
+					Start: start,
+					End:   end,
+				},
+			},
+		},
+	}
+}
+
+// KeysetPartitions splits keys sampled in key order into up to numPartitions ranges.
+// The first and last ranges are open ended, so rows outside the sample are still covered
+func KeysetPartitions(samples [][]string, numPartitions int64) []*protos.QRepPartition {
+	var boundaries [][]string
+	for i := int64(1); i < numPartitions; i++ {
+		idx := i*int64(len(samples))/numPartitions - 1
+		if idx < 0 {
+			continue
+		}
+		// keys that are not unique can repeat in the sample
+		if len(boundaries) > 0 && slices.Equal(boundaries[len(boundaries)-1], samples[idx]) {
+			continue
+		}
+		boundaries = append(boundaries, samples[idx])
+	}
+
+	partitions := make([]*protos.QRepPartition, 0, len(boundaries)+1)
+	var start []string
+	for _, end := range boundaries {
+		partitions = append(partitions, createKeysetPartition(start, end))
+		start = end
+	}
+	return append(partitions, createKeysetPartition(start, nil))
+}
+
+// SupportsRangePartitioning is whether a column can be split into ranges of its values,
+// other partition keys are split into keyset ranges
+func SupportsRangePartitioning(system protos.TypeSystem, columnType string) bool {
+	switch system {
+	case protos.TypeSystem_PG:
+		switch columnType {
+		case "int2", "int4", "int8", "timestamp", "timestamptz":
+			return true
+		}
+	case protos.TypeSystem_Q:
+		switch types.QValueKind(columnType) {
+		case types.QValueKindInt8, types.QValueKindInt16, types.QValueKindInt32, types.QValueKindInt64,
+			types.QValueKindUInt8, types.QValueKindUInt16, types.QValueKindUInt32, types.QValueKindUInt64,
+			types.QValueKindTimestamp, types.QValueKindTimestampTZ:
+			return true
+		}
+	}
+	return false
+}
+
 type PartitionHelper struct {
 	logger     log.Logger
 	prevStart  any
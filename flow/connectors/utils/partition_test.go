@@ -0,0 +1,40 @@
+package utils
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/PeerDB-io/peerdb/flow/generated/protos"
+)
+
+func TestKeysetPartitions(t *testing.T) {
+	t.Parallel()
+
+	samples := [][]string{{"a", "1"}, {"a", "2"}, {"b", "1"}, {"b", "1"}, {"c", "1"}, {"d", "1"}}
+	partitions := KeysetPartitions(samples, 3)
+	ranges := make([]*protos.KeysetPartitionRange, 0, len(partitions))
+	for _, partition := range partitions {
+		ranges = append(ranges, partition.Range.GetKeysetRange())
+	}
+	require.Equal(t, []*protos.KeysetPartitionRange{
+		{End: []string{"a", "2"}},
+		{Start: []string{"a", "2"}, End: []string{"b", "1"}},
+		{Start: []string{"b", "1"}},
+	}, ranges)
+
+	// repeated keys collapse boundaries, fewer samples than partitions still cover the table
+	partitions = KeysetPartitions([][]string{{"x"}, {"x"}}, 4)
+	require.Len(t, partitions, 2)
+	require.Equal(t, []string{"x"}, partitions[0].Range.GetKeysetRange().End)
+	require.Empty(t, KeysetPartitions(nil, 4)[0].Range.GetKeysetRange().Start)
+}
+
+func TestSupportsRangePartitioning(t *testing.T) {
+	t.Parallel()
+
+	require.True(t, SupportsRangePartitioning(protos.TypeSystem_Q, "int64"))
+	require.True(t, SupportsRangePartitioning(protos.TypeSystem_PG, "timestamptz"))
+	require.False(t, SupportsRangePartitioning(protos.TypeSystem_Q, "string"))
+	require.False(t, SupportsRangePartitioning(protos.TypeSystem_PG, "uuid"))
+}
@@ -87,6 +87,30 @@ func CreatePeerNoValidate(
 			return wrongConfigResponse, nil
 		}
 		innerConfig = esConfigObject.ElasticsearchConfig
+	case protos.DBType_OPENSEARCH:
+		osConfigObject, ok := config.(*protos.Peer_OpensearchConfig)
+		if !ok {
+			return wrongConfigResponse, nil
+		}
+		innerConfig = osConfigObject.OpensearchConfig
+	case protos.DBType_REDIS:
+		redisConfigObject, ok := config.(*protos.Peer_RedisConfig)
+		if !ok {
+			return wrongConfigResponse, nil
+		}
+		innerConfig = redisConfigObject.RedisConfig
+	case protos.DBType_NATS:
+		natsConfigObject, ok := config.(*protos.Peer_NatsConfig)
+		if !ok {
+			return wrongConfigResponse, nil
+		}
+		innerConfig = natsConfigObject.NatsConfig
+	case protos.DBType_WEBHOOK:
+		webhookConfigObject, ok := config.(*protos.Peer_WebhookConfig)
+		if !ok {
+			return wrongConfigResponse, nil
+		}
+		innerConfig = webhookConfigObject.WebhookConfig
 	case protos.DBType_MONGO:
 		mongoConfigObject, ok := config.(*protos.Peer_MongoConfig)
 		if !ok {
@@ -111,7 +135,8 @@ func CreatePeerNoValidate(
 
 	onConflict := "NOTHING"
 	if allowUpdate {
-		onConflict = "UPDATE SET type = $2,options = $3,enc_key_id = $4"
+		// bumping config_version lets running mirrors know to reconnect with the new configuration
+		onConflict = "UPDATE SET type = $2,options = $3,enc_key_id = $4,config_version = peers.config_version + 1"
 	}
 
 	if _, err := pool.Exec(ctx, `
@@ -0,0 +1,76 @@
+package connwebhook
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/PeerDB-io/peerdb/flow/generated/protos"
+	"github.com/PeerDB-io/peerdb/flow/model"
+	"github.com/PeerDB-io/peerdb/flow/shared"
+)
+
+func (*WebhookConnector) SetupQRepMetadataTables(_ context.Context, _ *protos.QRepConfig) error {
+	return nil
+}
+
+func (c *WebhookConnector) SyncQRepRecords(
+	ctx context.Context,
+	config *protos.QRepConfig,
+	partition *protos.QRepPartition,
+	stream *model.QRecordStream,
+) (int64, shared.QRepWarnings, error) {
+	startTime := time.Now()
+	schema, err := stream.Schema()
+	if err != nil {
+		return 0, nil, err
+	}
+
+	ls, err := c.loadScript(ctx, config.Script, config.FlowJobName)
+	if err != nil {
+		return 0, nil, err
+	}
+	defer ls.Close()
+
+	// partitions are retried as a whole, so they key requests the way sync batches do
+	batcher := requestBatcher{
+		conn:      c,
+		keyPrefix: fmt.Sprintf("%s-%s", config.FlowJobName, partition.PartitionId),
+		events:    make([]json.RawMessage, 0, c.batchSize),
+	}
+	var numRecords int64
+	for qRecord := range stream.Records {
+		items := model.NewRecordItems(len(qRecord))
+		for i, val := range qRecord {
+			items.AddColumn(schema.Fields[i].Name, val)
+		}
+		record := &model.InsertRecord[model.RecordItems]{
+			BaseRecord:           model.BaseRecord{},
+			Items:                items,
+			SourceTableName:      config.WatermarkTable,
+			DestinationTableName: config.DestinationTableIdentifier,
+			CommitID:             0,
+		}
+
+		events, err := recordEvents(ls, record)
+		if err != nil {
+			return 0, nil, err
+		}
+		if err := batcher.add(ctx, events...); err != nil {
+			return 0, nil, err
+		}
+		numRecords++
+	}
+	if err := stream.Err(); err != nil {
+		return 0, nil, fmt.Errorf("[webhook] failed to get record from stream: %w", err)
+	}
+	if err := batcher.flush(ctx); err != nil {
+		return 0, nil, err
+	}
+
+	if err := c.FinishQRepPartition(ctx, partition, config.FlowJobName, startTime); err != nil {
+		return 0, nil, err
+	}
+	return numRecords, nil, nil
+}
@@ -0,0 +1,322 @@
+package connwebhook
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"time"
+
+	lua "github.com/yuin/gopher-lua"
+	"go.temporal.io/sdk/log"
+
+	"github.com/PeerDB-io/gluajson"
+	metadataStore "github.com/PeerDB-io/peerdb/flow/connectors/external_metadata"
+	"github.com/PeerDB-io/peerdb/flow/connectors/utils"
+	"github.com/PeerDB-io/peerdb/flow/generated/protos"
+	"github.com/PeerDB-io/peerdb/flow/internal"
+	"github.com/PeerDB-io/peerdb/flow/model"
+	"github.com/PeerDB-io/peerdb/flow/pua"
+	"github.com/PeerDB-io/peerdb/flow/shared"
+)
+
+const (
+	idempotencyHeader = "Idempotency-Key"
+
+	defaultBatchSize   = 100
+	defaultMaxAttempts = 5
+	defaultTimeout     = 30 * time.Second
+	baseBackoff        = time.Second
+)
+
+type WebhookConnector struct {
+	*metadataStore.PostgresMetadata
+	client        *http.Client
+	logger        log.Logger
+	headers       map[string]string
+	url           string
+	authorization string
+	signingSecret string
+	batchSize     int
+	maxAttempts   int
+}
+
+func NewWebhookConnector(
+	ctx context.Context,
+	config *protos.WebhookConfig,
+) (*WebhookConnector, error) {
+	if config.Url == "" {
+		return nil, errors.New("webhook url is required")
+	}
+
+	pgMetadata, err := metadataStore.NewPostgresMetadata(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	timeout := defaultTimeout
+	if config.TimeoutSeconds > 0 {
+		timeout = time.Duration(config.TimeoutSeconds) * time.Second
+	}
+	batchSize := defaultBatchSize
+	if config.BatchSize > 0 {
+		batchSize = int(config.BatchSize)
+	}
+	maxAttempts := defaultMaxAttempts
+	if config.MaxAttempts > 0 {
+		maxAttempts = int(config.MaxAttempts)
+	}
+
+	return &WebhookConnector{
+		PostgresMetadata: pgMetadata,
+		client:           &http.Client{Timeout: timeout},
+		logger:           internal.LoggerFromCtx(ctx),
+		headers:          config.Headers,
+		url:              config.Url,
+		authorization:    config.GetAuthorization(),
+		signingSecret:    config.GetSigningSecret(),
+		batchSize:        batchSize,
+		maxAttempts:      maxAttempts,
+	}, nil
+}
+
+func (c *WebhookConnector) Close() error {
+	return nil
+}
+
+// ConnectionActive only checks that the endpoint answers, receivers aren't expected to handle anything but POST
+func (c *WebhookConnector) ConnectionActive(ctx context.Context) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodHead, c.url, http.NoBody)
+	if err != nil {
+		return err
+	}
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to reach webhook: %w", err)
+	}
+	resp.Body.Close()
+	return nil
+}
+
+func (c *WebhookConnector) CreateRawTable(ctx context.Context, req *protos.CreateRawTableInput) (*protos.CreateRawTableOutput, error) {
+	return &protos.CreateRawTableOutput{TableIdentifier: "n/a"}, nil
+}
+
+func (c *WebhookConnector) ReplayTableSchemaDeltas(_ context.Context, _ map[string]string,
+	flowJobName string, schemaDeltas []*protos.TableSchemaDelta,
+) error {
+	return nil
+}
+
+// post sends events as a JSON array, retrying with exponential backoff on network errors, 429 and 5xx responses,
+// every attempt carries the same idempotency key so receivers can drop requests they already processed
+func (c *WebhookConnector) post(ctx context.Context, idempotencyKey string, events []json.RawMessage) error {
+	body, err := json.Marshal(events)
+	if err != nil {
+		return fmt.Errorf("failed to serialize events: %w", err)
+	}
+
+	backoff := baseBackoff
+	for attempt := 1; ; attempt++ {
+		retryable, err := c.postOnce(ctx, idempotencyKey, body)
+		if err == nil {
+			return nil
+		}
+		if !retryable || attempt >= c.maxAttempts {
+			return fmt.Errorf("[webhook] request %s failed after %d attempts: %w", idempotencyKey, attempt, err)
+		}
+		c.logger.Warn(fmt.Sprintf("[webhook] request attempt #%d failed, retrying in %s: %v", attempt, backoff, err))
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(backoff):
+		}
+		backoff *= 2
+	}
+}
+
+func (c *WebhookConnector) postOnce(ctx context.Context, idempotencyKey string, body []byte) (bool, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.url, bytes.NewReader(body))
+	if err != nil {
+		return false, err
+	}
+	for key, value := range c.headers {
+		req.Header.Set(key, value)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(idempotencyHeader, idempotencyKey)
+	if c.authorization != "" {
+		req.Header.Set("Authorization", c.authorization)
+	}
+	timestamp := strconv.FormatInt(time.Now().Unix(), 10)
+	req.Header.Set(shared.WebhookTimestampHeader, timestamp)
+	if c.signingSecret != "" {
+		req.Header.Set(shared.WebhookSignatureHeader, shared.SignWebhookPayload(c.signingSecret, timestamp, body))
+	}
+
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return true, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode >= 200 && resp.StatusCode < 300 {
+		return false, nil
+	}
+	respBody, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+	err = fmt.Errorf("webhook returned %s: %s", resp.Status, respBody)
+	return resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode >= 500, err
+}
+
+func (c *WebhookConnector) loadScript(ctx context.Context, script string, flowJobName string) (*lua.LState, error) {
+	ls, err := utils.LoadScript(ctx, script, utils.LuaPrintFn(func(s string) {
+		_ = c.LogFlowInfo(ctx, flowJobName, s)
+	}))
+	if err != nil {
+		return nil, fmt.Errorf("[webhook] error loading script: %w", err)
+	}
+	if script == "" {
+		ls.Env.RawSetString("onRecord", ls.NewFunction(utils.DefaultOnRecord))
+	}
+	return ls, nil
+}
+
+// lvalueToEvent converts a value returned by onRecord to an event, strings holding JSON are sent as they are,
+// other strings as JSON strings, and tables are encoded as JSON
+func lvalueToEvent(ls *lua.LState, value lua.LValue) (json.RawMessage, error) {
+	switch v := value.(type) {
+	case lua.LString:
+		if json.Valid(shared.UnsafeFastStringToReadOnlyBytes(string(v))) {
+			return json.RawMessage(v), nil
+		}
+		return json.Marshal(string(v))
+	case *lua.LTable:
+		if err := ls.CallByParam(lua.P{Fn: ls.NewFunction(gluajson.LuaJsonEncode), NRet: 1, Protect: true}, v); err != nil {
+			return nil, fmt.Errorf("failed to encode event: %w", err)
+		}
+		encoded := ls.Get(-1)
+		ls.Pop(1)
+		return json.RawMessage(encoded.String()), nil
+	case *lua.LNilType:
+		return nil, nil
+	default:
+		return nil, fmt.Errorf("script returned invalid value: %s", value)
+	}
+}
+
+func recordEvents(ls *lua.LState, record model.Record[model.RecordItems]) ([]json.RawMessage, error) {
+	lfn := ls.Env.RawGetString("onRecord")
+	fn, ok := lfn.(*lua.LFunction)
+	if !ok {
+		return nil, fmt.Errorf("script should define `onRecord` as function, not %s", lfn)
+	}
+
+	ls.Push(fn)
+	ls.Push(pua.LuaRecord.New(ls, record))
+	if err := ls.PCall(1, -1, nil); err != nil {
+		return nil, fmt.Errorf("script failed: %w", err)
+	}
+	args := ls.GetTop()
+	values := make([]lua.LValue, 0, args)
+	for i := range args {
+		values = append(values, ls.Get(i-args))
+	}
+	ls.SetTop(0)
+
+	events := make([]json.RawMessage, 0, len(values))
+	for _, value := range values {
+		event, err := lvalueToEvent(ls, value)
+		if err != nil {
+			return nil, err
+		}
+		if event != nil {
+			events = append(events, event)
+		}
+	}
+	return events, nil
+}
+
+// requestBatcher collects events into requests of batchSize events, keyed by keyPrefix and the position of
+// the request, so a retried sync that replays the same records sends the same requests under the same keys
+type requestBatcher struct {
+	conn      *WebhookConnector
+	keyPrefix string
+	events    []json.RawMessage
+	requests  int
+}
+
+func (b *requestBatcher) add(ctx context.Context, events ...json.RawMessage) error {
+	for _, event := range events {
+		b.events = append(b.events, event)
+		if len(b.events) >= b.conn.batchSize {
+			if err := b.flush(ctx); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func (b *requestBatcher) flush(ctx context.Context) error {
+	if len(b.events) == 0 {
+		return nil
+	}
+	key := fmt.Sprintf("%s-%d-%d", b.keyPrefix, b.requests, len(b.events))
+	if err := b.conn.post(ctx, key, b.events); err != nil {
+		return err
+	}
+	b.requests++
+	b.events = b.events[:0]
+	return nil
+}
+
+// SyncRecords sends records in order and only advances the offset once the whole batch was delivered,
+// so that a retried batch starts from the same record and reproduces the same idempotency keys
+func (c *WebhookConnector) SyncRecords(ctx context.Context, req *model.SyncRecordsRequest[model.RecordItems]) (*model.SyncResponse, error) {
+	tableNameRowsMapping := utils.InitialiseTableRowsMap(req.TableMappings)
+	var numRecords int64
+
+	ls, err := c.loadScript(ctx, req.Script, req.FlowJobName)
+	if err != nil {
+		return nil, err
+	}
+	defer ls.Close()
+
+	batcher := requestBatcher{
+		conn:      c,
+		keyPrefix: fmt.Sprintf("%s-%d", req.FlowJobName, req.SyncBatchID),
+		events:    make([]json.RawMessage, 0, c.batchSize),
+	}
+	for record := range req.Records.GetRecords() {
+		events, err := recordEvents(ls, record)
+		if err != nil {
+			return nil, err
+		}
+		if len(events) > 0 {
+			if err := batcher.add(ctx, events...); err != nil {
+				return nil, err
+			}
+			record.PopulateCountMap(tableNameRowsMapping)
+		}
+		numRecords++
+	}
+	if err := batcher.flush(ctx); err != nil {
+		return nil, err
+	}
+
+	lastCheckpoint := req.Records.GetLastCheckpoint()
+	if err := c.FinishBatch(ctx, req.FlowJobName, req.SyncBatchID, lastCheckpoint); err != nil {
+		return nil, fmt.Errorf("[webhook] FinishBatch error: %w", err)
+	}
+
+	return &model.SyncResponse{
+		CurrentSyncBatchID:   req.SyncBatchID,
+		LastSyncedCheckpoint: lastCheckpoint,
+		NumRecordsSynced:     numRecords,
+		TableNameRowsMapping: tableNameRowsMapping,
+		TableSchemaDeltas:    req.Records.SchemaDeltas,
+	}, nil
+}
@@ -0,0 +1,113 @@
+package connwebhook
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	lua "github.com/yuin/gopher-lua"
+
+	"github.com/PeerDB-io/peerdb/flow/internal"
+	"github.com/PeerDB-io/peerdb/flow/shared"
+)
+
+func TestRequestBatcher(t *testing.T) {
+	t.Parallel()
+
+	var attempts atomic.Int32
+	var keys []string
+	var bodies []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// first attempt fails to exercise retries
+		if attempts.Add(1) == 1 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		body, err := io.ReadAll(r.Body)
+		if err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		signature := shared.SignWebhookPayload("secret", r.Header.Get(shared.WebhookTimestampHeader), body)
+		if r.Header.Get(shared.WebhookSignatureHeader) != signature ||
+			r.Header.Get("Authorization") != "Bearer token" || r.Header.Get("X-Source") != "peerdb" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		keys = append(keys, r.Header.Get(idempotencyHeader))
+		bodies = append(bodies, string(body))
+	}))
+	defer server.Close()
+
+	conn := &WebhookConnector{
+		client:        server.Client(),
+		logger:        internal.LoggerFromCtx(t.Context()),
+		headers:       map[string]string{"X-Source": "peerdb"},
+		url:           server.URL,
+		authorization: "Bearer token",
+		signingSecret: "secret",
+		batchSize:     2,
+		maxAttempts:   2,
+	}
+	batcher := requestBatcher{conn: conn, keyPrefix: "mirror-7"}
+	require.NoError(t, batcher.add(t.Context(),
+		json.RawMessage(`{"id":1}`), json.RawMessage(`{"id":2}`), json.RawMessage(`{"id":3}`)))
+	require.NoError(t, batcher.flush(t.Context()))
+
+	require.Equal(t, int32(3), attempts.Load())
+	require.Equal(t, []string{"mirror-7-0-2", "mirror-7-1-1"}, keys)
+	require.Equal(t, []string{`[{"id":1},{"id":2}]`, `[{"id":3}]`}, bodies)
+}
+
+func TestRequestBatcherClientError(t *testing.T) {
+	t.Parallel()
+
+	var attempts atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		attempts.Add(1)
+		w.WriteHeader(http.StatusBadRequest)
+	}))
+	defer server.Close()
+
+	conn := &WebhookConnector{
+		client:      &http.Client{Timeout: time.Second},
+		logger:      internal.LoggerFromCtx(t.Context()),
+		url:         server.URL,
+		batchSize:   10,
+		maxAttempts: 5,
+	}
+	batcher := requestBatcher{conn: conn, keyPrefix: "mirror-7"}
+	require.NoError(t, batcher.add(t.Context(), json.RawMessage(`{}`)))
+	require.Error(t, batcher.flush(t.Context()))
+	// 4xx other than 429 is not retried
+	require.Equal(t, int32(1), attempts.Load())
+}
+
+func TestLvalueToEvent(t *testing.T) {
+	t.Parallel()
+
+	ls := lua.NewState()
+	defer ls.Close()
+	require.NoError(t, ls.DoString(`return '{"id":1}', "plain", { id = 2 }, nil`))
+
+	event, err := lvalueToEvent(ls, ls.Get(-4))
+	require.NoError(t, err)
+	require.JSONEq(t, `{"id":1}`, string(event))
+
+	event, err = lvalueToEvent(ls, ls.Get(-3))
+	require.NoError(t, err)
+	require.Equal(t, `"plain"`, string(event))
+
+	event, err = lvalueToEvent(ls, ls.Get(-2))
+	require.NoError(t, err)
+	require.JSONEq(t, `{"id":2}`, string(event))
+
+	event, err = lvalueToEvent(ls, ls.Get(-1))
+	require.NoError(t, err)
+	require.Nil(t, event)
+}
@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"time"
 
+	"github.com/elastic/go-elasticsearch/v8/typedapi/types"
 	"github.com/stretchr/testify/require"
 
 	"github.com/PeerDB-io/peerdb/flow/e2e"
@@ -146,3 +147,71 @@ func (s elasticsearchSuite) Test_Composite_PKey_CDC_Mirror() {
 	env.Cancel(s.t.Context())
 	e2e.RequireEnvCanceled(s.t, env)
 }
+
+func (s elasticsearchSuite) Test_Explicit_Mappings_CDC_Mirror() {
+	srcTableName := e2e.AttachSchema(s, "es_explicit_mappings_cdc")
+
+	_, err := s.conn.Conn().Exec(s.t.Context(), fmt.Sprintf(`
+		CREATE TABLE IF NOT EXISTS %s (
+			id INT PRIMARY KEY GENERATED ALWAYS AS IDENTITY,
+			c1 INT,
+			val TEXT,
+			updated_at TIMESTAMP DEFAULT now()
+		);
+	`, srcTableName))
+	require.NoError(s.t, err, "failed creating table")
+
+	peer := s.Peer()
+	peer.Name = e2e.AddSuffix(s, "elasticsearch_explicit")
+	peer.GetElasticsearchConfig().ExplicitMappings = true
+	e2e.CreatePeer(s.t, peer)
+
+	tc := e2e.NewTemporalClient(s.t)
+	connectionGen := e2e.FlowConnectionGenerationConfig{
+		FlowJobName:      e2e.AddSuffix(s, "es_explicit_mappings_cdc"),
+		TableNameMapping: map[string]string{srcTableName: srcTableName},
+		Destination:      peer.Name,
+	}
+	flowConnConfig := connectionGen.GenerateFlowConnectionConfigs(s)
+	flowConnConfig.MaxBatchSize = 100
+
+	env := e2e.ExecutePeerflow(s.t.Context(), tc, peerflow.CDCFlowWorkflow, flowConnConfig, nil)
+	e2e.SetupCDCFlowStatusQuery(s.t, env, flowConnConfig)
+
+	e2e.EnvWaitFor(s.t, env, 3*time.Minute, "wait for index to be set up before any row is synced", func() bool {
+		return s.countDocumentsInIndex(srcTableName) == 0
+	})
+	mappings, err := s.esClient.Indices.GetMapping().Index(srcTableName).Do(s.t.Context())
+	require.NoError(s.t, err, "failed to get index mappings")
+	properties := mappings[srcTableName].Mappings.Properties
+	require.IsType(s.t, &types.IntegerNumberProperty{}, properties["c1"])
+	require.IsType(s.t, &types.TextProperty{}, properties["val"])
+	require.IsType(s.t, &types.DateProperty{}, properties["updated_at"])
+
+	rowCount := 10
+	for i := range rowCount {
+		_, err := s.conn.Conn().Exec(s.t.Context(), fmt.Sprintf(`
+		INSERT INTO %s(c1,val) VALUES(%d,'val%d')
+	`, srcTableName, i, i))
+		require.NoError(s.t, err, "failed to insert row")
+	}
+	e2e.EnvWaitFor(s.t, env, 3*time.Minute, "wait for inserted rows", func() bool {
+		return s.countDocumentsInIndex(srcTableName) == int64(rowCount)
+	})
+
+	// documents are versioned with the LSN of the change that wrote them
+	doc, err := s.esClient.Get(srcTableName, "1").Do(s.t.Context())
+	require.NoError(s.t, err, "failed to get document")
+	require.NotNil(s.t, doc.Version_)
+	require.Greater(s.t, *doc.Version_, int64(1))
+
+	_, err = s.conn.Conn().Exec(s.t.Context(), fmt.Sprintf(`
+	DELETE FROM %s WHERE id%%2=1;`, srcTableName))
+	require.NoError(s.t, err, "failed to delete rows on source")
+	e2e.EnvWaitFor(s.t, env, 3*time.Minute, "wait for deletes", func() bool {
+		return s.countDocumentsInIndex(srcTableName) == int64(rowCount/2)
+	})
+
+	env.Cancel(s.t.Context())
+	e2e.RequireEnvCanceled(s.t, env)
+}
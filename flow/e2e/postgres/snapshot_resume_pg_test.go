@@ -0,0 +1,68 @@
+package e2e_postgres
+
+import (
+	"fmt"
+
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/proto"
+
+	"github.com/PeerDB-io/peerdb/flow/activities"
+	"github.com/PeerDB-io/peerdb/flow/alerting"
+	"github.com/PeerDB-io/peerdb/flow/generated/protos"
+	"github.com/PeerDB-io/peerdb/flow/internal"
+)
+
+func (s PeerFlowE2ETestSuitePG) Test_Resume_Replication() {
+	ctx := s.t.Context()
+	catalogPool, err := internal.GetCatalogConnectionPoolFromEnv(ctx)
+	require.NoError(s.t, err)
+	snapshot := &activities.SnapshotActivity{CatalogPool: catalogPool, Alerter: alerting.NewAlerter(ctx, catalogPool, nil)}
+	input := &protos.SetupReplicationInput{FlowJobName: s.attachSuffix("test_resume_replication"), PeerName: s.Peer().Name}
+	s.t.Cleanup(func() {
+		require.NoError(s.t, internal.DeleteSnapshotResumeState(ctx, catalogPool, input.FlowJobName))
+	})
+
+	output, err := snapshot.ResumeReplication(ctx, input)
+	require.NoError(s.t, err)
+	require.Nil(s.t, output, "nothing to resume without saved state")
+
+	slotName := s.attachSuffix("resume_slot")
+	saved := &protos.SetupReplicationOutput{SlotName: slotName, ConsistentPoint: "0/16B3748", SupportsTidScans: true}
+	require.NoError(s.t, internal.SaveSnapshotResumeState(ctx, catalogPool, input.FlowJobName, saved))
+	_, err = snapshot.ResumeReplication(ctx, input)
+	require.ErrorContains(s.t, err, "no longer exists", "loaded partitions can't be reconciled without the slot")
+
+	_, err = s.Conn().Exec(ctx, "SELECT pg_create_logical_replication_slot($1, 'pgoutput')", slotName)
+	require.NoError(s.t, err)
+	s.t.Cleanup(func() {
+		_, _ = s.Conn().Exec(ctx, "SELECT pg_drop_replication_slot($1)", slotName)
+	})
+	output, err = snapshot.ResumeReplication(ctx, input)
+	require.NoError(s.t, err)
+	require.True(s.t, proto.Equal(saved, output))
+}
+
+func (s PeerFlowE2ETestSuitePG) Test_Tables_Have_Unique_Key() {
+	keyed := s.attachSchemaSuffix("test_unique_key")
+	replIdent := s.attachSchemaSuffix("test_unique_key_replident")
+	keyless := s.attachSchemaSuffix("test_unique_key_none")
+	_, err := s.Conn().Exec(s.t.Context(), fmt.Sprintf(`
+		CREATE TABLE %[1]s (id INT PRIMARY KEY);
+		CREATE TABLE %[2]s (id INT NOT NULL);
+		CREATE UNIQUE INDEX test_unique_key_replident_idx ON %[2]s (id);
+		ALTER TABLE %[2]s REPLICA IDENTITY USING INDEX test_unique_key_replident_idx;
+		CREATE TABLE %[3]s (id INT);
+		ALTER TABLE %[3]s REPLICA IDENTITY FULL;
+	`, keyed, replIdent, keyless))
+	require.NoError(s.t, err)
+
+	mappings := func(tables ...string) []*protos.TableMapping {
+		tableMappings := make([]*protos.TableMapping, 0, len(tables))
+		for _, table := range tables {
+			tableMappings = append(tableMappings, &protos.TableMapping{SourceTableIdentifier: table})
+		}
+		return tableMappings
+	}
+	require.NoError(s.t, s.conn.CheckTablesHaveUniqueKey(s.t.Context(), mappings(keyed, replIdent)))
+	require.ErrorContains(s.t, s.conn.CheckTablesHaveUniqueKey(s.t.Context(), mappings(keyed, keyless)), keyless)
+}
@@ -0,0 +1,104 @@
+package internal
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/jackc/pgx/v5"
+
+	"github.com/PeerDB-io/peerdb/flow/shared"
+)
+
+// CDCBufferSegment is a file of changes pulled from the source but not yet synced to the destination
+type CDCBufferSegment struct {
+	FileName       string
+	LastOffsetText string
+	SegmentID      int64
+	LastOffsetID   int64
+	NumRecords     int64
+	SizeBytes      int64
+}
+
+func LoadCDCBufferSegments(ctx context.Context, pool shared.CatalogPool, flowName string) ([]CDCBufferSegment, error) {
+	rows, err := pool.Query(ctx,
+		`SELECT segment_id, file_name, last_offset_id, last_offset_text, num_records, size_bytes
+		FROM cdc_buffer_segments WHERE flow_name=$1 ORDER BY segment_id`, flowName)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load CDC buffer segments: %w", err)
+	}
+	segments, err := pgx.CollectRows(rows, func(row pgx.CollectableRow) (CDCBufferSegment, error) {
+		var segment CDCBufferSegment
+		err := row.Scan(&segment.SegmentID, &segment.FileName, &segment.LastOffsetID, &segment.LastOffsetText,
+			&segment.NumRecords, &segment.SizeBytes)
+		return segment, err
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to load CDC buffer segments: %w", err)
+	}
+	return segments, nil
+}
+
+func NextCDCBufferSegmentID(ctx context.Context, pool shared.CatalogPool, flowName string) (int64, error) {
+	var segmentID int64
+	if err := pool.QueryRow(ctx,
+		"SELECT COALESCE(MAX(segment_id), 0) + 1 FROM cdc_buffer_segments WHERE flow_name=$1", flowName,
+	).Scan(&segmentID); err != nil {
+		return 0, fmt.Errorf("failed to get next CDC buffer segment id: %w", err)
+	}
+	return segmentID, nil
+}
+
+// CommitCDCBufferSegment registers a segment already durable on disk, moving the buffered offset to its end
+func CommitCDCBufferSegment(ctx context.Context, pool shared.CatalogPool, flowName string, segment CDCBufferSegment) error {
+	tx, err := pool.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction to commit CDC buffer segment: %w", err)
+	}
+	defer shared.RollbackTx(tx, LoggerFromCtx(ctx))
+
+	if _, err := tx.Exec(ctx,
+		`INSERT INTO cdc_buffer_segments
+		(flow_name, segment_id, file_name, last_offset_id, last_offset_text, num_records, size_bytes)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)`,
+		flowName, segment.SegmentID, segment.FileName, segment.LastOffsetID, segment.LastOffsetText,
+		segment.NumRecords, segment.SizeBytes,
+	); err != nil {
+		return fmt.Errorf("failed to commit CDC buffer segment: %w", err)
+	}
+	if _, err := tx.Exec(ctx,
+		`INSERT INTO cdc_buffer_offsets (flow_name, last_offset_id, last_offset_text) VALUES ($1, $2, $3)
+		ON CONFLICT (flow_name) DO UPDATE SET last_offset_id=$2, last_offset_text=$3, updated_at=NOW()`,
+		flowName, segment.LastOffsetID, segment.LastOffsetText,
+	); err != nil {
+		return fmt.Errorf("failed to update CDC buffer offset: %w", err)
+	}
+	if err := tx.Commit(ctx); err != nil {
+		return fmt.Errorf("failed to commit CDC buffer segment: %w", err)
+	}
+	return nil
+}
+
+// DeleteCDCBufferSegments forgets segments synced to the destination, up to and including segmentID
+func DeleteCDCBufferSegments(ctx context.Context, pool shared.CatalogPool, flowName string, segmentID int64) error {
+	if _, err := pool.Exec(ctx,
+		"DELETE FROM cdc_buffer_segments WHERE flow_name=$1 AND segment_id<=$2", flowName, segmentID,
+	); err != nil {
+		return fmt.Errorf("failed to delete CDC buffer segments: %w", err)
+	}
+	return nil
+}
+
+// LoadCDCBufferOffset returns the offset the source was drained to, ok is false before the first segment
+func LoadCDCBufferOffset(ctx context.Context, pool shared.CatalogPool, flowName string) (int64, string, bool, error) {
+	var offsetID int64
+	var offsetText string
+	if err := pool.QueryRow(ctx,
+		"SELECT last_offset_id, last_offset_text FROM cdc_buffer_offsets WHERE flow_name=$1", flowName,
+	).Scan(&offsetID, &offsetText); errors.Is(err, pgx.ErrNoRows) {
+		return 0, "", false, nil
+	} else if err != nil {
+		return 0, "", false, fmt.Errorf("failed to load CDC buffer offset: %w", err)
+	}
+	return offsetID, offsetText, true, nil
+}
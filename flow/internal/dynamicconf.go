@@ -316,6 +316,93 @@ var DynamicSettings = [...]*protos.DynamicSetting{
 		ApplyMode:        protos.DynconfApplyMode_APPLY_MODE_IMMEDIATE,
 		TargetForSetting: protos.DynconfTarget_ALL,
 	},
+	{
+		Name: "PEERDB_POSTGRES_DIRECT_APPLY",
+		Description: "For Postgres destinations: apply each batch to destination tables in commit order within one transaction, " +
+			"skipping the raw table and normalize. Batches are staged as usual until normalize has caught up",
+		DefaultValue:     "false",
+		ValueType:        protos.DynconfValueType_BOOL,
+		ApplyMode:        protos.DynconfApplyMode_APPLY_MODE_IMMEDIATE,
+		TargetForSetting: protos.DynconfTarget_ALL,
+	},
+	{
+		Name:             "PEERDB_QREP_PULL_MAX_ROWS_PER_SECOND",
+		Description:      "Cap on rows per second pulled from the source by each mirror for initial load and query replication, 0 disables",
+		DefaultValue:     "0",
+		ValueType:        protos.DynconfValueType_UINT,
+		ApplyMode:        protos.DynconfApplyMode_APPLY_MODE_IMMEDIATE,
+		TargetForSetting: protos.DynconfTarget_ALL,
+	},
+	{
+		Name:             "PEERDB_QREP_PULL_MAX_BYTES_PER_SECOND",
+		Description:      "Cap on bytes per second pulled from the source by each mirror for initial load and query replication, 0 disables",
+		DefaultValue:     "0",
+		ValueType:        protos.DynconfValueType_UINT,
+		ApplyMode:        protos.DynconfApplyMode_APPLY_MODE_IMMEDIATE,
+		TargetForSetting: protos.DynconfTarget_ALL,
+	},
+	{
+		Name: "PEERDB_QREP_PULL_PEER_MAX_ROWS_PER_SECOND",
+		Description: "Cap on rows per second pulled from each source peer for initial load and query replication, " +
+			"shared by all mirrors pulling from the peer on a worker, 0 disables",
+		DefaultValue:     "0",
+		ValueType:        protos.DynconfValueType_UINT,
+		ApplyMode:        protos.DynconfApplyMode_APPLY_MODE_IMMEDIATE,
+		TargetForSetting: protos.DynconfTarget_ALL,
+	},
+	{
+		Name: "PEERDB_QREP_PULL_PEER_MAX_BYTES_PER_SECOND",
+		Description: "Cap on bytes per second pulled from each source peer for initial load and query replication, " +
+			"shared by all mirrors pulling from the peer on a worker, 0 disables",
+		DefaultValue:     "0",
+		ValueType:        protos.DynconfValueType_UINT,
+		ApplyMode:        protos.DynconfApplyMode_APPLY_MODE_IMMEDIATE,
+		TargetForSetting: protos.DynconfTarget_ALL,
+	},
+	{
+		Name: "PEERDB_QREP_PULL_MAX_SOURCE_LAG_SECONDS",
+		Description: "Halve pull rate caps, or pause pulls without caps, while a Postgres source's replication lag exceeds this. " +
+			"Lag of standbys is only visible to roles with pg_monitor, 0 disables",
+		DefaultValue:     "0",
+		ValueType:        protos.DynconfValueType_UINT,
+		ApplyMode:        protos.DynconfApplyMode_APPLY_MODE_IMMEDIATE,
+		TargetForSetting: protos.DynconfTarget_ALL,
+	},
+	{
+		Name: "PEERDB_QREP_PULL_MAX_SOURCE_ACTIVE_CONNECTIONS",
+		Description: "Halve pull rate caps, or pause pulls without caps, while a Postgres source has more active connections " +
+			"in pg_stat_activity than this, 0 disables",
+		DefaultValue:     "0",
+		ValueType:        protos.DynconfValueType_UINT,
+		ApplyMode:        protos.DynconfApplyMode_APPLY_MODE_IMMEDIATE,
+		TargetForSetting: protos.DynconfTarget_ALL,
+	},
+	{
+		Name:             "PEERDB_INCREMENTAL_SNAPSHOT_CHUNK_SIZE",
+		Description:      "Rows read by primary key per chunk of an incremental snapshot running alongside CDC",
+		DefaultValue:     "10000",
+		ValueType:        protos.DynconfValueType_UINT,
+		ApplyMode:        protos.DynconfApplyMode_APPLY_MODE_AFTER_RESUME,
+		TargetForSetting: protos.DynconfTarget_ALL,
+	},
+	{
+		Name: "PEERDB_CDC_BUFFER_DIR",
+		Description: "Absolute directory or s3://bucket/prefix of durable CDC buffer segments, " +
+			"a directory must be a volume that outlives workers and is shared between them. " +
+			"Buffered changes are acknowledged to the source, so losing segments fails the mirror until it is resynced",
+		DefaultValue:     "",
+		ValueType:        protos.DynconfValueType_STRING,
+		ApplyMode:        protos.DynconfApplyMode_APPLY_MODE_AFTER_RESUME,
+		TargetForSetting: protos.DynconfTarget_ALL,
+	},
+	{
+		Name:             "PEERDB_CDC_BUFFER_MAX_BYTES",
+		Description:      "Size a mirror's durable CDC buffer may reach before pulling stops until the destination catches up",
+		DefaultValue:     "10737418240",
+		ValueType:        protos.DynconfValueType_INT,
+		ApplyMode:        protos.DynconfApplyMode_APPLY_MODE_IMMEDIATE,
+		TargetForSetting: protos.DynconfTarget_ALL,
+	},
 }
 
 var DynamicIndex = func() map[string]int {
@@ -612,3 +699,43 @@ func PeerDBSourceSchemaAsDestinationColumn(ctx context.Context, env map[string]s
 func PeerDBPostgresCDCHandleInheritanceForNonPartitionedTables(ctx context.Context, env map[string]string) (bool, error) {
 	return dynamicConfBool(ctx, env, "PEERDB_POSTGRES_CDC_HANDLE_INHERITANCE_FOR_NON_PARTITIONED_TABLES")
 }
+
+func PeerDBPostgresDirectApply(ctx context.Context, env map[string]string) (bool, error) {
+	return dynamicConfBool(ctx, env, "PEERDB_POSTGRES_DIRECT_APPLY")
+}
+
+func PeerDBQRepPullMaxRowsPerSecond(ctx context.Context, env map[string]string) (uint64, error) {
+	return dynamicConfUnsigned[uint64](ctx, env, "PEERDB_QREP_PULL_MAX_ROWS_PER_SECOND")
+}
+
+func PeerDBQRepPullMaxBytesPerSecond(ctx context.Context, env map[string]string) (uint64, error) {
+	return dynamicConfUnsigned[uint64](ctx, env, "PEERDB_QREP_PULL_MAX_BYTES_PER_SECOND")
+}
+
+func PeerDBQRepPullPeerMaxRowsPerSecond(ctx context.Context, env map[string]string) (uint64, error) {
+	return dynamicConfUnsigned[uint64](ctx, env, "PEERDB_QREP_PULL_PEER_MAX_ROWS_PER_SECOND")
+}
+
+func PeerDBQRepPullPeerMaxBytesPerSecond(ctx context.Context, env map[string]string) (uint64, error) {
+	return dynamicConfUnsigned[uint64](ctx, env, "PEERDB_QREP_PULL_PEER_MAX_BYTES_PER_SECOND")
+}
+
+func PeerDBQRepPullMaxSourceLagSeconds(ctx context.Context, env map[string]string) (uint64, error) {
+	return dynamicConfUnsigned[uint64](ctx, env, "PEERDB_QREP_PULL_MAX_SOURCE_LAG_SECONDS")
+}
+
+func PeerDBQRepPullMaxSourceActiveConnections(ctx context.Context, env map[string]string) (uint64, error) {
+	return dynamicConfUnsigned[uint64](ctx, env, "PEERDB_QREP_PULL_MAX_SOURCE_ACTIVE_CONNECTIONS")
+}
+
+func PeerDBIncrementalSnapshotChunkSize(ctx context.Context, env map[string]string) (uint64, error) {
+	return dynamicConfUnsigned[uint64](ctx, env, "PEERDB_INCREMENTAL_SNAPSHOT_CHUNK_SIZE")
+}
+
+func PeerDBCDCBufferDir(ctx context.Context, env map[string]string) (string, error) {
+	return dynLookup(ctx, env, "PEERDB_CDC_BUFFER_DIR")
+}
+
+func PeerDBCDCBufferMaxBytes(ctx context.Context, env map[string]string) (int64, error) {
+	return dynamicConfSigned[int64](ctx, env, "PEERDB_CDC_BUFFER_MAX_BYTES")
+}
@@ -0,0 +1,116 @@
+package internal
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/jackc/pgx/v5"
+
+	"github.com/PeerDB-io/peerdb/flow/shared"
+)
+
+// IncrementalSnapshot is the progress of a table loaded in primary key chunks alongside CDC
+type IncrementalSnapshot struct {
+	SourceTable string
+	// primary key of the last row known to be synced, nil before the first chunk
+	LastKey []string
+	// last chunk emitted, synced once the mirror's offset reaches PendingLSN
+	PendingKey  []string
+	PendingLSN  int64
+	PendingDone bool
+}
+
+// AddIncrementalSnapshots starts loading tables of a mirror in chunks, restarting tables already being loaded
+func AddIncrementalSnapshots(ctx context.Context, pool shared.CatalogPool, flowName string, sourceTables []string) error {
+	for _, sourceTable := range sourceTables {
+		if _, err := pool.Exec(ctx,
+			`INSERT INTO incremental_snapshots (flow_name, source_table) VALUES ($1, $2)
+			ON CONFLICT (flow_name, source_table) DO UPDATE
+			SET last_key=NULL, pending_key=NULL, pending_lsn=NULL, pending_done=FALSE, created_at=NOW()`,
+			flowName, sourceTable,
+		); err != nil {
+			return fmt.Errorf("failed to add incremental snapshot of %s: %w", sourceTable, err)
+		}
+	}
+	return nil
+}
+
+func LoadIncrementalSnapshots(ctx context.Context, pool shared.CatalogPool, flowName string) ([]IncrementalSnapshot, error) {
+	rows, err := pool.Query(ctx,
+		`SELECT source_table, last_key, pending_key, coalesce(pending_lsn, 0), pending_done
+		FROM incremental_snapshots WHERE flow_name=$1 ORDER BY created_at, source_table`, flowName)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load incremental snapshots: %w", err)
+	}
+	snapshots, err := pgx.CollectRows(rows, func(row pgx.CollectableRow) (IncrementalSnapshot, error) {
+		var snapshot IncrementalSnapshot
+		err := row.Scan(&snapshot.SourceTable, &snapshot.LastKey, &snapshot.PendingKey, &snapshot.PendingLSN, &snapshot.PendingDone)
+		return snapshot, err
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to load incremental snapshots: %w", err)
+	}
+	return snapshots, nil
+}
+
+// SaveIncrementalSnapshotChunk records a chunk emitted at lsn, done when it was the last chunk of the table
+func SaveIncrementalSnapshotChunk(
+	ctx context.Context,
+	pool shared.CatalogPool,
+	flowName string,
+	sourceTable string,
+	pendingKey []string,
+	pendingLSN int64,
+	pendingDone bool,
+) error {
+	if _, err := pool.Exec(ctx,
+		`UPDATE incremental_snapshots SET pending_key=$3, pending_lsn=$4, pending_done=$5
+		WHERE flow_name=$1 AND source_table=$2`, flowName, sourceTable, pendingKey, pendingLSN, pendingDone,
+	); err != nil {
+		return fmt.Errorf("failed to save incremental snapshot chunk of %s: %w", sourceTable, err)
+	}
+	return nil
+}
+
+// SettleIncrementalSnapshotChunk resolves the pending chunk of a table, moving past it once synced or dropping it otherwise.
+// A synced last chunk finishes the table's snapshot
+func SettleIncrementalSnapshotChunk(
+	ctx context.Context,
+	pool shared.CatalogPool,
+	flowName string,
+	snapshot *IncrementalSnapshot,
+	synced bool,
+) error {
+	var err error
+	if synced && snapshot.PendingDone {
+		_, err = pool.Exec(ctx,
+			"DELETE FROM incremental_snapshots WHERE flow_name=$1 AND source_table=$2", flowName, snapshot.SourceTable)
+	} else if synced {
+		_, err = pool.Exec(ctx,
+			`UPDATE incremental_snapshots SET last_key=pending_key, pending_key=NULL, pending_lsn=NULL, pending_done=FALSE
+			WHERE flow_name=$1 AND source_table=$2`, flowName, snapshot.SourceTable)
+	} else {
+		_, err = pool.Exec(ctx,
+			`UPDATE incremental_snapshots SET pending_key=NULL, pending_lsn=NULL, pending_done=FALSE
+			WHERE flow_name=$1 AND source_table=$2`, flowName, snapshot.SourceTable)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to settle incremental snapshot chunk of %s: %w", snapshot.SourceTable, err)
+	}
+	if synced {
+		snapshot.LastKey = snapshot.PendingKey
+	}
+	snapshot.PendingKey = nil
+	snapshot.PendingLSN = 0
+	snapshot.PendingDone = false
+	return nil
+}
+
+func DeleteIncrementalSnapshot(ctx context.Context, pool shared.CatalogPool, flowName string, sourceTable string) error {
+	if _, err := pool.Exec(ctx,
+		"DELETE FROM incremental_snapshots WHERE flow_name=$1 AND source_table=$2", flowName, sourceTable,
+	); err != nil {
+		return fmt.Errorf("failed to delete incremental snapshot of %s: %w", sourceTable, err)
+	}
+	return nil
+}
@@ -12,6 +12,10 @@ type PeerDBOAuthConfig struct {
 	// This is a custom claim we may wish to validate (if needed)
 	OAuthJwtClaimKey string `json:"oauth_jwt_claim_key"`
 	OAuthClaimValue  string `json:"oauth_jwt_claim_value"`
+	// Claim holding the roles or groups of the caller, enables role-based access control when set
+	OAuthRolesClaimKey string `json:"oauth_roles_claim_key"`
+	// JSON list of bindings from roles claim values to PeerDB roles, optionally scoped by mirror tags
+	OAuthRoleBindingsJson string `json:"oauth_role_bindings_json"`
 	// Enabling uses /.well-known/ OpenID discovery endpoints, thus key-set etc. don't need to be specified
 	OAuthDiscoveryEnabled bool `json:"oauth_discovery_enabled"`
 }
@@ -29,11 +33,16 @@ func GetPeerDBOAuthConfig() PeerDBOAuthConfig {
 	oauthJwtClaimKey := GetEnvString("PEERDB_OAUTH_JWT_CLAIM_KEY", "")
 	oauthJwtClaimValue := GetEnvString("PEERDB_OAUTH_JWT_CLAIM_VALUE", "")
 
+	oauthRolesClaimKey := GetEnvString("PEERDB_OAUTH_ROLES_CLAIM_KEY", "")
+	oauthRoleBindingsJson := GetEnvString("PEERDB_OAUTH_ROLE_BINDINGS_JSON", "")
+
 	return PeerDBOAuthConfig{
 		OAuthIssuerUrl:        oauthIssuerUrl,
 		OAuthDiscoveryEnabled: oauthDiscoveryEnabled,
 		KeySetJson:            oauthKeysetJson,
 		OAuthJwtClaimKey:      oauthJwtClaimKey,
 		OAuthClaimValue:       oauthJwtClaimValue,
+		OAuthRolesClaimKey:    oauthRolesClaimKey,
+		OAuthRoleBindingsJson: oauthRoleBindingsJson,
 	}
 }
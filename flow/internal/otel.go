@@ -7,3 +7,9 @@ func GetPeerDBOtelMetricsNamespace() string {
 func GetPeerDBOtelTemporalMetricsExportListEnv() string {
 	return GetEnvString("PEERDB_OTEL_TEMPORAL_METRICS_EXPORT_LIST", "")
 }
+
+// GetOtelMetricsExporter follows the OpenTelemetry SDK convention, "otlp" (default) pushes to a collector,
+// "prometheus" serves metrics for scraping
+func GetOtelMetricsExporter() string {
+	return GetEnvString("OTEL_METRICS_EXPORTER", "otlp")
+}
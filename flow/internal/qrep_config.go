@@ -0,0 +1,62 @@
+package internal
+
+import (
+	"errors"
+	"fmt"
+	"maps"
+	"strings"
+	"text/template"
+
+	"github.com/PeerDB-io/peerdb/flow/generated/protos"
+)
+
+// ApplyQRepFlowConfigUpdate modifies cfg in place, zero values in the update leave settings unchanged
+func ApplyQRepFlowConfigUpdate(cfg *protos.QRepConfig, update *protos.QRepFlowConfigUpdate) {
+	if update.MaxParallelWorkers > 0 {
+		cfg.MaxParallelWorkers = update.MaxParallelWorkers
+	}
+	if update.NumRowsPerPartition > 0 {
+		cfg.NumRowsPerPartition = update.NumRowsPerPartition
+	}
+	if update.WaitBetweenBatchesSeconds > 0 {
+		cfg.WaitBetweenBatchesSeconds = update.WaitBetweenBatchesSeconds
+	}
+	if update.Query != nil && *update.Query != "" {
+		cfg.Query = *update.Query
+	}
+	// an empty script clears it
+	if update.Script != nil {
+		cfg.Script = *update.Script
+	}
+	if update.WriteMode != nil {
+		cfg.WriteMode = update.WriteMode
+	}
+	if update.UpdatedEnv != nil {
+		if cfg.Env == nil {
+			cfg.Env = make(map[string]string, len(update.UpdatedEnv))
+		}
+		maps.Copy(cfg.Env, update.UpdatedEnv)
+	}
+}
+
+// ValidateQRepConfig checks what mirror creation checks of a QRep config,
+// so updates can't leave a mirror with a config it couldn't have been created with
+func ValidateQRepConfig(cfg *protos.QRepConfig) error {
+	if cfg.Query == "" {
+		return errors.New("query cannot be empty")
+	}
+	if _, err := template.New("query").Parse(cfg.Query); err != nil {
+		return fmt.Errorf("invalid query template: %w", err)
+	}
+	if cfg.WatermarkColumn != "xmin" && cfg.WriteMode.GetWriteType() != protos.QRepWriteType_QREP_WRITE_MODE_OVERWRITE &&
+		(!strings.Contains(cfg.Query, "{{.start}}") || !strings.Contains(cfg.Query, "{{.end}}")) {
+		return errors.New("query must include placeholders {{.start}} and {{.end}}")
+	}
+	if cfg.WriteMode.GetWriteType() == protos.QRepWriteType_QREP_WRITE_MODE_UPSERT && len(cfg.WriteMode.GetUpsertKeyColumns()) == 0 {
+		return errors.New("upsert key columns cannot be empty in upsert mode")
+	}
+	if cfg.NumRowsPerPartition == 0 {
+		return errors.New("rows per partition must be a positive integer")
+	}
+	return nil
+}
@@ -0,0 +1,106 @@
+package internal
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/proto"
+
+	"github.com/PeerDB-io/peerdb/flow/generated/protos"
+)
+
+func testQRepConfig() *protos.QRepConfig {
+	return &protos.QRepConfig{
+		Query:                     "SELECT * FROM t WHERE id BETWEEN {{.start}} AND {{.end}}",
+		Script:                    "transform",
+		WatermarkColumn:           "id",
+		WriteMode:                 &protos.QRepWriteMode{WriteType: protos.QRepWriteType_QREP_WRITE_MODE_APPEND},
+		Env:                       map[string]string{"a": "1"},
+		MaxParallelWorkers:        4,
+		NumRowsPerPartition:       1000,
+		WaitBetweenBatchesSeconds: 60,
+	}
+}
+
+func TestApplyQRepFlowConfigUpdate(t *testing.T) {
+	t.Parallel()
+
+	cfg := testQRepConfig()
+	ApplyQRepFlowConfigUpdate(cfg, &protos.QRepFlowConfigUpdate{Query: proto.String("")})
+	require.True(t, proto.Equal(testQRepConfig(), cfg), "zero values leave settings unchanged")
+
+	upsert := &protos.QRepWriteMode{
+		WriteType:        protos.QRepWriteType_QREP_WRITE_MODE_UPSERT,
+		UpsertKeyColumns: []string{"id"},
+	}
+	ApplyQRepFlowConfigUpdate(cfg, &protos.QRepFlowConfigUpdate{
+		MaxParallelWorkers:        8,
+		NumRowsPerPartition:       5000,
+		WaitBetweenBatchesSeconds: 30,
+		Query:                     proto.String("SELECT id FROM t WHERE id BETWEEN {{.start}} AND {{.end}}"),
+		Script:                    proto.String(""),
+		WriteMode:                 upsert,
+		UpdatedEnv:                map[string]string{"b": "2"},
+	})
+	expected := testQRepConfig()
+	expected.MaxParallelWorkers = 8
+	expected.NumRowsPerPartition = 5000
+	expected.WaitBetweenBatchesSeconds = 30
+	expected.Query = "SELECT id FROM t WHERE id BETWEEN {{.start}} AND {{.end}}"
+	expected.Script = ""
+	expected.WriteMode = upsert
+	expected.Env = map[string]string{"a": "1", "b": "2"}
+	require.True(t, proto.Equal(expected, cfg))
+
+	cfg = &protos.QRepConfig{}
+	ApplyQRepFlowConfigUpdate(cfg, &protos.QRepFlowConfigUpdate{UpdatedEnv: map[string]string{"a": "1"}})
+	require.Equal(t, map[string]string{"a": "1"}, cfg.Env)
+}
+
+func TestValidateQRepConfig(t *testing.T) {
+	t.Parallel()
+
+	require.NoError(t, ValidateQRepConfig(testQRepConfig()))
+
+	for _, tc := range []struct {
+		update func(*protos.QRepConfig)
+		name   string
+		err    string
+	}{
+		{name: "empty query", update: func(cfg *protos.QRepConfig) { cfg.Query = "" }, err: "query cannot be empty"},
+		{
+			name:   "unparseable query",
+			update: func(cfg *protos.QRepConfig) { cfg.Query = "SELECT * FROM t WHERE id BETWEEN {{.start}} AND {{.end" },
+			err:    "invalid query template",
+		},
+		{
+			name:   "query without placeholders",
+			update: func(cfg *protos.QRepConfig) { cfg.Query = "SELECT * FROM t" },
+			err:    "placeholders",
+		},
+		{
+			name: "upsert without keys",
+			update: func(cfg *protos.QRepConfig) {
+				cfg.WriteMode = &protos.QRepWriteMode{WriteType: protos.QRepWriteType_QREP_WRITE_MODE_UPSERT}
+			},
+			err: "upsert key columns",
+		},
+		{name: "no rows per partition", update: func(cfg *protos.QRepConfig) { cfg.NumRowsPerPartition = 0 }, err: "rows per partition"},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			cfg := testQRepConfig()
+			tc.update(cfg)
+			require.ErrorContains(t, ValidateQRepConfig(cfg), tc.err)
+		})
+	}
+
+	overwrite := testQRepConfig()
+	overwrite.Query = "SELECT * FROM t"
+	overwrite.WriteMode = &protos.QRepWriteMode{WriteType: protos.QRepWriteType_QREP_WRITE_MODE_OVERWRITE}
+	require.NoError(t, ValidateQRepConfig(overwrite), "overwrite reads the whole table")
+	xmin := testQRepConfig()
+	xmin.Query = "SELECT * FROM t"
+	xmin.WatermarkColumn = "xmin"
+	require.NoError(t, ValidateQRepConfig(xmin))
+}
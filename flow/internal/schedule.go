@@ -0,0 +1,35 @@
+package internal
+
+import (
+	"errors"
+	"time"
+
+	"github.com/PeerDB-io/peerdb/flow/generated/protos"
+	"github.com/PeerDB-io/peerdb/flow/shared"
+)
+
+// ValidateMirrorSchedule checks that a schedule only uses the crons supported by the type of mirror
+func ValidateMirrorSchedule(schedule *protos.MirrorSchedule, isCDC bool) error {
+	if schedule == nil {
+		return nil
+	}
+	if isCDC {
+		if schedule.RunCron != "" {
+			return errors.New("run_cron is only supported for qrep mirrors")
+		}
+		if (schedule.PauseCron == "") != (schedule.ResumeCron == "") {
+			return errors.New("pause_cron and resume_cron must be set together")
+		}
+	} else if schedule.PauseCron != "" || schedule.ResumeCron != "" {
+		return errors.New("pause_cron and resume_cron are only supported for cdc mirrors")
+	}
+
+	for _, spec := range []string{schedule.PauseCron, schedule.ResumeCron, schedule.RunCron} {
+		if spec != "" {
+			if _, err := shared.NextCronTime(spec, time.Now()); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,316 @@
+package internal
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/aws/arn"
+	"github.com/aws/aws-sdk-go-v2/config"
+	"github.com/aws/aws-sdk-go-v2/service/secretsmanager"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/types/descriptorpb"
+
+	"github.com/PeerDB-io/peerdb/flow/generated/protos"
+)
+
+// Fields marked peerdb_redacted may hold a reference to a secret instead of the secret itself:
+// vault://<path>#<key> reads <key> from a Vault KV (v1 or v2) secret using VAULT_ADDR and VAULT_TOKEN,
+// awssm://<arn or name>[#<key>] reads an AWS Secrets Manager secret string, or <key> of its JSON,
+// file://<path>[#<key>] reads a mounted file, or <key> of its JSON.
+// References are resolved with the worker's own credentials, so anyone able to create a peer could have a secret
+// sent to a host of their choosing. Each scheme is refused unless the operator allows where it may read from:
+// PEERDB_SECRETS_FILE_DIR for files, PEERDB_SECRETS_AWSSM_ALLOWED_PREFIXES and PEERDB_SECRETS_VAULT_ALLOWED_PATHS
+// as comma separated prefixes of secret names/ARNs and Vault paths.
+const (
+	vaultSecretScheme             = "vault://"
+	awsSecretsManagerSecretScheme = "awssm://" //nolint:gosec // scheme, not a credential
+	fileSecretScheme              = "file://"
+)
+
+type cachedSecret struct {
+	expiresAt time.Time
+	value     string
+}
+
+var secretCache sync.Map
+
+func secretCacheTTL() time.Duration {
+	return time.Duration(getEnvUint[uint32]("PEERDB_SECRET_CACHE_TTL_SECONDS", 300)) * time.Second
+}
+
+func IsSecretReference(value string) bool {
+	return strings.HasPrefix(value, vaultSecretScheme) ||
+		strings.HasPrefix(value, awsSecretsManagerSecretScheme) ||
+		strings.HasPrefix(value, fileSecretScheme)
+}
+
+// RangeRedactedFields calls fn for every set peerdb_redacted string field, including those of nested messages
+func RangeRedactedFields(message protoreflect.Message, fn func(message protoreflect.Message, fd protoreflect.FieldDescriptor, value string)) {
+	message.Range(func(fd protoreflect.FieldDescriptor, v protoreflect.Value) bool {
+		if fd.IsList() {
+			if fd.Kind() == protoreflect.MessageKind {
+				list := v.List()
+				for i := range list.Len() {
+					RangeRedactedFields(list.Get(i).Message(), fn)
+				}
+			}
+		} else if fd.IsMap() {
+			if fd.MapValue().Kind() == protoreflect.MessageKind {
+				v.Map().Range(func(_ protoreflect.MapKey, mv protoreflect.Value) bool {
+					RangeRedactedFields(mv.Message(), fn)
+					return true
+				})
+			}
+		} else if fd.Kind() == protoreflect.MessageKind {
+			RangeRedactedFields(v.Message(), fn)
+		} else if fd.Kind() == protoreflect.StringKind {
+			if proto.GetExtension(fd.Options().(*descriptorpb.FieldOptions), protos.E_PeerdbRedacted).(bool) {
+				fn(message, fd, v.String())
+			}
+		}
+		return true
+	})
+}
+
+// ResolveSecretReferences returns message with secret references replaced by the secrets they point to,
+// message itself is left untouched so that references, not secrets, are what gets stored
+func ResolveSecretReferences[T proto.Message](ctx context.Context, message T) (T, error) {
+	hasReferences := false
+	RangeRedactedFields(message.ProtoReflect(), func(_ protoreflect.Message, _ protoreflect.FieldDescriptor, value string) {
+		hasReferences = hasReferences || IsSecretReference(value)
+	})
+	if !hasReferences {
+		return message, nil
+	}
+
+	resolved := proto.Clone(message).(T)
+	var resolveErr error
+	RangeRedactedFields(resolved.ProtoReflect(), func(m protoreflect.Message, fd protoreflect.FieldDescriptor, value string) {
+		if resolveErr != nil || !IsSecretReference(value) {
+			return
+		}
+		secret, err := ResolveSecretReference(ctx, value)
+		if err != nil {
+			resolveErr = fmt.Errorf("failed to resolve secret for %s: %w", fd.Name(), err)
+			return
+		}
+		m.Set(fd, protoreflect.ValueOfString(secret))
+	})
+	if resolveErr != nil {
+		var none T
+		return none, resolveErr
+	}
+	return resolved, nil
+}
+
+// ResolveSecretReference fetches the secret a reference points to, caching it for PEERDB_SECRET_CACHE_TTL_SECONDS
+func ResolveSecretReference(ctx context.Context, reference string) (string, error) {
+	if cached, ok := secretCache.Load(reference); ok {
+		if secret := cached.(cachedSecret); time.Now().Before(secret.expiresAt) {
+			return secret.value, nil
+		}
+	}
+
+	var secret string
+	var err error
+	switch {
+	case strings.HasPrefix(reference, vaultSecretScheme):
+		secret, err = resolveVaultSecret(ctx, strings.TrimPrefix(reference, vaultSecretScheme))
+	case strings.HasPrefix(reference, awsSecretsManagerSecretScheme):
+		secret, err = resolveAwsSecretsManagerSecret(ctx, strings.TrimPrefix(reference, awsSecretsManagerSecretScheme))
+	case strings.HasPrefix(reference, fileSecretScheme):
+		secret, err = resolveFileSecret(strings.TrimPrefix(reference, fileSecretScheme))
+	default:
+		return "", errors.New("unsupported secret reference scheme")
+	}
+	if err != nil {
+		return "", err
+	}
+
+	if ttl := secretCacheTTL(); ttl > 0 {
+		secretCache.Store(reference, cachedSecret{value: secret, expiresAt: time.Now().Add(ttl)})
+	}
+	return secret, nil
+}
+
+func secretsAllowList(name string) []string {
+	var allowed []string
+	for prefix := range strings.SplitSeq(GetEnvString(name, ""), ",") {
+		if prefix = strings.TrimSpace(prefix); prefix != "" {
+			allowed = append(allowed, prefix)
+		}
+	}
+	return allowed
+}
+
+// secretPathAllowed checks that a slash separated path is one of the allowed paths or below one of them
+func secretPathAllowed(secretPath string, allowedPaths []string) bool {
+	for _, allowed := range allowedPaths {
+		allowed = strings.Trim(path.Clean("/"+allowed), "/")
+		if allowed == "" || secretPath == allowed || strings.HasPrefix(secretPath, allowed+"/") {
+			return true
+		}
+	}
+	return false
+}
+
+func splitSecretKey(location string) (string, string) {
+	location, key, _ := strings.Cut(location, "#")
+	return location, key
+}
+
+// secretFromJson returns the whole secret when no key is given, otherwise key of the JSON object secret
+func secretFromJson(secret string, key string) (string, error) {
+	if key == "" {
+		return secret, nil
+	}
+	var values map[string]any
+	if err := json.Unmarshal([]byte(secret), &values); err != nil {
+		// the decoding error quotes the secret, so it is left out
+		return "", fmt.Errorf("secret is not a JSON object, cannot read key %s", key)
+	}
+	return secretValue(values, key)
+}
+
+func secretValue(values map[string]any, key string) (string, error) {
+	value, ok := values[key]
+	if !ok {
+		return "", fmt.Errorf("key %s not found in secret", key)
+	}
+	switch v := value.(type) {
+	case string:
+		return v, nil
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64), nil
+	default:
+		return "", fmt.Errorf("key %s of secret is not a string", key)
+	}
+}
+
+func resolveFileSecret(location string) (string, error) {
+	filePath, key := splitSecretKey(location)
+	secretsDir := GetEnvString("PEERDB_SECRETS_FILE_DIR", "")
+	if secretsDir == "" {
+		return "", errors.New("file secret references are disabled, PEERDB_SECRETS_FILE_DIR is not set")
+	}
+	root, err := os.OpenRoot(secretsDir)
+	if err != nil {
+		return "", fmt.Errorf("failed to open secrets directory: %w", err)
+	}
+	defer root.Close()
+	// the root refuses paths escaping the secrets directory, including through symlinks
+	relPath := filePath
+	if filepath.IsAbs(filePath) {
+		if relPath, err = filepath.Rel(secretsDir, filePath); err != nil {
+			return "", errors.New("secret file is outside of PEERDB_SECRETS_FILE_DIR")
+		}
+	}
+	file, err := root.Open(relPath)
+	if err != nil {
+		return "", fmt.Errorf("failed to read secret file: %w", err)
+	}
+	defer file.Close()
+	content, err := io.ReadAll(file)
+	if err != nil {
+		return "", fmt.Errorf("failed to read secret file: %w", err)
+	}
+	return secretFromJson(strings.TrimRight(string(content), "\r\n"), key)
+}
+
+func resolveAwsSecretsManagerSecret(ctx context.Context, location string) (string, error) {
+	secretId, key := splitSecretKey(location)
+	if !slices.ContainsFunc(secretsAllowList("PEERDB_SECRETS_AWSSM_ALLOWED_PREFIXES"), func(prefix string) bool {
+		return strings.HasPrefix(secretId, prefix)
+	}) {
+		return "", errors.New("AWS Secrets Manager secret is not allowed by PEERDB_SECRETS_AWSSM_ALLOWED_PREFIXES")
+	}
+	cfg, err := config.LoadDefaultConfig(ctx, func(options *config.LoadOptions) error {
+		// secrets in another region can only be addressed by ARN
+		if secretArn, err := arn.Parse(secretId); err == nil {
+			options.Region = secretArn.Region
+		}
+		return nil
+	})
+	if err != nil {
+		return "", fmt.Errorf("failed to load AWS config: %w", err)
+	}
+
+	output, err := secretsmanager.NewFromConfig(cfg).GetSecretValue(ctx, &secretsmanager.GetSecretValueInput{
+		SecretId: aws.String(secretId),
+	})
+	if err != nil {
+		return "", fmt.Errorf("failed to get secret value from AWS Secrets Manager: %w", err)
+	}
+	if output.SecretString == nil {
+		return "", errors.New("AWS Secrets Manager secret has no string value")
+	}
+	return secretFromJson(*output.SecretString, key)
+}
+
+func resolveVaultSecret(ctx context.Context, location string) (string, error) {
+	secretPath, key := splitSecretKey(location)
+	if key == "" {
+		return "", errors.New("vault secret reference needs a key, as in vault://secret/data/peer#password")
+	}
+	// cleaning resolves .. before matching, so an allowed prefix cannot be escaped
+	secretPath = strings.Trim(path.Clean("/"+secretPath), "/")
+	if !secretPathAllowed(secretPath, secretsAllowList("PEERDB_SECRETS_VAULT_ALLOWED_PATHS")) {
+		return "", errors.New("vault secret is not allowed by PEERDB_SECRETS_VAULT_ALLOWED_PATHS")
+	}
+	vaultAddr := GetEnvString("VAULT_ADDR", "")
+	if vaultAddr == "" {
+		return "", errors.New("VAULT_ADDR is not set")
+	}
+	secretUrl, err := url.JoinPath(vaultAddr, "v1", secretPath)
+	if err != nil {
+		return "", fmt.Errorf("invalid vault secret path: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, secretUrl, http.NoBody)
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("X-Vault-Token", GetEnvString("VAULT_TOKEN", ""))
+	if namespace := GetEnvString("VAULT_NAMESPACE", ""); namespace != "" {
+		req.Header.Set("X-Vault-Namespace", namespace)
+	}
+
+	client := &http.Client{Timeout: 30 * time.Second}
+	resp, err := client.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("failed to read secret from vault: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("failed to read secret from vault: %s", resp.Status)
+	}
+
+	var secret struct {
+		Data map[string]any `json:"data"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&secret); err != nil {
+		return "", fmt.Errorf("failed to decode vault response: %w", err)
+	}
+	// KV v2 nests the secret under data.data, KV v1 returns it as data
+	if nested, ok := secret.Data["data"].(map[string]any); ok {
+		if _, hasKey := secret.Data[key]; !hasKey {
+			return secretValue(nested, key)
+		}
+	}
+	return secretValue(secret.Data, key)
+}
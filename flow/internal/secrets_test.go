@@ -0,0 +1,141 @@
+package internal
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/PeerDB-io/peerdb/flow/generated/protos"
+)
+
+func TestSplitSecretKey(t *testing.T) {
+	t.Parallel()
+
+	for _, tc := range []struct {
+		location string
+		path     string
+		key      string
+	}{
+		{"secret/data/peer#password", "secret/data/peer", "password"},
+		{"secret/data/peer", "secret/data/peer", ""},
+		{"a#b#c", "a", "b#c"},
+		{"#key", "", "key"},
+	} {
+		path, key := splitSecretKey(tc.location)
+		require.Equal(t, tc.path, path, tc.location)
+		require.Equal(t, tc.key, key, tc.location)
+	}
+}
+
+func TestSecretFromJson(t *testing.T) {
+	t.Parallel()
+
+	secret, err := secretFromJson("not json", "")
+	require.NoError(t, err)
+	require.Equal(t, "not json", secret)
+
+	secret, err = secretFromJson(`{"password":"hunter2","port":5432}`, "password")
+	require.NoError(t, err)
+	require.Equal(t, "hunter2", secret)
+	secret, err = secretFromJson(`{"password":"hunter2","port":5432}`, "port")
+	require.NoError(t, err)
+	require.Equal(t, "5432", secret)
+
+	_, err = secretFromJson(`{"password":"hunter2"}`, "user")
+	require.ErrorContains(t, err, "key user not found")
+	_, err = secretFromJson(`{"password":{"nested":true}}`, "password")
+	require.ErrorContains(t, err, "not a string")
+	_, err = secretFromJson("hunter2", "password")
+	require.Error(t, err)
+	require.NotContains(t, err.Error(), "hunter2", "errors must not quote the secret")
+}
+
+func TestResolveFileSecretReferences(t *testing.T) {
+	secretsDir := t.TempDir()
+	t.Setenv("PEERDB_SECRET_CACHE_TTL_SECONDS", "0")
+	require.NoError(t, os.WriteFile(filepath.Join(secretsDir, "password"), []byte("hunter2\n"), 0o600))
+	require.NoError(t, os.WriteFile(filepath.Join(secretsDir, "peer.json"), []byte(`{"password":"swordfish"}`), 0o600))
+	outside := filepath.Join(t.TempDir(), "password")
+	require.NoError(t, os.WriteFile(outside, []byte("outside"), 0o600))
+
+	config := &protos.PostgresConfig{Host: "host", Password: "file://password"}
+	_, err := ResolveSecretReferences(t.Context(), config)
+	require.ErrorContains(t, err, "PEERDB_SECRETS_FILE_DIR is not set")
+
+	t.Setenv("PEERDB_SECRETS_FILE_DIR", secretsDir)
+	resolved, err := ResolveSecretReferences(t.Context(), config)
+	require.NoError(t, err)
+	require.Equal(t, "hunter2", resolved.Password)
+	require.Equal(t, "host", resolved.Host)
+	require.Equal(t, "file://password", config.Password, "the reference itself is kept")
+
+	resolved, err = ResolveSecretReferences(t.Context(),
+		&protos.PostgresConfig{Password: "file://" + filepath.Join(secretsDir, "peer.json") + "#password"})
+	require.NoError(t, err)
+	require.Equal(t, "swordfish", resolved.Password)
+
+	for _, reference := range []string{"file://" + outside, "file://../password", "file://" + secretsDir + "/../password"} {
+		_, err := ResolveSecretReferences(t.Context(), &protos.PostgresConfig{Password: reference})
+		require.Error(t, err, reference)
+	}
+
+	noReferences := &protos.PostgresConfig{Password: "plain"}
+	resolved, err = ResolveSecretReferences(t.Context(), noReferences)
+	require.NoError(t, err)
+	require.Same(t, noReferences, resolved)
+}
+
+func TestResolveVaultSecretReferences(t *testing.T) {
+	var requests atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+		assert.Equal(t, "token", r.Header.Get("X-Vault-Token"))
+		if r.URL.Path != "/v1/secret/data/peerdb/pg" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		_, _ = w.Write([]byte(`{"data":{"data":{"password":"hunter2"}}}`))
+	}))
+	defer server.Close()
+	t.Setenv("PEERDB_SECRET_CACHE_TTL_SECONDS", "0")
+	t.Setenv("VAULT_ADDR", server.URL)
+	t.Setenv("VAULT_TOKEN", "token")
+
+	config := &protos.PostgresConfig{Password: "vault://secret/data/peerdb/pg#password"}
+	_, err := ResolveSecretReferences(t.Context(), config)
+	require.ErrorContains(t, err, "not allowed")
+
+	t.Setenv("PEERDB_SECRETS_VAULT_ALLOWED_PATHS", "secret/data/peerdb, secret/data/other")
+	resolved, err := ResolveSecretReferences(t.Context(), config)
+	require.NoError(t, err)
+	require.Equal(t, "hunter2", resolved.Password)
+	require.Equal(t, int32(1), requests.Load())
+
+	for _, reference := range []string{
+		"vault://secret/data/peerdbx/pg#password",
+		"vault://secret/data/peerdb/../admin#password",
+		"vault://secret/data#password",
+	} {
+		_, err := ResolveSecretReferences(t.Context(), &protos.PostgresConfig{Password: reference})
+		require.ErrorContains(t, err, "not allowed", reference)
+	}
+	require.Equal(t, int32(1), requests.Load(), "refused references must not reach vault")
+}
+
+func TestResolveAwsSecretsManagerSecretReferences(t *testing.T) {
+	t.Setenv("PEERDB_SECRET_CACHE_TTL_SECONDS", "0")
+	//nolint:gosec // reference, not a credential
+	_, err := ResolveSecretReferences(t.Context(), &protos.PostgresConfig{Password: "awssm://peerdb/pg#password"})
+	require.ErrorContains(t, err, "not allowed")
+
+	t.Setenv("PEERDB_SECRETS_AWSSM_ALLOWED_PREFIXES", "peerdb/")
+	//nolint:gosec // reference, not a credential
+	_, err = ResolveSecretReferences(t.Context(), &protos.PostgresConfig{Password: "awssm://admin/root#password"})
+	require.ErrorContains(t, err, "not allowed")
+}
@@ -0,0 +1,39 @@
+package internal
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/jackc/pgx/v5"
+
+	"github.com/PeerDB-io/peerdb/flow/shared"
+)
+
+// SaveSnapshotTableOffset records the replication offset a table's initial load was read at,
+// replacing the offset of an earlier attempt
+func SaveSnapshotTableOffset(ctx context.Context, pool shared.CatalogPool, flowName string, tableName string, offsetText string) error {
+	if _, err := pool.Exec(ctx,
+		`INSERT INTO snapshot_table_offsets (flow_name, table_name, offset_text) VALUES ($1, $2, $3)
+		ON CONFLICT (flow_name, table_name) DO UPDATE SET offset_text=$3, created_at=NOW()`, flowName, tableName, offsetText,
+	); err != nil {
+		return fmt.Errorf("failed to save snapshot table offset: %w", err)
+	}
+	return nil
+}
+
+// LoadSnapshotTableOffsets returns the offsets initial loads of a mirror were read at, keyed by source table
+func LoadSnapshotTableOffsets(ctx context.Context, pool shared.CatalogPool, flowName string) (map[string]string, error) {
+	rows, err := pool.Query(ctx, "SELECT table_name, offset_text FROM snapshot_table_offsets WHERE flow_name=$1", flowName)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load snapshot table offsets: %w", err)
+	}
+	offsets := make(map[string]string)
+	var tableName, offsetText string
+	if _, err := pgx.ForEachRow(rows, []any{&tableName, &offsetText}, func() error {
+		offsets[tableName] = offsetText
+		return nil
+	}); err != nil {
+		return nil, fmt.Errorf("failed to load snapshot table offsets: %w", err)
+	}
+	return offsets, nil
+}
@@ -0,0 +1,89 @@
+package internal
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/jackc/pgx/v5"
+	"google.golang.org/protobuf/proto"
+
+	"github.com/PeerDB-io/peerdb/flow/generated/protos"
+	"github.com/PeerDB-io/peerdb/flow/shared"
+)
+
+// LoadSnapshotResumeState returns the replication setup of an unfinished resumable initial load, or nil if there is none
+func LoadSnapshotResumeState(ctx context.Context, pool shared.CatalogPool, flowName string) (*protos.SetupReplicationOutput, error) {
+	var outputBytes []byte
+	if err := pool.QueryRow(ctx,
+		"SELECT setup_replication_output FROM snapshot_resume_state WHERE flow_name=$1", flowName,
+	).Scan(&outputBytes); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to load snapshot resume state: %w", err)
+	}
+	output := &protos.SetupReplicationOutput{}
+	return output, proto.Unmarshal(outputBytes, output)
+}
+
+func SaveSnapshotResumeState(ctx context.Context, pool shared.CatalogPool, flowName string, output *protos.SetupReplicationOutput) error {
+	outputBytes, err := proto.Marshal(output)
+	if err != nil {
+		return fmt.Errorf("unable to marshal setup replication output: %w", err)
+	}
+	if _, err := pool.Exec(ctx,
+		`INSERT INTO snapshot_resume_state (flow_name, setup_replication_output) VALUES ($1, $2)
+		ON CONFLICT (flow_name) DO UPDATE SET setup_replication_output=$2`, flowName, outputBytes,
+	); err != nil {
+		return fmt.Errorf("failed to save snapshot resume state: %w", err)
+	}
+	return nil
+}
+
+// DeleteSnapshotResumeState forgets an initial load's replication setup and the partition plans of its tables
+func DeleteSnapshotResumeState(ctx context.Context, pool shared.CatalogPool, flowName string) error {
+	if _, err := pool.Exec(ctx, "DELETE FROM snapshot_resume_state WHERE flow_name=$1", flowName); err != nil {
+		return fmt.Errorf("failed to delete snapshot resume state: %w", err)
+	}
+	if _, err := pool.Exec(ctx, "DELETE FROM snapshot_partition_plans WHERE parent_mirror_name=$1", flowName); err != nil {
+		return fmt.Errorf("failed to delete snapshot partition plans: %w", err)
+	}
+	return nil
+}
+
+// LoadSnapshotPartitionPlan returns the partitions planned by an earlier run of a resumable QRep flow, or nil if there are none
+func LoadSnapshotPartitionPlan(ctx context.Context, pool shared.CatalogPool, flowJobName string) (*protos.QRepParitionResult, error) {
+	var planBytes []byte
+	if err := pool.QueryRow(ctx,
+		"SELECT partitions FROM snapshot_partition_plans WHERE flow_name=$1", flowJobName,
+	).Scan(&planBytes); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to load snapshot partition plan: %w", err)
+	}
+	plan := &protos.QRepParitionResult{}
+	return plan, proto.Unmarshal(planBytes, plan)
+}
+
+// SaveSnapshotPartitionPlan keeps the first plan saved for a flow, so concurrent or repeated planning agrees on partition ids
+func SaveSnapshotPartitionPlan(
+	ctx context.Context,
+	pool shared.CatalogPool,
+	flowJobName string,
+	parentMirrorName string,
+	plan *protos.QRepParitionResult,
+) (*protos.QRepParitionResult, error) {
+	planBytes, err := proto.Marshal(plan)
+	if err != nil {
+		return nil, fmt.Errorf("unable to marshal partition plan: %w", err)
+	}
+	if _, err := pool.Exec(ctx,
+		`INSERT INTO snapshot_partition_plans (flow_name, parent_mirror_name, partitions) VALUES ($1, $2, $3)
+		ON CONFLICT (flow_name) DO NOTHING`, flowJobName, parentMirrorName, planBytes,
+	); err != nil {
+		return nil, fmt.Errorf("failed to save snapshot partition plan: %w", err)
+	}
+	return LoadSnapshotPartitionPlan(ctx, pool, flowJobName)
+}
@@ -0,0 +1,70 @@
+package internal
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/proto"
+
+	"github.com/PeerDB-io/peerdb/flow/generated/protos"
+	"github.com/PeerDB-io/peerdb/flow/shared"
+)
+
+func TestSnapshotResumeState(t *testing.T) {
+	t.Parallel()
+
+	ctx := t.Context()
+	catalogPool, err := GetCatalogConnectionPoolFromEnv(ctx)
+	require.NoError(t, err)
+	flowName := "test_snapshot_resume_" + shared.RandomString(8)
+
+	output, err := LoadSnapshotResumeState(ctx, catalogPool, flowName)
+	require.NoError(t, err)
+	require.Nil(t, output)
+
+	first := &protos.SetupReplicationOutput{SlotName: "slot", ConsistentPoint: "0/1", SupportsTidScans: true}
+	require.NoError(t, SaveSnapshotResumeState(ctx, catalogPool, flowName, first))
+	second := &protos.SetupReplicationOutput{SlotName: "slot", ConsistentPoint: "0/2"}
+	require.NoError(t, SaveSnapshotResumeState(ctx, catalogPool, flowName, second))
+	output, err = LoadSnapshotResumeState(ctx, catalogPool, flowName)
+	require.NoError(t, err)
+	require.True(t, proto.Equal(second, output), "a new replication setup replaces the saved one")
+
+	require.NoError(t, DeleteSnapshotResumeState(ctx, catalogPool, flowName))
+	output, err = LoadSnapshotResumeState(ctx, catalogPool, flowName)
+	require.NoError(t, err)
+	require.Nil(t, output)
+}
+
+func TestSnapshotPartitionPlan(t *testing.T) {
+	t.Parallel()
+
+	ctx := t.Context()
+	catalogPool, err := GetCatalogConnectionPoolFromEnv(ctx)
+	require.NoError(t, err)
+	parentMirrorName := "test_snapshot_plan_" + shared.RandomString(8)
+	flowJobName := parentMirrorName + "_public_t"
+
+	plan, err := LoadSnapshotPartitionPlan(ctx, catalogPool, flowJobName)
+	require.NoError(t, err)
+	require.Nil(t, plan)
+
+	first := &protos.QRepParitionResult{Partitions: []*protos.QRepPartition{{PartitionId: "a"}, {PartitionId: "b"}}}
+	plan, err = SaveSnapshotPartitionPlan(ctx, catalogPool, flowJobName, parentMirrorName, first)
+	require.NoError(t, err)
+	require.True(t, proto.Equal(first, plan))
+	// planning again, as a retried GetQRepPartitions does, keeps the partition ids of the first plan
+	plan, err = SaveSnapshotPartitionPlan(ctx, catalogPool, flowJobName, parentMirrorName,
+		&protos.QRepParitionResult{Partitions: []*protos.QRepPartition{{PartitionId: "c"}}})
+	require.NoError(t, err)
+	require.True(t, proto.Equal(first, plan))
+	plan, err = LoadSnapshotPartitionPlan(ctx, catalogPool, flowJobName)
+	require.NoError(t, err)
+	require.True(t, proto.Equal(first, plan))
+
+	// plans are deleted with the resume state of their parent mirror
+	require.NoError(t, DeleteSnapshotResumeState(ctx, catalogPool, parentMirrorName))
+	plan, err = LoadSnapshotPartitionPlan(ctx, catalogPool, flowJobName)
+	require.NoError(t, err)
+	require.Nil(t, plan)
+}
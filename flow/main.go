@@ -42,6 +42,13 @@ func main() {
 		Sources: cli.EnvVars("ENABLE_OTEL_METRICS"),
 	}
 
+	otelTracesFlag := &cli.BoolFlag{
+		Name:    "enable-otel-traces",
+		Value:   false, // Default is off
+		Usage:   "Enable OpenTelemetry tracing of workflows, activities and connector operations",
+		Sources: cli.EnvVars("ENABLE_OTEL_TRACES"),
+	}
+
 	pprofPortFlag := &cli.IntFlag{
 		Name:    "pprof-port",
 		Value:   6060,
@@ -49,6 +56,13 @@ func main() {
 		Sources: cli.EnvVars("PPROF_PORT"),
 	}
 
+	prometheusPortFlag := &cli.IntFlag{
+		Name:    "prometheus-port",
+		Value:   9464,
+		Usage:   "Port for Prometheus metrics HTTP server, used when OTEL_METRICS_EXPORTER=prometheus",
+		Sources: cli.EnvVars("PROMETHEUS_PORT"),
+	}
+
 	temporalNamespaceFlag := &cli.StringFlag{
 		Name:    "temporal-namespace",
 		Value:   "default",
@@ -142,11 +156,13 @@ func main() {
 						TemporalHostPort:                   temporalHostPort,
 						EnableProfiling:                    clicmd.Bool("enable-profiling"),
 						EnableOtelMetrics:                  clicmd.Bool("enable-otel-metrics"),
+						EnableOtelTraces:                   clicmd.Bool(otelTracesFlag.Name),
 						TemporalNamespace:                  clicmd.String("temporal-namespace"),
 						TemporalMaxConcurrentActivities:    clicmd.Int("temporal-max-concurrent-activities"),
 						TemporalMaxConcurrentWorkflowTasks: clicmd.Int("temporal-max-concurrent-workflow-tasks"),
 						UseMaintenanceTaskQueue:            clicmd.Bool(useMaintenanceTaskQueueFlag.Name),
 						PprofPort:                          clicmd.Int(pprofPortFlag.Name),
+						PrometheusPort:                     clicmd.Int(prometheusPortFlag.Name),
 					})
 					if err != nil {
 						return err
@@ -158,7 +174,9 @@ func main() {
 					temporalHostPortFlag,
 					profilingFlag,
 					otelMetricsFlag,
+					otelTracesFlag,
 					pprofPortFlag,
+					prometheusPortFlag,
 					temporalNamespaceFlag,
 					temporalMaxConcurrentActivitiesFlag,
 					temporalMaxConcurrentWorkflowTasksFlag,
@@ -171,8 +189,10 @@ func main() {
 					temporalHostPort := clicmd.String("temporal-host-port")
 					res, err := cmd.SnapshotWorkerMain(ctx, &cmd.SnapshotWorkerOptions{
 						EnableOtelMetrics: clicmd.Bool("enable-otel-metrics"),
+						EnableOtelTraces:  clicmd.Bool(otelTracesFlag.Name),
 						TemporalHostPort:  temporalHostPort,
 						TemporalNamespace: clicmd.String("temporal-namespace"),
+						PrometheusPort:    clicmd.Int(prometheusPortFlag.Name),
 					})
 					if err != nil {
 						return err
@@ -182,6 +202,8 @@ func main() {
 				},
 				Flags: []cli.Flag{
 					otelMetricsFlag,
+					otelTracesFlag,
+					prometheusPortFlag,
 					temporalHostPortFlag,
 					temporalNamespaceFlag,
 				},
@@ -202,6 +224,8 @@ func main() {
 					temporalHostPortFlag,
 					temporalNamespaceFlag,
 					otelMetricsFlag,
+					otelTracesFlag,
+					prometheusPortFlag,
 				},
 				Action: func(ctx context.Context, clicmd *cli.Command) error {
 					temporalHostPort := clicmd.String("temporal-host-port")
@@ -212,6 +236,8 @@ func main() {
 						GatewayPort:       uint16(clicmd.Uint("gateway-port")),
 						TemporalNamespace: clicmd.String("temporal-namespace"),
 						EnableOtelMetrics: clicmd.Bool(otelMetricsFlag.Name),
+						EnableOtelTraces:  clicmd.Bool(otelTracesFlag.Name),
+						PrometheusPort:    clicmd.Int(prometheusPortFlag.Name),
 					})
 				},
 			},
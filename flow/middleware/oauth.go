@@ -17,6 +17,7 @@ import (
 	"google.golang.org/grpc/status"
 
 	"github.com/PeerDB-io/peerdb/flow/internal"
+	"github.com/PeerDB-io/peerdb/flow/shared"
 )
 
 //nolint:lll
@@ -34,7 +35,7 @@ type identityProvider struct {
 	issuer      string
 }
 
-func AuthGrpcMiddleware(unauthenticatedMethods []string) (grpc.UnaryServerInterceptor, error) {
+func AuthGrpcMiddleware(unauthenticatedMethods []string, catalogPool shared.CatalogPool) (grpc.UnaryServerInterceptor, error) {
 	oauthConfig := internal.GetPeerDBOAuthConfig()
 	oauthJwtClaims := map[string]string{}
 	if oauthConfig.OAuthJwtClaimKey != "" {
@@ -66,6 +67,11 @@ func AuthGrpcMiddleware(unauthenticatedMethods []string) (grpc.UnaryServerInterc
 		return nil, err
 	}
 
+	authz, err := newAuthorizer(oauthConfig.OAuthRolesClaimKey, oauthConfig.OAuthRoleBindingsJson, catalogPool)
+	if err != nil {
+		return nil, err
+	}
+
 	unauthenticatedMethodsMap := make(map[string]struct{}, len(unauthenticatedMethods))
 	for _, method := range unauthenticatedMethods {
 		unauthenticatedMethodsMap[method] = struct{}{}
@@ -80,18 +86,23 @@ func AuthGrpcMiddleware(unauthenticatedMethods []string) (grpc.UnaryServerInterc
 				slog.Warn("Multiple Authorization headers supplied, request rejected", slog.String("method", info.FullMethod))
 				return nil, status.Errorf(codes.Unauthenticated, "multiple Authorization headers supplied, request rejected")
 			}
-			_, err := validateRequestToken(authHeader, cfg.OauthJwtCustomClaims, ip...)
+			token, err := validateRequestToken(authHeader, cfg.OauthJwtCustomClaims, ip...)
 			if err != nil {
 				slog.Debug("Failed to validate request token", slog.String("method", info.FullMethod), slog.Any("error", err))
 				return nil, status.Error(codes.Unauthenticated, err.Error())
 			}
+
+			ctx, err = authorizeRequest(ctx, authz, info.FullMethod, req, token)
+			if err != nil {
+				return nil, err
+			}
 		}
 
 		return handler(ctx, req)
 	}, nil
 }
 
-func validateRequestToken(authHeader string, claims map[string]string, ip ...identityProvider) ([]byte, error) {
+func validateRequestToken(authHeader string, claims map[string]string, ip ...identityProvider) (jwt.Token, error) {
 	payload, err := jwtFromRequest(authHeader)
 	if err != nil {
 		return nil, fmt.Errorf("failed to parse authorization header: %w", err)
@@ -123,7 +134,7 @@ func validateRequestToken(authHeader string, claims map[string]string, ip ...ide
 		}
 	}
 
-	return payload, nil
+	return token, nil
 }
 
 // jwtFromRequest extracts the JWT token from the Authorization header.
@@ -0,0 +1,340 @@
+package middleware
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"strings"
+
+	"github.com/lestrrat-go/jwx/v2/jwt"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/PeerDB-io/peerdb/flow/generated/protos"
+	"github.com/PeerDB-io/peerdb/flow/shared"
+)
+
+type Role string
+
+const (
+	RoleViewer   Role = "viewer"
+	RoleOperator Role = "operator"
+	RoleAdmin    Role = "admin"
+)
+
+func (r Role) rank() int {
+	switch r {
+	case RoleViewer:
+		return 1
+	case RoleOperator:
+		return 2
+	case RoleAdmin:
+		return 3
+	default:
+		return 0
+	}
+}
+
+// RoleBinding grants a role to tokens carrying ClaimValue in the roles claim,
+// when Tags is set the grant only covers mirrors with all of these tags and the peers they use.
+// Scoped grants can list mirrors, peers and alerts, which are filtered down to that scope,
+// other requests not about a particular mirror or peer (settings, scripts, audit events) need an unscoped grant
+type RoleBinding struct {
+	Tags       map[string]string `json:"tags,omitempty"`
+	ClaimValue string            `json:"claim_value"`
+	Role       Role              `json:"role"`
+}
+
+type roleGrant struct {
+	tags map[string]string
+	role Role
+}
+
+// Principal is the authenticated caller of an API request
+type Principal struct {
+	Subject string
+	Roles   []Role
+	// Scopes are the tags of the mirrors the caller is limited to, nil when any of its grants is unscoped
+	Scopes []map[string]string
+}
+
+type principalContextKey struct{}
+
+func ContextWithPrincipal(ctx context.Context, principal Principal) context.Context {
+	return context.WithValue(ctx, principalContextKey{}, principal)
+}
+
+func PrincipalFromContext(ctx context.Context) (Principal, bool) {
+	principal, ok := ctx.Value(principalContextKey{}).(Principal)
+	return principal, ok
+}
+
+type principalRecorderKey struct{}
+
+type recordedPrincipal struct {
+	principal Principal
+	ok        bool
+}
+
+// ContextWithPrincipalRecorder lets interceptors running before AuthGrpcMiddleware learn who the caller is,
+// also when the request is denied, the returned function reports it once the request has been handled
+func ContextWithPrincipalRecorder(ctx context.Context) (context.Context, func() (Principal, bool)) {
+	recorded := &recordedPrincipal{}
+	return context.WithValue(ctx, principalRecorderKey{}, recorded), func() (Principal, bool) {
+		return recorded.principal, recorded.ok
+	}
+}
+
+func recordPrincipal(ctx context.Context, principal Principal) {
+	if recorded, ok := ctx.Value(principalRecorderKey{}).(*recordedPrincipal); ok {
+		recorded.principal = principal
+		recorded.ok = true
+	}
+}
+
+// methods not listed here require RoleAdmin
+var methodRoles = map[string]Role{
+	protos.FlowService_ListMirrors_FullMethodName:              RoleViewer,
+	protos.FlowService_ListMirrorNames_FullMethodName:          RoleViewer,
+	protos.FlowService_MirrorStatus_FullMethodName:             RoleViewer,
+	protos.FlowService_GetCDCBatches_FullMethodName:            RoleViewer,
+	protos.FlowService_CDCBatches_FullMethodName:               RoleViewer,
+	protos.FlowService_CDCGraph_FullMethodName:                 RoleViewer,
+	protos.FlowService_CDCTableTotalCounts_FullMethodName:      RoleViewer,
+	protos.FlowService_InitialLoadSummary_FullMethodName:       RoleViewer,
+	protos.FlowService_ListMirrorLogs_FullMethodName:           RoleViewer,
+	protos.FlowService_GetFlowTags_FullMethodName:              RoleViewer,
+	protos.FlowService_ListPeers_FullMethodName:                RoleViewer,
+	protos.FlowService_GetPeerType_FullMethodName:              RoleViewer,
+	protos.FlowService_GetSchemas_FullMethodName:               RoleViewer,
+	protos.FlowService_GetPublications_FullMethodName:          RoleViewer,
+	protos.FlowService_GetTablesInSchema_FullMethodName:        RoleViewer,
+	protos.FlowService_GetAllTables_FullMethodName:             RoleViewer,
+	protos.FlowService_GetColumns_FullMethodName:               RoleViewer,
+	protos.FlowService_GetColumnsTypeConversion_FullMethodName: RoleViewer,
+	protos.FlowService_GetSlotInfo_FullMethodName:              RoleViewer,
+	protos.FlowService_GetSlotLagHistory_FullMethodName:        RoleViewer,
+	protos.FlowService_GetStatInfo_FullMethodName:              RoleViewer,
+	protos.FlowService_GetVersion_FullMethodName:               RoleViewer,
+	protos.FlowService_GetInstanceInfo_FullMethodName:          RoleViewer,
+	protos.FlowService_ListActiveAlerts_FullMethodName:         RoleViewer,
+	protos.FlowService_GetDynamicSettings_FullMethodName:       RoleViewer,
+	protos.FlowService_GetScripts_FullMethodName:               RoleViewer,
+	protos.FlowService_GetPeerInfo_FullMethodName:              RoleOperator,
+	protos.FlowService_ValidatePeer_FullMethodName:             RoleOperator,
+	protos.FlowService_ValidateCDCMirror_FullMethodName:        RoleOperator,
+	protos.FlowService_FlowStateChange_FullMethodName:          RoleOperator,
+	protos.FlowService_AcknowledgeAlert_FullMethodName:         RoleOperator,
+}
+
+// methods that don't expose anything about a particular mirror or peer, and so can be called with scoped grants
+var unscopedMethods = map[string]struct{}{
+	protos.FlowService_GetVersion_FullMethodName:      {},
+	protos.FlowService_GetInstanceInfo_FullMethodName: {},
+}
+
+// methods listing mirrors, peers or alerts, scoped grants can call these as handlers filter by Principal.Scopes
+var listMethods = map[string]struct{}{
+	protos.FlowService_ListMirrors_FullMethodName:      {},
+	protos.FlowService_ListMirrorNames_FullMethodName:  {},
+	protos.FlowService_ListPeers_FullMethodName:        {},
+	protos.FlowService_ListActiveAlerts_FullMethodName: {},
+}
+
+func requiredRole(method string, req any) Role {
+	// terminating or resyncing drops data, and config updates can change queries, scripts and env,
+	// so these are reserved to those who can create and drop mirrors
+	if stateChange, ok := req.(*protos.FlowStateChangeRequest); ok &&
+		(stateChange.RequestedFlowState == protos.FlowStatus_STATUS_TERMINATING ||
+			stateChange.RequestedFlowState == protos.FlowStatus_STATUS_RESYNC ||
+			stateChange.FlowConfigUpdate != nil) {
+		return RoleAdmin
+	}
+	if role, ok := methodRoles[method]; ok {
+		return role
+	}
+	return RoleAdmin
+}
+
+type authorizer struct {
+	catalogPool   shared.CatalogPool
+	bindings      map[string][]roleGrant
+	rolesClaimKey string
+}
+
+func newAuthorizer(rolesClaimKey string, roleBindingsJson string, catalogPool shared.CatalogPool) (*authorizer, error) {
+	if rolesClaimKey == "" {
+		return nil, nil
+	}
+
+	var bindings []RoleBinding
+	if roleBindingsJson == "" {
+		// without explicit bindings, claim values are PeerDB role names
+		for _, role := range []Role{RoleViewer, RoleOperator, RoleAdmin} {
+			bindings = append(bindings, RoleBinding{ClaimValue: string(role), Role: role})
+		}
+	} else if err := json.Unmarshal([]byte(roleBindingsJson), &bindings); err != nil {
+		return nil, fmt.Errorf("failed to parse role bindings: %w", err)
+	}
+
+	bindingsByClaim := make(map[string][]roleGrant, len(bindings))
+	for _, binding := range bindings {
+		if binding.Role.rank() == 0 {
+			return nil, fmt.Errorf("invalid role %q for claim value %q", binding.Role, binding.ClaimValue)
+		}
+		bindingsByClaim[binding.ClaimValue] = append(bindingsByClaim[binding.ClaimValue], roleGrant{
+			role: binding.Role,
+			tags: binding.Tags,
+		})
+	}
+
+	slog.Info("role-based access control enabled",
+		slog.String("claim", rolesClaimKey), slog.Int("bindings", len(bindings)))
+	return &authorizer{
+		rolesClaimKey: rolesClaimKey,
+		bindings:      bindingsByClaim,
+		catalogPool:   catalogPool,
+	}, nil
+}
+
+// claimValues reads a string or list of strings claim, nested claims can be addressed with dots (e.g. realm_access.roles)
+func claimValues(token jwt.Token, claimKey string) []string {
+	path := strings.Split(claimKey, ".")
+	claim, ok := token.PrivateClaims()[path[0]]
+	for _, key := range path[1:] {
+		if !ok {
+			return nil
+		}
+		nested, isMap := claim.(map[string]any)
+		if !isMap {
+			return nil
+		}
+		claim, ok = nested[key]
+	}
+
+	switch v := claim.(type) {
+	case string:
+		return []string{v}
+	case []string:
+		return v
+	case []any:
+		values := make([]string, 0, len(v))
+		for _, item := range v {
+			if s, ok := item.(string); ok {
+				values = append(values, s)
+			}
+		}
+		return values
+	default:
+		return nil
+	}
+}
+
+func (a *authorizer) grants(token jwt.Token) []roleGrant {
+	var grants []roleGrant
+	for _, value := range claimValues(token, a.rolesClaimKey) {
+		grants = append(grants, a.bindings[value]...)
+	}
+	return grants
+}
+
+func (a *authorizer) principal(token jwt.Token) Principal {
+	grants := a.grants(token)
+	roles := make([]Role, 0, len(grants))
+	var scopes []map[string]string
+	unscoped := false
+	for _, grant := range grants {
+		roles = append(roles, grant.role)
+		if len(grant.tags) == 0 {
+			unscoped = true
+		} else {
+			scopes = append(scopes, grant.tags)
+		}
+	}
+	if unscoped {
+		scopes = nil
+	}
+	return Principal{Subject: token.Subject(), Roles: roles, Scopes: scopes}
+}
+
+// requestTarget returns the mirror or peer a request is about, proto getters make this independent of the message type
+func requestTarget(req any) (string, string) {
+	switch r := req.(type) {
+	case interface{ GetFlowJobName() string }:
+		return r.GetFlowJobName(), ""
+	case interface{ GetFlowName() string }:
+		return r.GetFlowName(), ""
+	case interface{ GetParentMirrorName() string }:
+		return r.GetParentMirrorName(), ""
+	case interface{ GetPeerName() string }:
+		return "", r.GetPeerName()
+	default:
+		return "", ""
+	}
+}
+
+func (a *authorizer) inScope(ctx context.Context, method string, req any, tags map[string]string) (bool, error) {
+	if _, ok := unscopedMethods[method]; ok {
+		return true, nil
+	}
+	if _, ok := listMethods[method]; ok {
+		return true, nil
+	}
+
+	var inScope bool
+	switch flowName, peerName := requestTarget(req); {
+	case flowName != "":
+		if err := a.catalogPool.QueryRow(ctx,
+			"SELECT EXISTS(SELECT 1 FROM flows WHERE name = $1 AND tags @> $2)", flowName, tags,
+		).Scan(&inScope); err != nil {
+			return false, fmt.Errorf("failed to check mirror tags: %w", err)
+		}
+	case peerName != "":
+		if err := a.catalogPool.QueryRow(ctx,
+			`SELECT EXISTS(SELECT 1 FROM flows f JOIN peers p ON p.id IN (f.source_peer, f.destination_peer)
+			WHERE p.name = $1 AND f.tags @> $2)`, peerName, tags,
+		).Scan(&inScope); err != nil {
+			return false, fmt.Errorf("failed to check peer scope: %w", err)
+		}
+	}
+	return inScope, nil
+}
+
+// authorizeRequest returns the context handlers run with, carrying the caller's principal,
+// without role-based access control every valid token is an admin
+func authorizeRequest(ctx context.Context, authz *authorizer, method string, req any, token jwt.Token) (context.Context, error) {
+	principal := Principal{Subject: token.Subject(), Roles: []Role{RoleAdmin}}
+	if authz != nil {
+		principal = authz.principal(token)
+	}
+	recordPrincipal(ctx, principal)
+	if authz != nil {
+		if err := authz.authorize(ctx, method, req, token); err != nil {
+			slog.Warn("Request denied", slog.String("method", method),
+				slog.String("subject", token.Subject()), slog.Any("error", err))
+			return ctx, err
+		}
+	}
+	return ContextWithPrincipal(ctx, principal), nil
+}
+
+func (a *authorizer) authorize(ctx context.Context, method string, req any, token jwt.Token) error {
+	role := requiredRole(method, req)
+	for _, grant := range a.grants(token) {
+		if grant.role.rank() < role.rank() {
+			continue
+		}
+		if len(grant.tags) == 0 {
+			return nil
+		}
+		inScope, err := a.inScope(ctx, method, req, grant.tags)
+		if err != nil {
+			return status.Error(codes.Internal, err.Error())
+		}
+		if inScope {
+			return nil
+		}
+	}
+	return status.Errorf(codes.PermissionDenied, "role %s required for %s", role, method)
+}
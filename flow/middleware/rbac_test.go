@@ -0,0 +1,184 @@
+package middleware
+
+import (
+	"testing"
+
+	"github.com/lestrrat-go/jwx/v2/jwt"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/PeerDB-io/peerdb/flow/generated/protos"
+	"github.com/PeerDB-io/peerdb/flow/shared"
+)
+
+func tokenWithClaim(t *testing.T, key string, value any) jwt.Token {
+	t.Helper()
+	token := jwt.New()
+	require.NoError(t, token.Set(jwt.SubjectKey, "user@example.com"))
+	require.NoError(t, token.Set(key, value))
+	return token
+}
+
+func TestRequiredRole(t *testing.T) {
+	t.Parallel()
+
+	require.Equal(t, RoleViewer, requiredRole(protos.FlowService_MirrorStatus_FullMethodName, &protos.MirrorStatusRequest{}))
+	require.Equal(t, RoleAdmin, requiredRole(protos.FlowService_DropPeer_FullMethodName, &protos.DropPeerRequest{}))
+	require.Equal(t, RoleAdmin, requiredRole("/peerdb_route.FlowService/SomethingNew", nil))
+	require.Equal(t, RoleOperator, requiredRole(protos.FlowService_FlowStateChange_FullMethodName,
+		&protos.FlowStateChangeRequest{RequestedFlowState: protos.FlowStatus_STATUS_PAUSED}))
+	require.Equal(t, RoleAdmin, requiredRole(protos.FlowService_FlowStateChange_FullMethodName,
+		&protos.FlowStateChangeRequest{RequestedFlowState: protos.FlowStatus_STATUS_TERMINATING}))
+	require.Equal(t, RoleAdmin, requiredRole(protos.FlowService_FlowStateChange_FullMethodName,
+		&protos.FlowStateChangeRequest{
+			RequestedFlowState: protos.FlowStatus_STATUS_RUNNING,
+			FlowConfigUpdate: &protos.FlowConfigUpdate{Update: &protos.FlowConfigUpdate_QrepFlowConfigUpdate{
+				QrepFlowConfigUpdate: &protos.QRepFlowConfigUpdate{MaxParallelWorkers: 4},
+			}},
+		}))
+}
+
+func TestClaimValues(t *testing.T) {
+	t.Parallel()
+
+	require.Equal(t, []string{"admin"}, claimValues(tokenWithClaim(t, "role", "admin"), "role"))
+	require.Equal(t, []string{"a", "b"}, claimValues(tokenWithClaim(t, "groups", []any{"a", 1, "b"}), "groups"))
+	require.Equal(t, []string{"viewer"}, claimValues(
+		tokenWithClaim(t, "realm_access", map[string]any{"roles": []any{"viewer"}}), "realm_access.roles"))
+	require.Empty(t, claimValues(tokenWithClaim(t, "groups", "a"), "missing"))
+	require.Empty(t, claimValues(tokenWithClaim(t, "groups", "a"), "groups.nested"))
+}
+
+func TestNewAuthorizer(t *testing.T) {
+	t.Parallel()
+
+	authz, err := newAuthorizer("", "", shared.CatalogPool{})
+	require.NoError(t, err)
+	require.Nil(t, authz)
+
+	_, err = newAuthorizer("groups", `[{"claim_value": "analysts", "role": "superuser"}]`, shared.CatalogPool{})
+	require.Error(t, err)
+
+	_, err = newAuthorizer("groups", `not json`, shared.CatalogPool{})
+	require.Error(t, err)
+}
+
+func TestAuthorizeUnscoped(t *testing.T) {
+	t.Parallel()
+
+	authz, err := newAuthorizer("groups",
+		`[{"claim_value": "analysts", "role": "viewer"}, {"claim_value": "platform", "role": "admin"}]`, shared.CatalogPool{})
+	require.NoError(t, err)
+
+	analyst := tokenWithClaim(t, "groups", []any{"analysts"})
+	require.NoError(t, authz.authorize(t.Context(),
+		protos.FlowService_MirrorStatus_FullMethodName, &protos.MirrorStatusRequest{FlowJobName: "m"}, analyst))
+	err = authz.authorize(t.Context(), protos.FlowService_DropPeer_FullMethodName, &protos.DropPeerRequest{PeerName: "p"}, analyst)
+	require.Equal(t, codes.PermissionDenied, status.Code(err))
+
+	platform := tokenWithClaim(t, "groups", []any{"analysts", "platform"})
+	require.NoError(t, authz.authorize(t.Context(),
+		protos.FlowService_DropPeer_FullMethodName, &protos.DropPeerRequest{PeerName: "p"}, platform))
+	require.Equal(t, Principal{Subject: "user@example.com", Roles: []Role{RoleViewer, RoleAdmin}}, authz.principal(platform))
+
+	nobody := tokenWithClaim(t, "groups", []any{"unknown"})
+	err = authz.authorize(t.Context(), protos.FlowService_GetVersion_FullMethodName, &protos.PeerDBVersionRequest{}, nobody)
+	require.Equal(t, codes.PermissionDenied, status.Code(err))
+}
+
+func TestAuthorizeDefaultBindings(t *testing.T) {
+	t.Parallel()
+
+	authz, err := newAuthorizer("role", "", shared.CatalogPool{})
+	require.NoError(t, err)
+
+	operator := tokenWithClaim(t, "role", "operator")
+	require.NoError(t, authz.authorize(t.Context(), protos.FlowService_FlowStateChange_FullMethodName,
+		&protos.FlowStateChangeRequest{FlowJobName: "m", RequestedFlowState: protos.FlowStatus_STATUS_PAUSED}, operator))
+	err = authz.authorize(t.Context(), protos.FlowService_FlowStateChange_FullMethodName,
+		&protos.FlowStateChangeRequest{FlowJobName: "m", RequestedFlowState: protos.FlowStatus_STATUS_TERMINATING}, operator)
+	require.Equal(t, codes.PermissionDenied, status.Code(err))
+}
+
+func TestRequestTarget(t *testing.T) {
+	t.Parallel()
+
+	flowName, peerName := requestTarget(&protos.GetCDCBatchesRequest{FlowJobName: "m"})
+	require.Equal(t, "m", flowName)
+	require.Empty(t, peerName)
+
+	flowName, peerName = requestTarget(&protos.GetFlowTagsRequest{FlowName: "m"})
+	require.Equal(t, "m", flowName)
+	require.Empty(t, peerName)
+
+	flowName, peerName = requestTarget(&protos.PeerInfoRequest{PeerName: "p"})
+	require.Empty(t, flowName)
+	require.Equal(t, "p", peerName)
+
+	flowName, peerName = requestTarget(&protos.ListPeersRequest{})
+	require.Empty(t, flowName)
+	require.Empty(t, peerName)
+}
+
+func TestAuthorizeScopedWithoutTarget(t *testing.T) {
+	t.Parallel()
+
+	authz, err := newAuthorizer("groups",
+		`[{"claim_value": "team-a", "role": "operator", "tags": {"team": "a"}},
+		{"claim_value": "team-b", "role": "viewer", "tags": {"team": "b"}},
+		{"claim_value": "platform", "role": "viewer"}]`, shared.CatalogPool{})
+	require.NoError(t, err)
+
+	// listing is allowed, handlers filter what is listed by the principal's scopes
+	teams := tokenWithClaim(t, "groups", []any{"team-a", "team-b"})
+	for _, method := range []string{
+		protos.FlowService_ListMirrors_FullMethodName,
+		protos.FlowService_ListMirrorNames_FullMethodName,
+		protos.FlowService_ListPeers_FullMethodName,
+		protos.FlowService_ListActiveAlerts_FullMethodName,
+		protos.FlowService_GetVersion_FullMethodName,
+	} {
+		require.NoError(t, authz.authorize(t.Context(), method, nil, teams), method)
+	}
+	require.Equal(t, []map[string]string{{"team": "a"}, {"team": "b"}}, authz.principal(teams).Scopes)
+
+	// other requests that aren't about a mirror or peer need an unscoped grant
+	err = authz.authorize(t.Context(), protos.FlowService_GetDynamicSettings_FullMethodName,
+		&protos.GetDynamicSettingsRequest{}, teams)
+	require.Equal(t, codes.PermissionDenied, status.Code(err))
+	err = authz.authorize(t.Context(), protos.FlowService_ListAuditEvents_FullMethodName,
+		&protos.ListAuditEventsRequest{}, teams)
+	require.Equal(t, codes.PermissionDenied, status.Code(err))
+
+	withPlatform := tokenWithClaim(t, "groups", []any{"team-a", "platform"})
+	require.NoError(t, authz.authorize(t.Context(), protos.FlowService_GetDynamicSettings_FullMethodName,
+		&protos.GetDynamicSettingsRequest{}, withPlatform))
+	require.Nil(t, authz.principal(withPlatform).Scopes)
+}
+
+func TestAuthorizeRequestRecordsPrincipal(t *testing.T) {
+	t.Parallel()
+
+	authz, err := newAuthorizer("groups", `[{"claim_value": "analysts", "role": "viewer"}]`, shared.CatalogPool{})
+	require.NoError(t, err)
+	analyst := tokenWithClaim(t, "groups", []any{"analysts"})
+
+	// interceptors before authentication learn who was denied
+	ctx, recorded := ContextWithPrincipalRecorder(t.Context())
+	handlerCtx, err := authorizeRequest(ctx, authz, protos.FlowService_DropPeer_FullMethodName,
+		&protos.DropPeerRequest{PeerName: "p"}, analyst)
+	require.Equal(t, codes.PermissionDenied, status.Code(err))
+	_, ok := PrincipalFromContext(handlerCtx)
+	require.False(t, ok)
+	principal, ok := recorded()
+	require.True(t, ok)
+	require.Equal(t, Principal{Subject: "user@example.com", Roles: []Role{RoleViewer}}, principal)
+
+	handlerCtx, err = authorizeRequest(t.Context(), nil, protos.FlowService_DropPeer_FullMethodName,
+		&protos.DropPeerRequest{PeerName: "p"}, analyst)
+	require.NoError(t, err)
+	principal, ok = PrincipalFromContext(handlerCtx)
+	require.True(t, ok)
+	require.Equal(t, []Role{RoleAdmin}, principal.Roles, "without role-based access control every token is an admin")
+}
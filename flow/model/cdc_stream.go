@@ -3,6 +3,8 @@ package model
 import (
 	"context"
 	"log/slog"
+	"slices"
+	"sync"
 	"time"
 
 	"github.com/PeerDB-io/peerdb/flow/generated/protos"
@@ -17,6 +19,8 @@ type CDCStream[T Items] struct {
 	lastCheckpointText string
 	// Schema changes from slot
 	SchemaDeltas []*protos.TableSchemaDelta
+	// guards SchemaDeltas while the stream is still being filled
+	schemaDeltasLock sync.Mutex
 	// lastCheckpointID is the last ID of the commit that corresponds to this batch.
 	lastCheckpointID  int64
 	lastCheckpointSet bool
@@ -119,9 +123,19 @@ func (r *CDCStream[T]) AddSchemaDelta(
 	tableNameMapping map[string]NameAndExclude,
 	delta *protos.TableSchemaDelta,
 ) {
+	r.schemaDeltasLock.Lock()
+	defer r.schemaDeltasLock.Unlock()
 	r.SchemaDeltas = append(r.SchemaDeltas, delta)
 }
 
+// SchemaDeltasSince returns the schema deltas after the first n, it is safe to call while records are still coming in.
+// Deltas are added before the records that follow them, so every delta preceding a received record is included.
+func (r *CDCStream[T]) SchemaDeltasSince(n int) []*protos.TableSchemaDelta {
+	r.schemaDeltasLock.Lock()
+	defer r.schemaDeltasLock.Unlock()
+	return slices.Clone(r.SchemaDeltas[n:])
+}
+
 func (r *CDCStream[T]) NeedsNormalize() bool {
 	return r.needsNormalize
 }
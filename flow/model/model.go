@@ -152,6 +152,9 @@ type SyncRecordsRequest[T Items] struct {
 	StagingPath string
 	// Lua script
 	Script string
+	// for destinations applying records without a separate normalize step
+	SoftDeleteColName string
+	SyncedAtColName   string
 	// source:destination mappings
 	TableMappings []*protos.TableMapping
 	SyncBatchID   int64
@@ -199,10 +202,13 @@ type SetupReplicationResult struct {
 	Conn             interface{ Close(context.Context) error }
 	SlotName         string
 	SnapshotName     string
+	ConsistentPoint  string
 	SupportsTIDScans bool
 }
 
 type RemoveFlowDetailsFromCatalogRequest struct {
+	// env of the mirror, to find where its durable CDC buffer is kept
+	Env      map[string]string
 	FlowName string
 	Resync   bool
 }
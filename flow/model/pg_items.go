@@ -7,7 +7,7 @@ import (
 	"github.com/PeerDB-io/peerdb/flow/shared"
 )
 
-// encoding/gob cannot encode unexported fields
+// ColToVal is exported for the CDC store to encode records it spills to disk
 type PgItems struct {
 	ColToVal map[string][]byte
 }
@@ -23,7 +23,7 @@ func ItemsToJSON(items Items) (string, error) {
 	return string(bytes), err
 }
 
-// encoding/gob cannot encode unexported fields
+// ColToVal is exported for the CDC store to encode records it spills to disk
 type RecordItems struct {
 	ColToVal map[string]types.QValue
 }
@@ -134,6 +134,10 @@ var CDCDynamicPropertiesSignal = TypedSignal[*protos.CDCFlowConfigUpdate]{
 	Name: "cdc-dynamic-properties",
 }
 
+var QRepDynamicPropertiesSignal = TypedSignal[*protos.QRepFlowConfigUpdate]{
+	Name: "qrep-dynamic-properties",
+}
+
 var StartMaintenanceSignal = TypedSignal[*protos.StartMaintenanceSignal]{
 	Name: "start-maintenance-signal",
 }
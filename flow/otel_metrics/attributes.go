@@ -26,6 +26,8 @@ const (
 	TemporalActivityTypeKey    = "temporalActivityType"
 	TemporalWorkflowTypeKey    = "temporalWorkflowType"
 	IsFlowActiveKey            = "isFlowActive"
+	TableNameKey               = "tableName"
+	PartitionIdKey             = "partitionId"
 )
 
 const (
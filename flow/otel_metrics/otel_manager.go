@@ -46,6 +46,8 @@ const (
 	MaintenanceStatusGaugeName          = "maintenance_status"
 	FlowStatusGaugeName                 = "flow_status"
 	ActiveFlowsGaugeName                = "active_flows"
+	CDCBufferBytesGaugeName             = "cdc_buffer_bytes"
+	CDCBufferSegmentsGaugeName          = "cdc_buffer_segments"
 )
 
 type Metrics struct {
@@ -71,6 +73,8 @@ type Metrics struct {
 	ActiveFlowsGauge                metric.Int64Gauge
 	CPULimitsPerActiveFlowGauge     metric.Float64Gauge
 	MemoryLimitsPerActiveFlowGauge  metric.Float64Gauge
+	CDCBufferBytesGauge             metric.Int64Gauge
+	CDCBufferSegmentsGauge          metric.Int64Gauge
 }
 
 type SlotMetricGauges struct {
@@ -286,6 +290,19 @@ func (om *OtelManager) setupMetrics() error {
 		return err
 	}
 
+	if om.Metrics.CDCBufferBytesGauge, err = om.GetOrInitInt64Gauge(BuildMetricName(CDCBufferBytesGaugeName),
+		metric.WithUnit("By"),
+		metric.WithDescription("Size of changes in the durable CDC buffer waiting to be synced to the destination"),
+	); err != nil {
+		return err
+	}
+
+	if om.Metrics.CDCBufferSegmentsGauge, err = om.GetOrInitInt64Gauge(BuildMetricName(CDCBufferSegmentsGaugeName),
+		metric.WithDescription("Number of segments in the durable CDC buffer waiting to be synced to the destination"),
+	); err != nil {
+		return err
+	}
+
 	// Appending unit since UCUM does not support `vcores` as a unit
 	if om.Metrics.CPULimitsPerActiveFlowGauge, err = om.GetOrInitFloat64Gauge(BuildMetricName("cpu_limits_per_active_flow_vcores"),
 		metric.WithDescription(
@@ -423,13 +440,22 @@ func setupMetricsProvider(
 	if !enabled {
 		return noop.NewMeterProvider(), nil
 	}
-	metricExporter, err := setupExporter(ctx)
-	if err != nil {
-		return nil, err
+	var reader sdkmetric.Reader
+	if PrometheusExporterEnabled() {
+		var err error
+		if reader, err = newPrometheusReader(); err != nil {
+			return nil, err
+		}
+	} else {
+		metricExporter, err := setupExporter(ctx)
+		if err != nil {
+			return nil, err
+		}
+		reader = sdkmetric.NewPeriodicReader(metricExporter)
 	}
 
 	meterProvider := sdkmetric.NewMeterProvider(
-		sdkmetric.WithReader(sdkmetric.NewPeriodicReader(metricExporter)),
+		sdkmetric.WithReader(reader),
 		sdkmetric.WithResource(otelResource),
 		sdkmetric.WithView(views...),
 	)
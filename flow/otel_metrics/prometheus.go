@@ -0,0 +1,45 @@
+package otel_metrics
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"go.opentelemetry.io/otel/attribute"
+	otelprometheus "go.opentelemetry.io/otel/exporters/prometheus"
+	sdkmetric "go.opentelemetry.io/otel/sdk/metric"
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
+
+	"github.com/PeerDB-io/peerdb/flow/internal"
+)
+
+// every meter provider in the process registers its own exporter with this registry,
+// so that a single scrape endpoint serves PeerDB, Temporal and component metrics
+var prometheusRegistry = prometheus.NewRegistry()
+
+func PrometheusExporterEnabled() bool {
+	return internal.GetOtelMetricsExporter() == "prometheus"
+}
+
+func PrometheusHandler() http.Handler {
+	return promhttp.HandlerFor(prometheusRegistry, promhttp.HandlerOpts{
+		ErrorHandling: promhttp.ContinueOnError,
+	})
+}
+
+func newPrometheusReader() (sdkmetric.Reader, error) {
+	// target_info would be emitted once per meter provider with identical labels and fail the scrape,
+	// so resource attributes that identify the process become constant labels instead
+	exporter, err := otelprometheus.New(
+		otelprometheus.WithRegisterer(prometheusRegistry),
+		otelprometheus.WithoutTargetInfo(),
+		otelprometheus.WithResourceAsConstantLabels(func(kv attribute.KeyValue) bool {
+			return kv.Key == semconv.ServiceNameKey || kv.Key == DeploymentUidKey
+		}),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create Prometheus metrics exporter: %w", err)
+	}
+	return exporter, nil
+}
@@ -0,0 +1,98 @@
+package otel_metrics
+
+import (
+	"context"
+	"fmt"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracegrpc"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/propagation"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
+	"go.opentelemetry.io/otel/trace"
+	"go.opentelemetry.io/otel/trace/noop"
+
+	"github.com/PeerDB-io/peerdb/flow/internal"
+)
+
+const peerdbTracerName = "io.peerdb.flow"
+
+func setupTraceExporter(ctx context.Context) (sdktrace.SpanExporter, error) {
+	otlpTraceProtocol := internal.GetEnvString("OTEL_EXPORTER_OTLP_PROTOCOL",
+		internal.GetEnvString("OTEL_EXPORTER_OTLP_TRACES_PROTOCOL", "http/protobuf"))
+	var traceExporter sdktrace.SpanExporter
+	var err error
+	switch otlpTraceProtocol {
+	case "http/protobuf":
+		traceExporter, err = otlptracehttp.New(ctx)
+	case "grpc":
+		traceExporter, err = otlptracegrpc.New(ctx)
+	default:
+		return nil, fmt.Errorf("unsupported otel trace protocol: %s", otlpTraceProtocol)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to create OpenTelemetry trace exporter: %w", err)
+	}
+	return traceExporter, nil
+}
+
+// SetupTracerProvider creates the process wide tracer provider and installs it as the global one,
+// so that spans started through StartSpan and the Temporal tracing interceptor share the same exporter.
+// Sampling follows OTEL_TRACES_SAMPLER and OTEL_TRACES_SAMPLER_ARG.
+func SetupTracerProvider(ctx context.Context, otelServiceName string, enabled bool) (trace.TracerProvider, error) {
+	if !enabled {
+		return noop.NewTracerProvider(), nil
+	}
+	otelResource, err := newOtelResource(otelServiceName)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create OpenTelemetry resource: %w", err)
+	}
+	traceExporter, err := setupTraceExporter(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	tracerProvider := sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(traceExporter),
+		sdktrace.WithResource(otelResource),
+	)
+	otel.SetTracerProvider(tracerProvider)
+	otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator(propagation.TraceContext{}, propagation.Baggage{}))
+	return tracerProvider, nil
+}
+
+func CloseTracerProvider(ctx context.Context, tracerProvider trace.TracerProvider) error {
+	if provider, ok := tracerProvider.(*sdktrace.TracerProvider); ok {
+		return provider.Shutdown(ctx)
+	}
+	return nil
+}
+
+// StartSpan starts a span from the global tracer provider, which is a no-op when tracing is disabled
+func StartSpan(ctx context.Context, spanName string, attrs ...attribute.KeyValue) (context.Context, trace.Span) {
+	return otel.Tracer(peerdbTracerName).Start(ctx, spanName, trace.WithAttributes(attrs...))
+}
+
+// EndSpan marks the span as failed when err is not nil before ending it
+func EndSpan(span trace.Span, err error) {
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	span.End()
+}
+
+// StartStatementSpan starts a span for one of the SQL statements that normalize or merge a batch into a destination table
+func StartStatementSpan(
+	ctx context.Context, flowName string, tableName string, batchID int64, statement string,
+) (context.Context, trace.Span) {
+	return StartSpan(ctx, "NormalizeStatement",
+		attribute.String(FlowNameKey, flowName),
+		attribute.String(TableNameKey, tableName),
+		attribute.Int64(BatchIdKey, batchID),
+		semconv.DBQueryText(statement),
+	)
+}
@@ -68,6 +68,8 @@ func AttachToCdcStream(
 					handleErr(err)
 					break
 				}
+				// forward deltas ahead of the records following them, like the source stream has them
+				forwardSchemaDeltas(stream, outstream)
 				err := outstream.AddRecord(ctx, record)
 				if err != nil {
 					handleErr(err)
@@ -75,7 +77,7 @@ func AttachToCdcStream(
 				}
 			}
 		}
-		outstream.SchemaDeltas = stream.SchemaDeltas
+		forwardSchemaDeltas(stream, outstream)
 		lastCP := stream.GetLastCheckpoint()
 		outstream.UpdateLatestCheckpointID(lastCP.ID)
 		outstream.UpdateLatestCheckpointText(lastCP.Text)
@@ -83,3 +85,9 @@ func AttachToCdcStream(
 	}()
 	return outstream
 }
+
+func forwardSchemaDeltas(stream *model.CDCStream[model.RecordItems], outstream *model.CDCStream[model.RecordItems]) {
+	for _, delta := range stream.SchemaDeltasSince(len(outstream.SchemaDeltas)) {
+		outstream.AddSchemaDelta(nil, delta)
+	}
+}
@@ -0,0 +1,22 @@
+package shared
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/robfig/cron"
+)
+
+// NextCronTime returns the first time after after matching spec, a standard 5 field cron expression evaluated in UTC
+func NextCronTime(spec string, after time.Time) (time.Time, error) {
+	schedule, err := cron.ParseStandard(spec)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid cron expression %q: %w", spec, err)
+	}
+	next := schedule.Next(after.UTC())
+	if next.IsZero() {
+		return time.Time{}, errors.New("cron expression " + spec + " never fires")
+	}
+	return next, nil
+}
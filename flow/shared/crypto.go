@@ -1,11 +1,14 @@
 package shared
 
 import (
+	"crypto/hmac"
 	"crypto/rand"
 	"crypto/rsa"
+	"crypto/sha256"
 	"crypto/tls"
 	"crypto/x509"
 	"encoding/base64"
+	"encoding/hex"
 	"encoding/pem"
 	"errors"
 	"fmt"
@@ -36,6 +39,21 @@ func DecodePKCS8PrivateKey(rawKey []byte, password *string) (*rsa.PrivateKey, er
 	return privateKey, nil
 }
 
+const (
+	WebhookSignatureHeader = "X-Peerdb-Signature"
+	WebhookTimestampHeader = "X-Peerdb-Timestamp"
+)
+
+// SignWebhookPayload computes the signature webhook mirrors and webhook alerts send in WebhookSignatureHeader,
+// "sha256=" + hex(HMAC-SHA256(secret, timestamp + "." + body)), timestamp is included so receivers can reject replayed requests
+func SignWebhookPayload(secret string, timestamp string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(timestamp))
+	mac.Write([]byte{'.'})
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
 // PeerDBEncKey is a key for encrypting and decrypting data.
 type PeerDBEncKey struct {
 	ID    string `json:"id"`
@@ -0,0 +1,20 @@
+package exceptions
+
+// CDCBufferSyncError is the destination failing to sync changes replayed from the durable CDC buffer,
+// changes keep being buffered while there is room instead of failing the sync
+type CDCBufferSyncError struct {
+	error
+	BufferedBytes int64
+}
+
+func NewCDCBufferSyncError(err error, bufferedBytes int64) *CDCBufferSyncError {
+	return &CDCBufferSyncError{err, bufferedBytes}
+}
+
+func (e *CDCBufferSyncError) Error() string {
+	return "CDCBufferSync Error: " + e.error.Error()
+}
+
+func (e *CDCBufferSyncError) Unwrap() error {
+	return e.error
+}
@@ -3,6 +3,7 @@ package shared
 import (
 	"slices"
 	"testing"
+	"time"
 )
 
 func TestAdjustNumPartitions(t *testing.T) {
@@ -150,3 +151,39 @@ func TestParsePgArray(t *testing.T) {
 		}
 	}
 }
+
+func TestNextCronTime(t *testing.T) {
+	after := time.Date(2025, 3, 14, 22, 30, 0, 0, time.UTC)
+
+	next, err := NextCronTime("0 23 * * *", after)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if expected := time.Date(2025, 3, 14, 23, 0, 0, 0, time.UTC); !next.Equal(expected) {
+		t.Errorf("expected %v, got %v", expected, next)
+	}
+
+	// evaluated in UTC regardless of the location of after
+	next, err = NextCronTime("0 6 * * 1-5", after.In(time.FixedZone("UTC+9", 9*60*60)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if expected := time.Date(2025, 3, 17, 6, 0, 0, 0, time.UTC); !next.Equal(expected) {
+		t.Errorf("expected %v, got %v", expected, next)
+	}
+
+	if _, err := NextCronTime("0 25 * * *", after); err == nil {
+		t.Error("expected error for invalid hour")
+	}
+	if _, err := NextCronTime("0 0 30 2 *", after); err == nil {
+		t.Error("expected error for schedule that never fires")
+	}
+}
+
+func TestSignWebhookPayload(t *testing.T) {
+	// receivers verify with any HMAC implementation, so the format must not change
+	signature := SignWebhookPayload("secret", "1700000000", []byte(`{"a":1}`))
+	if signature != "sha256=49f24e537407743fa4a0242bb63b94b9a47ee99cbbe071ccd8a22550ae411686" {
+		t.Errorf("unexpected signature %s", signature)
+	}
+}
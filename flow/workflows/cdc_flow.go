@@ -30,11 +30,16 @@ type CDCFlowWorkflowState struct {
 	// for becoming DropFlow
 	DropFlowInput *protos.DropFlowInput
 	// used for computing backoff timeout
-	LastError  time.Time
-	ErrorCount int32
+	LastError time.Time
+	// kept across runs so a pause window starting in between is not missed
+	NextScheduledPause  time.Time
+	NextScheduledResume time.Time
+	ErrorCount          int32
 	// Current signalled state of the peer flow.
 	ActiveSignal      model.CDCFlowSignal
 	CurrentFlowStatus protos.FlowStatus
+	// only pauses from the schedule are resumed by it
+	ScheduledPause bool
 }
 
 // returns a new empty PeerFlowState
@@ -157,6 +162,24 @@ func processCDCFlowConfigUpdate(
 		maps.Copy(cfg.Env, flowConfigUpdate.UpdatedEnv)
 	}
 
+	if len(flowConfigUpdate.ResnapshotTables) > 0 && !cfg.IncrementalSnapshot {
+		logger.Warn("resnapshotting tables needs incremental snapshots, ignoring", slog.Any("tables", flowConfigUpdate.ResnapshotTables))
+	} else if len(flowConfigUpdate.ResnapshotTables) > 0 {
+		sourceTables := make([]string, 0, len(flowConfigUpdate.ResnapshotTables))
+		for _, sourceTable := range flowConfigUpdate.ResnapshotTables {
+			if slices.ContainsFunc(state.SyncFlowOptions.TableMappings, func(tableMapping *protos.TableMapping) bool {
+				return tableMapping.SourceTableIdentifier == sourceTable
+			}) {
+				sourceTables = append(sourceTables, sourceTable)
+			} else {
+				logger.Warn("table to resnapshot is not part of the mirror, ignoring", slog.String("table", sourceTable))
+			}
+		}
+		if err := addIncrementalSnapshots(ctx, cfg.FlowJobName, sourceTables); err != nil {
+			return err
+		}
+	}
+
 	tablesAreAdded := len(flowConfigUpdate.AdditionalTables) > 0
 	tablesAreRemoved := len(flowConfigUpdate.RemovedTables) > 0
 	if !tablesAreAdded && !tablesAreRemoved {
@@ -270,19 +293,40 @@ func processTableAdditions(
 		WaitForCancellation:   true,
 	}
 	childAddTablesCDCFlowCtx := workflow.WithChildOptions(ctx, childAddTablesCDCFlowOpts)
-	childAddTablesCDCFlowFuture := workflow.ExecuteChildWorkflow(
-		childAddTablesCDCFlowCtx,
-		CDCFlowWorkflow,
-		additionalTablesCfg,
-		nil,
-	)
-	var res *CDCFlowWorkflowResult
+	var srcTableIdNameMapping map[uint32]string
+	var addTablesDone bool
 	var addTablesFlowErr error
-	addTablesSelector.AddFuture(childAddTablesCDCFlowFuture, func(f workflow.Future) {
-		addTablesFlowErr = f.Get(childAddTablesCDCFlowCtx, &res)
-	})
+	if cfg.IncrementalSnapshot {
+		// tables are only set up here, sync flow loads them in chunks alongside CDC
+		childAddTablesSetupFlowFuture := workflow.ExecuteChildWorkflow(
+			childAddTablesCDCFlowCtx,
+			SetupFlowWorkflow,
+			additionalTablesCfg,
+		)
+		addTablesSelector.AddFuture(childAddTablesSetupFlowFuture, func(f workflow.Future) {
+			var res *protos.SetupFlowOutput
+			addTablesDone = true
+			if addTablesFlowErr = f.Get(childAddTablesCDCFlowCtx, &res); addTablesFlowErr == nil {
+				srcTableIdNameMapping = res.SrcTableIdNameMapping
+			}
+		})
+	} else {
+		childAddTablesCDCFlowFuture := workflow.ExecuteChildWorkflow(
+			childAddTablesCDCFlowCtx,
+			CDCFlowWorkflow,
+			additionalTablesCfg,
+			nil,
+		)
+		addTablesSelector.AddFuture(childAddTablesCDCFlowFuture, func(f workflow.Future) {
+			var res *CDCFlowWorkflowResult
+			addTablesDone = true
+			if addTablesFlowErr = f.Get(childAddTablesCDCFlowCtx, &res); addTablesFlowErr == nil {
+				srcTableIdNameMapping = res.SyncFlowOptions.SrcTableIdNameMapping
+			}
+		})
+	}
 
-	for res == nil {
+	for !addTablesDone {
 		addTablesSelector.Select(ctx)
 		if state.ActiveSignal == model.TerminateSignal || state.ActiveSignal == model.ResyncSignal {
 			if state.ActiveSignal == model.ResyncSignal {
@@ -304,13 +348,34 @@ func processTableAdditions(
 		}
 	}
 
-	maps.Copy(state.SyncFlowOptions.SrcTableIdNameMapping, res.SyncFlowOptions.SrcTableIdNameMapping)
+	if cfg.IncrementalSnapshot {
+		sourceTables := make([]string, 0, len(flowConfigUpdate.AdditionalTables))
+		for _, tableMapping := range flowConfigUpdate.AdditionalTables {
+			sourceTables = append(sourceTables, tableMapping.SourceTableIdentifier)
+		}
+		if err := addIncrementalSnapshots(ctx, cfg.FlowJobName, sourceTables); err != nil {
+			return err
+		}
+	}
+
+	maps.Copy(state.SyncFlowOptions.SrcTableIdNameMapping, srcTableIdNameMapping)
 
 	state.SyncFlowOptions.TableMappings = append(state.SyncFlowOptions.TableMappings, flowConfigUpdate.AdditionalTables...)
 	logger.Info("additional tables added to sync flow")
 	return nil
 }
 
+// addIncrementalSnapshots has sync flow load sourceTables in chunks alongside CDC
+func addIncrementalSnapshots(ctx workflow.Context, flowName string, sourceTables []string) error {
+	addCtx := workflow.WithLocalActivityOptions(ctx, workflow.LocalActivityOptions{
+		StartToCloseTimeout: time.Minute,
+	})
+	if err := workflow.ExecuteLocalActivity(addCtx, addIncrementalSnapshotsActivity, flowName, sourceTables).Get(addCtx, nil); err != nil {
+		return fmt.Errorf("failed to add incremental snapshots: %w", err)
+	}
+	return nil
+}
+
 func processTableRemovals(
 	ctx workflow.Context,
 	logger log.Logger,
@@ -373,6 +438,56 @@ func processTableRemovals(
 	return nil
 }
 
+// untilScheduledPause returns how long until the next pause window of the mirror starts
+func untilScheduledPause(
+	ctx workflow.Context,
+	logger log.Logger,
+	cfg *protos.FlowConnectionConfigs,
+	state *CDCFlowWorkflowState,
+) (time.Duration, bool) {
+	if cfg.Schedule == nil || cfg.Schedule.PauseCron == "" || cfg.Schedule.ResumeCron == "" {
+		return 0, false
+	}
+	now := workflow.Now(ctx)
+	if state.NextScheduledPause.IsZero() {
+		next, err := shared.NextCronTime(cfg.Schedule.PauseCron, now)
+		if err != nil {
+			logger.Error("failed to schedule pause", slog.Any("error", err))
+			return 0, false
+		}
+		state.NextScheduledPause = next
+	}
+	return state.NextScheduledPause.Sub(now), true
+}
+
+// applyScheduledPause pauses the mirror for the window that started at NextScheduledPause,
+// unless the window already ended, and moves on to the next window
+func applyScheduledPause(
+	ctx workflow.Context,
+	logger log.Logger,
+	cfg *protos.FlowConnectionConfigs,
+	state *CDCFlowWorkflowState,
+) {
+	now := workflow.Now(ctx)
+	pausedAt := state.NextScheduledPause
+	state.NextScheduledPause = time.Time{}
+	resume, err := shared.NextCronTime(cfg.Schedule.ResumeCron, pausedAt)
+	if err != nil {
+		logger.Error("failed to schedule resume", slog.Any("error", err))
+		return
+	}
+	if !resume.After(now) {
+		logger.Warn("scheduled pause window already ended", slog.Time("resumeAt", resume))
+		return
+	}
+	if state.ActiveSignal == model.NoopSignal {
+		logger.Info("pausing mirror for scheduled pause window", slog.Time("resumeAt", resume))
+		state.ActiveSignal = model.PauseSignal
+		state.ScheduledPause = true
+		state.NextScheduledResume = resume
+	}
+}
+
 func addCdcPropertiesSignalListener(
 	ctx workflow.Context,
 	logger log.Logger,
@@ -458,6 +573,15 @@ func CDCFlowWorkflow(
 			}
 		})
 		addCdcPropertiesSignalListener(ctx, logger, selector, state)
+		if state.ScheduledPause {
+			selector.AddFuture(model.SleepFuture(ctx, max(state.NextScheduledResume.Sub(workflow.Now(ctx)), 0)),
+				func(_ workflow.Future) {
+					if state.ActiveSignal == model.PauseSignal {
+						logger.Info("scheduled pause window ended, resuming mirror")
+						state.ActiveSignal = model.NoopSignal
+					}
+				})
+		}
 		startTime := workflow.Now(ctx)
 		state.updateStatus(ctx, logger, protos.FlowStatus_STATUS_PAUSED)
 
@@ -485,6 +609,8 @@ func CDCFlowWorkflow(
 			}
 		}
 
+		state.ScheduledPause = false
+		state.NextScheduledResume = time.Time{}
 		logger.Info(fmt.Sprintf("mirror has been resumed after %s", time.Since(startTime).Round(time.Second)))
 		state.updateStatus(ctx, logger, protos.FlowStatus_STATUS_RUNNING)
 		return state, workflow.NewContinueAsNewError(ctx, CDCFlowWorkflow, cfg, state)
@@ -701,6 +827,12 @@ func CDCFlowWorkflow(
 		return state, workflow.NewContinueAsNewError(ctx, CDCFlowWorkflow, cfg, state)
 	}
 
+	pauseIn, pauseScheduled := untilScheduledPause(ctx, logger, cfg, state)
+	if pauseScheduled && pauseIn <= 0 {
+		applyScheduledPause(ctx, logger, cfg, state)
+		return state, workflow.NewContinueAsNewError(ctx, CDCFlowWorkflow, cfg, state)
+	}
+
 	var finished bool
 	var finishedError bool
 	syncCtx, cancelSync := workflow.WithCancel(workflow.WithActivityOptions(ctx, workflow.ActivityOptions{
@@ -797,6 +929,15 @@ func CDCFlowWorkflow(
 	})
 
 	addCdcPropertiesSignalListener(ctx, logger, mainLoopSelector, state)
+	if pauseScheduled {
+		// pauses at a batch boundary like a pause signal
+		mainLoopSelector.AddFuture(model.SleepFuture(ctx, pauseIn), func(_ workflow.Future) {
+			applyScheduledPause(ctx, logger, cfg, state)
+			if state.ActiveSignal == model.PauseSignal {
+				finished = true
+			}
+		})
+	}
 
 	state.updateStatus(ctx, logger, protos.FlowStatus_STATUS_RUNNING)
 	for {
@@ -195,6 +195,7 @@ func DropFlowWorkflow(ctx workflow.Context, input *protos.DropFlowInput) error {
 	})
 
 	req := model.RemoveFlowDetailsFromCatalogRequest{
+		Env:      input.FlowConnectionConfigs.GetEnv(),
 		FlowName: input.FlowJobName,
 		Resync:   input.Resync,
 	}
@@ -66,3 +66,27 @@ func updateFlowStatusInCatalogActivity(
 	}
 	return internal.UpdateFlowStatusInCatalog(ctx, pool, workflowID, status)
 }
+
+func saveSnapshotResumeStateActivity(ctx context.Context, flowName string, output *protos.SetupReplicationOutput) error {
+	pool, err := internal.GetCatalogConnectionPoolFromEnv(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to get catalog connection pool: %w", err)
+	}
+	return internal.SaveSnapshotResumeState(ctx, pool, flowName, output)
+}
+
+func deleteSnapshotResumeStateActivity(ctx context.Context, flowName string) error {
+	pool, err := internal.GetCatalogConnectionPoolFromEnv(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to get catalog connection pool: %w", err)
+	}
+	return internal.DeleteSnapshotResumeState(ctx, pool, flowName)
+}
+
+func addIncrementalSnapshotsActivity(ctx context.Context, flowName string, sourceTables []string) error {
+	pool, err := internal.GetCatalogConnectionPoolFromEnv(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to get catalog connection pool: %w", err)
+	}
+	return internal.AddIncrementalSnapshots(ctx, pool, flowName, sourceTables)
+}
@@ -10,8 +10,10 @@ import (
 	"go.temporal.io/sdk/log"
 	"go.temporal.io/sdk/temporal"
 	"go.temporal.io/sdk/workflow"
+	"google.golang.org/protobuf/types/known/timestamppb"
 
 	"github.com/PeerDB-io/peerdb/flow/generated/protos"
+	"github.com/PeerDB-io/peerdb/flow/internal"
 	"github.com/PeerDB-io/peerdb/flow/model"
 	"github.com/PeerDB-io/peerdb/flow/shared"
 )
@@ -350,6 +352,49 @@ func (q *QRepFlowExecution) waitForNewRows(
 	return waitErr
 }
 
+// receiveConfigUpdates applies config updates signalled since the last partition round
+func (q *QRepFlowExecution) receiveConfigUpdates(updateChan model.TypedReceiveChannel[*protos.QRepFlowConfigUpdate]) {
+	for {
+		update, ok := updateChan.ReceiveAsync()
+		if !ok {
+			return
+		}
+		q.logger.Info("processing QRepFlowConfigUpdate", slog.Any("update", update))
+		internal.ApplyQRepFlowConfigUpdate(q.config, update)
+	}
+}
+
+// waitForScheduledRun replaces waitForNewRows for mirrors with a run_cron, the first run starts right away
+func (q *QRepFlowExecution) waitForScheduledRun(
+	ctx workflow.Context,
+	signalChan model.TypedReceiveChannel[model.CDCFlowSignal],
+	state *protos.QRepFlowState,
+) error {
+	if state.NextScheduledRun == nil {
+		return nil
+	}
+	waitFor := state.NextScheduledRun.AsTime().Sub(workflow.Now(ctx))
+	if waitFor <= 0 {
+		return nil
+	}
+	q.logger.Info("waiting for scheduled run", slog.Time("runAt", state.NextScheduledRun.AsTime()))
+
+	var ready bool
+	waitSelector := workflow.NewNamedSelector(ctx, "WaitForScheduledRun")
+	signalChan.AddToSelector(waitSelector, func(val model.CDCFlowSignal, _ bool) {
+		q.activeSignal = model.FlowSignalHandler(q.activeSignal, val, q.logger)
+	})
+	waitSelector.AddFuture(model.SleepFuture(ctx, waitFor), func(_ workflow.Future) {
+		ready = true
+	})
+	waitSelector.AddReceive(ctx.Done(), func(_ workflow.ReceiveChannel, _ bool) {})
+
+	for ctx.Err() == nil && !ready && q.activeSignal != model.PauseSignal {
+		waitSelector.Select(ctx)
+	}
+	return ctx.Err()
+}
+
 func (q *QRepFlowExecution) handleTableCreationForResync(ctx workflow.Context, state *protos.QRepFlowState) error {
 	if state.NeedsResync && q.config.DstTableFullResync {
 		renamedTableIdentifier := q.config.DestinationTableIdentifier + "_peerdb_resync"
@@ -521,6 +566,7 @@ func QRepFlowWorkflow(
 	}
 
 	signalChan := model.FlowSignal.GetSignalChannel(ctx)
+	updateChan := model.QRepDynamicPropertiesSignal.GetSignalChannel(ctx)
 	q := newQRepFlowExecution(ctx, config, originalRunID)
 
 	if state.CurrentFlowStatus == protos.FlowStatus_STATUS_PAUSING ||
@@ -542,11 +588,6 @@ func QRepFlowWorkflow(
 		updateStatus(ctx, q.logger, state, protos.FlowStatus_STATUS_RUNNING)
 	}
 
-	maxParallelWorkers := 16
-	if config.MaxParallelWorkers > 0 {
-		maxParallelWorkers = int(config.MaxParallelWorkers)
-	}
-
 	if err := q.setupWatermarkTableOnDestination(ctx); err != nil {
 		return state, fmt.Errorf("failed to setup watermark table: %w", err)
 	}
@@ -569,12 +610,22 @@ func QRepFlowWorkflow(
 	}
 
 	if !config.InitialCopyOnly && lastPartition != nil {
-		if err := q.waitForNewRows(ctx, signalChan, lastPartition); err != nil {
+		if config.GetSchedule().GetRunCron() != "" {
+			if err := q.waitForScheduledRun(ctx, signalChan, state); err != nil {
+				return state, err
+			}
+		} else if err := q.waitForNewRows(ctx, signalChan, lastPartition); err != nil {
 			return state, err
 		}
 	}
 
 	if q.activeSignal != model.PauseSignal {
+		q.receiveConfigUpdates(updateChan)
+		maxParallelWorkers := 16
+		if config.MaxParallelWorkers > 0 {
+			maxParallelWorkers = int(config.MaxParallelWorkers)
+		}
+
 		q.logger.Info("fetching partitions to replicate for peer flow")
 		partitions, err := q.getPartitions(ctx, state.LastPartition)
 		if err != nil {
@@ -607,6 +658,14 @@ func QRepFlowWorkflow(
 		if len(partitions.Partitions) > 0 && !fullRefresh {
 			state.LastPartition = partitions.Partitions[len(partitions.Partitions)-1]
 		}
+
+		if runCron := config.GetSchedule().GetRunCron(); runCron != "" {
+			nextRun, err := shared.NextCronTime(runCron, workflow.Now(ctx))
+			if err != nil {
+				return state, fmt.Errorf("failed to schedule next run: %w", err)
+			}
+			state.NextScheduledRun = timestamppb.New(nextRun)
+		}
 	}
 
 	// flush signal, after this workflow must not yield
@@ -617,6 +676,8 @@ func QRepFlowWorkflow(
 		}
 		q.activeSignal = model.FlowSignalHandler(q.activeSignal, val, q.logger)
 	}
+	// carried over to the next run through config
+	q.receiveConfigUpdates(updateChan)
 
 	q.logger.Info("Continuing as new workflow",
 		slog.Any("Last Partition", state.LastPartition),
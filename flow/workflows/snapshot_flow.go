@@ -1,6 +1,7 @@
 package peerflow
 
 import (
+	"errors"
 	"fmt"
 	"log/slog"
 	"slices"
@@ -26,9 +27,15 @@ const (
 	SNAPSHOT_TYPE_TX
 )
 
+const snapshotSessionTimeout = time.Hour * 24 * 365 * 100 // 100 years
+
 type SnapshotFlowExecution struct {
 	config *protos.FlowConnectionConfigs
 	logger log.Logger
+	// set for resumable initial loads, keeps clone flow names, and with them synced partitions, stable across attempts
+	pinnedLSN string
+	// partitions read the snapshot peer without a shared snapshot, so rows are upserted for CDC to reconcile
+	fencedUpsert bool
 }
 
 func (s *SnapshotFlowExecution) setupReplication(
@@ -58,6 +65,20 @@ func (s *SnapshotFlowExecution) setupReplication(
 		ExistingPublicationName:     s.config.PublicationName,
 		ExistingReplicationSlotName: s.config.ReplicationSlotName,
 		Env:                         s.config.Env,
+		// a resumable load reads past the consistent point instead, as exported snapshots do not outlive a worker
+		SkipSnapshotExport: s.config.ResumableSnapshot,
+	}
+
+	if s.config.ResumableSnapshot {
+		var resumed *protos.SetupReplicationOutput
+		if err := workflow.ExecuteActivity(ctx, snapshot.ResumeReplication, setupReplicationInput).Get(ctx, &resumed); err != nil {
+			return nil, fmt.Errorf("failed to resume replication on source peer: %w", err)
+		}
+		if resumed != nil {
+			s.logger.Info("resuming initial load from replication slot",
+				slog.String("slot", resumed.SlotName), slog.String("consistentPoint", resumed.ConsistentPoint))
+			return resumed, nil
+		}
 	}
 
 	res := &protos.SetupReplicationOutput{}
@@ -67,6 +88,15 @@ func (s *SnapshotFlowExecution) setupReplication(
 
 	s.logger.Info("replication slot live on source for peer flow")
 
+	if s.config.ResumableSnapshot && res != nil {
+		saveCtx := workflow.WithLocalActivityOptions(ctx, workflow.LocalActivityOptions{
+			StartToCloseTimeout: time.Minute,
+		})
+		if err := workflow.ExecuteLocalActivity(saveCtx, saveSnapshotResumeStateActivity, flowName, res).Get(saveCtx, nil); err != nil {
+			return nil, fmt.Errorf("failed to save snapshot resume state: %w", err)
+		}
+	}
+
 	return res, nil
 }
 
@@ -91,6 +121,107 @@ func (s *SnapshotFlowExecution) closeSlotKeepAlive(
 	return nil
 }
 
+// exportTxSnapshot exports a snapshot on peerName, kept alive by a transaction for the rest of the session
+func (s *SnapshotFlowExecution) exportTxSnapshot(
+	ctx workflow.Context,
+	sessionCtx workflow.Context,
+	peerName string,
+) (*activities.TxSnapshotState, error) {
+	sessionInfo := workflow.GetSessionInfo(sessionCtx)
+
+	exportCtx := workflow.WithActivityOptions(sessionCtx, workflow.ActivityOptions{
+		StartToCloseTimeout: snapshotSessionTimeout,
+		HeartbeatTimeout:    10 * time.Minute,
+		WaitForCancellation: true,
+		RetryPolicy: &temporal.RetryPolicy{
+			InitialInterval: 1 * time.Minute,
+		},
+	})
+
+	fMaintain := workflow.ExecuteActivity(
+		exportCtx,
+		snapshot.MaintainTx,
+		sessionInfo.SessionID,
+		peerName,
+		s.config.Env,
+	)
+
+	fExportSnapshot := workflow.ExecuteActivity(
+		exportCtx,
+		snapshot.WaitForExportSnapshot,
+		sessionInfo.SessionID,
+	)
+
+	var sessionError error
+	var txnSnapshotState *activities.TxSnapshotState
+	sessionSelector := workflow.NewNamedSelector(ctx, "ExportSnapshotSetup")
+	sessionSelector.AddFuture(fMaintain, func(f workflow.Future) {
+		// MaintainTx should never exit without an error before this point
+		sessionError = f.Get(exportCtx, nil)
+	})
+	sessionSelector.AddFuture(fExportSnapshot, func(f workflow.Future) {
+		// Happy path is waiting for this to return without error
+		sessionError = f.Get(exportCtx, &txnSnapshotState)
+	})
+	sessionSelector.AddReceive(ctx.Done(), func(_ workflow.ReceiveChannel, _ bool) {
+		sessionError = ctx.Err()
+	})
+	sessionSelector.Select(ctx)
+	if sessionError != nil {
+		return nil, sessionError
+	}
+	return txnSnapshotState, nil
+}
+
+// snapshotFromReplica waits for the snapshot peer to replay past the slot's consistent point and exports a snapshot there,
+// replicas before PG16 are read without a snapshot instead. Either way partitions see the source at or after
+// the consistent point, as the standby keeps replaying while it's waited for, so rows are always upserted
+// and CDC from the slot reconciles changes made in between
+func (s *SnapshotFlowExecution) snapshotFromReplica(
+	ctx workflow.Context,
+	sessionCtx workflow.Context,
+	consistentPoint string,
+) (*activities.TxSnapshotState, error) {
+	if consistentPoint == "" {
+		return nil, errors.New("replication slot has no consistent point to wait for")
+	}
+
+	waitCtx := workflow.WithActivityOptions(ctx, workflow.ActivityOptions{
+		StartToCloseTimeout: 24 * time.Hour,
+		HeartbeatTimeout:    5 * time.Minute,
+		RetryPolicy: &temporal.RetryPolicy{
+			InitialInterval: 1 * time.Minute,
+		},
+	})
+	var replicaState *activities.SnapshotReplicaState
+	if err := workflow.ExecuteActivity(
+		waitCtx,
+		snapshot.WaitForSnapshotReplica,
+		s.config.FlowJobName,
+		s.config.SnapshotPeerName,
+		consistentPoint,
+	).Get(waitCtx, &replicaState); err != nil {
+		return nil, err
+	}
+
+	if !replicaState.SupportsSnapshotExport || s.config.ResumableSnapshot {
+		s.logger.Info("initial load from snapshot peer without an exported snapshot, upserting partitions read past the consistent point",
+			slog.String("consistentPoint", consistentPoint))
+		s.fencedUpsert = true
+		return &activities.TxSnapshotState{SupportsTIDScans: replicaState.SupportsTIDScans}, nil
+	}
+
+	txnSnapshotState, err := s.exportTxSnapshot(ctx, sessionCtx, s.config.SnapshotPeerName)
+	if err != nil {
+		return nil, err
+	}
+	// upserting loads table schemas, so workflows started before always upserting keep only doing so
+	// when PEERDB_SKIP_SNAPSHOT_EXPORT skipped the export
+	alwaysUpsert := workflow.GetVersion(ctx, "snapshot-peer-always-upsert", workflow.DefaultVersion, 1) > workflow.DefaultVersion
+	s.fencedUpsert = alwaysUpsert || txnSnapshotState.SnapshotName == ""
+	return txnSnapshotState, nil
+}
+
 func (s *SnapshotFlowExecution) cloneTable(
 	ctx workflow.Context,
 	boundSelector *shared.BoundSelector,
@@ -104,9 +235,12 @@ func (s *SnapshotFlowExecution) cloneTable(
 
 	srcName := mapping.SourceTableIdentifier
 	dstName := mapping.DestinationTableIdentifier
-	originalRunID := workflow.GetInfo(ctx).OriginalRunID
+	runID := workflow.GetInfo(ctx).OriginalRunID
+	if s.pinnedLSN != "" {
+		runID = s.pinnedLSN
+	}
 
-	childWorkflowID := fmt.Sprintf("clone_%s_%s_%s", flowName, srcName, originalRunID)
+	childWorkflowID := fmt.Sprintf("clone_%s_%s_%s", flowName, srcName, runID)
 	childWorkflowID = shared.ReplaceIllegalCharactersWithUnderscores(childWorkflowID)
 
 	s.logger.Info(fmt.Sprintf("Obtained child id %s for source table %s and destination table %s",
@@ -162,15 +296,51 @@ func (s *SnapshotFlowExecution) cloneTable(
 	// usually MySQL supports double quotes with ANSI_QUOTES, but Vitess doesn't
 	// Vitess currently only supports initial load so change here is enough
 	srcTableEscaped := parsedSrcTable.String()
-	if dbtype, err := getPeerType(ctx, s.config.SourceName); err != nil {
+	sourceType, err := getPeerType(ctx, s.config.SourceName)
+	if err != nil {
 		return err
-	} else if dbtype == protos.DBType_MYSQL {
+	} else if sourceType == protos.DBType_MYSQL {
 		srcTableEscaped = parsedSrcTable.MySQL()
 	}
 
+	partitionKey := mapping.PartitionKey
+	consistentSnapshot := s.config.ConsistentSnapshot && sourceType == protos.DBType_MYSQL
+	if consistentSnapshot && partitionKey != "" {
+		// MySQL cannot share a snapshot across connections, so partitions would be read at different binlog positions
+		s.logger.Info("consistent snapshot loads the table as a single partition", cloneLog)
+		partitionKey = ""
+	}
+	var keysetColumns []string
+	if partitionKey != "" && partitionKey != "ctid" &&
+		(sourceType == protos.DBType_POSTGRES || sourceType == protos.DBType_MYSQL) &&
+		// loading the schema schedules an activity that histories started before keyset partitioning don't have
+		workflow.GetVersion(ctx, "snapshot-keyset-partitioning", workflow.DefaultVersion, 1) > workflow.DefaultVersion {
+		if err := initTableSchema(); err != nil {
+			return err
+		}
+		if keysetColumns, err = keysetPartitionColumns(tableSchema, partitionKey); err != nil {
+			s.logger.Warn("cannot partition table by its partition key, loading it as a single partition",
+				slog.Any("error", err), cloneLog)
+			partitionKey = ""
+		}
+	}
+
 	var query string
-	if mapping.PartitionKey == "" {
+	if partitionKey == "" {
 		query = fmt.Sprintf("SELECT %s FROM %s", from, srcTableEscaped)
+	} else if len(keysetColumns) > 0 {
+		quotedColumns := make([]string, 0, len(keysetColumns))
+		for _, col := range keysetColumns {
+			if sourceType == protos.DBType_MYSQL {
+				quotedColumns = append(quotedColumns, fmt.Sprintf("`%s`", col))
+			} else {
+				quotedColumns = append(quotedColumns, utils.QuoteIdentifier(col))
+			}
+		}
+		// first and last keyset ranges are open ended
+		query = fmt.Sprintf("SELECT %s FROM %s WHERE TRUE{{if .start}} AND (%[3]s) > {{.start}}{{end}}"+
+			"{{if .end}} AND (%[3]s) <= {{.end}}{{end}}",
+			from, srcTableEscaped, strings.Join(quotedColumns, ","))
 	} else {
 		query = fmt.Sprintf("SELECT %s FROM %s WHERE %s BETWEEN {{.start}} AND {{.end}}",
 			from, srcTableEscaped, mapping.PartitionKey)
@@ -190,11 +360,11 @@ func (s *SnapshotFlowExecution) cloneTable(
 		WriteType: protos.QRepWriteType_QREP_WRITE_MODE_APPEND,
 	}
 
-	// ensure document IDs are synchronized across initial load and CDC
+	// ensure document IDs and keys are synchronized across initial load and CDC
 	// for the same document
 	if dbtype, err := getPeerType(ctx, s.config.DestinationName); err != nil {
 		return err
-	} else if dbtype == protos.DBType_ELASTICSEARCH {
+	} else if dbtype == protos.DBType_ELASTICSEARCH || dbtype == protos.DBType_OPENSEARCH || dbtype == protos.DBType_REDIS {
 		if err := initTableSchema(); err != nil {
 			return err
 		}
@@ -202,14 +372,33 @@ func (s *SnapshotFlowExecution) cloneTable(
 			WriteType:        protos.QRepWriteType_QREP_WRITE_MODE_UPSERT,
 			UpsertKeyColumns: tableSchema.PrimaryKeyColumns,
 		}
+	} else if s.fencedUpsert {
+		// partitions may read a row moved by an update twice
+		if err := initTableSchema(); err != nil {
+			return err
+		}
+		if len(tableSchema.PrimaryKeyColumns) == 0 {
+			s.logger.Warn("table has no primary key, initial load from snapshot peer may duplicate rows", cloneLog)
+		} else {
+			snapshotWriteMode = &protos.QRepWriteMode{
+				WriteType:        protos.QRepWriteType_QREP_WRITE_MODE_UPSERT,
+				UpsertKeyColumns: tableSchema.PrimaryKeyColumns,
+			}
+		}
+	}
+
+	sourceName := s.config.SourceName
+	if s.config.SnapshotPeerName != "" {
+		sourceName = s.config.SnapshotPeerName
 	}
 
 	config := &protos.QRepConfig{
 		FlowJobName:                childWorkflowID,
-		SourceName:                 s.config.SourceName,
+		SourceName:                 sourceName,
 		DestinationName:            s.config.DestinationName,
 		Query:                      query,
-		WatermarkColumn:            mapping.PartitionKey,
+		WatermarkColumn:            partitionKey,
+		KeysetColumns:              keysetColumns,
 		WatermarkTable:             srcName,
 		InitialCopyOnly:            true,
 		SnapshotName:               snapshotName,
@@ -227,12 +416,49 @@ func (s *SnapshotFlowExecution) cloneTable(
 		Exclude:                    mapping.Exclude,
 		Columns:                    mapping.Columns,
 		Version:                    s.config.Version,
+		Resumable:                  s.pinnedLSN != "",
+		ConsistentSnapshot:         consistentSnapshot,
 	}
 
 	boundSelector.SpawnChild(childCtx, QRepFlowWorkflow, nil, config, nil)
 	return nil
 }
 
+// keysetPartitionColumns returns the columns of a partition key to split the table into keyset ranges over,
+// or nil when the key is a single column that can be split into value ranges.
+// partitionKey may list several comma separated columns, which must not be nullable as rows with null keys fall outside any range
+func keysetPartitionColumns(tableSchema *protos.TableSchema, partitionKey string) ([]string, error) {
+	columns := strings.Split(partitionKey, ",")
+	for i, col := range columns {
+		columns[i] = strings.TrimSpace(col)
+	}
+
+	fields := make([]*protos.FieldDescription, 0, len(columns))
+	for _, col := range columns {
+		idx := slices.IndexFunc(tableSchema.Columns, func(field *protos.FieldDescription) bool {
+			return field.Name == col
+		})
+		if idx == -1 {
+			if len(columns) == 1 {
+				// such as ctid
+				return nil, nil
+			}
+			return nil, fmt.Errorf("partition key column %s not found", col)
+		}
+		fields = append(fields, tableSchema.Columns[idx])
+	}
+
+	if len(fields) == 1 && utils.SupportsRangePartitioning(tableSchema.System, fields[0].Type) {
+		return nil, nil
+	}
+	for _, field := range fields {
+		if field.Nullable {
+			return nil, fmt.Errorf("partition key column %s is nullable", field.Name)
+		}
+	}
+	return columns, nil
+}
+
 func (s *SnapshotFlowExecution) cloneTables(
 	ctx workflow.Context,
 	snapshotType snapshotType,
@@ -297,18 +523,50 @@ func (s *SnapshotFlowExecution) cloneTablesWithSlot(
 		}
 	}()
 
+	snapshotName := slotInfo.SnapshotName
+	supportsTidScans := slotInfo.SupportsTidScans
+	if s.config.ResumableSnapshot {
+		if slotInfo.ConsistentPoint == "" {
+			return errors.New("replication slot has no consistent point to resume from")
+		}
+		// every attempt reads the source at some point past the consistent point,
+		// upserting lets partitions loaded by earlier attempts be reconciled by CDC from the slot
+		s.pinnedLSN = slotInfo.ConsistentPoint
+		s.fencedUpsert = true
+		snapshotName = ""
+	}
+	if s.config.SnapshotPeerName != "" {
+		txnSnapshotState, err := s.snapshotFromReplica(ctx, sessionCtx, slotInfo.ConsistentPoint)
+		if err != nil {
+			return fmt.Errorf("failed to prepare snapshot peer: %w", err)
+		}
+		snapshotName = txnSnapshotState.SnapshotName
+		supportsTidScans = txnSnapshotState.SupportsTIDScans
+	}
+
 	s.logger.Info(fmt.Sprintf("cloning %d tables in parallel", numTablesInParallel))
 	if err := s.cloneTables(ctx,
 		SNAPSHOT_TYPE_SLOT,
 		slotInfo.SlotName,
-		slotInfo.SnapshotName,
-		slotInfo.SupportsTidScans,
+		snapshotName,
+		supportsTidScans,
 		numTablesInParallel,
 	); err != nil {
 		s.logger.Error("failed to clone tables", slog.Any("error", err))
 		return fmt.Errorf("failed to clone tables: %w", err)
 	}
 
+	if s.config.ResumableSnapshot {
+		deleteCtx := workflow.WithLocalActivityOptions(ctx, workflow.LocalActivityOptions{
+			StartToCloseTimeout: time.Minute,
+		})
+		if err := workflow.ExecuteLocalActivity(
+			deleteCtx, deleteSnapshotResumeStateActivity, s.config.FlowJobName,
+		).Get(deleteCtx, nil); err != nil {
+			return fmt.Errorf("failed to delete snapshot resume state: %w", err)
+		}
+	}
+
 	return nil
 }
 
@@ -327,7 +585,7 @@ func SnapshotFlowWorkflow(
 
 	sessionOpts := &workflow.SessionOptions{
 		CreationTimeout:  5 * time.Minute,
-		ExecutionTimeout: time.Hour * 24 * 365 * 100, // 100 years
+		ExecutionTimeout: snapshotSessionTimeout,
 		HeartbeatTimeout: time.Hour,
 	}
 	sessionCtx, err := workflow.CreateSession(ctx, sessionOpts)
@@ -349,48 +607,9 @@ func SnapshotFlowWorkflow(
 	}
 
 	if config.InitialSnapshotOnly {
-		sessionInfo := workflow.GetSessionInfo(sessionCtx)
-
-		exportCtx := workflow.WithActivityOptions(sessionCtx, workflow.ActivityOptions{
-			StartToCloseTimeout: sessionOpts.ExecutionTimeout,
-			HeartbeatTimeout:    10 * time.Minute,
-			WaitForCancellation: true,
-			RetryPolicy: &temporal.RetryPolicy{
-				InitialInterval: 1 * time.Minute,
-			},
-		})
-
-		fMaintain := workflow.ExecuteActivity(
-			exportCtx,
-			snapshot.MaintainTx,
-			sessionInfo.SessionID,
-			config.SourceName,
-			config.Env,
-		)
-
-		fExportSnapshot := workflow.ExecuteActivity(
-			exportCtx,
-			snapshot.WaitForExportSnapshot,
-			sessionInfo.SessionID,
-		)
-
-		var sessionError error
-		var txnSnapshotState *activities.TxSnapshotState
-		sessionSelector := workflow.NewNamedSelector(ctx, "ExportSnapshotSetup")
-		sessionSelector.AddFuture(fMaintain, func(f workflow.Future) {
-			// MaintainTx should never exit without an error before this point
-			sessionError = f.Get(exportCtx, nil)
-		})
-		sessionSelector.AddFuture(fExportSnapshot, func(f workflow.Future) {
-			// Happy path is waiting for this to return without error
-			sessionError = f.Get(exportCtx, &txnSnapshotState)
-		})
-		sessionSelector.AddReceive(ctx.Done(), func(_ workflow.ReceiveChannel, _ bool) {
-			sessionError = ctx.Err()
-		})
-		sessionSelector.Select(ctx)
-		if sessionError != nil {
-			return sessionError
+		txnSnapshotState, err := se.exportTxSnapshot(ctx, sessionCtx, config.SourceName)
+		if err != nil {
+			return err
 		}
 
 		if err := se.cloneTables(ctx,
@@ -9,6 +9,7 @@ import (
 	"github.com/google/uuid"
 	"go.temporal.io/sdk/temporal"
 	"go.temporal.io/sdk/workflow"
+	"google.golang.org/protobuf/types/known/timestamppb"
 
 	"github.com/PeerDB-io/peerdb/flow/generated/protos"
 	"github.com/PeerDB-io/peerdb/flow/model"
@@ -34,6 +35,7 @@ func XminFlowWorkflow(
 	}
 
 	signalChan := model.FlowSignal.GetSignalChannel(ctx)
+	updateChan := model.QRepDynamicPropertiesSignal.GetSignalChannel(ctx)
 
 	q := newQRepFlowExecution(ctx, config, originalRunID)
 	logger := q.logger
@@ -70,6 +72,17 @@ func XminFlowWorkflow(
 		return state, err
 	}
 
+	if config.GetSchedule().GetRunCron() != "" {
+		if err := q.waitForScheduledRun(ctx, signalChan, state); err != nil {
+			return state, err
+		}
+		if q.activeSignal == model.PauseSignal {
+			updateStatus(ctx, q.logger, state, protos.FlowStatus_STATUS_PAUSED)
+			return state, workflow.NewContinueAsNewError(ctx, XminFlowWorkflow, config, state)
+		}
+	}
+
+	q.receiveConfigUpdates(updateChan)
 	var lastPartition int64
 	replicateXminPartitionCtx := workflow.WithActivityOptions(ctx, workflow.ActivityOptions{
 		StartToCloseTimeout: 24 * 5 * time.Hour,
@@ -106,6 +119,14 @@ func XminFlowWorkflow(
 		Range:       &protos.PartitionRange{Range: &protos.PartitionRange_IntRange{IntRange: &protos.IntPartitionRange{Start: lastPartition}}},
 	}
 
+	if runCron := config.GetSchedule().GetRunCron(); runCron != "" {
+		nextRun, err := shared.NextCronTime(runCron, workflow.Now(ctx))
+		if err != nil {
+			return state, fmt.Errorf("failed to schedule next run: %w", err)
+		}
+		state.NextScheduledRun = timestamppb.New(nextRun)
+	}
+
 	if err := ctx.Err(); err != nil {
 		return state, err
 	}
@@ -116,6 +137,8 @@ func XminFlowWorkflow(
 		}
 		q.activeSignal = model.FlowSignalHandler(q.activeSignal, val, q.logger)
 	}
+	// carried over to the next run through config
+	q.receiveConfigUpdates(updateChan)
 
 	logger.Info("Continuing as new workflow",
 		slog.Any("Last Partition", state.LastPartition),
@@ -276,6 +276,34 @@ impl StatementAnalyzer for PeerDDLAnalyzer {
                             _ => None,
                         };
 
+                        let snapshot_peer = match raw_options.remove("snapshot_peer") {
+                            Some(Expr::Value(ast::Value::SingleQuotedString(s))) => {
+                                Some(s.to_lowercase())
+                            }
+                            _ => None,
+                        };
+
+                        let resumable_snapshot = match raw_options.remove("resumable_snapshot") {
+                            Some(Expr::Value(ast::Value::Boolean(b))) => *b,
+                            _ => false,
+                        };
+
+                        let consistent_snapshot = match raw_options.remove("consistent_snapshot") {
+                            Some(Expr::Value(ast::Value::Boolean(b))) => *b,
+                            _ => false,
+                        };
+
+                        let incremental_snapshot = match raw_options.remove("incremental_snapshot")
+                        {
+                            Some(Expr::Value(ast::Value::Boolean(b))) => *b,
+                            _ => false,
+                        };
+
+                        let durable_cdc_buffer = match raw_options.remove("durable_cdc_buffer") {
+                            Some(Expr::Value(ast::Value::Boolean(b))) => *b,
+                            _ => false,
+                        };
+
                         let cdc_staging_path = match raw_options.remove("cdc_staging_path") {
                             Some(Expr::Value(ast::Value::SingleQuotedString(s))) => Some(s.clone()),
                             _ => None,
@@ -338,6 +366,11 @@ impl StatementAnalyzer for PeerDDLAnalyzer {
                             snapshot_max_parallel_workers,
                             snapshot_num_tables_in_parallel,
                             snapshot_staging_path,
+                            snapshot_peer,
+                            resumable_snapshot,
+                            consistent_snapshot,
+                            incremental_snapshot,
+                            durable_cdc_buffer,
                             cdc_staging_path,
                             replication_slot_name,
                             max_batch_size,
@@ -925,6 +958,11 @@ fn parse_db_options(db_type: DbType, with_options: &[SqlOption]) -> anyhow::Resu
                 })
                 .ok_or_else(|| anyhow::anyhow!("missing connection addresses for Elasticsearch"))?;
 
+            let explicit_mappings = opts
+                .get("explicit_mappings")
+                .and_then(|s| s.parse::<bool>().ok())
+                .unwrap_or_default();
+
             // either basic auth or API key auth, not both
             let api_key = opts.get("api_key").map(|s| s.to_string());
             let username = opts.get("username").map(|s| s.to_string());
@@ -941,6 +979,7 @@ fn parse_db_options(db_type: DbType, with_options: &[SqlOption]) -> anyhow::Resu
                     username: None,
                     password: None,
                     api_key,
+                    explicit_mappings,
                 })
             } else if username.is_some() && password.is_some() {
                 Config::ElasticsearchConfig(pt::peerdb_peers::ElasticsearchConfig {
@@ -949,6 +988,7 @@ fn parse_db_options(db_type: DbType, with_options: &[SqlOption]) -> anyhow::Resu
                     username,
                     password,
                     api_key: None,
+                    explicit_mappings,
                 })
             } else {
                 Config::ElasticsearchConfig(pt::peerdb_peers::ElasticsearchConfig {
@@ -957,6 +997,7 @@ fn parse_db_options(db_type: DbType, with_options: &[SqlOption]) -> anyhow::Resu
                     username: None,
                     password: None,
                     api_key: None,
+                    explicit_mappings,
                 })
             }
         }
@@ -1015,5 +1056,204 @@ fn parse_db_options(db_type: DbType, with_options: &[SqlOption]) -> anyhow::Resu
             .into(),
             aws_auth: None,
         }),
+        DbType::Opensearch => {
+            let addresses = opts
+                .get("addresses")
+                .map(|columns| {
+                    columns
+                        .split(',')
+                        .map(|column| column.trim().to_string())
+                        .collect::<Vec<_>>()
+                })
+                .ok_or_else(|| anyhow::anyhow!("missing connection addresses for OpenSearch"))?;
+
+            let username = opts.get("username").map(|s| s.to_string());
+            let password = opts.get("password").map(|s| s.to_string());
+            let (auth_type, aws_auth) = match opts.get("auth_type") {
+                Some(&"sigv4") => {
+                    if username.is_some() || password.is_some() {
+                        return Err(anyhow::anyhow!("both SigV4 auth and basic auth specified"));
+                    }
+                    let access_key_id = opts.get("access_key_id").map(|s| s.to_string());
+                    let secret_access_key = opts.get("secret_access_key").map(|s| s.to_string());
+                    let aws_auth = match (access_key_id, secret_access_key) {
+                        (Some(access_key_id), Some(secret_access_key)) => {
+                            pt::peerdb_peers::AwsAuthenticationConfig {
+                                region: opts.get("region").map(|s| s.to_string()).unwrap_or_default(),
+                                auth_type: pt::peerdb_peers::AwsIamAuthConfigType::IamAuthStaticCredentials.into(),
+                                auth_config: Some(
+                                    pt::peerdb_peers::aws_authentication_config::AuthConfig::StaticCredentials(
+                                        pt::peerdb_peers::AwsAuthStaticCredentialsConfig {
+                                            access_key_id,
+                                            secret_access_key,
+                                        },
+                                    ),
+                                ),
+                            }
+                        }
+                        (None, None) => pt::peerdb_peers::AwsAuthenticationConfig {
+                            region: opts.get("region").map(|s| s.to_string()).unwrap_or_default(),
+                            auth_type: pt::peerdb_peers::AwsIamAuthConfigType::IamAuthAutomatic.into(),
+                            auth_config: None,
+                        },
+                        _ => {
+                            return Err(anyhow::anyhow!(
+                                "access_key_id and secret_access_key must be specified together"
+                            ))
+                        }
+                    };
+                    (
+                        pt::peerdb_peers::OpenSearchAuthType::OpensearchAuthSigv4,
+                        Some(aws_auth),
+                    )
+                }
+                Some(&"basic") | None if username.is_some() && password.is_some() => (
+                    pt::peerdb_peers::OpenSearchAuthType::OpensearchAuthBasic,
+                    None,
+                ),
+                Some(&"basic") => {
+                    return Err(anyhow::anyhow!(
+                        "basic auth requires both username and password"
+                    ));
+                }
+                Some(&"none") | None => (
+                    pt::peerdb_peers::OpenSearchAuthType::OpensearchAuthNone,
+                    None,
+                ),
+                Some(other) => {
+                    return Err(anyhow::anyhow!("unknown OpenSearch auth_type {}", other));
+                }
+            };
+
+            Config::OpensearchConfig(pt::peerdb_peers::OpenSearchConfig {
+                addresses,
+                auth_type: auth_type.into(),
+                username,
+                password,
+                aws_auth,
+                signing_service: opts
+                    .get("signing_service")
+                    .map(|s| s.to_string())
+                    .unwrap_or_default(),
+                root_ca: opts.get("root_ca").map(|s| s.to_string()),
+                tls_host: opts
+                    .get("tls_host")
+                    .map(|s| s.to_string())
+                    .unwrap_or_default(),
+                explicit_mappings: opts
+                    .get("explicit_mappings")
+                    .and_then(|s| s.parse::<bool>().ok())
+                    .unwrap_or_default(),
+            })
+        }
+        DbType::Redis => Config::RedisConfig(pt::peerdb_peers::RedisConfig {
+            addresses: opts
+                .get("addresses")
+                .map(|columns| {
+                    columns
+                        .split(',')
+                        .map(|column| column.trim().to_string())
+                        .collect::<Vec<_>>()
+                })
+                .ok_or_else(|| anyhow::anyhow!("missing connection addresses for Redis"))?,
+            username: opts.get("username").map(|s| s.to_string()),
+            password: opts.get("password").map(|s| s.to_string()),
+            database: opts
+                .get("database")
+                .map(|s| s.parse::<u32>())
+                .transpose()
+                .context("unable to parse database as valid int")?
+                .unwrap_or_default(),
+            disable_tls: opts
+                .get("disable_tls")
+                .and_then(|s| s.parse::<bool>().ok())
+                .unwrap_or_default(),
+            root_ca: opts.get("root_ca").map(|s| s.to_string()),
+            tls_host: opts
+                .get("tls_host")
+                .map(|s| s.to_string())
+                .unwrap_or_default(),
+            key_template: opts
+                .get("key_template")
+                .map(|s| s.to_string())
+                .unwrap_or_default(),
+            value_format: match opts.get("value_format") {
+                Some(&"json") => pt::peerdb_peers::RedisValueFormat::RedisValueJson,
+                Some(&"hash") | None => pt::peerdb_peers::RedisValueFormat::RedisValueHash,
+                Some(other) => return Err(anyhow::anyhow!("unknown Redis value_format {}", other)),
+            }
+            .into(),
+            ttl_seconds: opts
+                .get("ttl_seconds")
+                .map(|s| s.parse::<u32>())
+                .transpose()
+                .context("unable to parse ttl_seconds as valid int")?
+                .unwrap_or_default(),
+            pipeline_size: opts
+                .get("pipeline_size")
+                .map(|s| s.parse::<u32>())
+                .transpose()
+                .context("unable to parse pipeline_size as valid int")?
+                .unwrap_or_default(),
+        }),
+        DbType::Nats => Config::NatsConfig(pt::peerdb_peers::NatsConfig {
+            servers: opts
+                .get("servers")
+                .map(|columns| {
+                    columns
+                        .split(',')
+                        .map(|column| column.trim().to_string())
+                        .collect::<Vec<_>>()
+                })
+                .ok_or_else(|| anyhow::anyhow!("missing servers for NATS"))?,
+            credentials: opts.get("credentials").map(|s| s.to_string()),
+            nkey_seed: opts.get("nkey_seed").map(|s| s.to_string()),
+            username: opts.get("username").map(|s| s.to_string()),
+            password: opts.get("password").map(|s| s.to_string()),
+            disable_tls: opts
+                .get("disable_tls")
+                .and_then(|s| s.parse::<bool>().ok())
+                .unwrap_or_default(),
+            root_ca: opts.get("root_ca").map(|s| s.to_string()),
+            tls_host: opts
+                .get("tls_host")
+                .map(|s| s.to_string())
+                .unwrap_or_default(),
+            stream_replicas: opts
+                .get("stream_replicas")
+                .map(|s| s.parse::<u32>())
+                .transpose()
+                .context("unable to parse stream_replicas as valid int")?
+                .unwrap_or_default(),
+        }),
+        DbType::Webhook => Config::WebhookConfig(pt::peerdb_peers::WebhookConfig {
+            url: opts.get("url").context("no url specified")?.to_string(),
+            authorization: opts.get("authorization").map(|s| s.to_string()),
+            headers: match opts.get("headers") {
+                Some(headers) => {
+                    serde_json::from_str(headers).context("failed to deserialize headers")?
+                }
+                None => HashMap::new(),
+            },
+            signing_secret: opts.get("signing_secret").map(|s| s.to_string()),
+            batch_size: opts
+                .get("batch_size")
+                .map(|s| s.parse::<u32>())
+                .transpose()
+                .context("unable to parse batch_size as valid int")?
+                .unwrap_or_default(),
+            max_attempts: opts
+                .get("max_attempts")
+                .map(|s| s.parse::<u32>())
+                .transpose()
+                .context("unable to parse max_attempts as valid int")?
+                .unwrap_or_default(),
+            timeout_seconds: opts
+                .get("timeout_seconds")
+                .map(|s| s.parse::<u32>())
+                .transpose()
+                .context("unable to parse timeout_seconds as valid int")?
+                .unwrap_or_default(),
+        }),
     }))
 }
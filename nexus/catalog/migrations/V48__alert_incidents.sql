@@ -0,0 +1,23 @@
+CREATE TABLE IF NOT EXISTS peerdb_stats.alert_incidents (
+    id BIGINT PRIMARY KEY GENERATED BY DEFAULT AS IDENTITY,
+    alert_config_id BIGINT NOT NULL,
+    alert_key TEXT NOT NULL,
+    alert_type TEXT NOT NULL,
+    flow_name TEXT NOT NULL DEFAULT '',
+    peer_name TEXT NOT NULL DEFAULT '',
+    status TEXT NOT NULL DEFAULT 'open' CHECK (status IN ('open', 'acknowledged', 'resolved')),
+    alert_message TEXT NOT NULL,
+    opened_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    last_seen_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    last_notified_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    acknowledged_at TIMESTAMP,
+    acknowledged_by TEXT,
+    resolved_at TIMESTAMP
+);
+
+-- at most one unresolved incident per alert config and alert key
+CREATE UNIQUE INDEX IF NOT EXISTS alert_incidents_active_idx
+ON peerdb_stats.alert_incidents (alert_config_id, alert_key) WHERE status <> 'resolved';
+
+CREATE INDEX IF NOT EXISTS alert_incidents_flow_name_idx
+ON peerdb_stats.alert_incidents (flow_name) WHERE status <> 'resolved';
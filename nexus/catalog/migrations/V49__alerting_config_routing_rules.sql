@@ -0,0 +1 @@
+ALTER TABLE peerdb_stats.alerting_config ADD COLUMN IF NOT EXISTS routing_rules JSONB;
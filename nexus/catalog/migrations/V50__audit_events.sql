@@ -0,0 +1,13 @@
+CREATE TABLE IF NOT EXISTS audit_events (
+    id BIGINT PRIMARY KEY GENERATED BY DEFAULT AS IDENTITY,
+    event_time TIMESTAMP NOT NULL DEFAULT NOW(),
+    method TEXT NOT NULL,
+    resource TEXT NOT NULL DEFAULT '',
+    subject TEXT NOT NULL DEFAULT '',
+    roles TEXT[] NOT NULL DEFAULT '{}',
+    request JSONB,
+    success BOOLEAN NOT NULL,
+    error TEXT NOT NULL DEFAULT ''
+);
+
+CREATE INDEX IF NOT EXISTS audit_events_resource_idx ON audit_events (resource, id DESC);
@@ -0,0 +1 @@
+ALTER TABLE peers ADD COLUMN IF NOT EXISTS config_version BIGINT NOT NULL DEFAULT 0;
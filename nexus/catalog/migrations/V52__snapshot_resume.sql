@@ -0,0 +1,14 @@
+CREATE TABLE IF NOT EXISTS snapshot_resume_state (
+    flow_name TEXT PRIMARY KEY,
+    setup_replication_output BYTEA NOT NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+CREATE TABLE IF NOT EXISTS snapshot_partition_plans (
+    flow_name TEXT PRIMARY KEY,
+    parent_mirror_name TEXT NOT NULL,
+    partitions BYTEA NOT NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS snapshot_partition_plans_parent_mirror_name_idx ON snapshot_partition_plans (parent_mirror_name);
@@ -0,0 +1,7 @@
+CREATE TABLE IF NOT EXISTS snapshot_table_offsets (
+    flow_name TEXT NOT NULL,
+    table_name TEXT NOT NULL,
+    offset_text TEXT NOT NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (flow_name, table_name)
+);
@@ -0,0 +1,12 @@
+CREATE TABLE IF NOT EXISTS incremental_snapshots (
+    flow_name TEXT NOT NULL,
+    source_table TEXT NOT NULL,
+    -- primary key of the last row known to be synced, NULL before the first chunk
+    last_key TEXT[],
+    -- chunk emitted in CDC but not yet known to be synced
+    pending_key TEXT[],
+    pending_lsn BIGINT,
+    pending_done BOOLEAN NOT NULL DEFAULT FALSE,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (flow_name, source_table)
+);
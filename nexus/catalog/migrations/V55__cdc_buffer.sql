@@ -0,0 +1,20 @@
+-- segment files of a mirror's durable CDC buffer, replayed to the destination in segment_id order
+CREATE TABLE IF NOT EXISTS cdc_buffer_segments (
+    flow_name TEXT NOT NULL,
+    segment_id BIGINT NOT NULL,
+    file_name TEXT NOT NULL,
+    last_offset_id BIGINT NOT NULL,
+    last_offset_text TEXT NOT NULL DEFAULT '',
+    num_records BIGINT NOT NULL,
+    size_bytes BIGINT NOT NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (flow_name, segment_id)
+);
+
+-- offset the source has been drained to, durably buffered or synced
+CREATE TABLE IF NOT EXISTS cdc_buffer_offsets (
+    flow_name TEXT PRIMARY KEY,
+    last_offset_id BIGINT NOT NULL,
+    last_offset_text TEXT NOT NULL DEFAULT '',
+    updated_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
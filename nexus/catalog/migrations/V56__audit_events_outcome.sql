@@ -0,0 +1,2 @@
+-- gRPC status code of the call, tells denied calls apart from ones that failed
+ALTER TABLE audit_events ADD COLUMN IF NOT EXISTS outcome TEXT NOT NULL DEFAULT '';
@@ -393,6 +393,27 @@ impl Catalog {
                             .with_context(err)?;
                     Config::ElasticsearchConfig(elasticsearch_config)
                 }
+                DbType::Opensearch => {
+                    let opensearch_config =
+                        pt::peerdb_peers::OpenSearchConfig::decode(&options[..])
+                            .with_context(err)?;
+                    Config::OpensearchConfig(opensearch_config)
+                }
+                DbType::Redis => {
+                    let redis_config =
+                        pt::peerdb_peers::RedisConfig::decode(&options[..]).with_context(err)?;
+                    Config::RedisConfig(redis_config)
+                }
+                DbType::Nats => {
+                    let nats_config =
+                        pt::peerdb_peers::NatsConfig::decode(&options[..]).with_context(err)?;
+                    Config::NatsConfig(nats_config)
+                }
+                DbType::Webhook => {
+                    let webhook_config =
+                        pt::peerdb_peers::WebhookConfig::decode(&options[..]).with_context(err)?;
+                    Config::WebhookConfig(webhook_config)
+                }
                 DbType::Mysql => {
                     let mysql_config =
                         pt::peerdb_peers::MySqlConfig::decode(&options[..]).with_context(err)?;
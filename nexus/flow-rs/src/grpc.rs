@@ -131,6 +131,11 @@ impl FlowGrpcClient {
             snapshot_max_parallel_workers: snapshot_max_parallel_workers.unwrap_or(0),
             snapshot_num_tables_in_parallel: snapshot_num_tables_in_parallel.unwrap_or(0),
             snapshot_staging_path: job.snapshot_staging_path.clone(),
+            snapshot_peer_name: job.snapshot_peer.clone().unwrap_or_default(),
+            resumable_snapshot: job.resumable_snapshot,
+            consistent_snapshot: job.consistent_snapshot,
+            incremental_snapshot: job.incremental_snapshot,
+            durable_cdc_buffer: job.durable_cdc_buffer,
             cdc_staging_path: job.cdc_staging_path.clone().unwrap_or_default(),
             replication_slot_name: replication_slot_name.unwrap_or_default(),
             max_batch_size: job.max_batch_size.unwrap_or_default(),
@@ -146,6 +151,7 @@ impl FlowGrpcClient {
             idle_timeout_seconds: job.sync_interval.unwrap_or_default(),
             env: Default::default(),
             version: 0, // filled in by server
+            schedule: None,
         };
 
         if job.disable_peerdb_columns {
@@ -204,6 +210,12 @@ impl FlowGrpcClient {
                         }
                     }
                     "staging_path" => cfg.staging_path.clone_from(s),
+                    "run_cron" => {
+                        cfg.schedule = Some(pt::peerdb_flow::MirrorSchedule {
+                            run_cron: s.clone(),
+                            ..Default::default()
+                        })
+                    }
                     _ => return anyhow::Result::Err(anyhow::anyhow!("invalid str option {}", key)),
                 },
                 Value::Number(n) => match key.as_str() {
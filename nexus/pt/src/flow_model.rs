@@ -23,6 +23,11 @@ pub struct FlowJob {
     pub snapshot_max_parallel_workers: Option<u32>,
     pub snapshot_num_tables_in_parallel: Option<u32>,
     pub snapshot_staging_path: String,
+    pub snapshot_peer: Option<String>,
+    pub resumable_snapshot: bool,
+    pub consistent_snapshot: bool,
+    pub incremental_snapshot: bool,
+    pub durable_cdc_buffer: bool,
     pub cdc_staging_path: Option<String>,
     pub replication_slot_name: Option<String>,
     pub max_batch_size: Option<u32>,
@@ -1,4 +1,7 @@
-import { PostAlertConfigRequest } from '@/grpc_generated/route';
+import {
+  AlertRoutingRule,
+  PostAlertConfigRequest,
+} from '@/grpc_generated/route';
 import { Button } from '@/lib/Button';
 import { Label } from '@/lib/Label/Label';
 import { TextField } from '@/lib/TextField';
@@ -27,6 +30,7 @@ export interface AlertConfigProps {
   alertConfig: serviceConfigType;
   forEdit?: boolean;
   alertForMirrors?: string[];
+  routingRules?: AlertRoutingRule[];
 }
 
 function ConfigLabel(data: { label: string; value: string }) {
@@ -208,6 +212,7 @@ export function NewConfig(alertProps: AlertConfigProps) {
           alertConfigReq.alertForMirrors?.filter(
             (mirror) => mirror && mirror.trim() !== ''
           ) || [],
+        routingRules: alertProps.routingRules ?? [],
       },
     };
 
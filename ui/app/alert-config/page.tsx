@@ -67,13 +67,18 @@ export default function AlertConfigPage() {
       alertConfig: JSON.parse(alertConfig.serviceConfig),
       forEdit: true,
       alertForMirrors: alertConfig.alertForMirrors,
+      routingRules: alertConfig.routingRules,
     });
   };
 
   const genConfigJSON = (alertConfig: AlertConfig) => {
     const parsedConfig = JSON.parse(alertConfig.serviceConfig);
     return JSON.stringify(
-      { ...parsedConfig, alertForMirrors: alertConfig.alertForMirrors },
+      {
+        ...parsedConfig,
+        alertForMirrors: alertConfig.alertForMirrors,
+        routingRules: alertConfig.routingRules,
+      },
       null,
       2
     );
@@ -388,12 +388,17 @@ export default function SchemaBox({
                               cursor: 'pointer',
                             }}
                             variant='simple'
-                            placeholder='Enter optional custom partiton key'
+                            placeholder='Optional column, or comma separated columns'
                             value={row.partitionKey}
                             onChange={(
                               e: React.ChangeEvent<HTMLInputElement>
                             ) => updatePartitionKey(row.source, e.target.value)}
                           />
+                          <p style={{ marginTop: '0.25rem', fontSize: 11 }}>
+                            Comma separated non-nullable columns, such as a
+                            composite primary key, split the initial load into
+                            key ranges.
+                          </p>
                         </div>
 
                         {peerType?.toString() ===
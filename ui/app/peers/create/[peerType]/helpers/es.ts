@@ -29,6 +29,14 @@ export const esSetting: PeerSetting[] = [
       { value: 'APIKEY', label: 'API Key' },
     ],
   },
+  {
+    label: 'Explicit mappings',
+    stateHandler: (value, setter) =>
+      setter((curr) => ({ ...curr, explicitMappings: value as boolean })),
+    type: 'switch',
+    tips: 'Create indices with mappings derived from source column types instead of relying on dynamic mapping.',
+    optional: true,
+  },
   // remaining fields are optional but displayed conditionally so not optional style wise
   {
     label: 'Username',
@@ -55,4 +63,5 @@ export const blankElasticsearchSetting: ElasticsearchConfig = {
   username: '',
   password: '',
   apiKey: '',
+  explicitMappings: false,
 };
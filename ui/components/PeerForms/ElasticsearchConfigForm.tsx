@@ -7,7 +7,8 @@ import {
   ElasticsearchConfig,
 } from '@/grpc_generated/peers';
 import { Label } from '@/lib/Label';
-import { RowWithSelect, RowWithTextField } from '@/lib/Layout';
+import { RowWithSelect, RowWithSwitch, RowWithTextField } from '@/lib/Layout';
+import { Switch } from '@/lib/Switch';
 import { TextField } from '@/lib/TextField';
 import { Tooltip } from '@/lib/Tooltip';
 import ReactSelect from 'react-select';
@@ -39,6 +40,23 @@ export default function ElasticsearchConfigForm({
               />
             }
           />
+        ) : setting.type === 'switch' ? (
+          <RowWithSwitch
+            key={index}
+            label={<Label>{setting.label}</Label>}
+            action={
+              <div>
+                <Switch
+                  onCheckedChange={(val: boolean) =>
+                    setting.stateHandler(val, setter)
+                  }
+                />
+                {setting.tips && (
+                  <InfoPopover tips={setting.tips} link={setting.helpfulLink} />
+                )}
+              </div>
+            }
+          />
         ) : (setting.label === 'API Key' &&
             config.authType === ElasticsearchAuthType.APIKEY) ||
           (setting.label !== 'API Key' &&
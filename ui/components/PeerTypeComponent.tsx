@@ -30,6 +30,14 @@ export function DBTypeToGoodText(ptype?: DBType) {
       return 'PubSub';
     case DBType.ELASTICSEARCH:
       return 'Elasticsearch';
+    case DBType.OPENSEARCH:
+      return 'OpenSearch';
+    case DBType.REDIS:
+      return 'Redis';
+    case DBType.NATS:
+      return 'NATS';
+    case DBType.WEBHOOK:
+      return 'Webhook';
     case DBType.MONGO:
       return 'MongoDB';
     default: